@@ -0,0 +1,128 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/archive"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveFormat string
+	archivePrefix string
+	archiveOutput string
+)
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive [--format=<fmt>] [--prefix=<prefix>/] [-o <file>] <tree-ish> [<path>...]",
+	Short: "create an archive of files from a named tree",
+	Long:  "create an archive of files from a named tree without touching the working tree",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("%w: usage: goit archive [<options>] <tree-ish> [<path>...]", ErrInvalidArgs)
+		}
+
+		format := archiveFormat
+		if format == "" {
+			format = archive.FormatFromName(archiveOutput)
+		}
+		if format == "tgz" {
+			format = archive.FormatTarGz
+		}
+		if format != archive.FormatTar && format != archive.FormatTarGz && format != archive.FormatZip {
+			return fmt.Errorf("fatal: Unknown archive format '%s'", format)
+		}
+
+		// the commit gives the modification time and the id embedded in the archive,
+		// and the tree is archived with the current time
+		repo := repository(cmd)
+		hash, err := repo.ResolveRevision(args[0])
+		if err != nil {
+			return fmt.Errorf("fatal: not a valid object name: %s", args[0])
+		}
+		obj, err := repo.Peel(hash)
+		if err != nil {
+			return err
+		}
+		opts := archive.Options{
+			Format:  format,
+			Prefix:  archivePrefix,
+			ModTime: time.Now(),
+		}
+		if obj.Type == goit.CommitObject {
+			commit, err := repo.CommitObject(obj.Hash)
+			if err != nil {
+				return err
+			}
+			opts.ModTime = commit.Committer.Timestamp
+			opts.CommitID = commit.Hash.String()
+		}
+		tree, err := repo.TreeObject(obj.Hash)
+		if errors.Is(err, goit.ErrNotTree) {
+			return fmt.Errorf("fatal: not a tree object: %s", args[0])
+		}
+		if err != nil {
+			return err
+		}
+
+		// the paths are relative to the current directory like the pathspec
+		ps, _, err := parsePathspec(repo, args[1:])
+		if err != nil {
+			return fmt.Errorf("fatal: %w", err)
+		}
+		files := archive.Files(tree, ps.Match)
+		if unmatched := ps.Unmatched(); len(unmatched) > 0 {
+			return fmt.Errorf("fatal: pathspec '%s' did not match any files", unmatched[0])
+		}
+
+		var w io.Writer = os.Stdout
+		if archiveOutput != "" {
+			f, err := os.Create(archiveOutput)
+			if err != nil {
+				return fmt.Errorf("fatal: could not create archive file '%s': %w", archiveOutput, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		bw := bufio.NewWriter(w)
+		load := func(hash goit.Hash) ([]byte, error) {
+			blob, err := repo.Object(hash)
+			if err != nil {
+				return nil, err
+			}
+			return blob.Data, nil
+		}
+		if err := archive.Write(bw, files, load, opts); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return fmt.Errorf("fail to write archive: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+
+	archiveCmd.Flags().StringVar(&archiveFormat, "format", "", "format of the archive: tar, tar.gz or zip")
+	archiveCmd.Flags().StringVar(&archivePrefix, "prefix", "", "prepend the prefix to each path in the archive")
+	archiveCmd.Flags().StringVarP(&archiveOutput, "output", "o", "", "write the archive to the file instead of stdout")
+}
@@ -0,0 +1,408 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	bisectSkipExitCode = 125
+)
+
+var (
+	ErrNotBisecting = errors.New(`You need to start by "goit bisect start"`)
+)
+
+// return "[hash] subject" of the commit.
+func commitOneline(commit *goit.Commit) string {
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	return fmt.Sprintf("[%s] %s", commit.Hash, subject)
+}
+
+func loadBisectState(repo *goit.Repository) (*goit.BisectState, error) {
+	state, err := repo.LoadBisect()
+	if errors.Is(err, goit.ErrNotBisecting) {
+		return nil, ErrNotBisecting
+	}
+	return state, err
+}
+
+// record the term of the revisions to the state and the log.
+// The revisions default to HEAD.
+func markBisect(repo *goit.Repository, state *goit.BisectState, term string, revs []string) error {
+	var hashes []goit.Hash
+	if len(revs) == 0 {
+		head := repo.Head()
+		if head.Hash == nil {
+			return ErrInvalidHEAD
+		}
+		hashes = append(hashes, head.Hash)
+	}
+	for _, rev := range revs {
+		hash, err := resolveCommit(repo, rev)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, hash)
+	}
+	if term == "bad" && len(hashes) > 1 {
+		return errors.New("fatal: 'goit bisect bad' can take only one argument")
+	}
+
+	for _, hash := range hashes {
+		switch term {
+		case "bad":
+			state.Bad = hash
+		case "good":
+			state.Good = append(state.Good, hash)
+		case "skip":
+			state.Skip = append(state.Skip, hash)
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return err
+		}
+		if err := repo.AppendBisectLog(fmt.Sprintf("# %s: %s", term, commitOneline(commit)), fmt.Sprintf("goit bisect %s %s", term, hash)); err != nil {
+			return err
+		}
+	}
+
+	return repo.SaveBisect(state)
+}
+
+// check out the commit on the detached HEAD.
+func checkoutBisect(ctx context.Context, repo *goit.Repository, hash goit.Hash) error {
+	result, err := repo.Worktree().Checkout(ctx, &goit.CheckoutOptions{Hash: hash})
+	if err != nil {
+		return checkoutError(err)
+	}
+	showSwitchResult(result)
+	return nil
+}
+
+// pick and check out the next commit to test. It returns true when the search is over.
+func bisectNext(ctx context.Context, repo *goit.Repository, state *goit.BisectState) (bool, error) {
+	if !state.IsReady() {
+		switch {
+		case state.Bad == nil && len(state.Good) == 0:
+			fmt.Println("status: waiting for both good and bad commits")
+		case state.Bad == nil:
+			fmt.Println("status: waiting for bad commit, good commit(s) known")
+		default:
+			fmt.Println("status: waiting for good commit(s), bad commit known")
+		}
+		return false, nil
+	}
+
+	result, err := repo.BisectNext(ctx, state)
+	if errors.Is(err, goit.ErrGoodNotAncestor) {
+		return false, fmt.Errorf("fatal: some good revs are not ancestors of the bad rev")
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case result.FirstBad != nil:
+		commit, err := repo.CommitObject(result.FirstBad)
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("%s is the first bad commit\n", commit.Hash)
+		fmt.Println(commit)
+		if err := repo.AppendBisectLog(fmt.Sprintf("# first bad commit: %s", commitOneline(commit))); err != nil {
+			return false, err
+		}
+		return true, nil
+	case result.Next == nil:
+		fmt.Println("There are only 'skip'ped commits left to test.")
+		fmt.Println("The first bad commit could be any of:")
+		for _, hash := range result.Candidates {
+			fmt.Println(hash)
+		}
+		return true, nil
+	}
+
+	if err := checkoutBisect(ctx, repo, result.Next); err != nil {
+		return false, err
+	}
+	commit, err := repo.CommitObject(result.Next)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Bisecting: %d revision(s) left to test after this (roughly %d step(s))\n", result.Remaining, result.Steps)
+	fmt.Println(commitOneline(commit))
+	return false, nil
+}
+
+// start the bisect session with the optional bad and good revisions.
+func startBisect(repo *goit.Repository, args []string) (*goit.BisectState, error) {
+	head := repo.Head()
+	if head.Hash == nil {
+		return nil, ErrInvalidHEAD
+	}
+	state := &goit.BisectState{
+		Start: currentBranch(repo),
+	}
+	if head.Target == "" {
+		state.Start = head.Hash.String()
+	}
+	// restarting keeps the original position
+	if prev, err := repo.LoadBisect(); err == nil {
+		state.Start = prev.Start
+	}
+	if err := repo.ClearBisect(); err != nil {
+		return nil, err
+	}
+	if err := repo.SaveBisect(state); err != nil {
+		return nil, err
+	}
+	if err := repo.AppendBisectLog(strings.TrimSpace("goit bisect start " + strings.Join(args, " "))); err != nil {
+		return nil, err
+	}
+
+	if len(args) > 0 {
+		if err := markBisect(repo, state, "bad", args[:1]); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 1 {
+		if err := markBisect(repo, state, "good", args[1:]); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// bisectCmd represents the bisect command
+var bisectCmd = &cobra.Command{
+	Use:   "bisect",
+	Short: "use binary search to find the commit that introduced a bug",
+	Long:  "use binary search to find the commit that introduced a bug",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+}
+
+var bisectStartCmd = &cobra.Command{
+	Use:   "start [<bad> [<good>...]]",
+	Short: "start the bisect session",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := repository(cmd)
+		state, err := startBisect(repo, args)
+		if err != nil {
+			return err
+		}
+		_, err = bisectNext(cmd.Context(), repo, state)
+		return err
+	},
+}
+
+// make the subcommand which marks the revisions with the term.
+func newBisectMarkCmd(term, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   term + " [<rev>...]",
+		Short: short,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repo := repository(cmd)
+			state, err := loadBisectState(repo)
+			if err != nil {
+				return err
+			}
+			if err := markBisect(repo, state, term, args); err != nil {
+				return err
+			}
+			_, err = bisectNext(cmd.Context(), repo, state)
+			return err
+		},
+	}
+}
+
+var bisectResetCmd = &cobra.Command{
+	Use:   "reset [<commit>]",
+	Short: "finish the bisect session and go back to the original branch",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := repository(cmd)
+		state, err := repo.LoadBisect()
+		if errors.Is(err, goit.ErrNotBisecting) {
+			fmt.Println("We are not bisecting.")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := state.Start
+		if len(args) > 0 {
+			target = args[0]
+		}
+		if _, err := repo.Reference("refs/heads/" + target); err == nil {
+			if _, err := repo.Worktree().Checkout(cmd.Context(), &goit.CheckoutOptions{Branch: target}); err != nil {
+				return checkoutError(err)
+			}
+			fmt.Printf("Switched to branch '%s'\n", target)
+		} else {
+			hash, err := resolveCommit(repo, target)
+			if err != nil {
+				return err
+			}
+			if err := checkoutBisect(cmd.Context(), repo, hash); err != nil {
+				return err
+			}
+			abbrev, err := repo.NewAbbreviator(0)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("HEAD is now at %s\n", abbrev.Abbrev(hash))
+		}
+
+		return repo.ClearBisect()
+	},
+}
+
+var bisectLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "show the log of the bisect session",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		content, err := repository(cmd).BisectLog()
+		if errors.Is(err, goit.ErrNotBisecting) {
+			return errors.New("error: We are not bisecting.")
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	},
+}
+
+var bisectReplayCmd = &cobra.Command{
+	Use:   "replay <logfile>",
+	Short: "replay the bisect session from the log",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("fatal: cannot read file '%s' for replaying", args[0])
+		}
+		defer f.Close()
+
+		repo := repository(cmd)
+		var state *goit.BisectState
+		scanner := bufio.NewScanner(f)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 3 || fields[0] != "goit" || fields[1] != "bisect" {
+				return fmt.Errorf("fatal: ?? what are you talking about? (line %d: %s)", lineNo, line)
+			}
+			switch term := fields[2]; term {
+			case "start":
+				if state, err = startBisect(repo, fields[3:]); err != nil {
+					return err
+				}
+			case "good", "bad", "skip":
+				if state == nil {
+					return fmt.Errorf("fatal: the log does not start with 'goit bisect start' (line %d)", lineNo)
+				}
+				if err := markBisect(repo, state, term, fields[3:]); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("fatal: ?? what are you talking about? (line %d: %s)", lineNo, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("fail to read %s: %w", args[0], err)
+		}
+		if state == nil {
+			return fmt.Errorf("fatal: no bisect session in '%s'", args[0])
+		}
+
+		_, err = bisectNext(cmd.Context(), repo, state)
+		return err
+	},
+}
+
+var bisectRunCmd = &cobra.Command{
+	Use:                "run <cmd> [<arg>...]",
+	Short:              "run the command at each step, whose exit code tells good (0), skip (125) or bad",
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("fatal: bisect run failed: no command provided")
+		}
+		repo := repository(cmd)
+		state, err := loadBisectState(repo)
+		if err != nil {
+			return err
+		}
+		if !state.IsReady() {
+			return errors.New("fatal: bisect run requires both the good and the bad commit")
+		}
+
+		for {
+			fmt.Printf("running '%s'\n", strings.Join(args, " "))
+			c := exec.Command(args[0], args[1:]...)
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+			term := "good"
+			if err := c.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if !errors.As(err, &exitErr) {
+					return fmt.Errorf("fatal: bisect run failed: %w", err)
+				}
+				code := exitErr.ExitCode()
+				switch {
+				case code == bisectSkipExitCode:
+					term = "skip"
+				case code > 0 && code < 128:
+					term = "bad"
+				default:
+					return fmt.Errorf("fatal: bisect run failed: exit code %d from '%s' is < 0 or >= 128", code, strings.Join(args, " "))
+				}
+			}
+
+			if err := markBisect(repo, state, term, nil); err != nil {
+				return err
+			}
+			isDone, err := bisectNext(cmd.Context(), repo, state)
+			if err != nil {
+				return err
+			}
+			if isDone {
+				fmt.Println("bisect found first bad commit")
+				return nil
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bisectCmd)
+
+	bisectCmd.AddCommand(bisectStartCmd)
+	bisectCmd.AddCommand(newBisectMarkCmd("bad", "mark the commit as bad, which contains the bug"))
+	bisectCmd.AddCommand(newBisectMarkCmd("good", "mark the commits as good, which do not contain the bug"))
+	bisectCmd.AddCommand(newBisectMarkCmd("skip", "mark the commits as untestable"))
+	bisectCmd.AddCommand(bisectResetCmd)
+	bisectCmd.AddCommand(bisectLogCmd)
+	bisectCmd.AddCommand(bisectReplayCmd)
+	bisectCmd.AddCommand(bisectRunCmd)
+}
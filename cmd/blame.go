@@ -0,0 +1,221 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/diff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	blameLineRange        string
+	blamePorcelain        bool
+	blameIgnoreWhitespace bool
+	blameReverse          bool
+)
+
+// resolve the revision into the commit hash.
+func resolveCommit(repo *goit.Repository, rev string) (goit.Hash, error) {
+	hash, err := repo.ResolveCommit(rev)
+	if errors.Is(err, goit.ErrNotCommit) {
+		return nil, fmt.Errorf("fatal: '%s' is not a commit", rev)
+	}
+	if err != nil {
+		return nil, revisionError(rev, err)
+	}
+	return hash, nil
+}
+
+// return the abbreviated hash, whose head is replaced with '^' on the boundary commit.
+func blameHash(line *goit.BlameLine) string {
+	hash := line.Commit.Hash.String()
+	if line.IsBoundary {
+		return "^" + hash[:7]
+	}
+	return hash[:8]
+}
+
+func printBlame(lines []*goit.BlameLine, path string) {
+	showPath := false
+	nameWidth, lineWidth, pathWidth := 0, len(fmt.Sprint(len(lines))), 0
+	for _, line := range lines {
+		if line.Path != path {
+			showPath = true
+		}
+		if len(line.Commit.Author.Name) > nameWidth {
+			nameWidth = len(line.Commit.Author.Name)
+		}
+		if len(line.Path) > pathWidth {
+			pathWidth = len(line.Path)
+		}
+		if n := len(fmt.Sprint(line.FinalLine)); n > lineWidth {
+			lineWidth = n
+		}
+	}
+
+	for _, line := range lines {
+		header := blameHash(line)
+		if showPath {
+			header += fmt.Sprintf(" %-*s", pathWidth, line.Path)
+		}
+		fmt.Printf("%s (%-*s %s %*d) %s\n",
+			header,
+			nameWidth,
+			line.Commit.Author.Name,
+			line.Commit.Author.Timestamp.Format("2006-01-02 15:04:05 -0700"),
+			lineWidth,
+			line.FinalLine,
+			strings.TrimSuffix(line.Text, "\n"),
+		)
+	}
+}
+
+func printSign(role string, sign goit.Signature) {
+	fmt.Printf("%s %s\n", role, sign.Name)
+	fmt.Printf("%s-mail <%s>\n", role, sign.Email)
+	fmt.Printf("%s-time %d\n", role, sign.Timestamp.Unix())
+	fmt.Printf("%s-tz %s\n", role, sign.Timestamp.Format("-0700"))
+}
+
+// print the lines in the format for machine consumption, where the commit information is shown
+// only for the first line attributed to the commit.
+func printBlamePorcelain(lines []*goit.BlameLine) {
+	isShown := make(map[string]bool)
+	for i, line := range lines {
+		// count the lines of the group, which continue in the same commit
+		isGroupHead := i == 0 || lines[i-1].Commit != line.Commit || lines[i-1].OrigLine+1 != line.OrigLine || lines[i-1].FinalLine+1 != line.FinalLine
+		if !isGroupHead {
+			fmt.Printf("%s %d %d\n", line.Commit.Hash, line.OrigLine, line.FinalLine)
+			fmt.Printf("\t%s\n", strings.TrimSuffix(line.Text, "\n"))
+			continue
+		}
+		count := 1
+		for j := i + 1; j < len(lines); j++ {
+			if lines[j].Commit != line.Commit || lines[j].OrigLine != line.OrigLine+count || lines[j].FinalLine != line.FinalLine+count {
+				break
+			}
+			count++
+		}
+		fmt.Printf("%s %d %d %d\n", line.Commit.Hash, line.OrigLine, line.FinalLine, count)
+		if hash := line.Commit.Hash.String(); !isShown[hash] {
+			isShown[hash] = true
+			printSign("author", line.Commit.Author)
+			printSign("committer", line.Commit.Committer)
+			summary, _, _ := strings.Cut(line.Commit.Message, "\n")
+			fmt.Printf("summary %s\n", summary)
+			if line.IsBoundary {
+				fmt.Println("boundary")
+			}
+		}
+		fmt.Printf("filename %s\n", line.Path)
+		fmt.Printf("\t%s\n", strings.TrimSuffix(line.Text, "\n"))
+	}
+}
+
+// blameCmd represents the blame command
+var blameCmd = &cobra.Command{
+	Use:   "blame [<rev>] <file>",
+	Short: "show what revision and author last modified each line of a file",
+	Long:  "show what revision and author last modified each line of a file",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("%w: usage: goit blame [<options>] [<rev>] <file>", ErrInvalidArgs)
+		}
+
+		repo := repository(cmd)
+		prefix, err := currentPrefix(repo)
+		if err != nil {
+			return err
+		}
+		path, err := rootRelativePath(prefix, args[len(args)-1])
+		if err != nil {
+			return err
+		}
+
+		opts := &goit.BlameOptions{
+			IgnoreWhitespace: blameIgnoreWhitespace,
+			Threshold:        diff.DefaultSimilarity,
+		}
+		if blameLineRange != "" {
+			opts.Start, opts.End, err = goit.ParseLineRange(blameLineRange)
+			if err != nil {
+				return fmt.Errorf("fatal: %w", err)
+			}
+		}
+
+		rev := ""
+		if len(args) == 2 {
+			rev = args[0]
+		}
+		var lines []*goit.BlameLine
+		if blameReverse {
+			// the range is <from>..<to>, where <to> is HEAD if omitted
+			fromRev, toRev, _ := strings.Cut(rev, "..")
+			if fromRev == "" {
+				return fmt.Errorf("fatal: --reverse requires the revision range such as <from>..<to>")
+			}
+			if toRev == "" {
+				toRev = "HEAD"
+			}
+			from, err := resolveCommit(repo, fromRev)
+			if err != nil {
+				return err
+			}
+			to, err := resolveCommit(repo, toRev)
+			if err != nil {
+				return err
+			}
+			lines, err = repo.BlameReverse(cmd.Context(), from, to, path, opts)
+			if err != nil {
+				return fmt.Errorf("fatal: %w", err)
+			}
+		} else {
+			var hash goit.Hash
+			if rev == "" {
+				head := repo.Head()
+				if head.Hash == nil {
+					return fmt.Errorf("fatal: your current branch '%s' does not have any commits yet", goit.ShortReferenceName(head.Target))
+				}
+				hash = head.Hash
+			} else {
+				hash, err = resolveCommit(repo, rev)
+				if err != nil {
+					return err
+				}
+			}
+			lines, err = repo.Blame(cmd.Context(), hash, path, opts)
+			if err != nil {
+				return fmt.Errorf("fatal: %w", err)
+			}
+		}
+
+		if blamePorcelain {
+			printBlamePorcelain(lines)
+		} else {
+			printBlame(lines, path)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd)
+
+	blameCmd.Flags().StringVarP(&blameLineRange, "line-range", "L", "", "annotate only the line range such as 3,10 and 3,+5")
+	blameCmd.Flags().BoolVar(&blamePorcelain, "porcelain", false, "show in a format designed for machine consumption")
+	blameCmd.Flags().BoolVarP(&blameIgnoreWhitespace, "ignore-whitespace", "w", false, "ignore whitespace when comparing the lines")
+	blameCmd.Flags().BoolVar(&blameReverse, "reverse", false, "walk history forward to find the last commit where each line existed")
+}
@@ -4,97 +4,419 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"time"
+	"path"
+	"strings"
 
-	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/refformat"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	renameOption string = ""
-	deleteOption string = ""
+	renameOption         string = ""
+	deleteOption         string = ""
+	forceDeleteOption    string = ""
+	copyOption           string = ""
+	setUpstreamOption    string = ""
+	isUnsetUpstream      bool
+	branchVerbose        int
+	branchMergedOption   *commitOption
+	branchNoMergedOption *commitOption
+	branchContainsOption *commitOption
+	branchSortKeys       []string
 )
 
+// commitOption is the value of --merged, --no-merged and --contains.
+// As git does, the option without the value takes the following argument as the commit,
+// and HEAD when no argument follows.
+type commitOption struct {
+	flags     *pflag.FlagSet
+	value     string
+	argsIndex int // the position of the commit in the arguments when the value is omitted, otherwise -1
+}
+
+const (
+	commitOptionDefault = "HEAD"
+	// the value pflag gives to the option without the value. '[' is not allowed in ref names,
+	// so that it is never taken for the commit given explicitly such as --merged=HEAD.
+	commitOptionOmitted = "[HEAD]"
+)
+
+func newCommitOption(flags *pflag.FlagSet, name, usage string) *commitOption {
+	o := &commitOption{flags: flags, argsIndex: -1}
+	flags.Var(o, name, usage)
+	flags.Lookup(name).NoOptDefVal = commitOptionOmitted
+	return o
+}
+
+func (o *commitOption) String() string { return o.value }
+
+func (o *commitOption) Type() string { return "commit" }
+
+func (o *commitOption) Set(value string) error {
+	o.value, o.argsIndex = value, -1
+	// the arguments are collected in order while parsing, so the next one is the commit
+	if value == commitOptionOmitted {
+		o.argsIndex = len(o.flags.Args())
+	}
+	return nil
+}
+
+// take the commits of the options given without the value from the arguments before "--",
+// returning the rest of the arguments. The option without the argument to take is HEAD.
+func resolveCommitOptions(args []string, argsLenAtDash int, options ...*commitOption) []string {
+	limit := len(args)
+	if argsLenAtDash >= 0 {
+		limit = argsLenAtDash
+	}
+	taken := make(map[int]bool)
+	for _, o := range options {
+		if o.argsIndex < 0 {
+			continue
+		}
+		// the later option takes the next argument if the options are given in a row
+		index := o.argsIndex
+		for taken[index] {
+			index++
+		}
+		if index < limit {
+			o.value = args[index]
+			taken[index] = true
+		} else {
+			o.value = commitOptionDefault
+		}
+		o.argsIndex = -1
+	}
+	var rest []string
+	for i, arg := range args {
+		if !taken[i] {
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// return the name of the current branch, which is empty on the detached HEAD.
+func currentBranch(repo *goit.Repository) string {
+	return strings.TrimPrefix(repo.Head().Target, "refs/heads/")
+}
+
+// resolve the start point of a new branch into the commit hash.
+func resolveStartPoint(repo *goit.Repository, startPoint string) (goit.Hash, error) {
+	hash, err := repo.ResolveRevision(startPoint)
+	if err != nil {
+		return nil, fmt.Errorf("fatal: not a valid object name: '%s'", startPoint)
+	}
+	obj, err := repo.Peel(hash)
+	if err != nil || obj.Type != goit.CommitObject {
+		return nil, fmt.Errorf("fatal: not a valid branch point: '%s'", startPoint)
+	}
+	return obj.Hash, nil
+}
+
+func createBranch(cmd *cobra.Command, branchName, startPoint string) error {
+	repo := repository(cmd)
+	var hash goit.Hash
+	if startPoint == "" {
+		head := repo.Head()
+		if head.Hash == nil {
+			return fmt.Errorf("fatal: not a valid object name: '%s'", currentBranch(repo))
+		}
+		hash = head.Hash
+		startPoint = currentBranch(repo)
+	} else {
+		startHash, err := resolveStartPoint(repo, startPoint)
+		if err != nil {
+			return err
+		}
+		hash = startHash
+	}
+
+	return repo.CreateBranch(cmd.Context(), branchName, hash, startPoint)
+}
+
+func copyBranch(cmd *cobra.Command, srcBranchName, dstBranchName string) error {
+	err := repository(cmd).CopyBranch(cmd.Context(), srcBranchName, dstBranchName)
+	if errors.Is(err, goit.ErrBranchNotFound) {
+		return fmt.Errorf("fatal: no branch named '%s'", srcBranchName)
+	}
+	return err
+}
+
+func deleteBranch(cmd *cobra.Command, branchName string, isForce bool) error {
+	err := repository(cmd).DeleteBranch(cmd.Context(), branchName, isForce)
+	switch {
+	case errors.Is(err, goit.ErrBranchNotFound):
+		return fmt.Errorf("error: branch '%s' not found", branchName)
+	case errors.Is(err, goit.ErrBranchNotMerged):
+		return fmt.Errorf("error: the branch '%s' is not fully merged.\nIf you are sure you want to delete it, run 'goit branch -D %s'", branchName, branchName)
+	}
+	return err
+}
+
+// set the upstream such as origin/main (remote-tracking branch) or main (local branch) of the branch.
+func setUpstream(cmd *cobra.Command, branchName, upstream string) error {
+	name, err := repository(cmd).SetUpstream(branchName, upstream)
+	switch {
+	case errors.Is(err, goit.ErrBranchNotFound):
+		return fmt.Errorf("fatal: branch '%s' does not exist", branchName)
+	case errors.Is(err, goit.ErrUpstreamNotFound):
+		return fmt.Errorf("fatal: the requested upstream branch '%s' does not exist", upstream)
+	case err != nil:
+		return err
+	}
+
+	fmt.Printf("branch '%s' set up to track '%s'.\n", branchName, name)
+	return nil
+}
+
+func unsetUpstream(cmd *cobra.Command, branchName string) error {
+	err := repository(cmd).UnsetUpstream(branchName)
+	if errors.Is(err, goit.ErrNoUpstream) {
+		return fmt.Errorf("fatal: branch '%s' has no upstream information", branchName)
+	}
+	return err
+}
+
+// return the tracking information such as "[origin/main: ahead 1, behind 2] ".
+func trackingInfo(repo *goit.Repository, ref *refformat.Ref) (string, error) {
+	if ref.Upstream == "" {
+		return "", nil
+	}
+	upstreamName := goit.ShortReferenceName(ref.Upstream)
+
+	upstreamRef, err := repo.Reference(ref.Upstream)
+	if errors.Is(err, goit.ErrReferenceNotFound) {
+		if branchVerbose >= 2 {
+			return fmt.Sprintf("[%s: gone] ", upstreamName), nil
+		}
+		return "[gone] ", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ahead, behind, err := repo.AheadBehind(ref.Hash, upstreamRef.Hash)
+	if err != nil {
+		return "", err
+	}
+	var counts []string
+	if ahead > 0 {
+		counts = append(counts, fmt.Sprintf("ahead %d", ahead))
+	}
+	if behind > 0 {
+		counts = append(counts, fmt.Sprintf("behind %d", behind))
+	}
+
+	switch {
+	case branchVerbose >= 2 && len(counts) > 0:
+		return fmt.Sprintf("[%s: %s] ", upstreamName, strings.Join(counts, ", ")), nil
+	case branchVerbose >= 2:
+		return fmt.Sprintf("[%s] ", upstreamName), nil
+	case len(counts) > 0:
+		return fmt.Sprintf("[%s] ", strings.Join(counts, ", ")), nil
+	default:
+		return "", nil
+	}
+}
+
+func listBranches(cmd *cobra.Command, patterns []string) error {
+	repo := repository(cmd)
+	var contains, merged, noMerged []string
+	if branchContainsOption.value != "" {
+		contains = append(contains, branchContainsOption.value)
+	}
+	if branchMergedOption.value != "" {
+		merged = append(merged, branchMergedOption.value)
+	}
+	if branchNoMergedOption.value != "" {
+		noMerged = append(noMerged, branchNoMergedOption.value)
+	}
+	filter, err := newRefFilter(repo, contains, merged, noMerged, nil)
+	if err != nil {
+		return err
+	}
+
+	refs, err := collectRefs(cmd.Context(), repo, "refs/heads/")
+	if err != nil {
+		return err
+	}
+	branches := make([]*refformat.Ref, 0, len(refs))
+	for _, ref := range refs {
+		if len(patterns) > 0 {
+			isMatched := false
+			for _, pattern := range patterns {
+				if ok, err := path.Match(pattern, strings.TrimPrefix(ref.Name, "refs/heads/")); err == nil && ok {
+					isMatched = true
+					break
+				}
+			}
+			if !isMatched {
+				continue
+			}
+		}
+		ok, err := filter.match(repo, ref)
+		if err != nil {
+			return err
+		}
+		if ok {
+			branches = append(branches, ref)
+		}
+	}
+	if err := refformat.Sort(branches, branchSortKeys); err != nil {
+		return err
+	}
+
+	abbrev, err := repo.NewAbbreviator(0)
+	if err != nil {
+		return err
+	}
+	width := 0
+	for _, ref := range branches {
+		if n := len(strings.TrimPrefix(ref.Name, "refs/heads/")); n > width {
+			width = n
+		}
+	}
+
+	for _, ref := range branches {
+		name := strings.TrimPrefix(ref.Name, "refs/heads/")
+		marker := "  "
+		if ref.IsHead {
+			marker = "* "
+		}
+		if branchVerbose == 0 {
+			if ref.IsHead {
+				color.Green("%s%s", marker, name)
+			} else {
+				fmt.Printf("%s%s\n", marker, name)
+			}
+			continue
+		}
+
+		track, err := trackingInfo(repo, ref)
+		if err != nil {
+			return err
+		}
+		subject := ""
+		if commit, err := repo.CommitObject(ref.Hash); err == nil {
+			subject, _, _ = strings.Cut(strings.TrimLeft(commit.Message, "\n"), "\n")
+		}
+		paddedName := fmt.Sprintf("%-*s", width, name)
+		if ref.IsHead {
+			paddedName = color.GreenString(paddedName)
+		}
+		fmt.Printf("%s%s %s %s%s\n", marker, paddedName, abbrev.Abbrev(ref.Hash), track, subject)
+	}
+
+	return nil
+}
+
 // branchCmd represents the branch command
 var branchCmd = &cobra.Command{
 	Use:   "branch",
 	Short: "handle with branch operation",
 	Long:  "handle with branch operation",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := repository(cmd)
+		args = resolveCommitOptions(args, cmd.Flags().ArgsLenAtDash(), branchContainsOption, branchMergedOption, branchNoMergedOption)
+
 		// get list flag
 		isList, err := cmd.Flags().GetBool("list")
 		if err != nil {
 			return fmt.Errorf("fail to get list flag: %w", err)
 		}
 
-		// parameter validation
-		if !((len(args) == 1 && !isList && renameOption == "" && deleteOption == "") ||
-			(len(args) == 0 && isList && renameOption == "" && deleteOption == "") ||
-			(len(args) == 0 && !isList && renameOption != "" && deleteOption == "") ||
-			(len(args) == 0 && !isList && renameOption == "" && deleteOption != "")) {
+		// parameter validation, only one operation is allowed at once
+		operationCount := 0
+		for _, isSet := range []bool{renameOption != "", deleteOption != "", forceDeleteOption != "", copyOption != "", setUpstreamOption != "", isUnsetUpstream} {
+			if isSet {
+				operationCount++
+			}
+		}
+		isListFilter := branchVerbose > 0 || branchMergedOption.value != "" || branchNoMergedOption.value != "" || branchContainsOption.value != "" || len(branchSortKeys) > 0
+		if operationCount > 1 || (operationCount == 1 && (isList || isListFilter)) {
 			return fmt.Errorf("parameters are not valid")
 		}
 
-		// add branch
-		if len(args) == 1 {
-			addBranchName := args[0]
-			addBranchHash := client.Head.Commit.Hash
-
-			if err := client.Refs.AddBranch(client.RootGoitPath, addBranchName, addBranchHash); err != nil {
-				return fmt.Errorf("fail to add branch '%s': %w", addBranchName, err)
+		switch {
+		// rename current branch
+		case renameOption != "":
+			if len(args) != 0 {
+				return fmt.Errorf("parameters are not valid")
 			}
-			if err := gLogger.WriteBranch(log.NewRecord(log.BranchRecord, nil, addBranchHash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("Created from %s", client.Head.Reference)), addBranchName); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			err := repo.RenameBranch(cmd.Context(), renameOption)
+			if errors.Is(err, goit.ErrInvalidReference) || errors.Is(err, goit.ErrBranchNotFound) {
+				return fmt.Errorf("fatal: %w", err)
+			}
+			if err != nil {
+				return err
 			}
-		}
-
-		// list branches
-		if isList {
-			client.Refs.ListBranches(client.Head.Reference)
-		}
 
-		// rename current branch
-		if renameOption != "" {
-			prevBranch := client.Head.Reference
-			if err := client.Refs.RenameBranch(client.RootGoitPath, client.Head.Reference, renameOption); err != nil {
-				return fmt.Errorf("fail to rename branch: %w", err)
+		// delete branch, refusing unmerged one unless forced
+		case deleteOption != "" || forceDeleteOption != "":
+			if len(args) != 0 {
+				return fmt.Errorf("parameters are not valid")
 			}
-			// update HEAD
-			if err := client.Head.Update(client.Refs, client.RootGoitPath, renameOption); err != nil {
-				return fmt.Errorf("fail to update HEAD: %w", err)
+			if deleteOption != "" {
+				return deleteBranch(cmd, deleteOption, false)
 			}
-			// log
-			if err := gLogger.WriteHEAD(log.NewRecord(log.BranchRecord, client.Head.Commit.Hash, nil, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("renamed refs/heads/%s to refs/heads/%s", prevBranch, client.Head.Reference))); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			return deleteBranch(cmd, forceDeleteOption, true)
+
+		// copy branch: -c <new> copies the current branch, -c <old> <new> copies <old>
+		case copyOption != "":
+			switch len(args) {
+			case 0:
+				return copyBranch(cmd, currentBranch(repo), copyOption)
+			case 1:
+				return copyBranch(cmd, copyOption, args[0])
+			default:
+				return fmt.Errorf("parameters are not valid")
 			}
-			if err := gLogger.WriteHEAD(log.NewRecord(log.BranchRecord, nil, client.Head.Commit.Hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("renamed refs/heads/%s to refs/heads/%s", prevBranch, client.Head.Reference))); err != nil {
-				return fmt.Errorf("log error: %w", err)
+
+		case setUpstreamOption != "":
+			if len(args) > 1 {
+				return fmt.Errorf("parameters are not valid")
 			}
-			if err := gLogger.DeleteBranch(prevBranch); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			branchName := currentBranch(repo)
+			if len(args) == 1 {
+				branchName = args[0]
 			}
-			if err := gLogger.WriteBranch(log.NewRecord(log.BranchRecord, nil, client.Head.Commit.Hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("Created from %s", prevBranch)), client.Head.Reference); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			return setUpstream(cmd, branchName, setUpstreamOption)
+
+		case isUnsetUpstream:
+			if len(args) > 1 {
+				return fmt.Errorf("parameters are not valid")
 			}
-			if err := gLogger.WriteBranch(log.NewRecord(log.BranchRecord, client.Head.Commit.Hash, client.Head.Commit.Hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("renamed refs/heads/%s refs/heads/%s", prevBranch, client.Head.Reference)), client.Head.Reference); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			branchName := currentBranch(repo)
+			if len(args) == 1 {
+				branchName = args[0]
 			}
-		}
+			return unsetUpstream(cmd, branchName)
 
-		if deleteOption != "" {
-			if err := client.Refs.DeleteBranch(client.RootGoitPath, client.Head.Reference, deleteOption); err != nil {
-				return fmt.Errorf("fail to delete branch: %w", err)
+		// add branch at HEAD or at the start point
+		case len(args) > 0 && !isList && !isListFilter:
+			if len(args) > 2 {
+				return fmt.Errorf("parameters are not valid")
 			}
-			if err := gLogger.DeleteBranch(deleteOption); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			startPoint := ""
+			if len(args) == 2 {
+				startPoint = args[1]
 			}
+			return createBranch(cmd, args[0], startPoint)
+
+		// list branches whose names match the patterns
+		default:
+			return listBranches(cmd, args)
 		}
 
 		return nil
@@ -106,5 +428,14 @@ func init() {
 
 	branchCmd.Flags().BoolP("list", "l", false, "show list of branches")
 	branchCmd.Flags().StringVarP(&renameOption, "rename", "r", "", "rename branch")
-	branchCmd.Flags().StringVarP(&deleteOption, "delete", "d", "", "delete branch")
+	branchCmd.Flags().StringVarP(&deleteOption, "delete", "d", "", "delete branch which is fully merged")
+	branchCmd.Flags().StringVarP(&forceDeleteOption, "force-delete", "D", "", "delete branch even if it is not merged")
+	branchCmd.Flags().StringVarP(&copyOption, "copy", "c", "", "copy branch together with its config and reflog")
+	branchCmd.Flags().StringVarP(&setUpstreamOption, "set-upstream-to", "u", "", "set up tracking information of the branch")
+	branchCmd.Flags().BoolVar(&isUnsetUpstream, "unset-upstream", false, "remove the upstream information of the branch")
+	branchCmd.Flags().CountVarP(&branchVerbose, "verbose", "v", "show hash and subject, give twice to show upstream branch")
+	branchMergedOption = newCommitOption(branchCmd.Flags(), "merged", "list only branches merged into the commit, which is HEAD if omitted")
+	branchNoMergedOption = newCommitOption(branchCmd.Flags(), "no-merged", "list only branches not merged into the commit, which is HEAD if omitted")
+	branchContainsOption = newCommitOption(branchCmd.Flags(), "contains", "list only branches which contain the commit, which is HEAD if omitted")
+	branchCmd.Flags().StringArrayVar(&branchSortKeys, "sort", nil, "field name to sort on; prefix - to sort in descending order")
 }
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestResolveCommitOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantValue string
+		wantRest  []string
+	}{
+		{
+			name:      "success: omitted value takes the next argument",
+			args:      []string{"--merged", "main", "feat*"},
+			wantValue: "main",
+			wantRest:  []string{"feat*"},
+		},
+		{
+			name:      "success: omitted value without argument",
+			args:      []string{"--merged"},
+			wantValue: "HEAD",
+			wantRest:  nil,
+		},
+		{
+			name:      "success: omitted value before --",
+			args:      []string{"--merged", "--", "feat*"},
+			wantValue: "HEAD",
+			wantRest:  []string{"feat*"},
+		},
+		{
+			name:      "success: explicit HEAD with pattern",
+			args:      []string{"--merged=HEAD", "feat*"},
+			wantValue: "HEAD",
+			wantRest:  []string{"feat*"},
+		},
+		{
+			name:      "success: explicit commit with pattern",
+			args:      []string{"--merged=main", "feat*"},
+			wantValue: "main",
+			wantRest:  []string{"feat*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("branch", pflag.ContinueOnError)
+			o := newCommitOption(flags, "merged", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			rest := resolveCommitOptions(flags.Args(), flags.ArgsLenAtDash(), o)
+			if o.value != tt.wantValue {
+				t.Errorf("got = %s, want = %s", o.value, tt.wantValue)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("got = %v, want = %v", rest, tt.wantRest)
+			}
+		})
+	}
+}
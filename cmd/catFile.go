@@ -4,32 +4,206 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"strings"
 
-	"github.com/JunNishimura/Goit/internal/object"
-	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
+const (
+	defaultCatFileBatchFormat = "%(objectname) %(objecttype) %(objectsize)"
+)
+
+var (
+	catFileBatch        string
+	catFileBatchCheck   string
+	isCatFileAllObjects bool
+	isCatFileTextconv   bool
+	catFileAtomRegexp   = regexp.MustCompile(`%\(([a-z]+)\)`)
+)
+
+var (
+	ErrObjectNotExist = errors.New("fatal: object does not exist")
+)
+
+// split the object name of the form <rev>:<path> into the revision and the path.
+// The colon inside the braces such as HEAD@{2023-10-01 12:00:00} is not the separator.
+func splitObjectPath(name string) (string, string, bool) {
+	depth := 0
+	for i, c := range name {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ':':
+			if depth == 0 {
+				return name[:i], name[i+1:], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// return the object of the name, which is either a revision, <rev>:<path> for the blob in the tree
+// or :<path> for the blob in the index. The path is returned together if it is given.
+func catFileObject(repo *goit.Repository, name string) (*goit.Object, string, error) {
+	rev, p, ok := splitObjectPath(name)
+	if !ok {
+		hash, err := resolveRevision(repo, name)
+		if err != nil {
+			return nil, "", err
+		}
+		obj, err := repo.Object(hash)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidHash, name)
+		}
+		return obj, "", nil
+	}
+
+	if rev == "" {
+		entry, isFound := repo.Index().Entry(p, 0)
+		if !isFound {
+			return nil, "", fmt.Errorf("fatal: path '%s' does not exist in the index", p)
+		}
+		obj, err := repo.Object(entry.Hash)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidHash, name)
+		}
+		return obj, p, nil
+	}
+
+	files, err := treeishFiles(repo, rev)
+	if err != nil {
+		return nil, "", err
+	}
+	hash, ok := files[p]
+	if !ok {
+		return nil, "", fmt.Errorf("fatal: path '%s' does not exist in '%s'", p, rev)
+	}
+	obj, err := repo.Object(hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidHash, name)
+	}
+	return obj, p, nil
+}
+
+// convert the blob into the text by the textconv filter of the diff attribute of the path.
+func textconv(repo *goit.Repository, obj *goit.Object, p string) ([]byte, error) {
+	data, err := repo.Textconv(obj, p)
+	if errors.Is(err, goit.ErrTextconvFailed) {
+		return nil, fmt.Errorf("fatal: %w", err)
+	}
+	return data, err
+}
+
+// expand the atoms such as %(objectname) in the format of --batch and --batch-check.
+func expandCatFileFormat(format string, obj *goit.Object, size int, rest string) string {
+	return catFileAtomRegexp.ReplaceAllStringFunc(format, func(atom string) string {
+		switch atom {
+		case "%(objectname)":
+			return obj.Hash.String()
+		case "%(objecttype)":
+			return obj.Type.String()
+		case "%(objectsize)":
+			return fmt.Sprint(size)
+		case "%(rest)":
+			return rest
+		default:
+			return atom
+		}
+	})
+}
+
+func validateCatFileFormat(format string) error {
+	for _, matches := range catFileAtomRegexp.FindAllStringSubmatch(format, -1) {
+		switch matches[1] {
+		case "objectname", "objecttype", "objectsize", "rest":
+		default:
+			return fmt.Errorf("fatal: unknown format element: %s", matches[0])
+		}
+	}
+	return nil
+}
+
+// answer the object names read from r, printing the content as well unless isCheck is set.
+// The responses are flushed one by one so that the caller can interleave the requests and the responses.
+func catFileBatchMode(ctx context.Context, repo *goit.Repository, r io.Reader, w io.Writer, format string, isCheck bool) error {
+	if err := validateCatFileFormat(format); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	isRestUsed := strings.Contains(format, "%(rest)")
+
+	answer := func(name, rest string) error {
+		obj, p, err := catFileObject(repo, name)
+		switch {
+		case errors.Is(err, goit.ErrAmbiguousHash):
+			fmt.Fprintf(bw, "%s ambiguous\n", name)
+			return bw.Flush()
+		case err != nil:
+			fmt.Fprintf(bw, "%s missing\n", name)
+			return bw.Flush()
+		}
+		data := obj.Data
+		if isCatFileTextconv {
+			if data, err = textconv(repo, obj, p); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(bw, expandCatFileFormat(format, obj, len(data), rest))
+		if !isCheck {
+			bw.Write(data)
+			bw.WriteString("\n")
+		}
+		return bw.Flush()
+	}
+
+	if isCatFileAllObjects {
+		return repo.Objects(ctx, func(obj *goit.Object) error {
+			return answer(obj.Hash.String(), "")
+		})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		name, rest := scanner.Text(), ""
+		// the rest of the line after the first whitespace is passed through with %(rest)
+		if isRestUsed {
+			if i := strings.IndexAny(name, " \t"); i >= 0 {
+				name, rest = name[:i], strings.TrimLeft(name[i:], " \t")
+			}
+		}
+		if err := answer(name, rest); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("fail to read stdin: %w", err)
+	}
+	return nil
+}
+
 // catFileCmd represents the catFile command
 var catFileCmd = &cobra.Command{
 	Use:   "cat-file",
 	Short: "cat goit object",
 	Long:  "this is a command to show the goit object",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// args validation check
-		if len(args) == 0 {
-			return ErrNotSpecifiedHash
-		}
-		if len(args) > 1 {
-			return ErrTooManyArgs
-		}
+		repo := repository(cmd)
 
 		// get flags
 		typeFlag, err := cmd.Flags().GetBool("type")
@@ -40,20 +214,70 @@ var catFileCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("fail to get print flag: %w", err)
 		}
+		sizeFlag, err := cmd.Flags().GetBool("size")
+		if err != nil {
+			return fmt.Errorf("fail to get size flag: %w", err)
+		}
+		existsFlag, err := cmd.Flags().GetBool("exists")
+		if err != nil {
+			return fmt.Errorf("fail to get exists flag: %w", err)
+		}
+		isBatch := cmd.Flags().Changed("batch")
+		isBatchCheck := cmd.Flags().Changed("batch-check")
+
+		// batch mode reads the object names from stdin
+		if isBatch || isBatchCheck {
+			if isBatch && isBatchCheck {
+				return fmt.Errorf("%w: --batch and --batch-check", ErrIncompatibleFlag)
+			}
+			if typeFlag || printFlag || sizeFlag || existsFlag {
+				return fmt.Errorf("%w: the batch mode and the others", ErrIncompatibleFlag)
+			}
+			if len(args) > 0 {
+				return ErrTooManyArgs
+			}
+			format := catFileBatch
+			if isBatchCheck {
+				format = catFileBatchCheck
+			}
+			return catFileBatchMode(cmd.Context(), repo, cmd.InOrStdin(), cmd.OutOrStdout(), format, isBatchCheck)
+		}
+		if isCatFileAllObjects {
+			return fmt.Errorf("%w: --batch-all-objects requires --batch or --batch-check", ErrInvalidArgs)
+		}
+
+		// args validation check
+		if len(args) == 0 {
+			return ErrNotSpecifiedHash
+		}
+		if len(args) > 1 {
+			return ErrTooManyArgs
+		}
 
 		// flag check
-		if typeFlag && printFlag {
+		modeCount := 0
+		for _, flag := range []bool{typeFlag, printFlag, sizeFlag, existsFlag, isCatFileTextconv} {
+			if flag {
+				modeCount++
+			}
+		}
+		if modeCount > 1 {
 			return ErrIncompatibleFlag
 		}
 
-		// get object from hash
-		hash, err := sha.ReadHash(args[0])
-		if err != nil {
-			return ErrInvalidHash
+		// get object from the name
+		obj, p, err := catFileObject(repo, args[0])
+		if existsFlag {
+			// exit silently with an error so that scripts can tell the object does not exist
+			if errors.Is(err, ErrInvalidHash) {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+				return ErrObjectNotExist
+			}
+			return err
 		}
-		obj, err := object.GetObject(client.RootGoitPath, hash)
 		if err != nil {
-			return ErrInvalidHash
+			return err
 		}
 
 		// print object type
@@ -61,14 +285,31 @@ var catFileCmd = &cobra.Command{
 			fmt.Printf("%s\n", obj.Type)
 		}
 
+		// print object size
+		if sizeFlag {
+			fmt.Printf("%d\n", obj.Size)
+		}
+
+		// print the blob converted for the diff
+		if isCatFileTextconv {
+			if p == "" {
+				return fmt.Errorf("%w: <rev>:<path> required with --textconv", ErrInvalidArgs)
+			}
+			data, err := textconv(repo, obj, p)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s", data)
+		}
+
 		// print object content
 		if printFlag {
-			if obj.Type == object.TreeObject {
+			if obj.Type == goit.TreeObject {
 				// need to print out in the different way since hash is written as hexideciaml in data of tree object
 				// convert tree object to tree and print out
-				tree, err := object.NewTree(client.RootGoitPath, obj)
+				tree, err := repo.TreeObject(obj.Hash)
 				if err != nil {
-					return fmt.Errorf("fail to get new tree: %w", err)
+					return err
 				}
 				fmt.Printf("%s\n", tree)
 			} else {
@@ -86,4 +327,12 @@ func init() {
 
 	catFileCmd.Flags().BoolP("type", "t", false, "print object type")
 	catFileCmd.Flags().BoolP("print", "p", false, "print object content")
+	catFileCmd.Flags().BoolP("size", "s", false, "print object size")
+	catFileCmd.Flags().BoolP("exists", "e", false, "exit with zero status if the object exists")
+	catFileCmd.Flags().StringVar(&catFileBatch, "batch", "", "print the information and the content of the objects read from stdin")
+	catFileCmd.Flags().Lookup("batch").NoOptDefVal = defaultCatFileBatchFormat
+	catFileCmd.Flags().StringVar(&catFileBatchCheck, "batch-check", "", "print the information of the objects read from stdin")
+	catFileCmd.Flags().Lookup("batch-check").NoOptDefVal = defaultCatFileBatchFormat
+	catFileCmd.Flags().BoolVar(&isCatFileAllObjects, "batch-all-objects", false, "answer all the objects in the repository instead of stdin")
+	catFileCmd.Flags().BoolVar(&isCatFileTextconv, "textconv", false, "print the blob converted by the textconv filter of the diff attribute")
 }
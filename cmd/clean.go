@@ -0,0 +1,194 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/pathspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanForceFlag       bool
+	cleanDryRunFlag      bool
+	cleanDirFlag         bool
+	cleanIgnoredFlag     bool
+	cleanOnlyIgnoredFlag bool
+	cleanExcludes        []string
+)
+
+// cleaner collects the untracked paths to be removed from the working tree.
+type cleaner struct {
+	repo     *goit.Repository
+	index    *goit.Index
+	excludes *goit.IgnoreRules // patterns given by -e
+	ps       *pathspec.Pathspec
+	hasArgs  bool     // pathspec is given, which makes -d unnecessary to remove the untracked directories
+	paths    []string // files, and directories with trailing "/"
+}
+
+// tell whether the untracked path is the target of the cleaning by the ignore rules.
+func (c *cleaner) isTarget(p string, isDir bool) bool {
+	isIgnored := c.repo.IsIgnored(p, isDir)
+	isExcluded := c.excludes.Match(p, isDir)
+	switch {
+	case cleanOnlyIgnoredFlag:
+		return isIgnored || isExcluded
+	case cleanIgnoredFlag:
+		return !isExcluded
+	default:
+		return !isIgnored && !isExcluded
+	}
+}
+
+// walk the directory and collect the paths to be removed.
+// It returns true if everything under the directory is to be removed.
+func (c *cleaner) walk(dir string) (bool, error) {
+	files, err := os.ReadDir(filepath.Join(c.repo.WorkDir(), filepath.FromSlash(dir)))
+	if err != nil {
+		return false, fmt.Errorf("fail to read directory %s: %w", dir, err)
+	}
+
+	removeAll := true
+	for _, file := range files {
+		p := file.Name()
+		if dir != "" {
+			p = fmt.Sprintf("%s/%s", dir, p)
+		}
+		if p == ".goit" {
+			removeAll = false
+			continue
+		}
+
+		if !file.IsDir() {
+			if c.index.IsTracked(p) || !c.isTarget(p, false) || !c.ps.Match(p) {
+				removeAll = false
+				continue
+			}
+			c.paths = append(c.paths, p)
+			continue
+		}
+
+		if c.index.IsTrackedDir(p) {
+			removeAll = false
+			if _, err := c.walk(p); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		// untracked directory
+		if !cleanDirFlag && !c.hasArgs {
+			removeAll = false
+			continue
+		}
+		// nested repository is never removed
+		if _, err := os.Stat(filepath.Join(c.repo.WorkDir(), filepath.FromSlash(p), ".goit")); err == nil {
+			removeAll = false
+			continue
+		}
+		// the contents of the ignored directory are kept unless ignored files are to be removed
+		if c.repo.IsIgnored(p, true) && !cleanIgnoredFlag && !cleanOnlyIgnoredFlag {
+			removeAll = false
+			continue
+		}
+		if cleanOnlyIgnoredFlag && c.isTarget(p, true) && cleanDirFlag && c.ps.Match(p) {
+			c.paths = append(c.paths, p+"/")
+			continue
+		}
+		if c.excludes.Match(p, true) && !cleanOnlyIgnoredFlag {
+			removeAll = false
+			continue
+		}
+
+		n := len(c.paths)
+		isAll, err := c.walk(p)
+		if err != nil {
+			return false, err
+		}
+		// the empty directory is not an ignored file
+		if cleanOnlyIgnoredFlag && len(c.paths) == n {
+			isAll = false
+		}
+		if isAll && cleanDirFlag && c.ps.Match(p) {
+			// remove the directory as a whole instead of the files under it
+			c.paths = append(c.paths[:n], p+"/")
+			continue
+		}
+		removeAll = false
+	}
+
+	return removeAll, nil
+}
+
+// cleanCmd represents the clean command
+var cleanCmd = &cobra.Command{
+	Use:   "clean [<pathspec>...]",
+	Short: "remove untracked files from the working tree",
+	Long:  "remove untracked files from the working tree. Nothing is removed without force flag",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cleanIgnoredFlag && cleanOnlyIgnoredFlag {
+			return fmt.Errorf("fatal: -x and -X cannot be used together")
+		}
+
+		// clean is limited to the current directory without pathspec
+		hasArgs := len(args) > 0
+		if !hasArgs {
+			args = []string{"."}
+		}
+		repo := repository(cmd)
+		ps, prefix, err := parsePathspec(repo, args)
+		if err != nil {
+			return err
+		}
+
+		c := &cleaner{
+			repo:     repo,
+			index:    repo.Index(),
+			excludes: goit.NewIgnoreRules(cleanExcludes),
+			ps:       ps,
+			hasArgs:  hasArgs,
+		}
+		if _, err := c.walk(""); err != nil {
+			return err
+		}
+
+		// dry run is the default unless force flag is given
+		isDryRun := cleanDryRunFlag || !cleanForceFlag
+		for _, p := range c.paths {
+			display := displayPath(prefix, p)
+			if isDryRun {
+				fmt.Printf("Would remove %s\n", display)
+				continue
+			}
+			fmt.Printf("Removing %s\n", display)
+			if err := os.RemoveAll(filepath.Join(repo.WorkDir(), filepath.FromSlash(p))); err != nil {
+				return fmt.Errorf("fail to remove %s: %w", p, err)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().BoolVarP(&cleanForceFlag, "force", "f", false, "remove the files actually")
+	cleanCmd.Flags().BoolVarP(&cleanDryRunFlag, "dry-run", "n", false, "only show what would be done, which is the default")
+	cleanCmd.Flags().BoolVarP(&cleanDirFlag, "dir", "d", false, "remove whole untracked directories")
+	cleanCmd.Flags().BoolVarP(&cleanIgnoredFlag, "ignored", "x", false, "remove ignored files as well")
+	cleanCmd.Flags().BoolVarP(&cleanOnlyIgnoredFlag, "only-ignored", "X", false, "remove only ignored files")
+	cleanCmd.Flags().StringArrayVarP(&cleanExcludes, "exclude", "e", []string{}, "add the pattern to the ignore rules")
+}
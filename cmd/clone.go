@@ -0,0 +1,55 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloneOrigin       string
+	isCloneNoCheckout bool
+)
+
+// cloneCmd represents the clone command
+var cloneCmd = &cobra.Command{
+	Use:   "clone <repository> [<directory>]",
+	Short: "clone a repository into a new directory",
+	Long:  "this is a command to clone the local repository into a new directory",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// args validation check
+		if len(args) == 0 {
+			return fmt.Errorf("%w: you must specify a repository to clone", ErrInvalidArgs)
+		}
+		if len(args) > 2 {
+			return ErrTooManyArgs
+		}
+		dir := filepath.Base(strings.TrimSuffix(filepath.Clean(args[0]), ".goit"))
+		if len(args) == 2 {
+			dir = args[1]
+		}
+
+		fmt.Printf("Cloning into '%s'...\n", dir)
+		if _, err := goit.Clone(cmd.Context(), args[0], dir, &goit.CloneOptions{
+			RemoteName: cloneOrigin,
+			NoCheckout: isCloneNoCheckout,
+		}); err != nil {
+			return checkoutError(err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+
+	cloneCmd.Flags().StringVarP(&cloneOrigin, "origin", "o", "origin", "use the name instead of origin to track the source repository")
+	cloneCmd.Flags().BoolVarP(&isCloneNoCheckout, "no-checkout", "n", false, "do not check out HEAD after the clone")
+}
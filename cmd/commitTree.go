@@ -0,0 +1,116 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commitTreeParents  []string
+	commitTreeMessages []string
+	commitTreeFile     string
+)
+
+// read the commit message from -m, -F or stdin. The paragraphs given by -m are separated by a blank line.
+func commitTreeMessage(r io.Reader) (string, error) {
+	if len(commitTreeMessages) > 0 {
+		return strings.Join(commitTreeMessages, "\n\n") + "\n", nil
+	}
+	if commitTreeFile != "" && commitTreeFile != "-" {
+		data, err := os.ReadFile(commitTreeFile)
+		if err != nil {
+			return "", fmt.Errorf("fatal: could not read log file '%s': %w", commitTreeFile, err)
+		}
+		return string(data), nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("fail to read stdin: %w", err)
+	}
+	return string(data), nil
+}
+
+// commitTreeCmd represents the commitTree command
+var commitTreeCmd = &cobra.Command{
+	Use:   "commit-tree <tree> [(-p <parent>)...] [(-m <message>)...] [-F <file>]",
+	Short: "create a new commit object",
+	Long:  "this is a command to create a new commit object of the tree without updating any reference",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// args validation check
+		if len(args) == 0 {
+			return fmt.Errorf("%w: tree is not specified", ErrInvalidArgs)
+		}
+		if len(args) > 1 {
+			return ErrTooManyArgs
+		}
+		if len(commitTreeMessages) > 0 && commitTreeFile != "" {
+			return fmt.Errorf("%w: -m and -F", ErrIncompatibleFlag)
+		}
+
+		repo := repository(cmd)
+		tree, err := resolveTree(repo, args[0])
+		if err != nil {
+			return err
+		}
+
+		// the duplicate parent is ignored as git does
+		var parents []goit.Hash
+		for _, parent := range commitTreeParents {
+			hash, err := resolveCommit(repo, parent)
+			if err != nil {
+				return err
+			}
+			isDuplicate := false
+			for _, p := range parents {
+				if p.Compare(hash) {
+					isDuplicate = true
+				}
+			}
+			if isDuplicate {
+				fmt.Fprintf(cmd.ErrOrStderr(), "error: duplicate parent %s ignored\n", hash)
+				continue
+			}
+			parents = append(parents, hash)
+		}
+
+		msg, err := commitTreeMessage(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+
+		hash, err := repo.CommitTree(cmd.Context(), tree.Hash(), parents, msg, nil)
+		if errors.Is(err, goit.ErrUserNotSet) {
+			return ErrUserNotSetOnConfig
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), hash)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commitTreeCmd)
+
+	commitTreeCmd.Flags().StringArrayVarP(&commitTreeParents, "parent", "p", nil, "the id of a parent commit object")
+	commitTreeCmd.Flags().StringArrayVarP(&commitTreeMessages, "message", "m", nil, "a paragraph in the commit log message")
+	commitTreeCmd.Flags().StringVarP(&commitTreeFile, "file", "F", "", "read the commit log message from the file, or stdin with -")
+}
@@ -4,11 +4,10 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
 
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +17,7 @@ var configCmd = &cobra.Command{
 	Short: "config setting",
 	Long:  "this is a command to set config",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
@@ -28,55 +27,24 @@ var configCmd = &cobra.Command{
 		if len(args) != 2 {
 			return ErrInvalidArgs
 		}
-		dotSplit := strings.Split(args[0], ".")
-		if len(dotSplit) != 2 {
-			return ErrInvalidArgs
-		}
 
 		// get global flag
 		isGlobal, err := cmd.Flags().GetBool("global")
 		if err != nil {
 			return fmt.Errorf("fail to get global falg: %w", err)
 		}
-
-		// check the existence of global config file
-		var globalConfigPath string
+		scope := goit.LocalConfig
 		if isGlobal {
-			userHomePath, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("fail to get user home dir: %w", err)
-			}
-			globalConfigPath = filepath.Join(userHomePath, ".goitconfig")
-			if _, err := os.Stat(globalConfigPath); os.IsNotExist(err) {
-				if _, err := os.Create(globalConfigPath); err != nil {
-					return fmt.Errorf("%w: %s", ErrIOHandling, globalConfigPath)
-				}
-			}
-		}
-		// check the existence of local config file
-		localConfigPath := filepath.Join(client.RootGoitPath, "config")
-		if _, err := os.Stat(localConfigPath); os.IsNotExist(err) {
-			// if there is no config file, make it
-			if _, err := os.Create(localConfigPath); err != nil {
-				return fmt.Errorf("%w: %s", ErrIOHandling, localConfigPath)
-			}
+			scope = goit.GlobalConfig
 		}
 
 		// add to config
-		identifier := dotSplit[0]
-		key := dotSplit[1]
-		value := args[1]
-		client.Conf.Add(identifier, key, value, isGlobal)
-
-		// write to config
-		var configPath string
-		if isGlobal {
-			configPath = globalConfigPath
-		} else {
-			configPath = localConfigPath
+		err = repository(cmd).SetConfig(args[0], args[1], scope)
+		if errors.Is(err, goit.ErrInvalidConfigKey) {
+			return fmt.Errorf("error: %w", err)
 		}
-		if err := client.Conf.Write(configPath, isGlobal); err != nil {
-			return fmt.Errorf("fail to write config: %w", err)
+		if err != nil {
+			return err
 		}
 
 		return nil
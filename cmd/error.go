@@ -1,9 +1,13 @@
 package cmd
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/JunNishimura/Goit/goit"
+)
 
 var (
-	ErrGoitNotInitialized = errors.New("not a goit repository (or any of the parent directories): .goit")
+	ErrGoitNotInitialized = goit.ErrRepositoryNotExists
 	ErrIOHandling         = errors.New("IO handling error")
 	ErrInvalidArgs        = errors.New("fatal: invalid arguments")
 	ErrIncompatibleFlag   = errors.New("error: incompatible pair of flags")
@@ -11,4 +15,6 @@ var (
 	ErrTooManyArgs        = errors.New("error: to many arguments")
 	ErrInvalidHash        = errors.New("error: not a valid object hash")
 	ErrInvalidHEAD        = errors.New("fatal: could not resolve HEAD")
+	ErrNoMatchingRef      = errors.New("error: no matching refs")
+	ErrNoPathspecToRemove = errors.New("fatal: No pathspec was given. Which files should I remove?")
 )
@@ -0,0 +1,264 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/refformat"
+	"github.com/spf13/cobra"
+)
+
+var (
+	forEachRefFormat   string
+	forEachRefSortKeys []string
+	forEachRefContains []string
+	forEachRefMerged   []string
+	forEachRefNoMerged []string
+	forEachRefPointsAt []string
+	forEachRefCount    int
+)
+
+// return true if the ref name matches the pattern, which is either
+// a prefix ending at a slash boundary or a glob like refs/heads/feature/*.
+func matchRefPattern(refName, pattern string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := path.Match(pattern, refName)
+		return err == nil && ok
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	return refName == pattern || strings.HasPrefix(refName, pattern+"/")
+}
+
+// collect the refs under the prefix with the objects they point to,
+// the upstream of the branches and the current branch.
+func collectRefs(ctx context.Context, repo *goit.Repository, prefix string) ([]*refformat.Ref, error) {
+	headRefName := repo.Head().Target
+	var refs []*refformat.Ref
+	if err := repo.References(ctx, prefix, func(r *goit.Reference) error {
+		obj, err := repo.Object(r.Hash)
+		if err != nil {
+			return fmt.Errorf("fail to get object of %s: %w", r.Name, err)
+		}
+		ref := &refformat.Ref{
+			Name:   r.Name,
+			Hash:   r.Hash,
+			Symref: r.Target,
+			Object: obj,
+		}
+		if obj.Type == goit.TagObject {
+			peeled, err := repo.Peel(obj.Hash)
+			if err != nil {
+				return fmt.Errorf("fail to peel %s: %w", r.Name, err)
+			}
+			ref.Peeled = peeled
+		}
+		if strings.HasPrefix(r.Name, "refs/heads/") {
+			branchName := strings.TrimPrefix(r.Name, "refs/heads/")
+			if upstream, ok := repo.Upstream(branchName); ok {
+				ref.Upstream = upstream
+			}
+			ref.IsHead = r.Name == headRefName
+		}
+		refs = append(refs, ref)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// refFilter narrows down the refs by the commits they point to.
+type refFilter struct {
+	contains []goit.Hash // the ref must contain one of them
+	merged   []goit.Hash // the ref must be merged into one of them
+	noMerged []goit.Hash // the ref must not be merged into any of them
+	pointsAt []goit.Hash // the ref must point to one of them
+}
+
+func newRefFilter(repo *goit.Repository, contains, merged, noMerged, pointsAt []string) (*refFilter, error) {
+	filter := &refFilter{}
+	for _, revs := range []struct {
+		names  []string
+		hashes *[]goit.Hash
+	}{
+		{contains, &filter.contains},
+		{merged, &filter.merged},
+		{noMerged, &filter.noMerged},
+		{pointsAt, &filter.pointsAt},
+	} {
+		for _, name := range revs.names {
+			hash, err := resolveRevision(repo, name)
+			if err != nil {
+				return nil, err
+			}
+			*revs.hashes = append(*revs.hashes, hash)
+		}
+	}
+	return filter, nil
+}
+
+// return the commit hash the ref finally points to, or nil if it is not a commit.
+func refCommitHash(ref *refformat.Ref) goit.Hash {
+	obj := ref.Object
+	if ref.Peeled != nil {
+		obj = ref.Peeled
+	}
+	if obj == nil || obj.Type != goit.CommitObject {
+		return nil
+	}
+	return obj.Hash
+}
+
+func (f *refFilter) match(repo *goit.Repository, ref *refformat.Ref) (bool, error) {
+	if len(f.pointsAt) > 0 {
+		isPointed := false
+		for _, hash := range f.pointsAt {
+			if ref.Hash.Compare(hash) || (ref.Peeled != nil && ref.Peeled.Hash.Compare(hash)) {
+				isPointed = true
+				break
+			}
+		}
+		if !isPointed {
+			return false, nil
+		}
+	}
+
+	if len(f.contains) == 0 && len(f.merged) == 0 && len(f.noMerged) == 0 {
+		return true, nil
+	}
+	commitHash := refCommitHash(ref)
+	if commitHash == nil {
+		return false, nil
+	}
+	if len(f.contains) > 0 {
+		isContained := false
+		for _, hash := range f.contains {
+			ok, err := repo.IsAncestor(hash, commitHash)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				isContained = true
+				break
+			}
+		}
+		if !isContained {
+			return false, nil
+		}
+	}
+	if len(f.merged) > 0 {
+		isMerged := false
+		for _, hash := range f.merged {
+			ok, err := repo.IsAncestor(commitHash, hash)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				isMerged = true
+				break
+			}
+		}
+		if !isMerged {
+			return false, nil
+		}
+	}
+	for _, hash := range f.noMerged {
+		ok, err := repo.IsAncestor(commitHash, hash)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// forEachRefCmd represents the forEachRef command
+var forEachRefCmd = &cobra.Command{
+	Use:   "for-each-ref [<pattern>...]",
+	Short: "output information on each ref",
+	Long:  "this is a command to iterate over refs and show them in the given format",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := refformat.Parse(forEachRefFormat)
+		if err != nil {
+			return err
+		}
+		repo := repository(cmd)
+		filter, err := newRefFilter(repo, forEachRefContains, forEachRefMerged, forEachRefNoMerged, forEachRefPointsAt)
+		if err != nil {
+			return err
+		}
+
+		refs, err := collectRefs(cmd.Context(), repo, "refs/")
+		if err != nil {
+			return err
+		}
+
+		filtered := make([]*refformat.Ref, 0, len(refs))
+		for _, ref := range refs {
+			if len(args) > 0 {
+				isMatched := false
+				for _, pattern := range args {
+					if matchRefPattern(ref.Name, pattern) {
+						isMatched = true
+						break
+					}
+				}
+				if !isMatched {
+					continue
+				}
+			}
+			ok, err := filter.match(repo, ref)
+			if err != nil {
+				return err
+			}
+			if ok {
+				filtered = append(filtered, ref)
+			}
+		}
+
+		if err := refformat.Sort(filtered, forEachRefSortKeys); err != nil {
+			return err
+		}
+		if forEachRefCount > 0 && forEachRefCount < len(filtered) {
+			filtered = filtered[:forEachRefCount]
+		}
+
+		for _, ref := range filtered {
+			line, err := format.Expand(ref)
+			if err != nil {
+				return err
+			}
+			fmt.Println(line)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forEachRefCmd)
+
+	forEachRefCmd.Flags().StringVar(&forEachRefFormat, "format", refformat.DefaultFormat, "format to show each ref, such as %(refname)")
+	forEachRefCmd.Flags().StringArrayVar(&forEachRefSortKeys, "sort", nil, "field name to sort on; prefix - to sort in descending order")
+	forEachRefCmd.Flags().StringArrayVar(&forEachRefContains, "contains", nil, "only list refs which contain the commit")
+	forEachRefCmd.Flags().StringArrayVar(&forEachRefMerged, "merged", nil, "only list refs whose tips are reachable from the commit")
+	forEachRefCmd.Flags().StringArrayVar(&forEachRefNoMerged, "no-merged", nil, "only list refs whose tips are not reachable from the commit")
+	forEachRefCmd.Flags().StringArrayVar(&forEachRefPointsAt, "points-at", nil, "only list refs which point at the object")
+	forEachRefCmd.Flags().IntVar(&forEachRefCount, "count", 0, "stop after showing the given number of refs")
+}
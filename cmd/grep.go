@@ -0,0 +1,315 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/grep"
+	"github.com/JunNishimura/Goit/internal/pathspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepTokens           []grep.Token
+	isGrepCached         bool
+	isGrepUntracked      bool
+	isGrepNoExclude      bool
+	isGrepLineNumber     bool
+	isGrepIgnoreCase     bool
+	isGrepWordRegexp     bool
+	isGrepFilesWithMatch bool
+	isGrepCount          bool
+	isGrepExtended       bool
+	isGrepFixed          bool
+)
+
+var (
+	ErrNoMatchingLine = errors.New("error: no matching lines")
+)
+
+// grepTokenValue records the pattern or the operator in the order given on the command line,
+// since the meaning of the expression depends on the order of -e, --and, --or and --not.
+type grepTokenValue struct {
+	tokenType grep.TokenType
+}
+
+func (v *grepTokenValue) String() string {
+	return ""
+}
+
+func (v *grepTokenValue) Set(s string) error {
+	token := grep.Token{Type: v.tokenType}
+	if v.tokenType == grep.PatternToken {
+		token.Pattern = s
+	}
+	grepTokens = append(grepTokens, token)
+	return nil
+}
+
+func (v *grepTokenValue) Type() string {
+	if v.tokenType == grep.PatternToken {
+		return "string"
+	}
+	return "bool"
+}
+
+// return the sources of the tracked files in the working tree, adding the untracked files if required.
+func worktreeGrepSources(repo *goit.Repository, ps *pathspec.Pathspec) ([]*grep.Source, error) {
+	workDir := repo.WorkDir()
+	isAdded := make(map[string]bool)
+	var paths []string
+	for _, entry := range repo.Index().Entries() {
+		if p := string(entry.Path); !isAdded[p] {
+			isAdded[p] = true
+			paths = append(paths, p)
+		}
+	}
+	if isGrepUntracked {
+		filePaths, err := repo.Worktree().Files(isGrepNoExclude)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range filePaths {
+			if !isAdded[p] {
+				isAdded[p] = true
+				paths = append(paths, p)
+			}
+		}
+		sort.Strings(paths)
+	}
+
+	var sources []*grep.Source
+	for _, p := range ps.Filter(paths) {
+		absPath := filepath.Join(workDir, filepath.FromSlash(p))
+		info, err := os.Lstat(absPath)
+		if err != nil || !info.Mode().IsRegular() {
+			// the file deleted from the working tree is not searched
+			continue
+		}
+		sources = append(sources, &grep.Source{
+			Path: p,
+			Load: func() ([]byte, error) {
+				return os.ReadFile(absPath)
+			},
+		})
+	}
+	return sources, nil
+}
+
+func blobGrepSource(repo *goit.Repository, path string, hash goit.Hash) *grep.Source {
+	return &grep.Source{
+		Path: path,
+		Load: func() ([]byte, error) {
+			obj, err := repo.Object(hash)
+			if err != nil {
+				return nil, err
+			}
+			return obj.Data, nil
+		},
+	}
+}
+
+// return the sources of the blobs in the index.
+func indexGrepSources(repo *goit.Repository, ps *pathspec.Pathspec) []*grep.Source {
+	var sources []*grep.Source
+	for _, entry := range repo.Index().Entries() {
+		// the unmerged paths are searched in the first stage found
+		if p := string(entry.Path); ps.Match(p) && (len(sources) == 0 || sources[len(sources)-1].Path != p) {
+			sources = append(sources, blobGrepSource(repo, p, entry.Hash))
+		}
+	}
+	return sources
+}
+
+// return the sources of the blobs in the tree-ish.
+func treeishGrepSources(repo *goit.Repository, files map[string]goit.Hash, ps *pathspec.Pathspec) []*grep.Source {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var sources []*grep.Source
+	for _, p := range ps.Filter(paths) {
+		sources = append(sources, blobGrepSource(repo, p, files[p]))
+	}
+	return sources
+}
+
+// print the results, where the names are prefixed with the revision if it is given.
+func printGrepResults(results []*grep.Result, rev, prefix string) {
+	for _, result := range results {
+		name := displayPath(prefix, result.Path)
+		if rev != "" {
+			name = rev + ":" + name
+		}
+		switch {
+		case isGrepFilesWithMatch:
+			fmt.Println(name)
+		case isGrepCount:
+			fmt.Printf("%s:%d\n", name, len(result.Matches))
+		case result.IsBinary:
+			fmt.Printf("Binary file %s matches\n", name)
+		default:
+			for _, match := range result.Matches {
+				if isGrepLineNumber {
+					fmt.Printf("%s:%d:%s\n", name, match.LineNumber, match.Line)
+				} else {
+					fmt.Printf("%s:%s\n", name, match.Line)
+				}
+			}
+		}
+	}
+}
+
+// grepCmd represents the grep command
+var grepCmd = &cobra.Command{
+	Use:   "grep [<options>] [-e] <pattern> [<rev>...] [[--] <path>...]",
+	Short: "print lines matching a pattern",
+	Long:  "print lines matching a pattern in the tracked files, the index or the revisions",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if isGrepExtended && isGrepFixed {
+			return fmt.Errorf("%w: -E and -F", ErrIncompatibleFlag)
+		}
+		if isGrepFilesWithMatch && isGrepCount {
+			return fmt.Errorf("%w: -l and -c", ErrIncompatibleFlag)
+		}
+
+		// split the arguments into the pattern, the revisions and the pathspec
+		revArgs, pathArgs := args, []string{}
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			revArgs, pathArgs = args[:dash], args[dash:]
+		}
+		tokens := grepTokens
+		if len(tokens) == 0 {
+			if len(revArgs) == 0 {
+				return fmt.Errorf("%w: no pattern given", ErrInvalidArgs)
+			}
+			tokens = []grep.Token{{Type: grep.PatternToken, Pattern: revArgs[0]}}
+			revArgs = revArgs[1:]
+		}
+		expr, err := grep.Parse(tokens, grep.Options{
+			IgnoreCase: isGrepIgnoreCase,
+			WordRegexp: isGrepWordRegexp,
+			Extended:   isGrepExtended,
+			Fixed:      isGrepFixed,
+		})
+		if err != nil {
+			return fmt.Errorf("fatal: %w", err)
+		}
+
+		type revision struct {
+			name  string
+			files map[string]goit.Hash
+		}
+		repo := repository(cmd)
+		var revs []*revision
+		for i, arg := range revArgs {
+			files, err := treeishFiles(repo, arg)
+			if err != nil {
+				if cmd.ArgsLenAtDash() >= 0 {
+					return err
+				}
+				// the rest are the paths if they are not separated by "--"
+				pathArgs = append(revArgs[i:], pathArgs...)
+				break
+			}
+			revs = append(revs, &revision{name: arg, files: files})
+		}
+		if isGrepCached && len(revs) > 0 {
+			return fmt.Errorf("%w: --cached and the revisions", ErrIncompatibleFlag)
+		}
+		if isGrepUntracked && (isGrepCached || len(revs) > 0) {
+			return fmt.Errorf("%w: --untracked can be used only with the working tree", ErrIncompatibleFlag)
+		}
+
+		// search under the current directory unless the pathspec is given
+		prefix, err := currentPrefix(repo)
+		if err != nil {
+			return err
+		}
+		if len(pathArgs) == 0 && prefix != "" {
+			pathArgs = []string{"."}
+		}
+		ps, err := pathspec.Parse(pathArgs, prefix)
+		if err != nil {
+			return fmt.Errorf("fatal: %w", err)
+		}
+
+		isMatched := false
+		search := func(sources []*grep.Source, rev string) error {
+			results, err := grep.Run(expr, sources)
+			if err != nil {
+				return err
+			}
+			if len(results) > 0 {
+				isMatched = true
+			}
+			printGrepResults(results, rev, prefix)
+			return nil
+		}
+		switch {
+		case len(revs) > 0:
+			for _, rev := range revs {
+				if err := search(treeishGrepSources(repo, rev.files, ps), rev.name); err != nil {
+					return err
+				}
+			}
+		case isGrepCached:
+			if err := search(indexGrepSources(repo, ps), ""); err != nil {
+				return err
+			}
+		default:
+			sources, err := worktreeGrepSources(repo, ps)
+			if err != nil {
+				return err
+			}
+			if err := search(sources, ""); err != nil {
+				return err
+			}
+		}
+
+		// exit silently with an error so that scripts can tell no line matched
+		if !isMatched {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return ErrNoMatchingLine
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+
+	grepCmd.Flags().VarP(&grepTokenValue{tokenType: grep.PatternToken}, "regexp", "e", "the pattern to search for")
+	grepCmd.Flags().Var(&grepTokenValue{tokenType: grep.AndToken}, "and", "combine the patterns so that a line matches both")
+	grepCmd.Flags().Var(&grepTokenValue{tokenType: grep.OrToken}, "or", "combine the patterns so that a line matches either, which is the default")
+	grepCmd.Flags().Var(&grepTokenValue{tokenType: grep.NotToken}, "not", "match the lines which do not match the next pattern")
+	for _, name := range []string{"and", "or", "not"} {
+		grepCmd.Flags().Lookup(name).NoOptDefVal = "true"
+	}
+	grepCmd.Flags().BoolVar(&isGrepCached, "cached", false, "search the blobs registered in the index")
+	grepCmd.Flags().BoolVar(&isGrepUntracked, "untracked", false, "search also the untracked files which are not ignored")
+	grepCmd.Flags().BoolVar(&isGrepNoExclude, "no-exclude-standard", false, "search also the ignored files with --untracked")
+	grepCmd.Flags().BoolVarP(&isGrepLineNumber, "line-number", "n", false, "prefix the line number to the matched lines")
+	grepCmd.Flags().BoolVarP(&isGrepIgnoreCase, "ignore-case", "i", false, "ignore case differences between the patterns and the files")
+	grepCmd.Flags().BoolVarP(&isGrepWordRegexp, "word-regexp", "w", false, "match the pattern only at word boundary")
+	grepCmd.Flags().BoolVarP(&isGrepFilesWithMatch, "files-with-matches", "l", false, "show only the names of the files which contain matches")
+	grepCmd.Flags().BoolVarP(&isGrepCount, "count", "c", false, "show the number of the matched lines for each file")
+	grepCmd.Flags().BoolVarP(&isGrepExtended, "extended-regexp", "E", false, "use the extended regexp for the patterns")
+	grepCmd.Flags().BoolVarP(&isGrepFixed, "fixed-strings", "F", false, "use the fixed strings for the patterns")
+}
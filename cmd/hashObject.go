@@ -4,48 +4,157 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
 
-	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
+var (
+	hashObjectType       string
+	hashObjectPath       string
+	isHashObjectWrite    bool
+	isHashObjectStdin    bool
+	isHashObjectPaths    bool
+	isHashObjectLiteral  bool
+	isHashObjectNoFilter bool
+)
+
+// make the object of the data, whose path decides the clean filter applied to the blob.
+// The path is empty if no filter is applied.
+func hashObject(repo *goit.Repository, objType goit.ObjectType, p string, data []byte) (*goit.Object, error) {
+	if objType == goit.BlobObject && !isHashObjectNoFilter && p != "" {
+		var err error
+		if data, err = repo.ConvertToBlob(p, data); err != nil {
+			return nil, err
+		}
+	}
+	obj, err := repo.NewObject(objType, data)
+	if err != nil {
+		return nil, err
+	}
+	if !isHashObjectLiteral {
+		if err := obj.Check(); err != nil {
+			return nil, fmt.Errorf("fatal: object fails fsck: %w", err)
+		}
+	}
+	if isHashObjectWrite {
+		if err := repo.WriteObject(obj); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// hash the file given in the current directory. Unless --path is given, the filter is chosen by the path of the file itself.
+func hashObjectFile(repo *goit.Repository, objType goit.ObjectType, prefix, arg string) (*goit.Object, error) {
+	f, err := os.Stat(arg)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf(`fatal: Cannot open '%s': No such file`, arg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrIOHandling, arg)
+	}
+	if f.IsDir() {
+		return nil, fmt.Errorf(`fatal: '%s' is invalid to make blob object`, arg)
+	}
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrIOHandling, arg)
+	}
+
+	p := hashObjectPath
+	if p == "" {
+		// the file outside the repository is filtered only by the patterns matching its base name
+		if p, err = rootRelativePath(prefix, arg); err != nil {
+			p = path.Base(filepath.ToSlash(arg))
+		}
+	}
+	return hashObject(repo, objType, p, data)
+}
+
 // hashObjectCmd represents the hashObject command
 var hashObjectCmd = &cobra.Command{
-	Use:   "hash-object",
+	Use:   "hash-object [<file>...]",
 	Short: "calculate the hash of the file",
 	Long:  "calculate the hash of the file",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		for _, arg := range args {
-			// check if arg is valid
-			f, err := os.Stat(arg)
-			if os.IsNotExist(err) {
-				return fmt.Errorf(`fatal: Cannot open '%s': No such file`, arg)
-			}
-			if f.IsDir() {
-				return fmt.Errorf(`fatal: '%s' is invalid to make blob object`, arg)
+		// flag validation check
+		objType, err := goit.ParseObjectType(hashObjectType)
+		if err != nil {
+			return fmt.Errorf("fatal: invalid object type \"%s\"", hashObjectType)
+		}
+		if isHashObjectStdin && isHashObjectPaths {
+			return fmt.Errorf("%w: --stdin and --stdin-paths", ErrIncompatibleFlag)
+		}
+		if isHashObjectPaths && len(args) > 0 {
+			return fmt.Errorf("%w: --stdin-paths does not take the file arguments", ErrInvalidArgs)
+		}
+		if isHashObjectNoFilter && cmd.Flags().Changed("path") {
+			return fmt.Errorf("%w: --path and --no-filters", ErrIncompatibleFlag)
+		}
+
+		repo := repository(cmd)
+		prefix, err := currentPrefix(repo)
+		if err != nil {
+			return err
+		}
+		if !isHashObjectNoFilter && cmd.Flags().Changed("path") {
+			if hashObjectPath, err = rootRelativePath(prefix, hashObjectPath); err != nil {
+				return err
 			}
+		}
 
-			// get data from file
-			data, err := os.ReadFile(arg)
+		// the content of stdin is hashed before the files, filtered only when --path is given
+		if isHashObjectStdin {
+			data, err := io.ReadAll(cmd.InOrStdin())
 			if err != nil {
-				return fmt.Errorf("%w: %s", ErrIOHandling, arg)
+				return fmt.Errorf("fail to read stdin: %w", err)
 			}
-
-			// make blob object
-			object, err := object.NewObject(object.BlobObject, data)
+			obj, err := hashObject(repo, objType, hashObjectPath, data)
 			if err != nil {
-				return fmt.Errorf("fail to get new object: %w", err)
+				return err
 			}
+			fmt.Fprintln(cmd.OutOrStdout(), obj.Hash)
+		}
 
-			fmt.Printf("%s\n", object.Hash)
+		// the paths are read one per line so that the object names can be read back as they are printed
+		if isHashObjectPaths {
+			w := bufio.NewWriter(cmd.OutOrStdout())
+			scanner := bufio.NewScanner(cmd.InOrStdin())
+			for scanner.Scan() {
+				obj, err := hashObjectFile(repo, objType, prefix, scanner.Text())
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(w, obj.Hash)
+				if err := w.Flush(); err != nil {
+					return fmt.Errorf("fail to write: %w", err)
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("fail to read stdin: %w", err)
+			}
+			return nil
+		}
+
+		for _, arg := range args {
+			obj, err := hashObjectFile(repo, objType, prefix, arg)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), obj.Hash)
 		}
 
 		return nil
@@ -54,4 +163,12 @@ var hashObjectCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(hashObjectCmd)
+
+	hashObjectCmd.Flags().StringVarP(&hashObjectType, "type", "t", "blob", "type of the object to make")
+	hashObjectCmd.Flags().BoolVarP(&isHashObjectWrite, "write", "w", false, "write the object into the object database")
+	hashObjectCmd.Flags().BoolVar(&isHashObjectStdin, "stdin", false, "read the object from stdin instead of the file")
+	hashObjectCmd.Flags().BoolVar(&isHashObjectPaths, "stdin-paths", false, "read the paths of the files from stdin, one per line")
+	hashObjectCmd.Flags().BoolVar(&isHashObjectLiteral, "literally", false, "skip the format check of the object")
+	hashObjectCmd.Flags().StringVar(&hashObjectPath, "path", "", "hash the object as if it were at the path, which decides the filters")
+	hashObjectCmd.Flags().BoolVar(&isHashObjectNoFilter, "no-filters", false, "hash the content as it is without the filters")
 }
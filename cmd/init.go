@@ -7,77 +7,42 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/sha"
 	"github.com/spf13/cobra"
 )
 
+var (
+	initObjectFormat string
+)
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "initialize Goit",
 	Long:  "This is a command to initialize Goit.",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath != "" {
-			return errors.New("goit is already initialized")
+		if repository(cmd) != nil {
+			return goit.ErrRepositoryAlreadyExists
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// make .goit directory
 		curPath, err := os.Getwd()
 		if err != nil {
 			return errors.New("fail to get current path")
 		}
-		goitDir := filepath.Join(curPath, ".goit")
-		if err := os.Mkdir(goitDir, os.ModePerm); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, goitDir)
-		}
-
-		// make .goit/config file
-		configFile := filepath.Join(goitDir, "config")
-		if _, err := os.Create(configFile); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, configFile)
+		repo, err := goit.Init(cmd.Context(), curPath, &goit.InitOptions{ObjectFormat: initObjectFormat})
+		if errors.Is(err, goit.ErrUnknownObjectFormat) {
+			return fmt.Errorf("fatal: %w", err)
 		}
-
-		// make .goit/HEAD file and write main branch
-		headFile := filepath.Join(goitDir, "HEAD")
-		f, err := os.Create(headFile)
 		if err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, headFile)
-		}
-		defer f.Close()
-		// set 'main' as default branch
-		if _, err := f.WriteString("ref: refs/heads/main"); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, headFile)
-		}
-
-		// make .goit/objects directory
-		objectsDir := filepath.Join(goitDir, "objects")
-		if err := os.Mkdir(objectsDir, os.ModePerm); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, objectsDir)
-		}
-
-		// make .goit/refs directory
-		refsDir := filepath.Join(goitDir, "refs")
-		if err := os.Mkdir(refsDir, os.ModePerm); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, refsDir)
-		}
-
-		// make .goit/refs/heads directory
-		headsDir := filepath.Join(refsDir, "heads")
-		if err := os.Mkdir(headsDir, os.ModePerm); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, headsDir)
-		}
-
-		// make .goit/refs/tags directory
-		tagsDir := filepath.Join(refsDir, "tags")
-		if err := os.Mkdir(tagsDir, os.ModePerm); err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, tagsDir)
+			return err
 		}
 
 		// print out message for initialization success
-		fmt.Printf("Initialized empty Goit repository in %s\n", goitDir)
+		fmt.Printf("Initialized empty Goit repository in %s\n", repo.Path())
 
 		return nil
 	},
@@ -85,4 +50,6 @@ var initCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().StringVar(&initObjectFormat, "object-format", sha.SHA1Format.Name, "hash algorithm of the objects: sha1 or sha256")
 }
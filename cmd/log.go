@@ -5,83 +5,204 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 
-	"github.com/JunNishimura/Goit/internal/object"
-	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/diff"
 	"github.com/spf13/cobra"
 )
 
 var (
-	maxCount int
+	maxCount       int
+	logNameStatus  bool
+	logFindRenames string
+	logFindCopies  string
+	logNoRenames   bool
+	logFollow      bool
+	logOneline     bool
+	logAbbrev      bool
 )
 
-type WalkFunc func(commit *object.Commit) error
-
-func walkHistory(rootGoitPath string, hash sha.SHA1, walkFunc WalkFunc) error {
-	queue := []sha.SHA1{hash}
-	visitMap := map[string]struct{}{}
-
-	loopCounter := 0
-	for len(queue) > 0 {
-		loopCounter++
-		if loopCounter > maxCount {
-			break
-		}
-
-		currentHash := queue[0]
-		if _, ok := visitMap[currentHash.String()]; ok {
-			queue = queue[1:]
-			continue
-		}
-		visitMap[currentHash.String()] = struct{}{}
-
-		commitObject, err := object.GetObject(rootGoitPath, currentHash)
-		if err != nil {
-			return err
-		}
-
-		commit, err := object.NewCommit(commitObject)
-		if err != nil {
-			return err
-		}
+// return the files of the commit, which are none if hash is nil.
+func commitFiles(repo *goit.Repository, hash goit.Hash) (map[string]goit.Hash, error) {
+	if hash == nil {
+		return map[string]goit.Hash{}, nil
+	}
+	tree, err := repo.TreeObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Files(), nil
+}
 
-		if err := walkFunc(commit); err != nil {
-			return err
-		}
+// return the changes of the commit from its first parent. Renames and copies are detected unless opts is nil.
+func commitChanges(repo *goit.Repository, commit *goit.Commit, opts *diff.RenameOptions) ([]*diff.FileChange, error) {
+	var parentHash goit.Hash
+	if len(commit.Parents) > 0 {
+		parentHash = commit.Parents[0]
+	}
+	parentFiles, err := commitFiles(repo, parentHash)
+	if err != nil {
+		return nil, err
+	}
+	files, err := commitFiles(repo, commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	changes := diff.Changes(parentFiles, files)
+	if opts == nil {
+		return changes, nil
+	}
+	return diff.DetectRenames(changes, parentFiles, opts)
+}
 
-		queue = append(queue[1:], commit.Parents...)
+// make the options to detect renames from the flags, which is nil if the detection is disabled.
+func logRenameOptions(repo *goit.Repository) (*diff.RenameOptions, error) {
+	if logNoRenames && !logFollow {
+		return nil, nil
+	}
+	similarity := logFindRenames
+	if logFindCopies != "" {
+		similarity = logFindCopies
 	}
+	threshold, err := diff.ParseSimilarity(similarity)
+	if err != nil {
+		return nil, fmt.Errorf("fatal: %w", err)
+	}
+	return &diff.RenameOptions{
+		Threshold:  threshold,
+		FindCopies: logFindCopies != "",
+		Load: func(hash goit.Hash) ([]byte, error) {
+			obj, err := repo.Object(hash)
+			if err != nil {
+				return nil, err
+			}
+			return obj.Data, nil
+		},
+	}, nil
+}
 
-	return nil
+// format the commit to print, abbreviating its hash with --oneline and --abbrev-commit.
+func logCommitString(commit *goit.Commit, abbrev *goit.Abbreviator) string {
+	if logOneline {
+		subject, _, _ := strings.Cut(strings.TrimLeft(commit.Message, "\n"), "\n")
+		return fmt.Sprintf("%s %s", abbrev.Abbrev(commit.Hash), subject)
+	}
+	if logAbbrev {
+		return strings.Replace(commit.String(), commit.Hash.String(), abbrev.Abbrev(commit.Hash), 1)
+	}
+	return commit.String()
 }
 
 // logCmd represents the log command
 var logCmd = &cobra.Command{
-	Use:   "log",
+	Use:   "log [<revision>] [[--] <path>...]",
 	Short: "print commit log",
 	Long:  "this is a command to print commit log",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// the first argument is the revision to start from unless it is a path
+		repo := repository(cmd)
+		head := repo.Head()
+		startHash := head.Hash
+		pathArgs := args
+		if dash := cmd.ArgsLenAtDash(); len(args) > 0 && dash != 0 {
+			if dash > 1 {
+				return fmt.Errorf("%w: only one revision can be given", ErrInvalidArgs)
+			}
+			hash, err := resolveCommit(repo, args[0])
+			switch {
+			case err == nil:
+				startHash = hash
+				pathArgs = args[1:]
+			case dash == 1:
+				return err
+			}
+		}
+
 		// see if committed before
-		dirName := filepath.Join(client.RootGoitPath, "refs", "heads")
-		files, err := os.ReadDir(dirName)
+		if startHash == nil {
+			return fmt.Errorf("fatal: your current branch '%s' does not have any commits yet", goit.ShortReferenceName(head.Target))
+		}
+
+		ps, prefix, err := parsePathspec(repo, pathArgs)
 		if err != nil {
-			return fmt.Errorf("%w: %s", ErrIOHandling, dirName)
+			return err
+		}
+		renameOpts, err := logRenameOptions(repo)
+		if err != nil {
+			return err
 		}
-		if len(files) == 0 {
-			return fmt.Errorf("fatal: your current branch 'main' does not have any commits yet")
+
+		// the path followed across renames
+		var followPath string
+		if logFollow {
+			if len(pathArgs) != 1 {
+				return fmt.Errorf("fatal: --follow requires exactly one pathspec")
+			}
+			followPath, err = rootRelativePath(prefix, pathArgs[0])
+			if err != nil {
+				return err
+			}
+		}
+
+		abbrev, err := repo.NewAbbreviator(0)
+		if err != nil {
+			return err
 		}
 
 		// print log
-		if err := walkHistory(client.RootGoitPath, client.Head.Commit.Hash, func(commit *object.Commit) error {
-			fmt.Println(commit)
+		count := 0
+		if err := repo.Log(cmd.Context(), startHash, func(commit *goit.Commit) error {
+			if count >= maxCount {
+				return goit.ErrStop
+			}
+
+			var changes []*diff.FileChange
+			if logNameStatus || !ps.IsEmpty() {
+				gotChanges, err := commitChanges(repo, commit, renameOpts)
+				if err != nil {
+					return err
+				}
+				for _, c := range gotChanges {
+					switch {
+					case logFollow:
+						if c.Path() == followPath {
+							changes = append(changes, c)
+						}
+					case ps.IsEmpty(), ps.Match(c.Path()), c.OldPath != "" && ps.Match(c.OldPath):
+						changes = append(changes, c)
+					}
+				}
+				if !ps.IsEmpty() && len(changes) == 0 {
+					return nil
+				}
+			}
+
+			fmt.Println(logCommitString(commit, abbrev))
+			if logNameStatus {
+				for _, c := range changes {
+					fmt.Println(c.NameStatus())
+				}
+				if len(changes) > 0 {
+					fmt.Println()
+				}
+			}
+			count++
+
+			// keep following the file by the name before the rename
+			if logFollow {
+				for _, c := range changes {
+					if c.Status == diff.Renamed || c.Status == diff.Copied {
+						followPath = c.OldPath
+					}
+				}
+			}
 			return nil
 		}); err != nil {
 			return fmt.Errorf("fail to log: %w", err)
@@ -95,4 +216,13 @@ func init() {
 	rootCmd.AddCommand(logCmd)
 
 	logCmd.Flags().IntVarP(&maxCount, "max-count", "n", 5, "max count of logs to print")
+	logCmd.Flags().BoolVar(&logNameStatus, "name-status", false, "show the name and the status of changed files")
+	logCmd.Flags().StringVarP(&logFindRenames, "find-renames", "M", "", "detect renames with the similarity threshold such as -M=90%")
+	logCmd.Flags().Lookup("find-renames").NoOptDefVal = "50%"
+	logCmd.Flags().StringVarP(&logFindCopies, "find-copies", "C", "", "detect copies as well as renames with the similarity threshold")
+	logCmd.Flags().Lookup("find-copies").NoOptDefVal = "50%"
+	logCmd.Flags().BoolVar(&logNoRenames, "no-renames", false, "do not detect renames")
+	logCmd.Flags().BoolVar(&logOneline, "oneline", false, "print each commit in a line with the abbreviated hash and the subject")
+	logCmd.Flags().BoolVar(&logAbbrev, "abbrev-commit", false, "print the abbreviated commit hash")
+	logCmd.Flags().BoolVar(&logFollow, "follow", false, "continue listing the history of a file beyond renames")
 }
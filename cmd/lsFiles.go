@@ -4,39 +4,104 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
 var (
-	isShowStaged bool
+	isLsFilesCached          bool
+	isLsFilesStage           bool
+	isLsFilesTag             bool
+	isLsFilesModified        bool
+	isLsFilesDeleted         bool
+	isLsFilesOthers          bool
+	isLsFilesIgnored         bool
+	isLsFilesUnmerged        bool
+	isLsFilesExcludeStandard bool
+	isLsFilesNullTerminated  bool
+	lsFilesExcludes          []string
 )
 
+// print the path with the tag of -t and the information of the entry shown with -s.
+func printLsFile(w io.Writer, prefix string, f *goit.ListedFile) {
+	if isLsFilesTag {
+		fmt.Fprintf(w, "%s ", f.Tag)
+	}
+	if (isLsFilesStage || isLsFilesUnmerged) && f.Entry != nil {
+		fmt.Fprintf(w, "100644 %s %d\t", f.Entry.Hash, f.Entry.Stage)
+	}
+	terminator := "\n"
+	if isLsFilesNullTerminated {
+		terminator = "\x00"
+	}
+	fmt.Fprintf(w, "%s%s", displayPath(prefix, f.Path), terminator)
+}
+
 // lsFilesCmd represents the lsFiles command
 var lsFilesCmd = &cobra.Command{
-	Use:   "ls-files",
+	Use:   "ls-files [<pathspec>...]",
 	Short: "print out index",
 	Long:  "this is a command to print out index",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		for _, entry := range client.Idx.Entries {
-			if isShowStaged {
-				fmt.Printf("%s    %s\n", entry.Hash, entry.Path)
-			} else {
-				fmt.Printf("%s\n", entry.Path)
-			}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wt, err := worktree(repository(cmd))
+		if err != nil {
+			return err
+		}
+
+		// only the files under the current directory are listed without pathspec
+		if len(args) == 0 && wt.Prefix != "" {
+			args = []string{"."}
+		}
+		files, err := wt.ListFiles(cmd.Context(), &goit.ListFilesOptions{
+			Pathspecs:       args,
+			Cached:          isLsFilesCached,
+			Stage:           isLsFilesStage,
+			Modified:        isLsFilesModified,
+			Deleted:         isLsFilesDeleted,
+			Others:          isLsFilesOthers,
+			Ignored:         isLsFilesIgnored,
+			Unmerged:        isLsFilesUnmerged,
+			ExcludeStandard: isLsFilesExcludeStandard,
+			Excludes:        lsFilesExcludes,
+		})
+		if errors.Is(err, goit.ErrIgnoredWithoutMode) || errors.Is(err, goit.ErrIgnoredWithoutExclude) {
+			return fmt.Errorf("fatal: %w", err)
 		}
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		for _, f := range files {
+			printLsFile(w, wt.Prefix, f)
+		}
+
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(lsFilesCmd)
 
-	lsFilesCmd.Flags().BoolVarP(&isShowStaged, "staged", "s", false, "show staged contents")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesCached, "cached", "c", false, "show cached files (default)")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesStage, "stage", "s", false, "show the mode, the object name and the stage of the entries")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesTag, "tag", "t", false, "show the status tag of the files")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesModified, "modified", "m", false, "show modified files")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesDeleted, "deleted", "d", false, "show deleted files")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesOthers, "others", "o", false, "show untracked files")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesIgnored, "ignored", "i", false, "show only ignored files")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesUnmerged, "unmerged", "u", false, "show only unmerged files, implying --stage")
+	lsFilesCmd.Flags().BoolVar(&isLsFilesExcludeStandard, "exclude-standard", false, "exclude the files ignored by .goitignore")
+	lsFilesCmd.Flags().StringArrayVarP(&lsFilesExcludes, "exclude", "x", []string{}, "skip the untracked files matching the pattern")
+	lsFilesCmd.Flags().BoolVarP(&isLsFilesNullTerminated, "null", "z", false, "terminate the lines with NUL instead of newline")
 }
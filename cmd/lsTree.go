@@ -0,0 +1,146 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	isLsTreeRecursive bool
+	isLsTreeShowTrees bool
+	isLsTreeNameOnly  bool
+)
+
+// return the mode and the type of the node, where the mode of the blob is 100644 unless it is recorded.
+func lsTreeNodeType(node *goit.TreeNode) (string, string) {
+	switch node.Mode {
+	case "040000", "40000":
+		return "040000", "tree"
+	case "160000":
+		return node.Mode, "commit"
+	case "":
+		return "100644", "blob"
+	default:
+		return node.Mode, "blob"
+	}
+}
+
+// print the nodes under the directory which match the patterns. The pattern names the path itself,
+// or the contents of the directory if it ends with a slash. The matched directory is expanded only with -r.
+func lsTree(w io.Writer, prefix string, nodes []*goit.TreeNode, dir string, patterns []string, isMatched bool) {
+	for _, node := range nodes {
+		p := node.Name
+		if dir != "" {
+			p = dir + "/" + node.Name
+		}
+		mode, objType := lsTreeNodeType(node)
+		isTree := objType == "tree"
+
+		matched, descend, isChildMatched := isMatched, false, false
+		for _, pattern := range patterns {
+			if matched {
+				break
+			}
+			switch {
+			case pattern == p:
+				matched = true
+			case isTree && pattern == p+"/":
+				descend, isChildMatched = true, true
+			case isTree && strings.HasPrefix(pattern, p+"/"):
+				descend = true
+			}
+		}
+
+		show := func() {
+			if isLsTreeNameOnly {
+				fmt.Fprintln(w, displayPath(prefix, p))
+			} else {
+				fmt.Fprintf(w, "%s %s %s\t%s\n", mode, objType, node.Hash, displayPath(prefix, p))
+			}
+		}
+		switch {
+		case matched && isTree && isLsTreeRecursive:
+			if isLsTreeShowTrees {
+				show()
+			}
+			lsTree(w, prefix, node.Children, p, patterns, true)
+		case matched:
+			show()
+		case descend:
+			if isLsTreeShowTrees {
+				show()
+			}
+			lsTree(w, prefix, node.Children, p, patterns, isChildMatched)
+		}
+	}
+}
+
+// lsTreeCmd represents the lsTree command
+var lsTreeCmd = &cobra.Command{
+	Use:   "ls-tree <tree-ish> [<path>...]",
+	Short: "list the contents of a tree object",
+	Long:  "this is a command to list the contents of a tree object",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// args validation check
+		if len(args) == 0 {
+			return fmt.Errorf("%w: tree-ish is not specified", ErrInvalidArgs)
+		}
+
+		repo := repository(cmd)
+		tree, err := resolveTree(repo, args[0])
+		if err != nil {
+			return err
+		}
+
+		// the paths are relative to the current directory, which is listed by default
+		prefix, err := currentPrefix(repo)
+		if err != nil {
+			return err
+		}
+		var patterns []string
+		for _, arg := range args[1:] {
+			pattern, err := rootRelativePath(prefix, arg)
+			if err != nil {
+				return err
+			}
+			if pattern != "" && (strings.HasSuffix(arg, "/") || arg == ".") {
+				pattern += "/"
+			}
+			patterns = append(patterns, pattern)
+		}
+		if len(args) == 1 && prefix != "" {
+			patterns = append(patterns, prefix+"/")
+		}
+		isMatched := len(patterns) == 0
+		for _, pattern := range patterns {
+			if pattern == "" {
+				isMatched = true
+			}
+		}
+
+		lsTree(cmd.OutOrStdout(), prefix, tree.Children, "", patterns, isMatched)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lsTreeCmd)
+
+	lsTreeCmd.Flags().BoolVarP(&isLsTreeRecursive, "recursive", "r", false, "recurse into sub-trees")
+	lsTreeCmd.Flags().BoolVarP(&isLsTreeShowTrees, "show-trees", "t", false, "show tree entries even when going to recurse them")
+	lsTreeCmd.Flags().BoolVar(&isLsTreeNameOnly, "name-only", false, "list only the names of the entries")
+}
@@ -0,0 +1,189 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/spf13/cobra"
+)
+
+var (
+	isMktreeNullTerminated bool
+	isMktreeMissing        bool
+	isMktreeBatch          bool
+)
+
+type mktreeEntry struct {
+	mode string
+	name string
+	hash goit.Hash
+}
+
+// the directory is sorted as if its name ended with a slash, as git does.
+func (e *mktreeEntry) sortKey() string {
+	if e.mode == "040000" {
+		return e.name + "/"
+	}
+	return e.name
+}
+
+// parse the line in the ls-tree format, "<mode> SP <type> SP <object> TAB <name>".
+func parseMktreeLine(repo *goit.Repository, line string) (*mktreeEntry, error) {
+	info, name, ok := strings.Cut(line, "\t")
+	fields := strings.Fields(info)
+	if !ok || len(fields) != 3 {
+		return nil, fmt.Errorf("fatal: input format error: %s", line)
+	}
+	mode, objType, hashString := fields[0], fields[1], fields[2]
+	if mode == "40000" {
+		mode = "040000"
+	}
+
+	wantType := "blob"
+	switch mode {
+	case "100644", "100755", "120000":
+	case "040000":
+		wantType = "tree"
+	case "160000":
+		wantType = "commit"
+	default:
+		return nil, fmt.Errorf("fatal: path %s has invalid mode %s", name, mode)
+	}
+	if objType != wantType {
+		return nil, fmt.Errorf("fatal: entry '%s' object type (%s) doesn't match mode type (%s)", name, objType, wantType)
+	}
+	hash, err := sha.ReadHash(hashString)
+	if err != nil || len(hashString) != repo.ObjectFormat().HexSize() {
+		return nil, fmt.Errorf("fatal: input format error: %s", line)
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("fatal: path %s contains slash", name)
+	}
+
+	// the commit of the submodule is not in this repository
+	if !isMktreeMissing && wantType != "commit" {
+		obj, err := repo.Object(hash)
+		if err != nil {
+			return nil, fmt.Errorf("fatal: entry '%s' object %s is unavailable", name, hash)
+		}
+		if obj.Type.String() != wantType {
+			return nil, fmt.Errorf("fatal: entry '%s' object %s is a %s but specified type was (%s)", name, hash, obj.Type, wantType)
+		}
+	}
+
+	return &mktreeEntry{mode: mode, name: name, hash: hash}, nil
+}
+
+// make and write the tree object of the entries.
+func mktree(repo *goit.Repository, entries []*mktreeEntry) (*goit.Object, error) {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].sortKey() < entries[j].sortKey() })
+	var data []byte
+	for _, entry := range entries {
+		data = append(data, []byte(fmt.Sprintf("%s %s", entry.mode, entry.name))...)
+		data = append(data, 0x00)
+		data = append(data, entry.hash...)
+	}
+	treeObject, err := repo.NewObject(goit.TreeObject, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := treeObject.Check(); err != nil {
+		return nil, fmt.Errorf("fatal: %w", err)
+	}
+	if err := repo.WriteObject(treeObject); err != nil {
+		return nil, err
+	}
+	return treeObject, nil
+}
+
+// split the records of the input by the newline, or NUL with -z.
+func scanMktreeRecords(data []byte, atEOF bool) (int, []byte, error) {
+	sep := byte('\n')
+	if isMktreeNullTerminated {
+		sep = 0x00
+	}
+	if i := bytes.IndexByte(data, sep); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func mktreeFromReader(repo *goit.Repository, r io.Reader, w io.Writer) error {
+	var entries []*mktreeEntry
+	hasInput := false
+	flush := func() error {
+		treeObject, err := mktree(repo, entries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, treeObject.Hash)
+		entries, hasInput = nil, false
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(scanMktreeRecords)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// the blank line terminates the tree in the batch mode
+		if line == "" && isMktreeBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		entry, err := parseMktreeLine(repo, line)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		hasInput = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("fail to read stdin: %w", err)
+	}
+	if !isMktreeBatch || hasInput {
+		return flush()
+	}
+	return nil
+}
+
+// mktreeCmd represents the mktree command
+var mktreeCmd = &cobra.Command{
+	Use:   "mktree",
+	Short: "build a tree object from ls-tree formatted text",
+	Long:  "this is a command to build a tree object from the ls-tree formatted text read from stdin",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return ErrTooManyArgs
+		}
+		return mktreeFromReader(repository(cmd), cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mktreeCmd)
+
+	mktreeCmd.Flags().BoolVarP(&isMktreeNullTerminated, "null", "z", false, "read the NUL-terminated ls-tree -z output")
+	mktreeCmd.Flags().BoolVar(&isMktreeMissing, "missing", false, "allow missing objects")
+	mktreeCmd.Flags().BoolVar(&isMktreeBatch, "batch", false, "build more than one tree, separated by a blank line")
+}
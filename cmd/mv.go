@@ -0,0 +1,220 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mvForceFlag  bool
+	mvSkipFlag   bool
+	mvDryRunFlag bool
+)
+
+// a single rename of the working tree path with the index entries under it.
+type move struct {
+	src   string
+	dst   string
+	isDir bool
+}
+
+// tell whether the path or any of its parent directories is ignored.
+func isIgnoredPath(repo *goit.Repository, p string, isDir bool) bool {
+	if repo.IsIgnored(p, isDir) {
+		return true
+	}
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		if repo.IsIgnored(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// check the rename of src to dst can be done.
+func checkMove(repo *goit.Repository, mv *move, planned map[string]bool) error {
+	index, workDir := repo.Index(), repo.WorkDir()
+	badSource := func(reason string) error {
+		return fmt.Errorf("fatal: %s, source=%s, destination=%s", reason, mv.src, mv.dst)
+	}
+
+	if mv.src == "" {
+		return badSource("can not move the working tree root")
+	}
+	srcInfo, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(mv.src)))
+	if err != nil {
+		return badSource("bad source")
+	}
+	mv.isDir = srcInfo.IsDir()
+	if mv.isDir {
+		if mv.dst == mv.src || strings.HasPrefix(mv.dst, mv.src+"/") {
+			return badSource("can not move directory into itself")
+		}
+		if !index.IsTrackedDir(mv.src) {
+			return badSource("source directory is empty")
+		}
+	} else if !index.IsTracked(mv.src) {
+		return badSource("not under version control")
+	}
+	if mv.src == mv.dst {
+		return badSource("can not move a file onto itself")
+	}
+
+	if planned[mv.dst] {
+		return badSource("multiple sources for the same target")
+	}
+	if isIgnoredPath(repo, mv.dst, mv.isDir) {
+		return badSource("destination is ignored")
+	}
+	dstInfo, err := os.Lstat(filepath.Join(workDir, filepath.FromSlash(mv.dst)))
+	if err == nil {
+		if mv.isDir || dstInfo.IsDir() {
+			return badSource("destination already exists")
+		}
+		// overwriting the file is allowed only with force flag
+		if !mvForceFlag {
+			return badSource("destination exists")
+		}
+	} else if index.IsTrackedDir(mv.dst) {
+		return badSource("destination already exists")
+	}
+	if index.IsTracked(mv.dst) && !mvForceFlag {
+		return badSource("destination exists")
+	}
+
+	return nil
+}
+
+// rename the path in the working tree and rewrite the index entries.
+func applyMove(repo *goit.Repository, mv *move) error {
+	index, workDir := repo.Index(), repo.WorkDir()
+	srcPath := filepath.Join(workDir, filepath.FromSlash(mv.src))
+	dstPath := filepath.Join(workDir, filepath.FromSlash(mv.dst))
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make directory %s: %w", filepath.Dir(dstPath), err)
+	}
+	if !mv.isDir {
+		if err := os.RemoveAll(dstPath); err != nil {
+			return fmt.Errorf("fail to remove %s: %w", dstPath, err)
+		}
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return fmt.Errorf("fatal: renaming '%s' failed: %w", mv.src, err)
+	}
+
+	if !mv.isDir {
+		index.Set(mv.dst, nil)
+		index.Move(mv.src, mv.dst)
+		return nil
+	}
+	var paths []string
+	for _, entry := range index.EntriesInDir(mv.src) {
+		p := string(entry.Path)
+		if len(paths) > 0 && paths[len(paths)-1] == p {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	for _, p := range paths {
+		index.Move(p, mv.dst+strings.TrimPrefix(p, mv.src))
+	}
+	return nil
+}
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv <source>... <destination>",
+	Short: "move or rename a file, a directory",
+	Long:  "move or rename a file, a directory, and update the index",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return fmt.Errorf("%w: usage: goit mv [<options>] <source>... <destination>", ErrInvalidArgs)
+		}
+
+		repo := repository(cmd)
+		prefix, err := currentPrefix(repo)
+		if err != nil {
+			return err
+		}
+		workDir := repo.WorkDir()
+
+		dst, err := rootRelativePath(prefix, args[len(args)-1])
+		if err != nil {
+			return err
+		}
+		// move into the destination directory when it is a directory or several sources are given
+		intoDir := len(args) > 2
+		if info, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(dst))); err == nil && info.IsDir() {
+			intoDir = true
+		} else if intoDir {
+			return fmt.Errorf("fatal: destination '%s' is not a directory", args[len(args)-1])
+		}
+
+		// validate all the moves before touching anything
+		var moves []*move
+		planned := make(map[string]bool)
+		for _, arg := range args[:len(args)-1] {
+			src, err := rootRelativePath(prefix, arg)
+			if err != nil {
+				return err
+			}
+			mv := &move{
+				src: src,
+				dst: dst,
+			}
+			if intoDir {
+				mv.dst = path.Join(dst, path.Base(src))
+			}
+			if err := checkMove(repo, mv, planned); err != nil {
+				if mvSkipFlag {
+					continue
+				}
+				return err
+			}
+			planned[mv.dst] = true
+			moves = append(moves, mv)
+		}
+
+		for _, mv := range moves {
+			if mvDryRunFlag {
+				fmt.Printf("Renaming %s to %s\n", displayPath(prefix, mv.src), displayPath(prefix, mv.dst))
+				continue
+			}
+			if err := applyMove(repo, mv); err != nil {
+				return err
+			}
+		}
+		if mvDryRunFlag || len(moves) == 0 {
+			return nil
+		}
+
+		if err := repo.Index().Write(); err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+
+	mvCmd.Flags().BoolVarP(&mvForceFlag, "force", "f", false, "force move/rename even if target exists")
+	mvCmd.Flags().BoolVarP(&mvSkipFlag, "skip-errors", "k", false, "skip move/rename errors")
+	mvCmd.Flags().BoolVarP(&mvDryRunFlag, "dry-run", "n", false, "dry run")
+}
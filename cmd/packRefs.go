@@ -0,0 +1,45 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/JunNishimura/Goit/goit"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	isPackAll bool
+	isNoPrune bool
+)
+
+// packRefsCmd represents the packRefs command
+var packRefsCmd = &cobra.Command{
+	Use:   "pack-refs",
+	Short: "pack heads and tags for efficient repository access",
+	Long:  "this is a command to pack loose refs into .goit/packed-refs",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return ErrInvalidArgs
+		}
+
+		return repository(cmd).PackReferences(cmd.Context(), &goit.PackOptions{
+			All:     isPackAll,
+			NoPrune: isNoPrune,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packRefsCmd)
+
+	packRefsCmd.Flags().BoolVar(&isPackAll, "all", false, "pack all refs, not only tags and already packed refs")
+	packRefsCmd.Flags().BoolVar(&isNoPrune, "no-prune", false, "do not remove loose refs after packing them")
+}
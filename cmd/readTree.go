@@ -0,0 +1,83 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	isReadTreeMerge bool
+	readTreePrefix  string
+)
+
+// readTreeCmd represents the readTree command
+var readTreeCmd = &cobra.Command{
+	Use:   "read-tree [-m] [--prefix=<prefix>] <tree-ish>...",
+	Short: "read tree information into the index",
+	Long:  "this is a command to read tree information into the index, merging up to three trees with -m",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// args validation check
+		if len(args) == 0 {
+			return fmt.Errorf("%w: tree-ish is not specified", ErrInvalidArgs)
+		}
+		if len(args) > 3 {
+			return ErrTooManyArgs
+		}
+		isPrefix := cmd.Flags().Changed("prefix")
+		if isPrefix && isReadTreeMerge {
+			return fmt.Errorf("%w: --prefix and -m", ErrIncompatibleFlag)
+		}
+		if len(args) > 1 && !isReadTreeMerge {
+			return fmt.Errorf("%w: reading multiple trees requires -m", ErrInvalidArgs)
+		}
+
+		if isPrefix && readTreePrefix == "" {
+			return fmt.Errorf("fatal: %w: empty prefix", goit.ErrPrefixExists)
+		}
+
+		repo := repository(cmd)
+		trees := make([]goit.Hash, 0, len(args))
+		for _, arg := range args {
+			tree, err := resolveTree(repo, arg)
+			if err != nil {
+				return err
+			}
+			trees = append(trees, tree.Hash())
+		}
+
+		err := repo.Worktree().ReadTree(cmd.Context(), &goit.ReadTreeOptions{
+			Merge:  isReadTreeMerge,
+			Prefix: readTreePrefix,
+		}, trees...)
+		var overwriteErr *goit.WouldOverwriteError
+		switch {
+		case errors.As(err, &overwriteErr):
+			return fmt.Errorf("error: %w", err)
+		case errors.Is(err, goit.ErrUnmergedIndex), errors.Is(err, goit.ErrPrefixExists):
+			return fmt.Errorf("fatal: %w", err)
+		case err != nil:
+			return err
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(readTreeCmd)
+
+	readTreeCmd.Flags().BoolVarP(&isReadTreeMerge, "merge", "m", false, "merge the trees into the index instead of reading a tree")
+	readTreeCmd.Flags().StringVar(&readTreePrefix, "prefix", "", "read the tree into the directory of the prefix, keeping the index")
+}
@@ -4,35 +4,237 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/JunNishimura/Goit/internal/store"
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+const (
+	defaultReflogExpire            = "90.days.ago"
+	defaultReflogExpireUnreachable = "30.days.ago"
+)
+
+var (
+	reflogExpire            string
+	reflogExpireUnreachable string
+	isReflogExpireAll       bool
+	reflogEntryRegexp       = regexp.MustCompile(`^(.*)@\{(\d+)\}$`)
+)
+
+// return the full name of the ref whose log is looked up, adding the prefix to the error.
+func reflogRefName(repo *goit.Repository, name string) (string, error) {
+	refName, err := repo.ReflogName(name)
+	if err != nil {
+		return "", fmt.Errorf("fatal: %w", err)
+	}
+	return refName, nil
+}
+
+// read the expiry date from the flag, falling back on the config and then the default.
+func reflogExpiry(cmd *cobra.Command, flagName, value, configName, defaultValue string, now time.Time) (time.Time, error) {
+	if !cmd.Flags().Changed(flagName) {
+		value = defaultValue
+		if v, ok := repository(cmd).Config(configName); ok {
+			value = v
+		}
+	}
+	expiry, err := goit.ParseExpiry(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fatal: %w", err)
+	}
+	return expiry, nil
+}
+
+// print the entries from the latest with the selector such as HEAD@{0} and main@{1},
+// decorated with the branches pointing to the commit of the entry.
+func showReflog(cmd *cobra.Command, name string) error {
+	repo := repository(cmd)
+	refName, err := reflogRefName(repo, name)
+	if err != nil {
+		return err
+	}
+	abbrev, err := repo.NewAbbreviator(0)
+	if err != nil {
+		return err
+	}
+
+	branches := make(map[string][]string)
+	if err := repo.References(cmd.Context(), "refs/heads/", func(ref *goit.Reference) error {
+		branches[ref.Hash.String()] = append(branches[ref.Hash.String()], strings.TrimPrefix(ref.Name, "refs/heads/"))
+		return nil
+	}); err != nil {
+		return err
+	}
+	head := repo.Head()
+	headBranch := currentBranch(repo)
+
+	selector := refName
+	if selector != "HEAD" {
+		selector = goit.ShortReferenceName(selector)
+	}
+	num := 0
+	return repo.Reflog(cmd.Context(), refName, func(entry *goit.ReflogEntry) error {
+		var references []string
+		if !entry.Hash.IsZero() {
+			if headBranch != "" && head.Hash != nil && head.Hash.Compare(entry.Hash) {
+				references = append(references, color.BlueString("HEAD -> ")+color.GreenString(headBranch))
+			}
+			for _, branch := range branches[entry.Hash.String()] {
+				if branch != headBranch {
+					references = append(references, color.GreenString(branch))
+				}
+			}
+		}
+		hash := color.YellowString(abbrev.Abbrev(entry.Hash))
+		if len(references) == 0 {
+			fmt.Printf("%s %s@{%d}: %s\n", hash, selector, num, entry.Message)
+		} else {
+			fmt.Printf("%s (%s) %s@{%d}: %s\n", hash, strings.Join(references, ", "), selector, num, entry.Message)
+		}
+		num++
+		return nil
+	})
+}
+
 // reflogCmd represents the reflog command
 var reflogCmd = &cobra.Command{
 	Use:   "reflog",
 	Short: "manage reference logs",
 	Long:  "manage reference logs",
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showReflog(cmd, "HEAD")
+	},
+}
+
+var reflogShowCmd = &cobra.Command{
+	Use:   "show [<ref>]",
+	Short: "show the log of the ref, which is HEAD by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := "HEAD"
+		if len(args) == 1 {
+			name = args[0]
+		}
+		return showReflog(cmd, name)
+	},
+}
+
+var reflogExpireCmd = &cobra.Command{
+	Use:   "expire [--expire=<time>] [--expire-unreachable=<time>] [--all] [<ref>...]",
+	Short: "prune the older reflog entries",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		reflog, err := store.NewReflog(client.RootGoitPath, client.Head, client.Refs)
+		now := time.Now()
+		expire, err := reflogExpiry(cmd, "expire", reflogExpire, "gc.reflogExpire", defaultReflogExpire, now)
+		if err != nil {
+			return err
+		}
+		expireUnreachable, err := reflogExpiry(cmd, "expire-unreachable", reflogExpireUnreachable, "gc.reflogExpireUnreachable", defaultReflogExpireUnreachable, now)
 		if err != nil {
-			return fmt.Errorf("fail to get reflog: %w", err)
+			return err
 		}
 
-		reflog.Show()
+		repo := repository(cmd)
+		var refNames []string
+		if isReflogExpireAll {
+			refNames, err = repo.ReflogNames()
+			if err != nil {
+				return err
+			}
+		}
+		for _, arg := range args {
+			refName, err := repo.ReflogName(arg)
+			if err != nil {
+				return fmt.Errorf("error: %w", err)
+			}
+			refNames = append(refNames, refName)
+		}
+		if len(refNames) == 0 {
+			return fmt.Errorf("%w: no reflog specified to expire", ErrInvalidArgs)
+		}
+
+		for _, refName := range refNames {
+			if err := repo.ExpireReflog(cmd.Context(), refName, expire, expireUnreachable); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	},
 }
 
+var reflogDeleteCmd = &cobra.Command{
+	Use:   "delete <ref>@{<specifier>}...",
+	Short: "delete the entries from the reflog",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := repository(cmd)
+		for _, arg := range args {
+			matches := reflogEntryRegexp.FindStringSubmatch(arg)
+			if matches == nil {
+				return fmt.Errorf("%w: not a reflog: %s", ErrInvalidArgs, arg)
+			}
+			refName, err := repo.ReflogName(matches[1])
+			if err != nil {
+				return fmt.Errorf("error: %w", err)
+			}
+			num, err := strconv.Atoi(matches[2])
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidArgs, arg)
+			}
+			err = repo.DeleteReflogEntry(refName, num)
+			if errors.Is(err, goit.ErrReflogEntryNotFound) {
+				return fmt.Errorf("error: %w", err)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+var reflogExistsCmd = &cobra.Command{
+	Use:   "exists <ref>",
+	Short: "check whether the ref has a reflog",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// exit with an error so that scripts can tell the reflog does not exist
+		repo := repository(cmd)
+		refName, err := repo.ReflogName(args[0])
+		if err != nil {
+			return err
+		}
+		if !repo.ReflogExists(refName) {
+			return fmt.Errorf("%w: %s", goit.ErrReflogNotFound, args[0])
+		}
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(reflogCmd)
+
+	reflogCmd.AddCommand(reflogShowCmd)
+	reflogCmd.AddCommand(reflogExpireCmd)
+	reflogCmd.AddCommand(reflogDeleteCmd)
+	reflogCmd.AddCommand(reflogExistsCmd)
+
+	reflogExpireCmd.Flags().StringVar(&reflogExpire, "expire", defaultReflogExpire, "prune the entries older than the time, defaults to gc.reflogExpire")
+	reflogExpireCmd.Flags().StringVar(&reflogExpireUnreachable, "expire-unreachable", defaultReflogExpireUnreachable, "prune the entries older than the time which are not reachable from the tip, defaults to gc.reflogExpireUnreachable")
+	reflogExpireCmd.Flags().BoolVar(&isReflogExpireAll, "all", false, "process the reflogs of all the refs")
 }
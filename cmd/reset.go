@@ -5,74 +5,24 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
-	"time"
 
-	"github.com/JunNishimura/Goit/internal/log"
-	"github.com/JunNishimura/Goit/internal/object"
-	"github.com/JunNishimura/Goit/internal/store"
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
 var (
-	isSoft      bool
-	isMixed     bool
-	isHard      bool
-	resetRegexp = regexp.MustCompile(`HEAD@\{\d\}`)
+	isSoft  bool
+	isMixed bool
+	isHard  bool
 )
 
-func resetHead(arg, rootGoitPath string, logRecord *store.LogRecord, head *store.Head, refs *store.Refs, conf *store.Config) error {
-	// reset Head
-	prevHeadHash := head.Commit.Hash
-	if err := head.Reset(rootGoitPath, refs, logRecord.Hash); err != nil {
-		return fmt.Errorf("fail to reset HEAD: %w", err)
-	}
-
-	// log
-	newRecord := log.NewRecord(log.ResetRecord, prevHeadHash, logRecord.Hash, conf.GetUserName(), conf.GetEmail(), time.Now(), fmt.Sprintf("moving to %s", arg))
-	if err := gLogger.WriteHEAD(newRecord); err != nil {
-		return fmt.Errorf("log error: %w", err)
-	}
-	if err := gLogger.WriteBranch(newRecord, head.Reference); err != nil {
-		return fmt.Errorf("log error: %w", err)
-	}
-
-	return nil
-}
-
-func resetIndex(rootGoitPath string, logRecord *store.LogRecord, index *store.Index) error {
-	// reset index
-	if err := index.Reset(rootGoitPath, logRecord.Hash); err != nil {
-		return fmt.Errorf("fail to reset index: %w", err)
-	}
-
-	return nil
-}
-
-func resetWorkingTree(rootGoitPath string, index *store.Index) error {
-	for _, entry := range index.Entries {
-		obj, err := object.GetObject(rootGoitPath, entry.Hash)
-		if err != nil {
-			return fmt.Errorf("fail to get object: %w", err)
-		}
-		if err := obj.ReflectToWorkingTree(rootGoitPath, string(entry.Path)); err != nil {
-			return fmt.Errorf("fail to reflect %s to working directory: %w", string(entry.Path), err)
-		}
-	}
-
-	return nil
-}
-
 // resetCmd represents the reset command
 var resetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "reset current HEAD to the specified state",
 	Long:  "reset current HEAD to the specified state",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
@@ -89,44 +39,26 @@ var resetCmd = &cobra.Command{
 		}
 
 		// args validation
-		if !(len(args) == 1 && resetRegexp.MatchString(args[0])) {
-			return errors.New("only one argument is acceptible. argument format is 'HEAD@{number}'")
+		if len(args) != 1 {
+			return errors.New("only one argument is acceptible. argument format is a commit such as 'HEAD@{number}'")
 		}
 
-		// get log record
-		reflog, err := store.NewReflog(client.RootGoitPath, client.Head, client.Refs)
-		if err != nil {
-			return fmt.Errorf("fail to initialize reflog: %w", err)
-		}
-		sp := strings.Split(args[0], "HEAD@")[1]
-		headNum, err := strconv.Atoi(sp[1 : len(sp)-1])
+		// get the commit to reset to, which might be the reflog selector such as HEAD@{1} and main@{yesterday}
+		repo := repository(cmd)
+		hash, err := resolveCommit(repo, args[0])
 		if err != nil {
-			return fmt.Errorf("fail to convert number '%s': %w", args[0], err)
-		}
-		logRecord, err := reflog.GetRecord(headNum)
-		if err != nil {
-			return fmt.Errorf("fail to get log record: %w", err)
+			return err
 		}
 
-		// reset HEAD
-		if isSoft || isMixed || isHard {
-			if err := resetHead(args[0], client.RootGoitPath, logRecord, client.Head, client.Refs, client.Conf); err != nil {
-				return fmt.Errorf("fail to reset HEAD: %w", err)
-			}
+		mode := goit.ResetMixed
+		switch {
+		case isSoft:
+			mode = goit.ResetSoft
+		case isHard:
+			mode = goit.ResetHard
 		}
-
-		// reset index
-		if isMixed || isHard {
-			if err := resetIndex(client.RootGoitPath, logRecord, client.Idx); err != nil {
-				return fmt.Errorf("fail to reset index: %w", err)
-			}
-		}
-
-		// reset working tree
-		if isHard {
-			if err := resetWorkingTree(client.RootGoitPath, client.Idx); err != nil {
-				return fmt.Errorf("fail to reset working tree: %w", err)
-			}
+		if err := repo.Worktree().Reset(cmd.Context(), hash, &goit.ResetOptions{Mode: mode, Revision: args[0]}); err != nil {
+			return err
 		}
 
 		return nil
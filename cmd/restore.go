@@ -4,97 +4,258 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/JunNishimura/Goit/internal/file"
-	"github.com/JunNishimura/Goit/internal/object"
-	"github.com/JunNishimura/Goit/internal/store"
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/diff"
 	"github.com/spf13/cobra"
 )
 
-func restoreIndex(rootGoitPath, path string, index *store.Index, tree *object.Tree) error {
-	// get entry
-	_, _, isEntryFound := index.GetEntry([]byte(path))
+var (
+	restoreSource     string
+	isRestoreStaged   bool
+	isRestoreWorktree bool
+	isRestoreOurs     bool
+	isRestoreTheirs   bool
+	isRestorePatch    bool
+	restoreHunkFile   string
+)
 
-	// get node
-	node, isNodeFound := object.GetNode(tree.Children, path)
+// return the blobs of the tree-ish keyed by their paths.
+func treeishFiles(repo *goit.Repository, treeish string) (map[string]goit.Hash, error) {
+	tree, err := resolveTree(repo, treeish)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Files(), nil
+}
 
-	// if the path is registered in the Index
-	if isEntryFound {
-		// restore index
-		if isNodeFound { // if the file is updated
-			// change hash
-			isUpdated, err := index.Update(rootGoitPath, node.Hash, []byte(path))
-			if err != nil {
-				return fmt.Errorf("fail to update index: %w", err)
-			}
-			if !isUpdated {
-				return errors.New("fail to restore index")
-			}
-		} else { // if the file is newly added
-			// delete entry
-			if err := index.DeleteEntry(rootGoitPath, []byte(path)); err != nil {
-				return fmt.Errorf("fail to delete entry: %w", err)
-			}
+// resolve the tree-ish, which is either a tree or a commit, into the tree.
+func resolveTree(repo *goit.Repository, treeish string) (*goit.Tree, error) {
+	hash, err := repo.ResolveRevision(treeish)
+	if err != nil {
+		return nil, fmt.Errorf("fatal: could not resolve %s", treeish)
+	}
+	tree, err := repo.TreeObject(hash)
+	if errors.Is(err, goit.ErrNotTree) {
+		return nil, fmt.Errorf("fatal: reference is not a tree: %s", treeish)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// hunkSelection holds the hunk numbers, starting from 1, to restore for each path.
+type hunkSelection map[string]map[int]bool
+
+// read the hunk list whose line consists of the path and the hunk numbers such as "main.go 1,3-4" or "main.go all".
+// The paths are relative to the current directory like the pathspec.
+func readHunkSelection(r io.Reader, prefix string) (hunkSelection, error) {
+	selection := make(hunkSelection)
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-	} else { // if the path is not registered in the index,
-		if isNodeFound { // if the file is deleted
-			isUpdated, err := index.Update(rootGoitPath, node.Hash, []byte(path))
-			if err != nil {
-				return fmt.Errorf("fail to update index: %w", err)
+		sep := strings.LastIndexAny(line, " \t")
+		if sep < 0 {
+			return nil, fmt.Errorf("fatal: invalid hunk list at line %d: %s", lineNum, line)
+		}
+		filePath := path.Clean(path.Join(prefix, strings.TrimSpace(line[:sep])))
+		numbers := line[sep+1:]
+		if _, ok := selection[filePath]; !ok {
+			selection[filePath] = make(map[int]bool)
+		}
+		if numbers == "all" {
+			selection[filePath][0] = true
+			continue
+		}
+		for _, number := range strings.Split(numbers, ",") {
+			first, last, isRange := strings.Cut(number, "-")
+			start, err := strconv.Atoi(first)
+			if err != nil || start < 1 {
+				return nil, fmt.Errorf("fatal: invalid hunk number at line %d: %s", lineNum, number)
 			}
-			if !isUpdated {
-				return errors.New("fail to restore index")
+			end := start
+			if isRange {
+				end, err = strconv.Atoi(last)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("fatal: invalid hunk number at line %d: %s", lineNum, number)
+				}
+			}
+			for n := start; n <= end; n++ {
+				selection[filePath][n] = true
 			}
-		} else {
-			return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to goit", path)
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fail to read hunk list: %w", err)
+	}
+	return selection, nil
+}
 
-	return nil
+func (s hunkSelection) isSelected(filePath string, i int) bool {
+	numbers, ok := s[filePath]
+	if !ok {
+		return false
+	}
+	return numbers[0] || numbers[i+1]
 }
 
-func restoreWorkingDirectory(rootGoitPath, path string, index *store.Index) error {
-	_, entry, isEntryFound := index.GetEntry([]byte(path))
-	if !isEntryFound {
-		return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to goit", path)
+// restorer restores the index and the working tree from the source.
+type restorer struct {
+	repo          *goit.Repository
+	index         *goit.Index
+	source        map[string]goit.Hash
+	isIndexSource bool
+	selection     hunkSelection // nil unless hunks are selected by the hunk list
+}
+
+// return the blob to restore the working tree file from.
+func (r *restorer) worktreeSource(filePath string) (goit.Hash, error) {
+	if !r.isIndexSource {
+		return r.source[filePath], nil
+	}
+	_, isMerged := r.index.Entry(filePath, 0)
+	if !r.index.IsTracked(filePath) || isMerged {
+		return r.source[filePath], nil
+	}
+	switch {
+	case isRestoreOurs:
+		entry, ok := r.index.Entry(filePath, 2)
+		if !ok {
+			return nil, fmt.Errorf("error: path '%s' does not have our version", filePath)
+		}
+		return entry.Hash, nil
+	case isRestoreTheirs:
+		entry, ok := r.index.Entry(filePath, 3)
+		if !ok {
+			return nil, fmt.Errorf("error: path '%s' does not have their version", filePath)
+		}
+		return entry.Hash, nil
+	default:
+		return nil, fmt.Errorf("error: path '%s' is unmerged", filePath)
 	}
+}
+
+// return the content of the blob, which is empty if hash is nil.
+func (r *restorer) blobContent(hash goit.Hash) (string, error) {
+	if hash == nil {
+		return "", nil
+	}
+	obj, err := r.repo.Object(hash)
+	if err != nil {
+		return "", err
+	}
+	return string(obj.Data), nil
+}
 
-	obj, err := object.GetObject(rootGoitPath, entry.Hash)
+// apply the selected hunks of the difference between the source and the current content,
+// or print the hunks to be selected if no hunk list is given.
+func (r *restorer) patch(filePath string, srcHash goit.Hash, current string) (string, bool, error) {
+	src, err := r.blobContent(srcHash)
 	if err != nil {
-		return fmt.Errorf("fail to get object '%s': %w", path, err)
+		return "", false, err
 	}
+	if src == current {
+		return "", false, nil
+	}
+	a, b := diff.SplitLines(src), diff.SplitLines(current)
+	hunks := diff.Hunks(diff.Diff(a, b))
+	if r.selection == nil {
+		fmt.Printf("diff --goit a/%s b/%s\n--- a/%s\n+++ b/%s\n", filePath, filePath, filePath, filePath)
+		for _, h := range hunks {
+			fmt.Print(h.Format(a, b))
+		}
+		return "", false, nil
+	}
+	if _, ok := r.selection[filePath]; !ok {
+		return "", false, nil
+	}
+	return diff.Revert(a, b, hunks, func(i int) bool { return r.selection.isSelected(filePath, i) }), true, nil
+}
 
-	// get abs path
-	absPath, err := filepath.Abs(path)
+func (r *restorer) restoreWorktree(filePath string) error {
+	srcHash, err := r.worktreeSource(filePath)
 	if err != nil {
-		return fmt.Errorf("fail to get abs path '%s': %w", path, err)
+		return err
 	}
+	absPath := filepath.Join(r.repo.WorkDir(), filePath)
 
-	// make sure the parent path exists
-	parentPath := filepath.Dir(absPath)
-	if _, err := os.Stat(parentPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(parentPath, 0777); err != nil {
-			return fmt.Errorf("fail to make directory %s: %w", parentPath, err)
+	if isRestorePatch {
+		data, err := os.ReadFile(absPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to read %s: %w", filePath, err)
+		}
+		content, ok, err := r.patch(filePath, srcHash, string(data))
+		if err != nil || !ok {
+			return err
 		}
+		return writeWorktreeFile(absPath, []byte(content))
 	}
 
-	// restore file
-	f, err := os.Create(absPath)
+	// the tracked file which does not exist in the source is removed
+	if srcHash == nil {
+		if err := os.Remove(absPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to remove %s: %w", filePath, err)
+		}
+		return nil
+	}
+	obj, err := r.repo.Object(srcHash)
 	if err != nil {
-		return fmt.Errorf("%w: %s", ErrIOHandling, absPath)
+		return fmt.Errorf("fail to get object '%s': %w", filePath, err)
 	}
-	defer f.Close()
+	return writeWorktreeFile(absPath, obj.Data)
+}
+
+func (r *restorer) restoreIndex(filePath string) error {
+	srcHash := r.source[filePath]
 
-	// write contents to the file
-	if _, err := f.WriteString(string(obj.Data)); err != nil {
-		return fmt.Errorf("fail to write to file '%s': %w", absPath, err)
+	if isRestorePatch {
+		var curHash goit.Hash
+		if entry, ok := r.index.Entry(filePath, 0); ok {
+			curHash = entry.Hash
+		}
+		current, err := r.blobContent(curHash)
+		if err != nil {
+			return err
+		}
+		content, ok, err := r.patch(filePath, srcHash, current)
+		if err != nil || !ok {
+			return err
+		}
+		obj, err := r.repo.NewObject(goit.BlobObject, []byte(content))
+		if err != nil {
+			return err
+		}
+		if err := r.repo.WriteObject(obj); err != nil {
+			return err
+		}
+		srcHash = obj.Hash
 	}
 
+	r.index.Set(filePath, srcHash)
+	return nil
+}
+
+func writeWorktreeFile(absPath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(absPath), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make directory %s: %w", filepath.Dir(absPath), err)
+	}
+	if err := os.WriteFile(absPath, data, 0o644); err != nil {
+		return fmt.Errorf("%w: %s", ErrIOHandling, absPath)
+	}
 	return nil
 }
 
@@ -104,7 +265,7 @@ var restoreCmd = &cobra.Command{
 	Short: "restore file",
 	Long:  "restore file",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
@@ -114,163 +275,109 @@ var restoreCmd = &cobra.Command{
 		if len(args) == 0 {
 			return errors.New("fatal: you must specify path(s) to restore")
 		}
+		if isRestoreOurs && isRestoreTheirs {
+			return ErrIncompatibleFlag
+		}
+		if (isRestoreOurs || isRestoreTheirs) && (isRestoreStaged || restoreSource != "") {
+			return errors.New("fatal: --ours/--theirs can only restore the working tree from the index")
+		}
+		if isRestorePatch && isRestoreStaged && isRestoreWorktree {
+			return errors.New("fatal: --patch cannot restore the index and the working tree at once")
+		}
+		if restoreHunkFile != "" && !isRestorePatch {
+			return errors.New("fatal: --hunk-file requires --patch")
+		}
+		isWorktree := isRestoreWorktree || !isRestoreStaged
 
-		// get staged option
-		isStaged, err := cmd.Flags().GetBool("staged")
+		repo := repository(cmd)
+		ps, prefix, err := parsePathspec(repo, args)
 		if err != nil {
-			return fmt.Errorf("fail to get staged flag: %w", err)
+			return err
 		}
 
-		// staged validation check
-		if isStaged {
-			// restore --stage is comparing index with commit object pointed by HEAD
-			// so, at lease one commit is needed
-			branchPath := filepath.Join(client.RootGoitPath, "refs", "heads", client.Head.Reference)
-			if _, err := os.Stat(branchPath); os.IsNotExist(err) {
-				return errors.New("fatal: could not resolve HEAD")
-			}
+		r := &restorer{
+			repo:  repo,
+			index: repo.Index(),
+		}
 
-			// get tree from HEAD commit
-			treeObject, err := object.GetObject(client.RootGoitPath, client.Head.Commit.Tree)
+		// decide the source, which is the index when only the working tree is restored
+		switch {
+		case restoreSource != "":
+			r.source, err = treeishFiles(repo, restoreSource)
 			if err != nil {
-				return fmt.Errorf("fail to get tree object from commit HEAD: %w", err)
+				return err
+			}
+		case isRestoreStaged:
+			// restore --staged compares the index with the commit pointed by HEAD
+			if repo.Head().Hash == nil {
+				return ErrInvalidHEAD
 			}
-			tree, err := object.NewTree(client.RootGoitPath, treeObject)
+			r.source, err = treeishFiles(repo, "HEAD")
 			if err != nil {
-				return fmt.Errorf("fail to get tree: %w", err)
+				return err
 			}
-
-			for _, arg := range args {
-				argAbsPath, err := filepath.Abs(arg)
-				if err != nil {
-					return fmt.Errorf("fail to get arg abs path: %w", err)
-				}
-				f, err := os.Stat(argAbsPath)
-				if os.IsNotExist(err) { // even if the file is not found, the file might be the deleted file
-					// get node
-					cleanedArg := filepath.Clean(arg)
-					cleanedArg = strings.ReplaceAll(cleanedArg, `\`, "/")
-					node, isNodeFound := object.GetNode(tree.Children, cleanedArg)
-					if !isNodeFound {
-						return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to goit", arg)
-					}
-
-					// check if the arg is dir or not
-					if len(node.Children) > 0 { // node is directory
-						paths := node.GetPaths()
-
-						for _, path := range paths {
-							if err := restoreIndex(client.RootGoitPath, path, client.Idx, tree); err != nil {
-								return err
-							}
-						}
-					} else { // node is a file
-						if err := restoreIndex(client.RootGoitPath, cleanedArg, client.Idx, tree); err != nil {
-							return err
-						}
-					}
-
-					continue
-				}
-
-				if f.IsDir() { // directory
-					filePaths, err := file.GetFilePathsUnderDirectory(argAbsPath)
-					if err != nil {
-						return fmt.Errorf("fail to get file path under directory: %w", err)
-					}
-					for _, filePath := range filePaths {
-						curPath, err := os.Getwd()
-						if err != nil {
-							return fmt.Errorf("fail to get current directory: %w", err)
-						}
-						relPath, err := filepath.Rel(curPath, filePath)
-						if err != nil {
-							return fmt.Errorf("fail to get relative path: %w", err)
-						}
-						cleanedRelPath := strings.ReplaceAll(relPath, `\`, "/")
-
-						// restore index
-						if err := restoreIndex(client.RootGoitPath, cleanedRelPath, client.Idx, tree); err != nil {
-							return err
-						}
-					}
-				} else { // file
-					cleanedArg := filepath.Clean(arg)
-					cleanedArg = strings.ReplaceAll(cleanedArg, `\`, "/")
-
-					// restore index
-					if err := restoreIndex(client.RootGoitPath, cleanedArg, client.Idx, tree); err != nil {
-						return err
-					}
+		default:
+			r.isIndexSource = true
+			r.source = make(map[string]goit.Hash)
+			for _, entry := range r.index.Entries() {
+				if entry.Stage == 0 {
+					r.source[string(entry.Path)] = entry.Hash
 				}
 			}
-		} else {
-			// execute restore working directory
-			for _, arg := range args {
-				argAbsPath, err := filepath.Abs(arg)
+		}
+
+		if restoreHunkFile != "" {
+			var in io.Reader = os.Stdin
+			if restoreHunkFile != "-" {
+				f, err := os.Open(restoreHunkFile)
 				if err != nil {
-					return fmt.Errorf("fail to get arg abs path: %w", err)
+					return fmt.Errorf("fail to open %s: %w", restoreHunkFile, err)
 				}
-				f, err := os.Stat(argAbsPath)
-				if os.IsNotExist(err) {
-					// check if the arg is registered in the index
-					cleanedArg := filepath.Clean(arg)
-					cleanedArg = strings.ReplaceAll(cleanedArg, `\`, "/")
-					_, _, isRegistered := client.Idx.GetEntry([]byte(cleanedArg))
-					isRegisteredAsDir := client.Idx.IsRegisteredAsDirectory(cleanedArg)
-
-					if !(isRegistered || isRegisteredAsDir) {
-						return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to goit", arg)
-					}
+				defer f.Close()
+				in = f
+			}
+			r.selection, err = readHunkSelection(in, prefix)
+			if err != nil {
+				return err
+			}
+		}
 
-					if isRegisteredAsDir {
-						entries := client.Idx.GetEntriesByDirectory(cleanedArg)
-						for _, entry := range entries {
-							if err := restoreWorkingDirectory(client.RootGoitPath, string(entry.Path), client.Idx); err != nil {
-								return err
-							}
-						}
-					} else {
-						if err := restoreWorkingDirectory(client.RootGoitPath, cleanedArg, client.Idx); err != nil {
-							return err
-						}
-					}
+		// collect the paths known to the source and the index
+		pathSet := make(map[string]struct{})
+		for filePath := range r.source {
+			pathSet[filePath] = struct{}{}
+		}
+		for _, entry := range r.index.Entries() {
+			pathSet[string(entry.Path)] = struct{}{}
+		}
+		var paths []string
+		for filePath := range pathSet {
+			if ps.Match(filePath) {
+				paths = append(paths, filePath)
+			}
+		}
+		if unmatched := ps.Unmatched(); len(unmatched) > 0 {
+			return fmt.Errorf("error: pathspec '%s' did not match any file(s) known to goit", unmatched[0])
+		}
+		sort.Strings(paths)
 
-					continue
+		for _, filePath := range paths {
+			if isWorktree {
+				if err := r.restoreWorktree(filePath); err != nil {
+					return err
 				}
-
-				if f.IsDir() { // directory
-					filePaths, err := file.GetFilePathsUnderDirectory(argAbsPath)
-					if err != nil {
-						return fmt.Errorf("fail to get file path under directory: %w", err)
-					}
-					for _, filePath := range filePaths {
-						curPath, err := os.Getwd()
-						if err != nil {
-							return fmt.Errorf("fail to get current directory: %w", err)
-						}
-						relPath, err := filepath.Rel(curPath, filePath)
-						if err != nil {
-							return fmt.Errorf("fail to get relative path: %w", err)
-						}
-						cleanedRelPath := strings.ReplaceAll(relPath, `\`, "/")
-
-						// restore working directory
-						if err := restoreWorkingDirectory(client.RootGoitPath, cleanedRelPath, client.Idx); err != nil {
-							return err
-						}
-					}
-				} else { // file
-					cleanedArg := filepath.Clean(arg)
-					cleanedArg = strings.ReplaceAll(cleanedArg, `\`, "/")
-
-					// restore working directory
-					if err := restoreWorkingDirectory(client.RootGoitPath, cleanedArg, client.Idx); err != nil {
-						return err
-					}
+			}
+			if isRestoreStaged {
+				if err := r.restoreIndex(filePath); err != nil {
+					return err
 				}
 			}
 		}
+		if isRestoreStaged {
+			if err := r.index.Write(); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	},
@@ -279,5 +386,11 @@ var restoreCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(restoreCmd)
 
-	restoreCmd.Flags().Bool("staged", false, "restore index")
+	restoreCmd.Flags().StringVarP(&restoreSource, "source", "s", "", "restore from the tree-ish")
+	restoreCmd.Flags().BoolVarP(&isRestoreStaged, "staged", "S", false, "restore index")
+	restoreCmd.Flags().BoolVarP(&isRestoreWorktree, "worktree", "W", false, "restore working tree (default)")
+	restoreCmd.Flags().BoolVar(&isRestoreOurs, "ours", false, "restore unmerged paths from our version")
+	restoreCmd.Flags().BoolVar(&isRestoreTheirs, "theirs", false, "restore unmerged paths from their version")
+	restoreCmd.Flags().BoolVarP(&isRestorePatch, "patch", "p", false, "show hunks to restore, or restore the hunks listed by --hunk-file")
+	restoreCmd.Flags().StringVar(&restoreHunkFile, "hunk-file", "", "file listing hunks to restore as '<path> <n>[,<n>-<m>...]' or '<path> all' per line ('-' for stdin)")
 }
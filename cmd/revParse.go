@@ -4,29 +4,49 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"strconv"
 
-	"github.com/JunNishimura/Goit/internal/store"
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
-func revParse(rootGoitPath string, head *store.Head, refNames ...string) error {
+var (
+	revParseShort string
+)
+
+// add the prefix to the error of resolving the revision.
+func revisionError(revision string, err error) error {
+	switch {
+	case errors.Is(err, goit.ErrUnknownRevision):
+		return fmt.Errorf(`fatal: ambiguous argument '%s': unknown revision or path not in the working tree`, revision)
+	case errors.Is(err, goit.ErrAmbiguousHash):
+		return fmt.Errorf("error: %w", err)
+	}
+	return fmt.Errorf("fatal: %w", err)
+}
+
+// resolve the revision such as a full object hash, HEAD, a ref name, a reflog selector or a short object hash into the object hash.
+func resolveRevision(repo *goit.Repository, revision string) (goit.Hash, error) {
+	hash, err := repo.ResolveRevision(revision)
+	if err != nil {
+		return nil, revisionError(revision, err)
+	}
+	return hash, nil
+}
+
+func revParse(repo *goit.Repository, abbrev *goit.Abbreviator, refNames ...string) error {
 	for _, refName := range refNames {
-		var refPath string
-		if strings.ToLower(refName) == "head" {
-			refPath = filepath.Join(rootGoitPath, "refs", "heads", head.Reference)
-		} else {
-			refPath = filepath.Join(rootGoitPath, "refs", "heads", refName)
-		}
-		hashBytes, err := os.ReadFile(refPath)
+		hash, err := resolveRevision(repo, refName)
 		if err != nil {
-			return fmt.Errorf(`fatal: ambiguous argument '%s': unknown revision or path not in the working tree`, refName)
+			return err
+		}
+		if abbrev != nil {
+			fmt.Println(abbrev.Abbrev(hash))
+		} else {
+			fmt.Println(hash)
 		}
-		hashString := string(hashBytes)
-		fmt.Println(hashString)
 	}
 	return nil
 }
@@ -37,13 +57,32 @@ var revParseCmd = &cobra.Command{
 	Short: "pick out and massage parameters",
 	Long:  "pick out and massage parameters",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := revParse(client.RootGoitPath, client.Head, args...); err != nil {
+		repo := repository(cmd)
+		var abbrev *goit.Abbreviator
+		if cmd.Flags().Changed("short") {
+			// the length set by core.abbrev is used for --short without the length
+			length := 0
+			if revParseShort != "auto" {
+				var err error
+				if length, err = strconv.Atoi(revParseShort); err != nil {
+					return fmt.Errorf("%w: --short=%s", ErrInvalidArgs, revParseShort)
+				}
+				if length < goit.MinAbbrev {
+					length = goit.MinAbbrev
+				}
+			}
+			var err error
+			if abbrev, err = repo.NewAbbreviator(length); err != nil {
+				return err
+			}
+		}
+		if err := revParse(repo, abbrev, args...); err != nil {
 			return err
 		}
 
@@ -53,4 +92,7 @@ var revParseCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(revParseCmd)
+
+	revParseCmd.Flags().StringVar(&revParseShort, "short", "", "print the unique abbreviation of the object name which is at least the given length")
+	revParseCmd.Flags().Lookup("short").NoOptDefVal = "auto"
 }
@@ -4,27 +4,46 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 
-	"github.com/JunNishimura/Goit/internal/file"
-	"github.com/JunNishimura/Goit/internal/log"
-	"github.com/JunNishimura/Goit/internal/store"
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/pathspec"
 	"github.com/spf13/cobra"
 )
 
 var (
-	gLogger     *log.GoitLogger
-	client      *store.Client
 	goitVersion = ""
 )
 
+// repositoryKey is the key of the repository in the context of the command.
+type repositoryKey struct{}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "goit",
 	Short: "Git made by Golang",
 	Long:  "This is a Git-like CLI tool made by Golang",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the repository is opened for each command, which is left out of the context outside the repository
+		repo, err := goit.Open(cmd.Context(), ".")
+		if errors.Is(err, goit.ErrRepositoryNotExists) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		repo.Warnings = cmd.ErrOrStderr()
+		cmd.SetContext(context.WithValue(cmd.Context(), repositoryKey{}, repo))
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		versionFlag, err := cmd.Flags().GetBool("version")
 		if err != nil {
@@ -39,52 +58,96 @@ var rootCmd = &cobra.Command{
 	},
 }
 
-func Execute(version string) {
-	// set version
-	if version == "" {
-		if buildInfo, ok := debug.ReadBuildInfo(); ok {
-			goitVersion = buildInfo.Main.Version
-		}
-	} else {
-		goitVersion = version
-	}
+// return the repository opened for the command, which is nil outside the repository.
+func repository(cmd *cobra.Command) *goit.Repository {
+	repo, _ := cmd.Context().Value(repositoryKey{}).(*goit.Repository)
+	return repo
+}
 
-	err := rootCmd.Execute()
+// return the current directory relative to the working tree root, which is the prefix of pathspec.
+func currentPrefix(repo *goit.Repository) (string, error) {
+	curDir, err := os.Getwd()
 	if err != nil {
-		os.Exit(1)
+		return "", fmt.Errorf("fail to get current directory: %w", err)
+	}
+	workDir, err := filepath.Abs(repo.WorkDir())
+	if err != nil {
+		return "", fmt.Errorf("fail to get working tree path: %w", err)
 	}
+	return pathspec.Prefix(workDir, curDir)
 }
 
-func init() {
-	rootGoitPath, _ := file.FindGoitRoot(".") // ignore the error since the error is not important
-	config, err := store.NewConfig(rootGoitPath)
+// parse the pathspec arguments given in the current directory and return it with the prefix.
+func parsePathspec(repo *goit.Repository, args []string) (*pathspec.Pathspec, string, error) {
+	prefix, err := currentPrefix(repo)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, "", err
 	}
-	index, err := store.NewIndex(rootGoitPath)
+	ps, err := pathspec.Parse(args, prefix)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, "", err
 	}
-	head, err := store.NewHead(rootGoitPath)
+	return ps, prefix, nil
+}
+
+// convert the path relative to the working tree root into the path relative to the current directory.
+func displayPath(prefix, path string) string {
+	rel, err := filepath.Rel(filepath.FromSlash("/"+prefix), filepath.FromSlash("/"+path))
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return path
+	}
+	// keep the trailing slash of the directory such as the collapsed untracked one
+	if strings.HasSuffix(path, "/") {
+		return filepath.ToSlash(rel) + "/"
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resolve the path given in the current directory into the path relative to the working tree root.
+func rootRelativePath(prefix, arg string) (string, error) {
+	p := path.Clean(path.Join(prefix, filepath.ToSlash(arg)))
+	if p == ".." || strings.HasPrefix(p, "../") || path.IsAbs(p) {
+		return "", fmt.Errorf("fatal: %s: '%s' is outside repository", arg, arg)
+	}
+	if p == "." {
+		p = ""
 	}
-	refs, err := store.NewRefs(rootGoitPath)
+	return p, nil
+}
+
+// return the working tree whose pathspecs are given in the current directory.
+func worktree(repo *goit.Repository) (*goit.Worktree, error) {
+	prefix, err := currentPrefix(repo)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, err
 	}
-	ignore, err := store.NewIgnore(rootGoitPath)
+	wt := repo.Worktree()
+	wt.Prefix = prefix
+	return wt, nil
+}
+
+func Execute(version string) {
+	// set version
+	if version == "" {
+		if buildInfo, ok := debug.ReadBuildInfo(); ok {
+			goitVersion = buildInfo.Main.Version
+		}
+	} else {
+		goitVersion = version
+	}
+
+	// the running command stops on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
-		fmt.Println(err)
 		os.Exit(1)
 	}
-	client = store.NewClient(config, index, head, refs, ignore, rootGoitPath)
+}
 
-	gLogger = log.NewGoitLogger(client.RootGoitPath)
+func init() {
+	// the persistent hooks of the parents run as well, so that the repository is opened for every command
+	cobra.EnableTraverseRunHooks = true
 
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	rootCmd.Flags().BoolP("version", "v", false, "Show Goit version")
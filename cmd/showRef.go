@@ -0,0 +1,157 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	isShowRefHeads       bool
+	isShowRefTags        bool
+	isShowRefVerify      bool
+	isShowRefHead        bool
+	isShowRefDereference bool
+	isShowRefHashOnly    bool
+	isShowRefQuiet       bool
+)
+
+// return true if the pattern matches the whole ref name or its last components,
+// e.g. "main" matches both refs/heads/main and refs/remotes/origin/main.
+func matchShowRefPattern(refName, pattern string) bool {
+	return refName == pattern || strings.HasSuffix(refName, "/"+pattern)
+}
+
+func printShowRef(repo *goit.Repository, refName string, hash goit.Hash) error {
+	if isShowRefQuiet {
+		return nil
+	}
+	if isShowRefHashOnly {
+		fmt.Println(hash)
+	} else {
+		fmt.Printf("%s %s\n", hash, refName)
+	}
+
+	if !isShowRefDereference {
+		return nil
+	}
+	obj, err := repo.Object(hash)
+	if err != nil {
+		return fmt.Errorf("fail to get object of %s: %w", refName, err)
+	}
+	if obj.Type != goit.TagObject {
+		return nil
+	}
+	peeled, err := repo.Peel(hash)
+	if err != nil {
+		return fmt.Errorf("fail to peel %s: %w", refName, err)
+	}
+	if isShowRefHashOnly {
+		fmt.Println(peeled.Hash)
+	} else {
+		fmt.Printf("%s %s^{}\n", peeled.Hash, refName)
+	}
+	return nil
+}
+
+// showRefCmd represents the showRef command
+var showRefCmd = &cobra.Command{
+	Use:   "show-ref [<pattern>...]",
+	Short: "list references in a local repository",
+	Long:  "this is a command to list heads, tags, remotes and stash with the objects they point to",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := repository(cmd)
+
+		// show-ref --verify <ref>...
+		if isShowRefVerify {
+			if len(args) == 0 {
+				return fmt.Errorf("fatal: --verify requires a reference")
+			}
+			for _, refName := range args {
+				if refName != "HEAD" && !strings.HasPrefix(refName, "refs/") {
+					return fmt.Errorf("fatal: '%s' - not a valid ref", refName)
+				}
+				ref, err := repo.Reference(refName)
+				if err != nil {
+					return fmt.Errorf("fatal: '%s' - not a valid ref", refName)
+				}
+				if err := printShowRef(repo, refName, ref.Hash); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		isShown := false
+		if isShowRefHead {
+			if head, err := repo.Reference("HEAD"); err == nil {
+				if err := printShowRef(repo, "HEAD", head.Hash); err != nil {
+					return err
+				}
+				isShown = true
+			}
+		}
+
+		err := repo.References(cmd.Context(), "refs/", func(ref *goit.Reference) error {
+			if isShowRefHeads || isShowRefTags {
+				isHead := isShowRefHeads && strings.HasPrefix(ref.Name, "refs/heads/")
+				isTag := isShowRefTags && strings.HasPrefix(ref.Name, "refs/tags/")
+				if !isHead && !isTag {
+					return nil
+				}
+			}
+			if len(args) > 0 {
+				isMatched := false
+				for _, pattern := range args {
+					if matchShowRefPattern(ref.Name, pattern) {
+						isMatched = true
+						break
+					}
+				}
+				if !isMatched {
+					return nil
+				}
+			}
+			if err := printShowRef(repo, ref.Name, ref.Hash); err != nil {
+				return err
+			}
+			isShown = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// exit silently with an error so that scripts can tell no ref matched
+		if !isShown {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return ErrNoMatchingRef
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(showRefCmd)
+
+	showRefCmd.Flags().BoolVar(&isShowRefHeads, "heads", false, "show only refs/heads")
+	showRefCmd.Flags().BoolVar(&isShowRefTags, "tags", false, "show only refs/tags")
+	showRefCmd.Flags().BoolVar(&isShowRefVerify, "verify", false, "require an exact ref name and show it")
+	showRefCmd.Flags().BoolVar(&isShowRefHead, "head", false, "show the HEAD as well")
+	showRefCmd.Flags().BoolVarP(&isShowRefDereference, "dereference", "d", false, "show the object annotated tags point to as well")
+	showRefCmd.Flags().BoolVarP(&isShowRefHashOnly, "hash", "s", false, "show only the object hash")
+	showRefCmd.Flags().BoolVarP(&isShowRefQuiet, "quiet", "q", false, "do not print anything")
+}
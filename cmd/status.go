@@ -5,112 +5,307 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"strings"
 
-	"github.com/JunNishimura/Goit/internal/file"
-	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/JunNishimura/Goit/internal/diff"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+const (
+	statusModeAbsent = "000000"
+	statusModeFile   = "100644"
+)
+
+var (
+	statusFindRenames    string
+	statusNoRenames      bool
+	isStatusShort        bool
+	isStatusBranch       bool
+	statusPorcelain      string
+	isStatusNull         bool
+	statusUntrackedFiles string
+	isStatusIgnored      bool
+)
+
+// the label of the change in the long format, such as "modified:".
+func statusLabel(code byte) string {
+	switch code {
+	case 'D':
+		return "deleted:"
+	case 'A':
+		return "new file:"
+	case 'M':
+		return "modified:"
+	case 'R':
+		return "renamed:"
+	default:
+		return ""
+	}
+}
+
+// print the status in the human readable format, whose paths are relative to the current directory.
+func printLongStatus(w io.Writer, repo *goit.Repository, s *goit.Status, prefix string) error {
+	var statusMessage string
+
+	// set branch info
+	if s.Branch.Name == "" {
+		abbrev, err := repo.NewAbbreviator(0)
+		if err != nil {
+			return err
+		}
+		statusMessage += fmt.Sprintf("HEAD detached at %s\n", abbrev.Abbrev(s.Branch.OID))
+	} else {
+		statusMessage += fmt.Sprintf("On branch %s\n", s.Branch.Name)
+	}
+
+	if staged := s.Staged(); len(staged) > 0 {
+		statusMessage += "\nChanges to be committed:\n  (use 'goit restore --staged <file>...' to unstage)\n"
+		for _, entry := range staged {
+			path := displayPath(prefix, entry.Path)
+			if entry.OrigPath != "" {
+				path = fmt.Sprintf("%s -> %s", displayPath(prefix, entry.OrigPath), path)
+			}
+			statusMessage += color.GreenString("\t%-13s%s\n", statusLabel(entry.X), path)
+		}
+	}
+	if unmerged := s.Unmerged(); len(unmerged) > 0 {
+		statusMessage += "\nUnmerged paths:\n  (use 'goit add <file>...' to mark resolution)\n"
+		for _, entry := range unmerged {
+			statusMessage += color.RedString("\t%-17s%s\n", entry.UnmergedDescription()+":", displayPath(prefix, entry.Path))
+		}
+	}
+	if unstaged := s.Unstaged(); len(unstaged) > 0 {
+		statusMessage += "\nChanges not staged for commit:\n  (use 'goit add/rm <file>...' to update what will be committed)\n  (use 'goit restore <file>...' to discard changes in working directory)\n"
+		for _, entry := range unstaged {
+			statusMessage += color.RedString("\t%-13s%s\n", statusLabel(entry.Y), displayPath(prefix, entry.Path))
+		}
+	}
+	if len(s.Untracked) > 0 {
+		statusMessage += "\nUntracked files:\n  (use 'goit add <file>...' to include in what will be committed)\n"
+		for _, file := range s.Untracked {
+			statusMessage += color.RedString("\t%s\n", displayPath(prefix, file))
+		}
+	}
+	if len(s.Ignored) > 0 {
+		statusMessage += "\nIgnored files:\n  (use 'goit add -f <file>...' to include in what will be committed)\n"
+		for _, file := range s.Ignored {
+			statusMessage += color.RedString("\t%s\n", displayPath(prefix, file))
+		}
+	}
+
+	// show message
+	fmt.Fprintln(w, statusMessage)
+
+	return nil
+}
+
+// the branch header of the short format such as "main...origin/main [ahead 1, behind 2]".
+func shortBranchHeader(b *goit.BranchStatus) string {
+	if b.Name == "" {
+		return "HEAD (no branch)"
+	}
+	if b.OID == nil {
+		return fmt.Sprintf("No commits yet on %s", b.Name)
+	}
+	header := b.Name
+	if b.Upstream == "" {
+		return header
+	}
+	header += "..." + goit.ShortReferenceName(b.Upstream)
+	if b.UpstreamGone {
+		return header + " [gone]"
+	}
+	var counts []string
+	if b.Ahead > 0 {
+		counts = append(counts, fmt.Sprintf("ahead %d", b.Ahead))
+	}
+	if b.Behind > 0 {
+		counts = append(counts, fmt.Sprintf("behind %d", b.Behind))
+	}
+	if len(counts) > 0 {
+		header += fmt.Sprintf(" [%s]", strings.Join(counts, ", "))
+	}
+	return header
+}
+
+// print the status in the short format, which is also the porcelain v1 format without the colors.
+// The paths are relative to the current directory in the short format, and to the root in the porcelain format.
+func printShortStatus(w io.Writer, s *goit.Status, prefix string, isPorcelain bool) {
+	terminator := "\n"
+	if isStatusNull {
+		terminator = "\x00"
+	}
+	path := func(p string) string {
+		if isPorcelain {
+			return p
+		}
+		return displayPath(prefix, p)
+	}
+	green, red := color.GreenString, color.RedString
+	if isPorcelain {
+		green, red = fmt.Sprintf, fmt.Sprintf
+	}
+
+	if isStatusBranch {
+		fmt.Fprintf(w, "## %s%s", shortBranchHeader(s.Branch), terminator)
+	}
+	for _, entry := range s.Entries {
+		x, y := green("%c", entry.X), red("%c", entry.Y)
+		if entry.Unmerged {
+			x = red("%c", entry.X)
+		}
+		switch {
+		case entry.OrigPath == "":
+			fmt.Fprintf(w, "%s%s %s%s", x, y, path(entry.Path), terminator)
+		case isStatusNull:
+			// the source follows the destination as a separate field with -z
+			fmt.Fprintf(w, "%s%s %s\x00%s\x00", x, y, path(entry.Path), path(entry.OrigPath))
+		default:
+			fmt.Fprintf(w, "%s%s %s -> %s\n", x, y, path(entry.OrigPath), path(entry.Path))
+		}
+	}
+	for _, file := range s.Untracked {
+		fmt.Fprintf(w, "%s %s%s", red("??"), path(file), terminator)
+	}
+	for _, file := range s.Ignored {
+		fmt.Fprintf(w, "%s %s%s", red("!!"), path(file), terminator)
+	}
+}
+
+// print the status in the porcelain v2 format, whose paths are relative to the root.
+func printPorcelainV2Status(w io.Writer, s *goit.Status, format *goit.ObjectFormat) {
+	terminator := "\n"
+	if isStatusNull {
+		terminator = "\x00"
+	}
+	mode := func(isPresent bool) string {
+		if isPresent {
+			return statusModeFile
+		}
+		return statusModeAbsent
+	}
+	hash := func(h goit.Hash) goit.Hash {
+		if h == nil {
+			return format.ZeroHash()
+		}
+		return h
+	}
+	code := func(c byte) byte {
+		if c == ' ' {
+			return '.'
+		}
+		return c
+	}
+
+	if isStatusBranch {
+		b := s.Branch
+		oid, head := "(initial)", "(detached)"
+		if b.OID != nil {
+			oid = b.OID.String()
+		}
+		if b.Name != "" {
+			head = b.Name
+		}
+		fmt.Fprintf(w, "# branch.oid %s%s", oid, terminator)
+		fmt.Fprintf(w, "# branch.head %s%s", head, terminator)
+		if b.Upstream != "" {
+			fmt.Fprintf(w, "# branch.upstream %s%s", goit.ShortReferenceName(b.Upstream), terminator)
+			if !b.UpstreamGone {
+				fmt.Fprintf(w, "# branch.ab +%d -%d%s", b.Ahead, b.Behind, terminator)
+			}
+		}
+	}
+
+	for _, entry := range s.Entries {
+		xy := fmt.Sprintf("%c%c", code(entry.X), code(entry.Y))
+		switch {
+		case entry.Unmerged:
+			fmt.Fprintf(w, "u %s N... %s %s %s %s %s %s %s %s%s", xy,
+				mode(entry.Stages[0] != nil), mode(entry.Stages[1] != nil), mode(entry.Stages[2] != nil), mode(entry.InWorktree),
+				hash(entry.Stages[0]), hash(entry.Stages[1]), hash(entry.Stages[2]), entry.Path, terminator)
+		case entry.OrigPath != "":
+			// the source is separated by a tab, or NUL with -z
+			separator := "\t"
+			if isStatusNull {
+				separator = "\x00"
+			}
+			fmt.Fprintf(w, "2 %s N... %s %s %s %s %s %c%d %s%s%s%s", xy,
+				mode(entry.HeadHash != nil), mode(entry.IndexHash != nil), mode(entry.InWorktree),
+				hash(entry.HeadHash), hash(entry.IndexHash), entry.X, entry.Score, entry.Path, separator, entry.OrigPath, terminator)
+		default:
+			fmt.Fprintf(w, "1 %s N... %s %s %s %s %s %s%s", xy,
+				mode(entry.HeadHash != nil), mode(entry.IndexHash != nil), mode(entry.InWorktree),
+				hash(entry.HeadHash), hash(entry.IndexHash), entry.Path, terminator)
+		}
+	}
+	for _, file := range s.Untracked {
+		fmt.Fprintf(w, "? %s%s", file, terminator)
+	}
+	for _, file := range s.Ignored {
+		fmt.Fprintf(w, "! %s%s", file, terminator)
+	}
+}
+
 // statusCmd represents the status command
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [<pathspec>...]",
 	Short: "show the working tree status",
 	Long:  "show the working tree status",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var statusMessage string
-
-		// set branch info
-		statusMessage += fmt.Sprintf("On branch %s\n", client.Head.Reference)
-
-		// walk through working directory
-		var newFiles []string
-		var modifiedFiles []string
-		filePaths, err := file.GetFilePathsUnderDirectoryWithIgnore(".", client.Idx, client.Ignore)
-		if err != nil {
-			return fmt.Errorf("fail to get files: %w", err)
-		}
-		for _, filePath := range filePaths {
-			_, entry, isRegistered := client.Idx.GetEntry([]byte(filePath))
-
-			if !isRegistered { // new file
-				newFiles = append(newFiles, filePath)
-			} else {
-				// check if the file is modified
-				data, err := os.ReadFile(filePath)
-				if err != nil {
-					return fmt.Errorf("fail to read %s: %w", filePath, err)
-				}
-				obj, err := object.NewObject(object.BlobObject, data)
-				if err != nil {
-					return fmt.Errorf("fail to get new object: %w", err)
-				}
-				if !entry.Hash.Compare(obj.Hash) {
-					modifiedFiles = append(modifiedFiles, filePath)
-				}
-			}
-		}
-
-		// walk through index
-		var deletedFiles []string
-		for _, entry := range client.Idx.Entries {
-			filePath := string(entry.Path)
-			if _, err := os.Stat(filePath); os.IsNotExist(err) {
-				deletedFiles = append(deletedFiles, filePath)
-			}
-		}
-
-		// compare index with HEAD commit
-		treeObj, err := object.GetObject(client.RootGoitPath, client.Head.Commit.Tree)
+		repo := repository(cmd)
+		wt, err := worktree(repo)
 		if err != nil {
-			return fmt.Errorf("fail to get tree object: %w", err)
+			return err
 		}
-		tree, err := object.NewTree(client.RootGoitPath, treeObj)
+		threshold, err := diff.ParseSimilarity(statusFindRenames)
 		if err != nil {
-			return fmt.Errorf("fail to get tree: %w", err)
+			return fmt.Errorf("fatal: %w", err)
 		}
-		diffEntries, err := client.Idx.DiffWithTree(tree)
+		untrackedMode, err := goit.ParseUntrackedMode(statusUntrackedFiles)
 		if err != nil {
-			return fmt.Errorf("fail to get diff entries: %w", err)
+			return fmt.Errorf("fatal: %w", err)
 		}
 
-		// construct message
-		if len(diffEntries) > 0 {
-			statusMessage += "\nChanges to be committed:\n  (use 'goit restore --staged <file>...' to unstage)\n"
-			for _, diffEntry := range diffEntries {
-				statusMessage += color.GreenString("\t%-13s%s\n", diffEntry.Dt, diffEntry.Entry.Path)
-			}
+		// -z implies the porcelain v1 format unless the format is given
+		isPorcelain := cmd.Flags().Changed("porcelain")
+		if isPorcelain && statusPorcelain != "v1" && statusPorcelain != "v2" {
+			return fmt.Errorf("fatal: unsupported porcelain version '%s'", statusPorcelain)
 		}
-		if len(modifiedFiles) > 0 {
-			statusMessage += "\nChanges not staged for commit:\n  (use 'goit add/rm <file>...' to update what will be committed)\n  (use 'goit restore <file>...' to discard changes in working directory)\n"
-			for _, file := range modifiedFiles {
-				statusMessage += color.RedString("\t%-13s%s\n", "modified:", file)
-			}
+		if isStatusNull && !isPorcelain && !isStatusShort {
+			isPorcelain, statusPorcelain = true, "v1"
 		}
-		if len(deletedFiles) > 0 {
-			if len(modifiedFiles) == 0 {
-				statusMessage += "\nChanges not staged for commit:\n  (use 'goit add/rm <file>...' to update what will be committed)\n  (use 'goit restore <file>...' to discard changes in working directory)\n"
-			}
-			for _, file := range deletedFiles {
-				statusMessage += color.RedString("\t%-13s%s\n", "deleted:", file)
-			}
-		}
-		if len(newFiles) > 0 {
-			statusMessage += "\nUntracked files:\n  (use 'goit add <file>...' to include in what will be committed)\n"
-			for _, file := range newFiles {
-				statusMessage += color.RedString("\t%s\n", file)
-			}
+
+		s, err := wt.Status(cmd.Context(), &goit.StatusOptions{
+			Pathspecs:     args,
+			Untracked:     untrackedMode,
+			Ignored:       isStatusIgnored,
+			DetectRenames: !statusNoRenames,
+			Threshold:     threshold,
+		})
+		if err != nil {
+			return err
 		}
 
-		// show message
-		fmt.Println(statusMessage)
+		w := cmd.OutOrStdout()
+		switch {
+		case isPorcelain && statusPorcelain == "v2":
+			printPorcelainV2Status(w, s, repo.ObjectFormat())
+		case isPorcelain:
+			printShortStatus(w, s, wt.Prefix, true)
+		case isStatusShort:
+			printShortStatus(w, s, wt.Prefix, false)
+		default:
+			return printLongStatus(w, repo, s, wt.Prefix)
+		}
 
 		return nil
 	},
@@ -118,4 +313,16 @@ var statusCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().StringVarP(&statusFindRenames, "find-renames", "M", "", "detect renames with the similarity threshold such as -M=90%")
+	statusCmd.Flags().Lookup("find-renames").NoOptDefVal = "50%"
+	statusCmd.Flags().BoolVar(&statusNoRenames, "no-renames", false, "do not detect renames")
+	statusCmd.Flags().BoolVarP(&isStatusShort, "short", "s", false, "give the output in the short format")
+	statusCmd.Flags().BoolVarP(&isStatusBranch, "branch", "b", false, "show the branch and the tracking info in the short and the porcelain format")
+	statusCmd.Flags().StringVar(&statusPorcelain, "porcelain", "", "give the output in the stable format for scripts, v1 or v2")
+	statusCmd.Flags().Lookup("porcelain").NoOptDefVal = "v1"
+	statusCmd.Flags().BoolVarP(&isStatusNull, "null", "z", false, "terminate the entries with NUL, implying --porcelain=v1")
+	statusCmd.Flags().StringVarP(&statusUntrackedFiles, "untracked-files", "u", "normal", "show untracked files, no, normal or all")
+	statusCmd.Flags().Lookup("untracked-files").NoOptDefVal = "all"
+	statusCmd.Flags().BoolVar(&isStatusIgnored, "ignored", false, "show ignored files as well")
 }
@@ -6,65 +6,127 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"time"
+	"strings"
 
-	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
 var (
-	createOption string
+	createOption   string
+	orphanOption   string
+	discardChanges bool
+	isMergeSwitch  bool
 )
 
+// show the local changes carried to the new branch.
+func showSwitchResult(result *goit.CheckoutResult) {
+	for _, change := range result.Changes {
+		fmt.Printf("%c\t%s\n", change.Status, change.Path)
+	}
+}
+
+// add the prefix to the error of the checkout, listing the paths whose local changes would be overwritten.
+func checkoutError(err error) error {
+	var localErr *goit.LocalChangesError
+	switch {
+	case errors.As(err, &localErr):
+		var b strings.Builder
+		if len(localErr.Modified) > 0 {
+			b.WriteString("error: Your local changes to the following files would be overwritten by checkout:\n")
+			for _, path := range localErr.Modified {
+				b.WriteString(fmt.Sprintf("\t%s\n", path))
+			}
+			b.WriteString("Please commit your changes or stash them before you switch branches.")
+		}
+		if len(localErr.Untracked) > 0 {
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("error: The following untracked working tree files would be overwritten by checkout:\n")
+			for _, path := range localErr.Untracked {
+				b.WriteString(fmt.Sprintf("\t%s\n", path))
+			}
+			b.WriteString("Please move or remove them before you switch branches.")
+		}
+		return errors.New(b.String())
+	case errors.Is(err, goit.ErrUnmergedIndex):
+		return fmt.Errorf("error: %w", err)
+	case errors.Is(err, goit.ErrBranchExists), errors.Is(err, goit.ErrInvalidReference):
+		return fmt.Errorf("fatal: %w", err)
+	}
+	return err
+}
+
 // switchCmd represents the switch command
 var switchCmd = &cobra.Command{
 	Use:   "switch",
 	Short: "switch branches",
 	Long:  "switch branches",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// validation
+		if discardChanges && isMergeSwitch {
+			return ErrIncompatibleFlag
+		}
+		if createOption != "" && orphanOption != "" {
+			return ErrIncompatibleFlag
+		}
+		if orphanOption != "" && len(args) >= 1 {
+			return errors.New("fatal: '--orphan' cannot take <start-point>")
+		}
 		if len(args) >= 2 {
 			return errors.New("fatal: only one reference expected")
 		}
-		if createOption == "" && len(args) == 0 {
+		if createOption == "" && orphanOption == "" && len(args) == 0 {
 			return errors.New("fatal: missing branch")
-		} else if createOption != "" && len(args) >= 1 {
-			return errors.New("invalid create option format")
 		}
 
-		// switch branch == update HEAD
-		if len(args) == 1 {
-			prevBranch := client.Head.Reference
-			if err := client.Head.Update(client.Refs, client.RootGoitPath, args[0]); err != nil {
-				return fmt.Errorf("fail to update HEAD: %w", err)
-			}
-			if err := gLogger.WriteHEAD(log.NewRecord(log.CheckoutRecord, client.Head.Commit.Hash, client.Head.Commit.Hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("moving from %s to %s", prevBranch, client.Head.Reference))); err != nil {
-				return fmt.Errorf("log error: %w", err)
-			}
+		repo := repository(cmd)
+		prevBranch := goit.ShortReferenceName(repo.Head().Target)
+		opts := &goit.CheckoutOptions{
+			Force: discardChanges,
+			Merge: isMergeSwitch,
 		}
-
-		if createOption != "" {
-			prevBranch := client.Head.Reference
-			if err := client.Refs.AddBranch(client.RootGoitPath, createOption, client.Head.Commit.Hash); err != nil {
-				return fmt.Errorf("fail to create new branch %s: %w", createOption, err)
-			}
-			if err := client.Head.Update(client.Refs, client.RootGoitPath, createOption); err != nil {
-				return fmt.Errorf("fail to update HEAD: %w", err)
+		var successMessage string
+		switch {
+		case orphanOption != "":
+			// orphan branch starts from the empty tree
+			opts.Branch, opts.Orphan = orphanOption, true
+			successMessage = fmt.Sprintf("Switched to a new branch '%s'", orphanOption)
+		case createOption != "":
+			// create new branch from the start point
+			startPoint := "HEAD"
+			if len(args) == 1 {
+				startPoint = args[0]
 			}
-			if err := gLogger.WriteHEAD(log.NewRecord(log.CheckoutRecord, client.Head.Commit.Hash, client.Head.Commit.Hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("moving from %s to %s", prevBranch, client.Head.Reference))); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			startHash, err := resolveStartPoint(repo, startPoint)
+			if err != nil {
+				return err
 			}
-			if err := gLogger.WriteBranch(log.NewRecord(log.BranchRecord, nil, client.Head.Commit.Hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("Created from %s", prevBranch)), createOption); err != nil {
-				return fmt.Errorf("log error: %w", err)
+			opts.Branch, opts.Create, opts.Hash, opts.StartPoint = createOption, true, startHash, startPoint
+			successMessage = fmt.Sprintf("Switched to a new branch '%s'", createOption)
+		default:
+			// switch to the existing branch
+			opts.Branch = args[0]
+			successMessage = fmt.Sprintf("Switched to branch '%s'", args[0])
+			if args[0] == prevBranch {
+				successMessage = fmt.Sprintf("Already on '%s'", args[0])
 			}
 		}
 
+		result, err := repo.Worktree().Checkout(cmd.Context(), opts)
+		if err != nil {
+			return checkoutError(err)
+		}
+		showSwitchResult(result)
+		fmt.Println(successMessage)
+
 		return nil
 	},
 }
@@ -73,4 +135,8 @@ func init() {
 	rootCmd.AddCommand(switchCmd)
 
 	switchCmd.Flags().StringVarP(&createOption, "create", "c", "", "create new branch")
+	switchCmd.Flags().StringVar(&orphanOption, "orphan", "", "create new branch with no history and an empty index")
+	switchCmd.Flags().BoolVar(&discardChanges, "discard-changes", false, "throw away local changes")
+	switchCmd.Flags().BoolVarP(&discardChanges, "force", "f", false, "alias for --discard-changes")
+	switchCmd.Flags().BoolVarP(&isMergeSwitch, "merge", "m", false, "three-way merge local changes into the new branch")
 }
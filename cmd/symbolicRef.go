@@ -0,0 +1,114 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JunNishimura/Goit/goit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	isShortSymref  bool
+	isDeleteSymref bool
+)
+
+func validateSymbolicRefName(name string) error {
+	if name == "HEAD" {
+		return nil
+	}
+	if !strings.HasPrefix(name, "refs/") {
+		return fmt.Errorf("fatal: refusing to handle '%s' outside of refs/", name)
+	}
+	if err := goit.ValidateReferenceName(name); err != nil {
+		return fmt.Errorf("fatal: %w", err)
+	}
+	return nil
+}
+
+// symbolicRefCmd represents the symbolicRef command
+var symbolicRefCmd = &cobra.Command{
+	Use:   "symbolic-ref",
+	Short: "read, modify and delete symbolic refs",
+	Long:  "this is a command to read, modify and delete symbolic refs such as HEAD",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if repository(cmd) == nil {
+			return ErrGoitNotInitialized
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// args validation
+		if len(args) == 0 || len(args) > 2 {
+			return ErrInvalidArgs
+		}
+		if isDeleteSymref && (len(args) != 1 || isShortSymref) {
+			return ErrIncompatibleFlag
+		}
+		name := args[0]
+		if err := validateSymbolicRefName(name); err != nil {
+			return err
+		}
+
+		repo := repository(cmd)
+
+		// delete symbolic ref
+		if isDeleteSymref {
+			if name == "HEAD" {
+				return errors.New("fatal: deleting a symbolic ref 'HEAD' is not allowed")
+			}
+			if err := repo.RemoveSymbolicReference(name); err != nil {
+				return fmt.Errorf("fatal: fail to delete %s: %w", name, err)
+			}
+			return nil
+		}
+
+		// read symbolic ref
+		if len(args) == 1 {
+			target, err := repo.SymbolicReference(name)
+			if err != nil {
+				return fmt.Errorf("fatal: ref %s is not a symbolic ref", name)
+			}
+			if isShortSymref {
+				target = goit.ShortReferenceName(target)
+			}
+			fmt.Println(target)
+			return nil
+		}
+
+		// update symbolic ref
+		target := args[1]
+		if !strings.HasPrefix(target, "refs/") {
+			return fmt.Errorf("fatal: refusing to point %s outside of refs/", name)
+		}
+		if err := goit.ValidateReferenceName(target); err != nil {
+			return fmt.Errorf("fatal: %w", err)
+		}
+		if name == "HEAD" {
+			// HEAD always points to a branch in goit
+			if !strings.HasPrefix(target, "refs/heads/") {
+				return fmt.Errorf("fatal: refusing to point HEAD outside of refs/heads/")
+			}
+		}
+		err := repo.SetSymbolicReference(name, target)
+		if errors.Is(err, goit.ErrSymbolicReferenceCycle) || errors.Is(err, goit.ErrSymbolicReferenceDepth) {
+			return fmt.Errorf("fatal: %w", err)
+		}
+		if err != nil {
+			return err
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(symbolicRefCmd)
+
+	symbolicRefCmd.Flags().BoolVar(&isShortSymref, "short", false, "shorten the ref name, e.g. refs/heads/main to main")
+	symbolicRefCmd.Flags().BoolVarP(&isDeleteSymref, "delete", "d", false, "delete the symbolic ref")
+}
@@ -4,63 +4,187 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
+	"io"
 	"strings"
 
-	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/goit"
 	"github.com/spf13/cobra"
 )
 
 var (
-	branchRegexp = regexp.MustCompile("refs/heads/.+")
+	isDeleteRef      bool
+	isUpdateRefStdin bool
+	updateRefMessage string
 )
 
+// resolve the revision such as HEAD, main@{1} or the object hash as rev-parse does.
+// The full zero hash is taken as it is, which means the ref does not exist.
+func readUpdateRefHash(repo *goit.Repository, hashString string) (goit.Hash, error) {
+	hash, err := repo.ResolveRevision(hashString)
+	if errors.Is(err, goit.ErrAmbiguousHash) {
+		return nil, fmt.Errorf("error: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHash, hashString)
+	}
+	return hash, nil
+}
+
+// parse update-ref --stdin commands and queue them to the transaction.
+//
+//	update SP <ref> SP <new> [SP <old>] LF
+//	create SP <ref> SP <new> LF
+//	delete SP <ref> [SP <old>] LF
+//	verify SP <ref> [SP <old>] LF
+func queueUpdateRefCommands(repo *goit.Repository, r io.Reader, tx *goit.RefTransaction) error {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := scanner.Text()
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		command := fields[0]
+		params := fields[1:]
+
+		var err error
+		switch command {
+		case "update":
+			if len(params) != 2 && len(params) != 3 {
+				return fmt.Errorf("fatal: update: expected <ref> <new> [<old>] on line %d", lineNum)
+			}
+			newHash, hashErr := readUpdateRefHash(repo, params[1])
+			if hashErr != nil {
+				return fmt.Errorf("fatal: update %s: %w", params[0], hashErr)
+			}
+			var oldHash goit.Hash
+			if len(params) == 3 {
+				oldHash, hashErr = readUpdateRefHash(repo, params[2])
+				if hashErr != nil {
+					return fmt.Errorf("fatal: update %s: %w", params[0], hashErr)
+				}
+			}
+			err = tx.Update(params[0], newHash, oldHash, len(params) == 3)
+		case "create":
+			if len(params) != 2 {
+				return fmt.Errorf("fatal: create: expected <ref> <new> on line %d", lineNum)
+			}
+			newHash, hashErr := readUpdateRefHash(repo, params[1])
+			if hashErr != nil {
+				return fmt.Errorf("fatal: create %s: %w", params[0], hashErr)
+			}
+			err = tx.Create(params[0], newHash)
+		case "delete":
+			if len(params) != 1 && len(params) != 2 {
+				return fmt.Errorf("fatal: delete: expected <ref> [<old>] on line %d", lineNum)
+			}
+			var oldHash goit.Hash
+			if len(params) == 2 {
+				var hashErr error
+				oldHash, hashErr = readUpdateRefHash(repo, params[1])
+				if hashErr != nil {
+					return fmt.Errorf("fatal: delete %s: %w", params[0], hashErr)
+				}
+			}
+			err = tx.Delete(params[0], oldHash, len(params) == 2)
+		case "verify":
+			if len(params) != 1 && len(params) != 2 {
+				return fmt.Errorf("fatal: verify: expected <ref> [<old>] on line %d", lineNum)
+			}
+			var oldHash goit.Hash
+			if len(params) == 2 {
+				var hashErr error
+				oldHash, hashErr = readUpdateRefHash(repo, params[1])
+				if hashErr != nil {
+					return fmt.Errorf("fatal: verify %s: %w", params[0], hashErr)
+				}
+			}
+			err = tx.Verify(params[0], oldHash)
+		default:
+			return fmt.Errorf("fatal: unknown command: %s", text)
+		}
+		if err != nil {
+			return fmt.Errorf("fatal: %s: %w", command, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("fail to read stdin: %w", err)
+	}
+
+	return nil
+}
+
 // updateRefCmd represents the updateRef command
 var updateRefCmd = &cobra.Command{
 	Use:   "update-ref",
 	Short: "update reference",
-	Long:  "update reference",
+	Long:  "update reference safely, verifying its old value",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if client.RootGoitPath == "" {
+		if repository(cmd) == nil {
 			return ErrGoitNotInitialized
 		}
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 2 {
-			return ErrInvalidArgs
-		}
+		repo := repository(cmd)
+		tx := repo.NewRefTransaction()
 
-		// get reference path
-		if ok := branchRegexp.MatchString(args[0]); !ok {
-			return fmt.Errorf("invalid branch path %s", args[0])
+		switch {
+		case isUpdateRefStdin:
+			if isDeleteRef {
+				return ErrIncompatibleFlag
+			}
+			if len(args) != 0 {
+				return ErrInvalidArgs
+			}
+			if err := queueUpdateRefCommands(repo, cmd.InOrStdin(), tx); err != nil {
+				return err
+			}
+		case isDeleteRef:
+			// update-ref -d <ref> [<old>]
+			if len(args) != 1 && len(args) != 2 {
+				return ErrInvalidArgs
+			}
+			var oldHash goit.Hash
+			if len(args) == 2 {
+				hash, err := readUpdateRefHash(repo, args[1])
+				if err != nil {
+					return err
+				}
+				oldHash = hash
+			}
+			if err := tx.Delete(args[0], oldHash, len(args) == 2); err != nil {
+				return fmt.Errorf("fatal: %w", err)
+			}
+		default:
+			// update-ref <ref> <new> [<old>]
+			if len(args) != 2 && len(args) != 3 {
+				return ErrInvalidArgs
+			}
+			newHash, err := readUpdateRefHash(repo, args[1])
+			if err != nil {
+				return err
+			}
+			var oldHash goit.Hash
+			if len(args) == 3 {
+				hash, err := readUpdateRefHash(repo, args[2])
+				if err != nil {
+					return err
+				}
+				oldHash = hash
+			}
+			if err := tx.Update(args[0], newHash, oldHash, len(args) == 3); err != nil {
+				return fmt.Errorf("fatal: %w", err)
+			}
 		}
-		branchSplit := strings.Split(args[0], "/")
-		branchName := branchSplit[len(branchSplit)-1]
 
-		// hash validation
-		hashString := args[1]
-		if len(hashString) != 40 {
-			return ErrInvalidHash
-		}
-		hashPath := filepath.Join(client.RootGoitPath, "objects", hashString[:2], hashString[2:])
-		if _, err := os.Stat(hashPath); os.IsNotExist(err) {
-			return fmt.Errorf("fatal: trying to write ref '%s' with nonexistent object %s", args[0], hashString)
-		}
-		newHash, err := sha.ReadHash(hashString)
-		if err != nil {
-			return ErrInvalidHash
-		}
-
-		if err := client.Refs.UpdateBranchHash(client.RootGoitPath, branchName, newHash); err != nil {
-			return fmt.Errorf("fail to update reference %s: %w", args[0], err)
-		}
-
-		if err := client.Head.Update(client.Refs, client.RootGoitPath, branchName); err != nil {
-			return fmt.Errorf("fail to update HEAD: %w", err)
+		if err := tx.Commit(cmd.Context(), updateRefMessage); err != nil {
+			return fmt.Errorf("fatal: %w", err)
 		}
 
 		return nil
@@ -69,4 +193,8 @@ var updateRefCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(updateRefCmd)
+
+	updateRefCmd.Flags().BoolVarP(&isDeleteRef, "delete", "d", false, "delete the reference")
+	updateRefCmd.Flags().BoolVar(&isUpdateRefStdin, "stdin", false, "read update commands from stdin and apply them atomically")
+	updateRefCmd.Flags().StringVarP(&updateRefMessage, "message", "m", "", "reason of the update written to the reflog")
 }
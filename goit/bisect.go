@@ -0,0 +1,53 @@
+package goit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JunNishimura/Goit/internal/bisect"
+)
+
+type (
+	// BisectState is the bisect session, which is kept in the repository until ClearBisect.
+	BisectState = bisect.State
+	// BisectResult is the next commit to test, or the first bad commit when the search is over.
+	BisectResult = bisect.Result
+)
+
+// LoadBisect reads the bisect session in progress, which is ErrNotBisecting if there is none.
+func (r *Repository) LoadBisect() (*BisectState, error) {
+	return bisect.Load(r.client.RootGoitPath)
+}
+
+// SaveBisect writes the bisect session.
+func (r *Repository) SaveBisect(state *BisectState) error {
+	return state.Save(r.client.RootGoitPath)
+}
+
+// ClearBisect removes the bisect session together with its log.
+func (r *Repository) ClearBisect() error {
+	return bisect.Clean(r.client.RootGoitPath)
+}
+
+// AppendBisectLog appends the lines to the log of the bisect session.
+func (r *Repository) AppendBisectLog(lines ...string) error {
+	return bisect.AppendLog(r.client.RootGoitPath, lines...)
+}
+
+// BisectLog returns the log of the bisect session, which is ErrNotBisecting if there is none.
+func (r *Repository) BisectLog() (string, error) {
+	return bisect.ReadLog(r.client.RootGoitPath)
+}
+
+// BisectNext picks the commit to test next from the bad and the good commits of the session,
+// which must be ready with both of them.
+func (r *Repository) BisectNext(ctx context.Context, state *BisectState) (*BisectResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := bisect.Next(r.commitGraph(), state)
+	if errors.Is(err, bisect.ErrGoodNotAncestor) {
+		return nil, ErrGoodNotAncestor
+	}
+	return result, err
+}
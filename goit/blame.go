@@ -0,0 +1,37 @@
+package goit
+
+import (
+	"context"
+
+	"github.com/JunNishimura/Goit/internal/blame"
+)
+
+type (
+	// BlameOptions limits the lines to blame and tells how the lines are compared.
+	BlameOptions = blame.Options
+	// BlameLine is the line of the file with the commit it is attributed to.
+	BlameLine = blame.Line
+)
+
+// ParseLineRange parses the line range such as "3,10", "3,+5", "3," and ",10" into the 1-based
+// inclusive range, where 0 stands for the head or the end of the file.
+func ParseLineRange(spec string) (int, int, error) {
+	return blame.ParseRange(spec)
+}
+
+// Blame attributes each line of the file at the path in the commit to the commit which last modified it.
+func (r *Repository) Blame(ctx context.Context, hash Hash, path string, opts *BlameOptions) ([]*BlameLine, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return blame.Blame(blame.NewRepository(r.client.RootGoitPath), hash, path, opts)
+}
+
+// BlameReverse walks the history forward from the commit from to the commit to, and attributes each line
+// of the file at the path in from to the last commit where the line still existed.
+func (r *Repository) BlameReverse(ctx context.Context, from, to Hash, path string, opts *BlameOptions) ([]*BlameLine, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return blame.Reverse(blame.NewRepository(r.client.RootGoitPath), from, to, path, opts)
+}
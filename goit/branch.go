@@ -0,0 +1,218 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/graph"
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+func branchConfigIdent(branch string) string {
+	return fmt.Sprintf(`branch "%s"`, branch)
+}
+
+// the commit graph, which caches the commits read while walking the history.
+func (r *Repository) commitGraph() *graph.Graph {
+	if r.graph == nil {
+		r.graph = graph.New(r.client.RootGoitPath)
+	}
+	return r.graph
+}
+
+// IsAncestor tells whether the ancestor is reachable from the descendant, which includes the commit itself.
+func (r *Repository) IsAncestor(ancestor, descendant Hash) (bool, error) {
+	return r.commitGraph().IsAncestor(ancestor, descendant)
+}
+
+// AheadBehind counts the commits only the local commit reaches and the commits only the upstream reaches.
+func (r *Repository) AheadBehind(local, upstream Hash) (int, int, error) {
+	return r.commitGraph().AheadBehind(local, upstream)
+}
+
+// CreateBranch makes the branch at the commit, recording the start point such as "main" in its reflog.
+func (r *Repository) CreateBranch(ctx context.Context, name string, hash Hash, startPoint string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	client := r.client
+	if err := client.Refs.AddBranch(client.RootGoitPath, name, hash); err != nil {
+		return fmt.Errorf("fail to add branch '%s': %w", name, err)
+	}
+	record := log.NewRecord(log.BranchRecord, nil, hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("Created from %s", startPoint))
+	if err := r.logger.WriteBranch(record, name); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	return nil
+}
+
+// CopyBranch makes the branch dst at the commit of src, which takes over the reflog and the config of src.
+func (r *Repository) CopyBranch(ctx context.Context, src, dst string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	client := r.client
+	hash, err := store.ReadRef(client.RootGoitPath, "refs/heads/"+src)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBranchNotFound, src)
+	}
+	if err := client.Refs.AddBranch(client.RootGoitPath, dst, hash); err != nil {
+		return fmt.Errorf("fail to copy branch '%s': %w", src, err)
+	}
+
+	if err := r.logger.CopyRef("refs/heads/"+src, "refs/heads/"+dst); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	record := log.NewRecord(log.BranchRecord, hash, hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("copied refs/heads/%s to refs/heads/%s", src, dst))
+	if err := r.logger.WriteBranch(record, dst); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	if client.Conf.CopyIdent(branchConfigIdent(src), branchConfigIdent(dst), false) {
+		return r.writeLocalConfig()
+	}
+	return nil
+}
+
+// tell whether the branch is merged into its upstream, or into HEAD if it has no upstream.
+func (r *Repository) isBranchMerged(branch string, hash Hash) (bool, error) {
+	var target Hash
+	if upstream, ok := r.Upstream(branch); ok {
+		if upstreamHash, err := store.ReadRef(r.client.RootGoitPath, upstream); err == nil {
+			target = upstreamHash
+		}
+	}
+	if target == nil && r.client.Head.Commit != nil {
+		target = r.client.Head.Commit.Hash
+	}
+	if target == nil {
+		return false, nil
+	}
+	return r.IsAncestor(hash, target)
+}
+
+// DeleteBranch deletes the branch together with its reflog and config. The branch which is not merged
+// into its upstream, or into HEAD without the upstream, is refused with ErrBranchNotMerged unless force is set.
+func (r *Repository) DeleteBranch(ctx context.Context, name string, force bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	client := r.client
+	hash, err := store.ReadRef(client.RootGoitPath, "refs/heads/"+name)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBranchNotFound, name)
+	}
+	if !force {
+		isMerged, err := r.isBranchMerged(name, hash)
+		if err != nil {
+			return err
+		}
+		if !isMerged {
+			return fmt.Errorf("%w: %s", ErrBranchNotMerged, name)
+		}
+	}
+
+	if err := client.Refs.DeleteBranch(client.RootGoitPath, client.Head.Reference, name); err != nil {
+		return fmt.Errorf("fail to delete branch: %w", err)
+	}
+	if err := r.logger.DeleteBranch(name); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	if client.Conf.RemoveIdent(branchConfigIdent(name), false) {
+		return r.writeLocalConfig()
+	}
+	return nil
+}
+
+// RenameBranch renames the current branch, whose reflog and config follow the new name.
+func (r *Repository) RenameBranch(ctx context.Context, newName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	client := r.client
+	head := client.Head
+	if head.IsDetached() {
+		return fmt.Errorf("%w: HEAD", ErrInvalidReference)
+	}
+	if head.Commit == nil {
+		return fmt.Errorf("%w: %s", ErrBranchNotFound, head.Reference)
+	}
+	prevName := head.Reference
+	if err := client.Refs.RenameBranch(client.RootGoitPath, prevName, newName); err != nil {
+		return fmt.Errorf("fail to rename branch: %w", err)
+	}
+	if err := head.Update(client.Refs, client.RootGoitPath, newName); err != nil {
+		return fmt.Errorf("fail to update HEAD: %w", err)
+	}
+
+	// HEAD log records the rename as the deletion and the creation
+	name, email, now := client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now()
+	hash := head.Commit.Hash
+	message := fmt.Sprintf("renamed refs/heads/%s to refs/heads/%s", prevName, newName)
+	if err := r.logger.WriteHEAD(log.NewRecord(log.BranchRecord, hash, nil, name, email, now, message)); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	if err := r.logger.WriteHEAD(log.NewRecord(log.BranchRecord, nil, hash, name, email, now, message)); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	// the log of the branch moves along with it
+	if err := r.logger.RenameRef("refs/heads/"+prevName, "refs/heads/"+newName); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	if err := r.logger.WriteBranch(log.NewRecord(log.BranchRecord, hash, hash, name, email, now, fmt.Sprintf("renamed refs/heads/%s refs/heads/%s", prevName, newName)), newName); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+
+	// config of the branch follows the new name
+	if client.Conf.CopyIdent(branchConfigIdent(prevName), branchConfigIdent(newName), false) {
+		client.Conf.RemoveIdent(branchConfigIdent(prevName), false)
+		return r.writeLocalConfig()
+	}
+	return nil
+}
+
+// SetUpstream sets branch.<name>.remote and branch.<name>.merge of the branch from the upstream such as
+// origin/main (remote-tracking branch) or main (local branch), and returns the short name of the upstream.
+func (r *Repository) SetUpstream(branch, upstream string) (string, error) {
+	client := r.client
+	if !client.Refs.IsBranchExist(branch) {
+		return "", fmt.Errorf("%w: %s", ErrBranchNotFound, branch)
+	}
+
+	var remote, merge string
+	name := strings.TrimPrefix(strings.TrimPrefix(upstream, "refs/remotes/"), "refs/heads/")
+	if _, err := store.ReadRef(client.RootGoitPath, "refs/remotes/"+name); err == nil && !strings.HasPrefix(upstream, "refs/heads/") {
+		sp := strings.SplitN(name, "/", 2)
+		if len(sp) != 2 {
+			return "", fmt.Errorf("%w: %s", ErrUpstreamNotFound, upstream)
+		}
+		remote = sp[0]
+		merge = "refs/heads/" + sp[1]
+	} else if _, err := store.ReadRef(client.RootGoitPath, "refs/heads/"+name); err == nil {
+		remote = "."
+		merge = "refs/heads/" + name
+	} else {
+		return "", fmt.Errorf("%w: %s", ErrUpstreamNotFound, upstream)
+	}
+
+	ident := branchConfigIdent(branch)
+	client.Conf.Add(ident, "remote", remote, false)
+	client.Conf.Add(ident, "merge", merge, false)
+	if err := r.writeLocalConfig(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// UnsetUpstream removes the upstream of the branch.
+func (r *Repository) UnsetUpstream(branch string) error {
+	ident := branchConfigIdent(branch)
+	isRemoteRemoved := r.client.Conf.Remove(ident, "remote", false)
+	isMergeRemoved := r.client.Conf.Remove(ident, "merge", false)
+	if !isRemoteRemoved && !isMergeRemoved {
+		return fmt.Errorf("%w: %s", ErrNoUpstream, branch)
+	}
+	return r.writeLocalConfig()
+}
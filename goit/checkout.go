@@ -0,0 +1,180 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/checkout"
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+type (
+	// CheckoutResult reports the paths the checkout touched and the local changes it carried.
+	CheckoutResult = checkout.Result
+	// LocalChangesError tells the paths whose local changes would be overwritten by the checkout.
+	LocalChangesError = checkout.LocalChangesError
+)
+
+// CheckoutOptions tells what to check out and how the local changes are handled.
+// The branch is switched to if only Branch is given, and HEAD is detached at Hash if Branch is empty.
+type CheckoutOptions struct {
+	Branch     string // the branch to switch to, or to make with Create or Orphan
+	Create     bool   // make the branch at Hash, which is HEAD if nil
+	Orphan     bool   // make the branch with no history and an empty index
+	Hash       Hash   // the start point with Create, or the commit on the detached HEAD
+	StartPoint string // the name of the start point recorded in the reflog, which is the hash if empty
+	Force      bool   // throw away the local changes
+	Merge      bool   // three-way merge the local changes into the new tree
+}
+
+// move the index and the working tree from the HEAD commit to the target commit.
+func (w *Worktree) switchTree(target Hash, label string, opts *CheckoutOptions) (*CheckoutResult, error) {
+	client := w.repo.client
+	var headHash sha.Hash
+	if client.Head.Commit != nil {
+		headHash = client.Head.Commit.Hash
+	}
+	from, err := checkout.TreeFiles(client.RootGoitPath, headHash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get files of HEAD: %w", err)
+	}
+	to, err := checkout.TreeFiles(client.RootGoitPath, target)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get files of %s: %w", label, err)
+	}
+	result, err := checkout.Switch(client.RootGoitPath, client.Idx, from, to, &checkout.Options{
+		Force:       opts.Force,
+		Merge:       opts.Merge,
+		OursLabel:   label,
+		TheirsLabel: "local",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// record the move of HEAD in the reflog.
+func (w *Worktree) logCheckout(prevHash, hash Hash, prev, next string) error {
+	conf := w.repo.client.Conf
+	record := log.NewRecord(log.CheckoutRecord, prevHash, hash, conf.GetUserName(), conf.GetEmail(), time.Now(), fmt.Sprintf("moving from %s to %s", prev, next))
+	if err := w.repo.logger.WriteHEAD(record); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	return nil
+}
+
+// Checkout switches the branch, makes the new branch or detaches HEAD, updating the index and
+// the working tree. Nothing is changed and LocalChangesError is returned if a local change would
+// be overwritten unless Force or Merge is set.
+func (w *Worktree) Checkout(ctx context.Context, opts *CheckoutOptions) (*CheckoutResult, error) {
+	if opts == nil {
+		opts = &CheckoutOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	client := w.repo.client
+
+	var prevHash sha.Hash
+	if client.Head.Commit != nil {
+		prevHash = client.Head.Commit.Hash
+	}
+	prev := client.Head.Reference
+	if client.Head.IsDetached() {
+		prev = prevHash.String()
+	}
+
+	switch {
+	case opts.Orphan:
+		// orphan branch starts from the empty tree
+		if client.Refs.IsBranchExist(opts.Branch) {
+			return nil, fmt.Errorf("a branch named '%s' %w", opts.Branch, ErrBranchExists)
+		}
+		result, err := w.switchTree(nil, opts.Branch, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Head.Orphan(client.Refs, client.RootGoitPath, opts.Branch); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+		return result, nil
+	case opts.Create:
+		if client.Refs.IsBranchExist(opts.Branch) {
+			return nil, fmt.Errorf("a branch named '%s' %w", opts.Branch, ErrBranchExists)
+		}
+		startHash, startPoint := opts.Hash, opts.StartPoint
+		if startHash == nil {
+			if prevHash == nil {
+				return nil, fmt.Errorf("%w: HEAD", ErrInvalidReference)
+			}
+			startHash = prevHash
+		}
+		if startPoint == "" {
+			startPoint = startHash.String()
+		}
+		result, err := w.switchTree(startHash, opts.Branch, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Refs.AddBranch(client.RootGoitPath, opts.Branch, startHash); err != nil {
+			return nil, fmt.Errorf("fail to create new branch %s: %w", opts.Branch, err)
+		}
+		if err := w.repo.logger.WriteBranch(log.NewRecord(log.BranchRecord, nil, startHash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("Created from %s", startPoint)), opts.Branch); err != nil {
+			return nil, fmt.Errorf("log error: %w", err)
+		}
+		if err := client.Head.Update(client.Refs, client.RootGoitPath, opts.Branch); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+		if err := w.logCheckout(prevHash, startHash, prev, opts.Branch); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case opts.Branch != "":
+		// switch to the existing branch
+		if !client.Refs.IsBranchExist(opts.Branch) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidReference, opts.Branch)
+		}
+		hash, err := store.ReadRef(client.RootGoitPath, "refs/heads/"+opts.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidReference, opts.Branch)
+		}
+		obj, err := object.Peel(client.RootGoitPath, hash)
+		if err != nil || obj.Type != object.CommitObject {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidReference, opts.Branch)
+		}
+		result, err := w.switchTree(obj.Hash, opts.Branch, opts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Branch == prev && !client.Head.IsDetached() {
+			return result, nil
+		}
+		if err := client.Head.Update(client.Refs, client.RootGoitPath, opts.Branch); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+		if err := w.logCheckout(prevHash, obj.Hash, prev, opts.Branch); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case opts.Hash != nil:
+		// check out the commit on the detached HEAD
+		result, err := w.switchTree(opts.Hash, opts.Hash.String(), opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Head.Detach(client.RootGoitPath, opts.Hash); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+		if err := w.logCheckout(prevHash, opts.Hash, prev, opts.Hash.String()); err != nil {
+			return nil, err
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%w: missing branch or commit", ErrInvalidReference)
+	}
+}
@@ -0,0 +1,156 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// CloneOptions controls how the repository is cloned.
+type CloneOptions struct {
+	RemoteName string // the name of the source repository, which is origin if empty
+	NoCheckout bool   // do not check out the branch of HEAD in the source repository
+}
+
+// copy the objects of the source repository which the destination does not have.
+func copyObjects(ctx context.Context, srcGoitPath, dstGoitPath string) error {
+	srcDir := filepath.Join(srcGoitPath, "objects")
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstGoitPath, "objects", rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, os.ModePerm)
+		}
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("fail to read %s: %w", path, err)
+		}
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return fmt.Errorf("fail to write %s: %w", dst, err)
+		}
+		return nil
+	})
+}
+
+// Clone copies the local repository which contains src into the new repository in dst.
+// The branches of the source become the remote-tracking branches, the tags are copied as they are,
+// and the branch of HEAD in the source is made and checked out with its upstream set.
+func Clone(ctx context.Context, src, dst string, opts *CloneOptions) (*Repository, error) {
+	if opts == nil {
+		opts = &CloneOptions{}
+	}
+	remote := opts.RemoteName
+	if remote == "" {
+		remote = "origin"
+	}
+	srcRepo, err := Open(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("fail to make %s: %w", dst, err)
+	}
+	repo, err := Init(ctx, dst, &InitOptions{ObjectFormat: srcRepo.client.Format.Name})
+	if err != nil {
+		return nil, err
+	}
+	client := repo.client
+
+	if err := copyObjects(ctx, srcRepo.client.RootGoitPath, client.RootGoitPath); err != nil {
+		return nil, fmt.Errorf("fail to copy objects: %w", err)
+	}
+
+	// the branches become the remote-tracking branches
+	transaction := store.NewRefTransaction(client.RootGoitPath, client.Format)
+	if err := srcRepo.References(ctx, "refs/", func(ref *Reference) error {
+		switch {
+		case ref.Target != "":
+			return nil
+		case strings.HasPrefix(ref.Name, "refs/heads/"):
+			return transaction.Create(fmt.Sprintf("refs/remotes/%s/%s", remote, strings.TrimPrefix(ref.Name, "refs/heads/")), ref.Hash)
+		case strings.HasPrefix(ref.Name, "refs/tags/"):
+			return transaction.Create(ref.Name, ref.Hash)
+		default:
+			return nil
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := transaction.Commit(); err != nil {
+		return nil, fmt.Errorf("fail to write refs: %w", err)
+	}
+
+	// the remote HEAD points to the branch of HEAD in the source, so that the remote name resolves to it
+	srcHead := srcRepo.client.Head
+	if !srcHead.IsDetached() && srcHead.Commit != nil {
+		remoteHead := fmt.Sprintf("refs/remotes/%s/HEAD", remote)
+		if err := store.WriteSymbolicRef(client.RootGoitPath, remoteHead, fmt.Sprintf("refs/remotes/%s/%s", remote, srcHead.Reference)); err != nil {
+			return nil, fmt.Errorf("fail to write %s: %w", remoteHead, err)
+		}
+	}
+
+	// remember where the repository comes from
+	srcWorkDir, err := filepath.Abs(srcRepo.WorkDir())
+	if err != nil {
+		return nil, err
+	}
+	remoteIdent := fmt.Sprintf(`remote "%s"`, remote)
+	client.Conf.Add(remoteIdent, "url", srcWorkDir, false)
+	client.Conf.Add(remoteIdent, "fetch", fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", remote), false)
+
+	// the branch of HEAD in the source is made locally, tracking the remote one
+	if !srcHead.IsDetached() && srcHead.Commit != nil {
+		branch, hash := srcHead.Reference, srcHead.Commit.Hash
+		branchIdent := fmt.Sprintf(`branch "%s"`, branch)
+		client.Conf.Add(branchIdent, "remote", remote, false)
+		client.Conf.Add(branchIdent, "merge", "refs/heads/"+branch, false)
+
+		if err := client.Refs.AddBranch(client.RootGoitPath, branch, hash); err != nil {
+			return nil, fmt.Errorf("fail to create branch %s: %w", branch, err)
+		}
+		record := log.NewRecord(log.CloneRecord, nil, hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("from %s", srcWorkDir))
+		if err := repo.logger.WriteBranch(record, branch); err != nil {
+			return nil, fmt.Errorf("log error: %w", err)
+		}
+		if !opts.NoCheckout {
+			if _, err := repo.Worktree().switchTree(hash, branch, &CheckoutOptions{}); err != nil {
+				return nil, err
+			}
+		}
+		if err := client.Head.Update(client.Refs, client.RootGoitPath, branch); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+		if err := repo.logger.WriteHEAD(record); err != nil {
+			return nil, fmt.Errorf("log error: %w", err)
+		}
+	} else if !srcHead.IsDetached() && srcHead.Reference != client.Head.Reference {
+		// the source has no commit yet on its branch
+		if err := client.Head.Orphan(client.Refs, client.RootGoitPath, srcHead.Reference); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+	}
+	if err := client.Conf.Write(filepath.Join(client.RootGoitPath, "config"), false); err != nil {
+		return nil, fmt.Errorf("fail to write config: %w", err)
+	}
+
+	return repo, nil
+}
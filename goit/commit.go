@@ -0,0 +1,280 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// CommitOptions controls who makes the commit.
+type CommitOptions struct {
+	Author    *Signature // the identity from user.name and user.email is used if nil
+	Committer *Signature // the author is used if nil
+}
+
+// write the tree objects of the index entries, which are sorted by their paths, and return the root tree.
+func writeTree(rootGoitPath string, format *sha.Format, entries []*store.Entry) (*object.Object, error) {
+	var dirName string
+	var data []byte
+	var entryBuf []*store.Entry
+	i := 0
+	for {
+		if i >= len(entries) {
+			// if the last entry is in the directory
+			if dirName != "" {
+				treeObject, err := writeTree(rootGoitPath, format, entryBuf)
+				if err != nil {
+					return nil, err
+				}
+				data = append(data, []byte(fmt.Sprintf("040000 %s", dirName))...)
+				data = append(data, 0x00)
+				data = append(data, treeObject.Hash...)
+			}
+			break
+		}
+
+		entry := entries[i]
+		slashSplit := strings.SplitN(string(entry.Path), "/", 2)
+		if len(slashSplit) == 1 { // if entry is not in sub-directory
+			if dirName != "" { // if previous entry is in sub-directory
+				// make tree object from entryBuf
+				treeObject, err := writeTree(rootGoitPath, format, entryBuf)
+				if err != nil {
+					return nil, err
+				}
+				data = append(data, []byte(fmt.Sprintf("040000 %s", dirName))...)
+				data = append(data, 0x00)
+				data = append(data, treeObject.Hash...)
+				// clear dirName and entryBuf
+				dirName = ""
+				entryBuf = make([]*store.Entry, 0)
+			}
+			data = append(data, []byte(fmt.Sprintf("100644 %s", string(entry.Path)))...)
+			data = append(data, 0x00)
+			data = append(data, entry.Hash...)
+		} else { // if entry is in sub-directory
+			if dirName == "" { // previous entry is not in sub-directory
+				dirName = slashSplit[0] // root sub-directory name e.x) cmd/pkg/main.go -> cmd
+				newEntry := store.NewEntry(entry.Hash, []byte(slashSplit[1]))
+				entryBuf = append(entryBuf, newEntry)
+			} else if dirName != "" && dirName == slashSplit[0] { // previous entry is in sub-directory, and current entry is in the same sub-directory
+				newEntry := store.NewEntry(entry.Hash, []byte(slashSplit[1]))
+				entryBuf = append(entryBuf, newEntry)
+			} else if dirName != "" && dirName != slashSplit[0] { // previous entry is in sub-directory, and current entry is in the different sub-directory
+				// make tree object
+				treeObject, err := writeTree(rootGoitPath, format, entryBuf)
+				if err != nil {
+					return nil, err
+				}
+				data = append(data, []byte(fmt.Sprintf("040000 %s", dirName))...)
+				data = append(data, 0x00)
+				data = append(data, treeObject.Hash...)
+				// start making tree object for different sub-directory
+				dirName = slashSplit[0]
+				newEntry := store.NewEntry(entry.Hash, []byte(slashSplit[1]))
+				entryBuf = []*store.Entry{newEntry}
+			}
+		}
+
+		i++
+	}
+
+	// make tree object
+	treeObject, err := object.NewObject(format, object.TreeObject, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// write tree object
+	if err := treeObject.Write(rootGoitPath); err != nil {
+		return nil, err
+	}
+
+	return treeObject, nil
+}
+
+// WriteTree writes the tree objects of the index and returns the hash of the root tree.
+func (r *Repository) WriteTree(ctx context.Context) (Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// tree can not be built from conflict stages
+	if len(r.client.Idx.UnmergedPaths()) > 0 {
+		return nil, ErrUnmergedIndex
+	}
+	treeObject, err := writeTree(r.client.RootGoitPath, r.client.Format, r.client.Idx.Entries)
+	if err != nil {
+		return nil, fmt.Errorf("fail to write tree object: %w", err)
+	}
+	return treeObject.Hash, nil
+}
+
+// CommitTree writes the commit of the tree with the parents without updating any ref, and returns its hash.
+// The message is recorded as it is.
+func (r *Repository) CommitTree(ctx context.Context, tree Hash, parents []Hash, message string, opts *CommitOptions) (Hash, error) {
+	if opts == nil {
+		opts = &CommitOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	author := opts.Author
+	if author == nil {
+		sign, err := r.signature()
+		if err != nil {
+			return nil, err
+		}
+		author = sign
+	}
+	committer := opts.Committer
+	if committer == nil {
+		committer = author
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("tree %s\n", tree))
+	for _, parent := range parents {
+		b.WriteString(fmt.Sprintf("parent %s\n", parent))
+	}
+	b.WriteString(fmt.Sprintf("author %s\ncommitter %s\n\n%s", author, committer, message))
+	commitObject, err := object.NewObject(r.client.Format, object.CommitObject, []byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("fail to get new object: %w", err)
+	}
+	if err := commitObject.Write(r.client.RootGoitPath); err != nil {
+		return nil, fmt.Errorf("fail to write commit object: %w", err)
+	}
+	return commitObject.Hash, nil
+}
+
+// tell whether the index differs from the tree of the commit.
+func (w *Worktree) isCommitNecessary(commitObj *object.Commit) (bool, error) {
+	rootGoitPath := w.repo.client.RootGoitPath
+	treeObject, err := object.GetObject(rootGoitPath, commitObj.Tree)
+	if err != nil {
+		return false, fmt.Errorf("fail to get tree object: %w", err)
+	}
+	tree, err := object.NewTree(rootGoitPath, treeObject)
+	if err != nil {
+		return false, fmt.Errorf("fail to get tree: %w", err)
+	}
+	diffEntries, err := w.repo.client.Idx.DiffWithTree(tree)
+	if err != nil {
+		return false, fmt.Errorf("fail to compare index with tree: %w", err)
+	}
+	return len(diffEntries) != 0, nil
+}
+
+// Commit records the index as the new commit on the current branch, or on the detached HEAD,
+// and returns the hash of the commit.
+func (w *Worktree) Commit(ctx context.Context, message string, opts *CommitOptions) (Hash, error) {
+	if opts == nil {
+		opts = &CommitOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	client := w.repo.client
+	author := opts.Author
+	if author == nil {
+		sign, err := w.repo.signature()
+		if err != nil {
+			return nil, err
+		}
+		author = sign
+	}
+	committer := opts.Committer
+	if committer == nil {
+		committer = author
+	}
+	if len(client.Idx.UnmergedPaths()) > 0 {
+		return nil, ErrUnmergedFiles
+	}
+
+	// see if there is anything to commit
+	head := client.Head
+	if head.Commit == nil {
+		if client.Idx.EntryNum == 0 {
+			return nil, ErrNothingToCommit
+		}
+	} else {
+		isDiff, err := w.isCommitNecessary(head.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("fail to compare last commit with index: %w", err)
+		}
+		if !isDiff {
+			return nil, ErrNothingToCommit
+		}
+	}
+
+	// make and write tree object
+	treeObject, err := writeTree(client.RootGoitPath, client.Format, client.Idx.Entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// make and write commit object
+	var data []byte
+	if head.Commit == nil {
+		// no commit on HEAD means that this is the initial commit
+		data = []byte(fmt.Sprintf("tree %s\nauthor %s\ncommitter %s\n\n%s\n", treeObject.Hash, author, committer, message))
+	} else {
+		data = []byte(fmt.Sprintf("tree %s\nparent %s\nauthor %s\ncommitter %s\n\n%s\n", treeObject.Hash, head.Commit.Hash, author, committer, message))
+	}
+	commitObject, err := object.NewObject(client.Format, object.CommitObject, data)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get new object: %w", err)
+	}
+	commit, err := object.NewCommit(commitObject)
+	if err != nil {
+		return nil, fmt.Errorf("fail to make commit object: %w", err)
+	}
+	if err := commit.Write(client.RootGoitPath); err != nil {
+		return nil, fmt.Errorf("fail to write commit object: %w", err)
+	}
+
+	// the detached HEAD moves by itself without any branch
+	if head.IsDetached() {
+		record := log.NewRecord(log.CommitRecord, head.Commit.Hash, commit.Hash, committer.Name, committer.Email, time.Now(), message)
+		if err := w.repo.logger.WriteHEAD(record); err != nil {
+			return nil, fmt.Errorf("log error: %w", err)
+		}
+		if err := head.Detach(client.RootGoitPath, commit.Hash); err != nil {
+			return nil, fmt.Errorf("fail to update HEAD: %w", err)
+		}
+		return commit.Hash, nil
+	}
+
+	// create/update branch
+	var from sha.Hash
+	if client.Refs.IsBranchExist(head.Reference) {
+		if err := client.Refs.UpdateBranchHash(client.RootGoitPath, head.Reference, commit.Hash); err != nil {
+			return nil, fmt.Errorf("fail to update branch %s: %w", head.Reference, err)
+		}
+		from = head.Commit.Hash
+	} else {
+		if err := client.Refs.AddBranch(client.RootGoitPath, head.Reference, commit.Hash); err != nil {
+			return nil, fmt.Errorf("fail to create branch %s: %w", head.Reference, err)
+		}
+	}
+	record := log.NewRecord(log.CommitRecord, from, commit.Hash, committer.Name, committer.Email, time.Now(), message)
+	if err := w.repo.logger.WriteHEAD(record); err != nil {
+		return nil, fmt.Errorf("log error: %w", err)
+	}
+	if err := w.repo.logger.WriteBranch(record, head.Reference); err != nil {
+		return nil, fmt.Errorf("log error: %w", err)
+	}
+
+	// update HEAD
+	if err := head.Update(client.Refs, client.RootGoitPath, head.Reference); err != nil {
+		return nil, fmt.Errorf("fail to update HEAD: %w", err)
+	}
+
+	return commit.Hash, nil
+}
@@ -0,0 +1,70 @@
+package goit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// ConfigScope tells which config file is written.
+type ConfigScope int
+
+const (
+	LocalConfig  ConfigScope = iota // .goit/config of the repository
+	GlobalConfig                    // ~/.goitconfig of the user
+)
+
+// Config returns the value of the config name such as "core.abbrev" or "branch.main.merge".
+// The local config takes precedence over the global config.
+func (r *Repository) Config(name string) (string, bool) {
+	return r.client.Conf.Get(name)
+}
+
+// SetConfig sets the config name such as "user.name" to the value and writes the config file of the scope,
+// which is made if it does not exist yet.
+func (r *Repository) SetConfig(name, value string, scope ConfigScope) error {
+	ident, key, err := store.ConfigIdent(name)
+	if errors.Is(err, store.ErrInvalidConfigKey) {
+		return fmt.Errorf("%w: %s", ErrInvalidConfigKey, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	isGlobal := scope == GlobalConfig
+	configPath := filepath.Join(r.client.RootGoitPath, "config")
+	if isGlobal {
+		userHomePath, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("fail to get user home dir: %w", err)
+		}
+		configPath = filepath.Join(userHomePath, ".goitconfig")
+	}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if err := os.WriteFile(configPath, nil, 0o644); err != nil {
+			return fmt.Errorf("fail to make %s: %w", configPath, err)
+		}
+	}
+
+	r.client.Conf.Add(ident, key, value, isGlobal)
+	if err := r.client.Conf.Write(configPath, isGlobal); err != nil {
+		return fmt.Errorf("fail to write config: %w", err)
+	}
+	return nil
+}
+
+// write the local config after the changes of the branch settings.
+func (r *Repository) writeLocalConfig() error {
+	if err := r.client.Conf.Write(filepath.Join(r.client.RootGoitPath, "config"), false); err != nil {
+		return fmt.Errorf("fail to write config: %w", err)
+	}
+	return nil
+}
+
+// Upstream returns the full ref name of the upstream of the branch, such as refs/remotes/origin/main.
+func (r *Repository) Upstream(branch string) (string, bool) {
+	return r.client.Conf.GetUpstream(branch)
+}
@@ -0,0 +1,18 @@
+// Package goit is the Go API of Goit, on which the goit command is built.
+//
+// A repository is opened or created with Open, Init or Clone, and the working tree is
+// changed through its Worktree:
+//
+//	repo, err := goit.Open(ctx, ".")
+//	if err != nil {
+//		return err
+//	}
+//	wt := repo.Worktree()
+//	if err := wt.Add(ctx, "main.go"); err != nil {
+//		return err
+//	}
+//	hash, err := wt.Commit(ctx, "add main.go", nil)
+//
+// The operations stop with the error of the context when it is canceled, and the errors
+// can be tested against the sentinel values such as ErrNothingToCommit with errors.Is.
+package goit
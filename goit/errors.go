@@ -0,0 +1,55 @@
+package goit
+
+import (
+	"errors"
+
+	"github.com/JunNishimura/Goit/internal/bisect"
+	"github.com/JunNishimura/Goit/internal/checkout"
+	"github.com/JunNishimura/Goit/internal/lsfiles"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/status"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+var (
+	ErrRepositoryNotExists     = errors.New("not a goit repository (or any of the parent directories): .goit")
+	ErrRepositoryAlreadyExists = errors.New("goit is already initialized")
+	ErrUserNotSet              = errors.New("user.name and user.email are not set")
+	ErrNothingSpecified        = errors.New("nothing specified")
+	ErrPathspecNotMatched      = errors.New("did not match any files")
+	ErrNothingToCommit         = errors.New("nothing to commit, working tree clean")
+	ErrUnmergedFiles           = errors.New("committing is not possible because you have unmerged files")
+	ErrBranchExists            = errors.New("already exists")
+	ErrInvalidReference        = errors.New("invalid reference")
+	ErrUnmergedIndex           = checkout.ErrUnmergedIndex
+	ErrObjectNotFound          = object.ErrObjectNotFound
+	ErrReferenceNotFound       = store.ErrRefNotFound
+	ErrInvalidUntrackedMode    = status.ErrInvalidUntrackedMode
+	ErrUnknownObjectFormat     = sha.ErrUnknownFormat
+	ErrInvalidConfigKey        = errors.New("key does not contain a section")
+	ErrTextconvFailed          = errors.New("unable to read files to diff")
+	ErrNotTree                 = errors.New("not a tree")
+	ErrNotCommit               = errors.New("not a commit")
+	ErrUnknownRevision         = errors.New("unknown revision or path not in the working tree")
+	ErrInvalidHash             = errors.New("not a valid object hash")
+	ErrAmbiguousHash           = object.ErrAmbiguousHash
+	ErrSymbolicReferenceCycle  = store.ErrSymrefCycle
+	ErrNotSymbolicReference    = store.ErrNotSymbolicRef
+	ErrSymbolicReferenceDepth  = store.ErrSymrefTooDeep
+	ErrBranchNotFound          = errors.New("branch not found")
+	ErrBranchNotMerged         = errors.New("branch is not fully merged")
+	ErrUpstreamNotFound        = errors.New("upstream branch does not exist")
+	ErrNoUpstream              = errors.New("no upstream information")
+	ErrReflogNotFound          = store.ErrReflogNotFound
+	ErrReflogEmpty             = store.ErrReflogEmpty
+	ErrReflogEntryNotFound     = store.ErrReflogEntryNotFound
+	ErrInvalidExpiry           = store.ErrInvalidExpiry
+	ErrNotBisecting            = bisect.ErrNotBisecting
+	ErrGoodNotAncestor         = bisect.ErrGoodNotAncestor
+	ErrIgnoredWithoutMode      = lsfiles.ErrIgnoredWithoutMode
+	ErrIgnoredWithoutExclude   = lsfiles.ErrIgnoredWithoutExclude
+
+	// ErrStop is returned by the callback of the iteration to stop it without an error.
+	ErrStop = errors.New("stop iteration")
+)
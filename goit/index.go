@@ -0,0 +1,91 @@
+package goit
+
+import (
+	"fmt"
+
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// IndexEntry is the entry of the index, whose Stage is 0 unless the path is in conflict.
+type IndexEntry = store.Entry
+
+// Index is the staging area of the repository. The changes are kept in memory until Write.
+type Index struct {
+	repo *Repository
+}
+
+// Index returns the index of the repository, which is shared with the working tree.
+func (r *Repository) Index() *Index {
+	return &Index{repo: r}
+}
+
+func (i *Index) idx() *store.Index {
+	return i.repo.client.Idx
+}
+
+// Entries returns the entries sorted by their paths and stages.
+func (i *Index) Entries() []*IndexEntry {
+	return i.idx().Entries
+}
+
+// Entry returns the entry of the path at the stage.
+func (i *Index) Entry(path string, stage int) (*IndexEntry, bool) {
+	return i.idx().GetStageEntry([]byte(path), stage)
+}
+
+// IsTracked tells whether the path is in the index at any stage.
+func (i *Index) IsTracked(path string) bool {
+	_, _, isFound := i.idx().GetEntry([]byte(path))
+	return isFound
+}
+
+// IsTrackedDir tells whether the index has any entry under the directory.
+func (i *Index) IsTrackedDir(dir string) bool {
+	return i.idx().IsRegisteredAsDirectory(dir)
+}
+
+// EntriesInDir returns the entries under the directory.
+func (i *Index) EntriesInDir(dir string) []*IndexEntry {
+	return i.idx().GetEntriesByDirectory(dir)
+}
+
+// UnmergedPaths returns the paths which have the conflict stages, sorted by path.
+func (i *Index) UnmergedPaths() []string {
+	return i.idx().UnmergedPaths()
+}
+
+// Set replaces every entry of the path, including the conflict stages, with the merged entry of the blob.
+// The path is removed from the index if hash is nil.
+func (i *Index) Set(path string, hash Hash) {
+	if hash == nil {
+		i.idx().Put([]byte(path))
+		return
+	}
+	i.idx().Put([]byte(path), store.NewEntry(hash, []byte(path)))
+}
+
+// Move renames the entries of the path, including the conflict stages, and tells whether the path was found.
+func (i *Index) Move(src, dst string) bool {
+	return i.idx().Move([]byte(src), []byte(dst))
+}
+
+// Write writes the index to .goit/index.
+func (i *Index) Write() error {
+	if err := i.idx().Write(i.repo.client.RootGoitPath); err != nil {
+		return fmt.Errorf("fail to write index: %w", err)
+	}
+	return nil
+}
+
+// IgnoreRules is the set of the patterns in the format of .goitignore.
+type IgnoreRules = store.Ignore
+
+// NewIgnoreRules makes the rules of the patterns in the format of .goitignore, such as the ones given by -e.
+func NewIgnoreRules(patterns []string) *IgnoreRules {
+	return store.NewIgnoreFromPatterns(patterns)
+}
+
+// IsIgnored tells whether .goitignore matches the path relative to the working tree root.
+func (r *Repository) IsIgnored(path string, isDir bool) bool {
+	return r.client.Ignore.Match(path, isDir)
+}
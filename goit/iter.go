@@ -0,0 +1,103 @@
+package goit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// Reference is the ref such as refs/heads/main.
+type Reference struct {
+	Name   string // the full name such as refs/heads/main
+	Hash   Hash
+	Target string // the ref which the symbolic ref points to, empty otherwise
+}
+
+// call fn until it returns an error, which is ignored if it is ErrStop.
+func stopped(err error) error {
+	if errors.Is(err, ErrStop) {
+		return nil
+	}
+	return err
+}
+
+// References calls fn with the loose and packed refs under the prefix such as "refs/tags/" in
+// the order of their names. Every ref under refs/ is visited if the prefix is empty.
+func (r *Repository) References(ctx context.Context, prefix string, fn func(*Reference) error) error {
+	if prefix == "" {
+		prefix = "refs/"
+	}
+	refs, err := store.ListRefs(r.client.RootGoitPath, prefix)
+	if err != nil {
+		return fmt.Errorf("fail to list refs: %w", err)
+	}
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&Reference{Name: ref.Name, Hash: ref.Hash, Target: ref.Target}); err != nil {
+			return stopped(err)
+		}
+	}
+	return nil
+}
+
+// Objects calls fn with every object in the repository in the order of their hashes.
+func (r *Repository) Objects(ctx context.Context, fn func(*Object) error) error {
+	hashes, err := object.ListHashes(r.client.RootGoitPath, r.client.Format)
+	if err != nil {
+		return fmt.Errorf("fail to list objects: %w", err)
+	}
+	for _, hash := range hashes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		obj, err := r.Object(hash)
+		if err != nil {
+			return err
+		}
+		if err := fn(obj); err != nil {
+			return stopped(err)
+		}
+	}
+	return nil
+}
+
+// Log calls fn with the commits reachable from the hash, the newer committer date first.
+func (r *Repository) Log(ctx context.Context, from Hash, fn func(*Commit) error) error {
+	start, err := r.CommitObject(from)
+	if err != nil {
+		return err
+	}
+	isQueued := map[string]bool{start.Hash.String(): true}
+	queue := []*Commit{start}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		commit := queue[0]
+		queue = queue[1:]
+		if err := fn(commit); err != nil {
+			return stopped(err)
+		}
+		for _, parentHash := range commit.Parents {
+			if isQueued[parentHash.String()] {
+				continue
+			}
+			isQueued[parentHash.String()] = true
+			parent, err := r.CommitObject(parentHash)
+			if err != nil {
+				return err
+			}
+			queue = append(queue, parent)
+		}
+		sort.SliceStable(queue, func(i, j int) bool {
+			return queue[i].Committer.Timestamp.After(queue[j].Committer.Timestamp)
+		})
+	}
+	return nil
+}
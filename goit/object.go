@@ -0,0 +1,161 @@
+package goit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+type (
+	// ObjectType is the type of the object such as blob and tree.
+	ObjectType = object.Type
+	// ObjectFormat is the hash algorithm of the objects, SHA-1 or SHA-256.
+	ObjectFormat = sha.Format
+	// Tree is the parsed tree object, whose nodes are loaded recursively.
+	Tree = object.Tree
+	// TreeNode is the entry of the tree, which has the children if it is the directory.
+	TreeNode = object.Node
+	// Abbreviator shortens the object names while they stay unique in the repository.
+	Abbreviator = object.Abbreviator
+)
+
+// MinAbbrev is the minimum length of the abbreviated object name.
+const MinAbbrev = object.MinAbbrev
+
+const (
+	BlobObject   = object.BlobObject
+	TreeObject   = object.TreeObject
+	CommitObject = object.CommitObject
+	TagObject    = object.TagObject
+)
+
+// ParseObjectType parses the name of the object type such as "blob".
+func ParseObjectType(name string) (ObjectType, error) {
+	return object.NewType(name)
+}
+
+// ObjectFormat returns the hash algorithm of the objects in the repository.
+func (r *Repository) ObjectFormat() *ObjectFormat {
+	return r.client.Format
+}
+
+// Peel reads the object of the hash, following the annotated tags to the object they point to.
+func (r *Repository) Peel(hash Hash) (*Object, error) {
+	obj, err := object.Peel(r.client.RootGoitPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	return obj, nil
+}
+
+// TreeObject reads the tree of the hash, which is either the tree or the commit of the tree.
+// The annotated tag is peeled.
+func (r *Repository) TreeObject(hash Hash) (*Tree, error) {
+	obj, err := r.Peel(hash)
+	if err != nil {
+		return nil, err
+	}
+	if obj.Type == object.CommitObject {
+		commit, err := object.NewCommit(obj)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get commit: %w", err)
+		}
+		if obj, err = r.Object(commit.Tree); err != nil {
+			return nil, err
+		}
+	}
+	if obj.Type != object.TreeObject {
+		return nil, fmt.Errorf("%w: %s", ErrNotTree, hash)
+	}
+	tree, err := object.NewTree(r.client.RootGoitPath, obj)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tree: %w", err)
+	}
+	return tree, nil
+}
+
+// NewObject makes the object of the data in the object format of the repository without writing it.
+func (r *Repository) NewObject(objType ObjectType, data []byte) (*Object, error) {
+	obj, err := object.NewObject(r.client.Format, objType, data)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get new object: %w", err)
+	}
+	return obj, nil
+}
+
+// WriteObject writes the object into the object database.
+func (r *Repository) WriteObject(obj *Object) error {
+	if err := obj.Write(r.client.RootGoitPath); err != nil {
+		return fmt.Errorf("fail to write object: %w", err)
+	}
+	return nil
+}
+
+// NewAbbreviator returns the abbreviator whose names are at least the length,
+// which is the length set by core.abbrev if it is 0.
+func (r *Repository) NewAbbreviator(length int) (*Abbreviator, error) {
+	if length == 0 {
+		var err error
+		if length, err = r.client.Conf.AbbrevLength(r.client.Format); err != nil {
+			return nil, err
+		}
+	}
+	return object.NewAbbreviator(r.client.RootGoitPath, r.client.Format, length), nil
+}
+
+// ConvertToBlob converts the content of the file at the path into the blob as Add stores it,
+// applying the clean filter and the end-of-line conversion given by the attributes of the path.
+func (r *Repository) ConvertToBlob(path string, data []byte) ([]byte, error) {
+	attrs, err := store.NewAttributes(r.client.RootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewCleanFilter(r.client.Conf, attrs).Clean(path, data)
+}
+
+// Textconv converts the blob at the path into the text by the command of diff.<driver>.textconv,
+// where the driver is given by the diff attribute of the path. The content is returned as it is
+// if the object is not a blob or no driver is set.
+func (r *Repository) Textconv(obj *Object, path string) ([]byte, error) {
+	if obj.Type != object.BlobObject || path == "" {
+		return obj.Data, nil
+	}
+	attrs, err := store.NewAttributes(r.client.RootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	driver, ok := attrs.Get(path, "diff")
+	if !ok || driver == store.AttributeSet || driver == store.AttributeUnset {
+		return obj.Data, nil
+	}
+	command, ok := r.client.Conf.Get(fmt.Sprintf("diff.%s.textconv", driver))
+	if !ok {
+		return obj.Data, nil
+	}
+
+	// the command reads the content from the temporary file given as the argument
+	f, err := os.CreateTemp("", "goit-textconv-*")
+	if err != nil {
+		return nil, fmt.Errorf("fail to create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(obj.Data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("fail to write temporary file: %w", err)
+	}
+	f.Close()
+
+	var stdout, stderr bytes.Buffer
+	c := exec.Command("sh", "-c", command+` "$@"`, command, f.Name())
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v: %s", ErrTextconvFailed, command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
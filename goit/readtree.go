@@ -0,0 +1,64 @@
+package goit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JunNishimura/Goit/internal/readtree"
+)
+
+var ErrPrefixExists = readtree.ErrPrefixExists
+
+// WouldOverwriteError tells the path whose entry in the index the merge of the trees cannot carry over.
+type WouldOverwriteError = readtree.WouldOverwriteError
+
+// ReadTreeOptions tells how the trees are read into the index.
+type ReadTreeOptions struct {
+	Merge  bool   // merge up to three trees instead of replacing the index with one tree
+	Prefix string // read the tree under the directory, keeping the rest of the index
+}
+
+// ReadTree reads the trees into the index and writes it. The index is replaced with the tree
+// unless Merge or Prefix is set, and two or three trees are merged with Merge.
+func (w *Worktree) ReadTree(ctx context.Context, opts *ReadTreeOptions, trees ...Hash) error {
+	if opts == nil {
+		opts = &ReadTreeOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	files := make([]readtree.Files, 0, len(trees))
+	for _, hash := range trees {
+		tree, err := w.repo.TreeObject(hash)
+		if err != nil {
+			return err
+		}
+		files = append(files, tree.Files())
+	}
+
+	index := w.repo.client.Idx
+	var err error
+	switch {
+	case opts.Prefix != "":
+		err = readtree.ReadPrefix(index, opts.Prefix, files[0])
+	case len(files) == 1:
+		if opts.Merge && len(index.UnmergedPaths()) > 0 {
+			return ErrUnmergedIndex
+		}
+		readtree.Read(index, files[0])
+	case len(files) == 2:
+		err = readtree.TwoWay(index, files[0], files[1])
+	case len(files) == 3:
+		err = readtree.ThreeWay(index, files[0], files[1], files[2])
+	default:
+		return ErrNothingSpecified
+	}
+	if errors.Is(err, readtree.ErrUnmergedIndex) {
+		return ErrUnmergedIndex
+	}
+	if err != nil {
+		return err
+	}
+
+	return w.repo.Index().Write()
+}
@@ -0,0 +1,130 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// ReflogEntry is the update of the ref recorded in its reflog, from OldHash to Hash.
+type ReflogEntry struct {
+	OldHash   Hash
+	Hash      Hash
+	Committer Signature
+	Message   string // the message including the type of the update such as "commit: initial commit"
+}
+
+// ParseExpiry parses the expiry date of the reflog such as "90.days.ago", "2023-01-02", "now" and "never".
+// "never" returns the zero time, which expires nothing.
+func ParseExpiry(value string, now time.Time) (time.Time, error) {
+	return store.ParseExpiry(value, now)
+}
+
+// ReflogName returns the full name of the ref whose reflog is looked up, such as HEAD and refs/heads/main.
+// The reflog of the deleted ref is found as well as the existing ref.
+func (r *Repository) ReflogName(name string) (string, error) {
+	if name == "" || name == "HEAD" {
+		return "HEAD", nil
+	}
+	if fullName, err := store.ExpandRefName(r.client.RootGoitPath, name); err == nil {
+		return fullName, nil
+	}
+	for _, candidate := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name} {
+		if store.ReflogExists(r.client.RootGoitPath, candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrReflogNotFound, name)
+}
+
+// ReflogExists tells whether the ref of the full name has the reflog.
+func (r *Repository) ReflogExists(refName string) bool {
+	return store.ReflogExists(r.client.RootGoitPath, refName)
+}
+
+// ReflogNames returns the full names of the refs which have the reflog, in the order of the names.
+func (r *Repository) ReflogNames() ([]string, error) {
+	return store.ReflogRefNames(r.client.RootGoitPath)
+}
+
+// Reflog calls fn with the entries of the reflog of the ref from the latest one, which is <ref>@{0}.
+func (r *Repository) Reflog(ctx context.Context, refName string, fn func(*ReflogEntry) error) error {
+	reflog, err := store.NewReflog(r.client.RootGoitPath, refName)
+	if err != nil {
+		return fmt.Errorf("fail to get reflog: %w", err)
+	}
+	for i := 0; i < reflog.Len(); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		record, err := reflog.GetRecord(i)
+		if err != nil {
+			return err
+		}
+		entry := &ReflogEntry{
+			OldHash: record.OldHash,
+			Hash:    record.Hash,
+			Committer: Signature{
+				Name:      record.Name,
+				Email:     record.Email,
+				Timestamp: record.Time,
+			},
+			Message: record.Message,
+		}
+		if err := fn(entry); err != nil {
+			return stopped(err)
+		}
+	}
+	return nil
+}
+
+// the commits reachable from the tip of the ref, where the tip which is missing or not a commit reaches nothing.
+func (r *Repository) reachableCommits(ctx context.Context, refName string) (map[string]bool, error) {
+	isReachable := make(map[string]bool)
+	_, tip, err := store.ResolveRef(r.client.RootGoitPath, refName)
+	if err != nil {
+		return isReachable, nil
+	}
+	if obj, err := r.Peel(tip); err != nil || obj.Type != object.CommitObject {
+		return isReachable, nil
+	}
+	if err := r.Log(ctx, tip, func(commit *Commit) error {
+		isReachable[commit.Hash.String()] = true
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("fail to walk history of %s: %w", refName, err)
+	}
+	return isReachable, nil
+}
+
+// ExpireReflog removes the entries older than expire from the reflog of the ref, and the entries older than
+// expireUnreachable whose commit is not reachable from the tip of the ref. The zero time expires nothing.
+func (r *Repository) ExpireReflog(ctx context.Context, refName string, expire, expireUnreachable time.Time) error {
+	reflog, err := store.NewReflog(r.client.RootGoitPath, refName)
+	if err != nil {
+		return fmt.Errorf("fail to get reflog: %w", err)
+	}
+	isReachable, err := r.reachableCommits(ctx, refName)
+	if err != nil {
+		return err
+	}
+	if reflog.Expire(expire, expireUnreachable, func(hash Hash) bool { return isReachable[hash.String()] }) == 0 {
+		return nil
+	}
+	return reflog.Write(r.client.RootGoitPath)
+}
+
+// DeleteReflogEntry removes <ref>@{num} from the reflog of the ref, where 0 is the latest entry.
+func (r *Repository) DeleteReflogEntry(refName string, num int) error {
+	reflog, err := store.NewReflog(r.client.RootGoitPath, refName)
+	if err != nil {
+		return fmt.Errorf("fail to get reflog: %w", err)
+	}
+	if err := reflog.Delete(num); err != nil {
+		return err
+	}
+	return reflog.Write(r.client.RootGoitPath)
+}
@@ -0,0 +1,154 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// ValidateReferenceName checks the full ref name such as refs/heads/main against the rules of git check-ref-format.
+func ValidateReferenceName(name string) error {
+	return store.ValidateRefName(name)
+}
+
+// ShortReferenceName strips the well-known prefix such as refs/heads/ from the full ref name.
+func ShortReferenceName(name string) string {
+	return store.ShortenRefName(name)
+}
+
+// Reference reads the ref of the full name such as HEAD or refs/heads/main, following the symbolic refs.
+// The Target is the ref which the symbolic ref points to.
+func (r *Repository) Reference(name string) (*Reference, error) {
+	refName, hash, err := store.ResolveRef(r.client.RootGoitPath, name)
+	if err != nil {
+		return nil, err
+	}
+	ref := &Reference{Name: name, Hash: hash}
+	if refName != name {
+		ref.Target = refName
+	}
+	return ref, nil
+}
+
+// SymbolicReference returns the name of the ref which the symbolic ref points to.
+func (r *Repository) SymbolicReference(name string) (string, error) {
+	return store.ReadSymbolicRef(r.client.RootGoitPath, name)
+}
+
+// SetSymbolicReference makes the symbolic ref point to the target ref, refusing to make the cycle.
+func (r *Repository) SetSymbolicReference(name, target string) error {
+	resolvedName, err := store.ResolveRefName(r.client.RootGoitPath, target)
+	if err != nil {
+		return err
+	}
+	if resolvedName == name {
+		return fmt.Errorf("%w: %s", ErrSymbolicReferenceCycle, name)
+	}
+	if err := store.WriteSymbolicRef(r.client.RootGoitPath, name, target); err != nil {
+		return fmt.Errorf("fail to write symbolic ref %s: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveSymbolicReference deletes the symbolic ref, which must not be HEAD.
+func (r *Repository) RemoveSymbolicReference(name string) error {
+	if name == "HEAD" {
+		return fmt.Errorf("%w: %s", ErrInvalidReference, name)
+	}
+	return store.DeleteSymbolicRef(r.client.RootGoitPath, name)
+}
+
+// PackOptions tells which refs PackReferences packs.
+type PackOptions struct {
+	All     bool // pack all refs, not only the tags and the refs already packed
+	NoPrune bool // keep the loose refs after packing them
+}
+
+// PackReferences moves the loose refs into packed-refs.
+func (r *Repository) PackReferences(ctx context.Context, opts *PackOptions) error {
+	if opts == nil {
+		opts = &PackOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := r.client.Refs.Pack(r.client.RootGoitPath, opts.All, !opts.NoPrune); err != nil {
+		return fmt.Errorf("fail to pack refs: %w", err)
+	}
+	return nil
+}
+
+// RefTransaction updates several refs at once, so that either all of the updates are applied or none of them.
+type RefTransaction struct {
+	repo *Repository
+	tx   *store.RefTransaction
+}
+
+// NewRefTransaction starts the transaction, whose updates are applied by Commit.
+func (r *Repository) NewRefTransaction() *RefTransaction {
+	return &RefTransaction{
+		repo: r,
+		tx:   store.NewRefTransaction(r.client.RootGoitPath, r.client.Format),
+	}
+}
+
+// Update sets the ref to newHash, deleting it if newHash is nil or zero.
+// If verifyOld is true, the ref must currently be at oldHash, where the zero hash means the ref must not exist.
+func (t *RefTransaction) Update(name string, newHash, oldHash Hash, verifyOld bool) error {
+	return t.tx.Update(name, newHash, oldHash, verifyOld)
+}
+
+// Create sets the ref to newHash, which must not exist yet.
+func (t *RefTransaction) Create(name string, newHash Hash) error {
+	return t.tx.Create(name, newHash)
+}
+
+// Delete removes the ref. If verifyOld is true, the ref must currently be at oldHash.
+func (t *RefTransaction) Delete(name string, oldHash Hash, verifyOld bool) error {
+	return t.tx.Delete(name, oldHash, verifyOld)
+}
+
+// Verify checks that the ref is at oldHash without changing it, where nil or the zero hash
+// means the ref must not exist.
+func (t *RefTransaction) Verify(name string, oldHash Hash) error {
+	return t.tx.Verify(name, oldHash)
+}
+
+// Commit applies the updates and records them in the reflogs with the message.
+// HEAD log is written as well when the current branch is updated.
+func (t *RefTransaction) Commit(ctx context.Context, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	results, err := t.tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	client := t.repo.client
+	headRefName := ""
+	if !client.Head.IsDetached() {
+		headRefName = "refs/heads/" + client.Head.Reference
+	}
+	for _, result := range results {
+		if result.NewHash == nil {
+			if err := t.repo.logger.DeleteRef(result.RefName); err != nil {
+				return fmt.Errorf("log error: %w", err)
+			}
+			continue
+		}
+		record := log.NewRecord(log.UpdateRefRecord, result.OldHash, result.NewHash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), message)
+		if err := t.repo.logger.WriteRef(record, result.RefName); err != nil {
+			return fmt.Errorf("log error: %w", err)
+		}
+		if result.RefName == headRefName {
+			if err := t.repo.logger.WriteHEAD(record); err != nil {
+				return fmt.Errorf("log error: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package goit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/JunNishimura/Goit/internal/file"
+	"github.com/JunNishimura/Goit/internal/graph"
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+type (
+	// Hash is the object name, whose String returns the hexadecimal form.
+	Hash = sha.Hash
+	// Object is the content of the object with its type and hash.
+	Object = object.Object
+	// Commit is the parsed commit object.
+	Commit = object.Commit
+	// Signature is the author or the committer of the commit.
+	Signature = object.Sign
+)
+
+// NewSignature returns the signature of the person at the current time.
+func NewSignature(name, email string) *Signature {
+	return object.NewSign(name, email)
+}
+
+// Repository is the goit repository, which consists of the .goit directory and the working tree.
+type Repository struct {
+	client *store.Client
+	logger *log.GoitLogger
+	graph  *graph.Graph
+
+	// Warnings receives the warnings such as the reflog which does not go back far enough.
+	// They are discarded if it is nil.
+	Warnings io.Writer
+}
+
+// InitOptions controls how the repository is created.
+type InitOptions struct {
+	ObjectFormat string // the hash algorithm of the objects, sha1 or sha256, which is sha1 if empty
+}
+
+func newRepository(client *store.Client) *Repository {
+	return &Repository{
+		client: client,
+		logger: log.NewGoitLogger(client.RootGoitPath),
+	}
+}
+
+// Open opens the repository which contains dir, looking up the parent directories for .goit.
+func Open(ctx context.Context, dir string) (*Repository, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rootGoitPath, err := file.FindGoitRoot(dir)
+	if errors.Is(err, file.ErrGoitRootNotFound) {
+		return nil, ErrRepositoryNotExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	client, err := store.LoadClient(rootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	return newRepository(client), nil
+}
+
+// Init creates the empty repository in dir, whose HEAD points to the main branch.
+func Init(ctx context.Context, dir string, opts *InitOptions) (*Repository, error) {
+	if opts == nil {
+		opts = &InitOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	formatName := opts.ObjectFormat
+	if formatName == "" {
+		formatName = sha.SHA1Format.Name
+	}
+	format, err := sha.FormatByName(formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get absolute path of %s: %w", dir, err)
+	}
+	goitDir := filepath.Join(absDir, ".goit")
+	if _, err := os.Stat(goitDir); err == nil {
+		return nil, ErrRepositoryAlreadyExists
+	}
+
+	// make the directories
+	for _, d := range []string{
+		goitDir,
+		filepath.Join(goitDir, "objects"),
+		filepath.Join(goitDir, "refs"),
+		filepath.Join(goitDir, "refs", "heads"),
+		filepath.Join(goitDir, "refs", "tags"),
+	} {
+		if err := os.MkdirAll(d, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("fail to make %s: %w", d, err)
+		}
+	}
+
+	// make config file, which records the object format unless it is the default one
+	configFile := filepath.Join(goitDir, "config")
+	if err := os.WriteFile(configFile, nil, 0o644); err != nil {
+		return nil, fmt.Errorf("fail to make %s: %w", configFile, err)
+	}
+	if format != sha.SHA1Format {
+		conf, err := store.NewConfig(goitDir)
+		if err != nil {
+			return nil, err
+		}
+		conf.Add("core", "repositoryformatversion", "1", false)
+		conf.Add("extensions", "objectFormat", format.Name, false)
+		if err := conf.Write(configFile, false); err != nil {
+			return nil, fmt.Errorf("fail to write config: %w", err)
+		}
+	}
+
+	// set 'main' as default branch
+	headFile := filepath.Join(goitDir, "HEAD")
+	if err := os.WriteFile(headFile, []byte("ref: refs/heads/main"), 0o644); err != nil {
+		return nil, fmt.Errorf("fail to make %s: %w", headFile, err)
+	}
+
+	client, err := store.LoadClient(goitDir)
+	if err != nil {
+		return nil, err
+	}
+	return newRepository(client), nil
+}
+
+// Path returns the path of the .goit directory.
+func (r *Repository) Path() string {
+	return r.client.RootGoitPath
+}
+
+// WorkDir returns the path of the working tree.
+func (r *Repository) WorkDir() string {
+	return filepath.Dir(r.client.RootGoitPath)
+}
+
+// Worktree returns the working tree of the repository, whose pathspecs are relative to the root.
+func (r *Repository) Worktree() *Worktree {
+	return &Worktree{repo: r}
+}
+
+// Head returns HEAD, whose Target is the full name of the branch unless HEAD is detached.
+// The Hash is nil on the branch which has no commit yet.
+func (r *Repository) Head() *Reference {
+	head := &Reference{Name: "HEAD"}
+	if r.client.Head.Commit != nil {
+		head.Hash = r.client.Head.Commit.Hash
+	}
+	if !r.client.Head.IsDetached() {
+		head.Target = "refs/heads/" + r.client.Head.Reference
+	}
+	return head
+}
+
+// Object reads the object of the hash.
+func (r *Repository) Object(hash Hash) (*Object, error) {
+	obj, err := object.GetObject(r.client.RootGoitPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	return obj, nil
+}
+
+// CommitObject reads the commit of the hash, peeling the annotated tag.
+func (r *Repository) CommitObject(hash Hash) (*Commit, error) {
+	obj, err := object.Peel(r.client.RootGoitPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	commit, err := object.NewCommit(obj)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get commit %s: %w", hash, err)
+	}
+	return commit, nil
+}
+
+// the identity of the user from user.name and user.email.
+func (r *Repository) signature() (*Signature, error) {
+	if !r.client.Conf.IsUserSet() {
+		return nil, ErrUserNotSet
+	}
+	return object.NewSign(r.client.Conf.GetUserName(), r.client.Conf.GetEmail()), nil
+}
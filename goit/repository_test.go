@@ -0,0 +1,299 @@
+package goit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// make the empty repository in the temporary directory without the global config of the user.
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	repo, err := Init(context.Background(), t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func writeTestFile(t *testing.T, repo *Repository, path, content string) {
+	t.Helper()
+	absPath := filepath.Join(repo.WorkDir(), filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(absPath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(absPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSignature() *Signature {
+	return NewSignature("test", "test@example.com")
+}
+
+// add the files and commit them, returning the hash of the commit.
+func commitTestFiles(t *testing.T, repo *Repository, files map[string]string, message string) Hash {
+	t.Helper()
+	ctx := context.Background()
+	wt := repo.Worktree()
+	for path, content := range files {
+		writeTestFile(t, repo, path, content)
+		if err := wt.Add(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := wt.Commit(ctx, message, &CommitOptions{Author: testSignature()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *InitOptions
+		twice   bool
+		wantErr error
+	}{
+		{
+			name: "success: default format",
+			opts: nil,
+		},
+		{
+			name: "success: sha256",
+			opts: &InitOptions{ObjectFormat: "sha256"},
+		},
+		{
+			name:    "fail: already initialized",
+			opts:    nil,
+			twice:   true,
+			wantErr: ErrRepositoryAlreadyExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			ctx := context.Background()
+			dir := t.TempDir()
+			repo, err := Init(ctx, dir, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.twice {
+				_, err = Init(ctx, dir, tt.opts)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// the repository is found from the sub directory
+			sub := filepath.Join(dir, "sub")
+			if err := os.Mkdir(sub, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			opened, err := Open(ctx, sub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opened.Path() != repo.Path() {
+				t.Errorf("got = %s, want = %s", opened.Path(), repo.Path())
+			}
+			if got := opened.Head(); got.Target != "refs/heads/main" || got.Hash != nil {
+				t.Errorf("got = %+v, want HEAD on unborn main", got)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open(context.Background(), t.TempDir())
+	if !errors.Is(err, ErrRepositoryNotExists) {
+		t.Errorf("got = %v, want = %v", err, ErrRepositoryNotExists)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Open(ctx, t.TempDir()); !errors.Is(err, context.Canceled) {
+		t.Errorf("got = %v, want = %v", err, context.Canceled)
+	}
+}
+
+func TestIteration(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	first := commitTestFiles(t, repo, map[string]string{"a": "1"}, "first")
+	second := commitTestFiles(t, repo, map[string]string{"b": "2"}, "second")
+	if _, err := repo.Worktree().Checkout(ctx, &CheckoutOptions{Branch: "feat", Create: true, Hash: first}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("success: references", func(t *testing.T) {
+		var got []string
+		if err := repo.References(ctx, "", func(ref *Reference) error {
+			got = append(got, ref.Name+" "+ref.Hash.String())
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"refs/heads/feat " + first.String(), "refs/heads/main " + second.String()}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("success: log", func(t *testing.T) {
+		var got []string
+		if err := repo.Log(ctx, second, func(commit *Commit) error {
+			got = append(got, commit.Message)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"second", "first"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("success: objects stopped", func(t *testing.T) {
+		count := 0
+		if err := repo.Objects(ctx, func(obj *Object) error {
+			count++
+			return ErrStop
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("got = %d, want = 1", count)
+		}
+	})
+
+	t.Run("fail: canceled", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		err := repo.Objects(canceled, func(obj *Object) error { return nil })
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got = %v, want = %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestClone(t *testing.T) {
+	ctx := context.Background()
+	src := newTestRepository(t)
+	hash := commitTestFiles(t, src, map[string]string{"a": "1", "dir/b": "2"}, "first")
+
+	dst := filepath.Join(t.TempDir(), "clone")
+	repo, err := Clone(ctx, src.WorkDir(), dst, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head := repo.Head(); head.Target != "refs/heads/main" || !head.Hash.Compare(hash) {
+		t.Errorf("got = %+v, want main at %s", head, hash)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "dir", "b"))
+	if err != nil || string(data) != "2" {
+		t.Errorf("got = %q, %v, want = %q", data, err, "2")
+	}
+	var refs []string
+	if err := repo.References(ctx, "refs/remotes/", func(ref *Reference) error {
+		refs = append(refs, ref.Name)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"refs/remotes/origin/HEAD", "refs/remotes/origin/main"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("got = %v, want = %v", refs, want)
+	}
+	if got, err := repo.ResolveRevision("origin"); err != nil || !got.Compare(hash) {
+		t.Errorf("got = %s, %v, want = %s", got, err, hash)
+	}
+	s, err := repo.Worktree().Status(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Branch.Upstream != "refs/remotes/origin/main" || len(s.Entries) != 0 {
+		t.Errorf("got = %+v, %v, want the clean main tracking origin/main", s.Branch, s.Entries)
+	}
+}
+
+func TestBranch(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	first := commitTestFiles(t, repo, map[string]string{"a": "1"}, "first")
+	wt := repo.Worktree()
+	if _, err := wt.Checkout(ctx, &CheckoutOptions{Branch: "feat", Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	second := commitTestFiles(t, repo, map[string]string{"a": "2"}, "second")
+	if _, err := wt.Checkout(ctx, &CheckoutOptions{Branch: "main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.CreateBranch(ctx, "old", first, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if ref, err := repo.Reference("refs/heads/old"); err != nil || !ref.Hash.Compare(first) {
+		t.Errorf("got = %v, %v, want = %s", ref, err, first)
+	}
+	if ahead, behind, err := repo.AheadBehind(second, first); err != nil || ahead != 1 || behind != 0 {
+		t.Errorf("got = %d, %d, %v, want = 1, 0", ahead, behind, err)
+	}
+
+	if err := repo.DeleteBranch(ctx, "nope", false); !errors.Is(err, ErrBranchNotFound) {
+		t.Errorf("got = %v, want = %v", err, ErrBranchNotFound)
+	}
+	if err := repo.DeleteBranch(ctx, "feat", false); !errors.Is(err, ErrBranchNotMerged) {
+		t.Errorf("got = %v, want = %v", err, ErrBranchNotMerged)
+	}
+	if err := repo.DeleteBranch(ctx, "feat", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Reference("refs/heads/feat"); !errors.Is(err, ErrReferenceNotFound) {
+		t.Errorf("got = %v, want = %v", err, ErrReferenceNotFound)
+	}
+
+	if err := repo.RenameBranch(ctx, "trunk"); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.Head().Target; got != "refs/heads/trunk" {
+		t.Errorf("got = %s, want = refs/heads/trunk", got)
+	}
+	// the log of main moves to trunk, followed by the rename
+	var messages []string
+	if err := repo.Reflog(ctx, "refs/heads/trunk", func(entry *ReflogEntry) error {
+		messages = append(messages, entry.Message)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"branch: renamed refs/heads/main refs/heads/trunk", "commit: first"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("got = %v, want = %v", messages, want)
+	}
+	if repo.ReflogExists("refs/heads/main") {
+		t.Error("got the log of main, want it moved")
+	}
+	if _, err := repo.SetUpstream("trunk", "nope"); !errors.Is(err, ErrUpstreamNotFound) {
+		t.Errorf("got = %v, want = %v", err, ErrUpstreamNotFound)
+	}
+	if _, err := repo.SetUpstream("trunk", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if upstream, ok := repo.Upstream("trunk"); !ok || upstream != "refs/heads/old" {
+		t.Errorf("got = %s, %v, want = refs/heads/old", upstream, ok)
+	}
+	if err := repo.UnsetUpstream("trunk"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.UnsetUpstream("trunk"); !errors.Is(err, ErrNoUpstream) {
+		t.Errorf("got = %v, want = %v", err, ErrNoUpstream)
+	}
+}
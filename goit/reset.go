@@ -0,0 +1,83 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// ResetMode tells how far the reset goes beyond HEAD.
+type ResetMode int
+
+const (
+	ResetMixed ResetMode = iota // reset HEAD and the index
+	ResetSoft                   // reset HEAD only
+	ResetHard                   // reset HEAD, the index and the working tree
+)
+
+// ResetOptions tells how the reset is done.
+type ResetOptions struct {
+	Mode     ResetMode
+	Revision string // the name of the commit recorded in the reflog, which is the hash if empty
+}
+
+// Reset moves HEAD, or the branch it points to, to the commit. The index and the working tree
+// are reset to the commit as well depending on the mode.
+func (w *Worktree) Reset(ctx context.Context, hash Hash, opts *ResetOptions) error {
+	if opts == nil {
+		opts = &ResetOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	client := w.repo.client
+	revision := opts.Revision
+	if revision == "" {
+		revision = hash.String()
+	}
+
+	// reset HEAD
+	var prevHash sha.Hash
+	if client.Head.Commit != nil {
+		prevHash = client.Head.Commit.Hash
+	}
+	if err := client.Head.Reset(client.RootGoitPath, client.Refs, hash); err != nil {
+		return fmt.Errorf("fail to reset HEAD: %w", err)
+	}
+	record := log.NewRecord(log.ResetRecord, prevHash, hash, client.Conf.GetUserName(), client.Conf.GetEmail(), time.Now(), fmt.Sprintf("moving to %s", revision))
+	if err := w.repo.logger.WriteHEAD(record); err != nil {
+		return fmt.Errorf("log error: %w", err)
+	}
+	if !client.Head.IsDetached() {
+		if err := w.repo.logger.WriteBranch(record, client.Head.Reference); err != nil {
+			return fmt.Errorf("log error: %w", err)
+		}
+	}
+	if opts.Mode == ResetSoft {
+		return nil
+	}
+
+	// reset index
+	if err := client.Idx.Reset(client.RootGoitPath, hash); err != nil {
+		return fmt.Errorf("fail to reset index: %w", err)
+	}
+	if opts.Mode != ResetHard {
+		return nil
+	}
+
+	// reset working tree
+	for _, entry := range client.Idx.Entries {
+		obj, err := w.repo.Object(entry.Hash)
+		if err != nil {
+			return err
+		}
+		if err := obj.ReflectToWorkingTree(client.RootGoitPath, string(entry.Path)); err != nil {
+			return fmt.Errorf("fail to reflect %s to working directory: %w", string(entry.Path), err)
+		}
+	}
+
+	return nil
+}
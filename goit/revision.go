@@ -0,0 +1,109 @@
+package goit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/approxidate"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+var reflogSelectorRegexp = regexp.MustCompile(`^(.*)@\{([^}]+)\}$`)
+
+// resolve the reflog selector such as HEAD@{1}, main@{yesterday} and @{2.days.ago} into the object hash.
+// The empty ref name means the current branch, and the date picks the entry in effect at that time.
+func (r *Repository) resolveReflogSelector(name, selector string) (Hash, error) {
+	refName := "HEAD"
+	switch {
+	case name == "" && !r.client.Head.IsDetached():
+		refName = "refs/heads/" + r.client.Head.Reference
+	case name != "" && name != "HEAD":
+		fullName, err := r.ReflogName(name)
+		if err != nil {
+			return nil, err
+		}
+		refName = fullName
+	}
+	reflog, err := store.NewReflog(r.client.RootGoitPath, refName)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get reflog: %w", err)
+	}
+
+	if num, err := strconv.Atoi(selector); err == nil {
+		record, err := reflog.GetRecord(num)
+		if err != nil {
+			return nil, err
+		}
+		return record.Hash, nil
+	}
+
+	t, err := approxidate.Parse(selector, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	hash, isExact, err := reflog.HashAt(t)
+	if err != nil {
+		return nil, err
+	}
+	if !isExact && r.Warnings != nil {
+		oldest, _ := reflog.OldestTime()
+		fmt.Fprintf(r.Warnings, "warning: log for '%s' only goes back to %s\n", ShortReferenceName(refName), oldest.Format("Mon Jan 2 15:04:05 2006 -0700"))
+	}
+	return hash, nil
+}
+
+// ResolveRevision resolves the revision such as a full object hash, HEAD, a ref name, a reflog selector
+// like main@{1} or a short object hash into the object hash. The ref name takes precedence over
+// the short object hash as git does.
+func (r *Repository) ResolveRevision(revision string) (Hash, error) {
+	// full object hash is returned as it is
+	if hash, err := sha.ReadHash(revision); err == nil && len(revision) == r.client.Format.HexSize() {
+		return hash, nil
+	}
+
+	if matches := reflogSelectorRegexp.FindStringSubmatch(revision); matches != nil {
+		return r.resolveReflogSelector(matches[1], matches[2])
+	}
+
+	name := revision
+	if strings.ToLower(name) == "head" {
+		name = "HEAD"
+	}
+	fullName, err := store.ExpandRefName(r.client.RootGoitPath, name)
+	if err != nil {
+		if object.IsHexPrefix(revision, r.client.Format) {
+			hash, err := object.ResolvePrefix(r.client.RootGoitPath, r.client.Format, revision)
+			if err == nil {
+				return hash, nil
+			}
+			if errors.Is(err, object.ErrAmbiguousHash) {
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRevision, revision)
+	}
+	_, hash, err := store.ResolveRef(r.client.RootGoitPath, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("fail to resolve '%s': %w", revision, err)
+	}
+	return hash, nil
+}
+
+// ResolveCommit resolves the revision into the hash of the commit, peeling the annotated tag.
+func (r *Repository) ResolveCommit(revision string) (Hash, error) {
+	hash, err := r.ResolveRevision(revision)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := r.Peel(hash)
+	if err != nil || obj.Type != object.CommitObject {
+		return nil, fmt.Errorf("%w: %s", ErrNotCommit, revision)
+	}
+	return obj.Hash, nil
+}
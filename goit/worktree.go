@@ -0,0 +1,316 @@
+package goit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/JunNishimura/Goit/internal/file"
+	"github.com/JunNishimura/Goit/internal/lsfiles"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/pathspec"
+	"github.com/JunNishimura/Goit/internal/status"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+type (
+	// Status is the state of the working tree and the index against HEAD.
+	Status = status.Status
+	// StatusEntry is the change of the tracked path with its XY code.
+	StatusEntry = status.Entry
+	// BranchStatus is the state of HEAD and its upstream.
+	BranchStatus = status.Branch
+	// UntrackedMode tells how the untracked files are listed.
+	UntrackedMode = status.UntrackedMode
+	// ListedFile is the path listed by ListFiles with its tag of ls-files -t and its index entry.
+	ListedFile = lsfiles.File
+)
+
+const (
+	UntrackedNormal = status.UntrackedNormal // the directory whose files are all untracked is shown as "dir/"
+	UntrackedNo     = status.UntrackedNo     // the untracked files are not shown
+	UntrackedAll    = status.UntrackedAll    // every untracked file is shown
+)
+
+// ParseUntrackedMode parses the untracked mode, which is "no", "normal" or "all".
+func ParseUntrackedMode(mode string) (UntrackedMode, error) {
+	return status.ParseUntrackedMode(mode)
+}
+
+// Worktree is the working tree of the repository together with the index.
+type Worktree struct {
+	repo *Repository
+
+	// Prefix is the directory relative to the root in which the pathspecs are given,
+	// such as "src/" for the pathspecs given in the src directory. It is the root if empty.
+	Prefix string
+}
+
+// StatusOptions controls which changes Status collects.
+type StatusOptions struct {
+	Pathspecs     []string // limit the paths, which selects every path if empty
+	Untracked     UntrackedMode
+	Ignored       bool // list the ignored files as well
+	DetectRenames bool // pair the deleted and the added paths in the index
+	Threshold     int  // similarity in percentage for the rename detection
+}
+
+// the filter which converts the files in the working tree into the blobs as Add stores them.
+func (w *Worktree) cleanFilter() (*store.CleanFilter, error) {
+	attrs, err := store.NewAttributes(w.repo.client.RootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewCleanFilter(w.repo.client.Conf, attrs), nil
+}
+
+// Files returns the files in the working tree, which are relative to the root whatever the Prefix is.
+// The files ignored by .goitignore are left out unless withIgnored is set.
+func (w *Worktree) Files(withIgnored bool) ([]string, error) {
+	ignore := w.repo.client.Ignore
+	if withIgnored {
+		ignore = store.NewIgnoreFromPatterns(nil)
+	}
+	filePaths, err := file.GetWorkingTreeFiles(w.repo.WorkDir(), ignore)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get files in the working tree: %w", err)
+	}
+	return filePaths, nil
+}
+
+// store the file in the working tree as the blob and register it to the index.
+func (w *Worktree) addFile(path string, filter *store.CleanFilter) error {
+	client := w.repo.client
+	absPath := filepath.Join(w.repo.WorkDir(), filepath.FromSlash(path))
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("fail to read %s: %w", path, err)
+	}
+	data, err = filter.Clean(path, data)
+	if err != nil {
+		return err
+	}
+
+	// make blob object
+	obj, err := object.NewObject(client.Format, object.BlobObject, data)
+	if err != nil {
+		return fmt.Errorf("fail to get new object: %w", err)
+	}
+
+	// update index
+	isUpdated, err := client.Idx.Update(client.RootGoitPath, obj.Hash, []byte(path))
+	if err != nil {
+		return fmt.Errorf("fail to update index: %w", err)
+	}
+	if !isUpdated {
+		return nil
+	}
+
+	// write object to file
+	if err := obj.Write(client.RootGoitPath); err != nil {
+		return fmt.Errorf("fail to write object: %w", err)
+	}
+
+	return nil
+}
+
+// Add registers the files matching the pathspecs to the index. The ignored files are skipped
+// unless they are tracked, and the tracked files removed from the working tree are removed from the index.
+func (w *Worktree) Add(ctx context.Context, pathspecs ...string) error {
+	if len(pathspecs) == 0 {
+		return fmt.Errorf("%w, nothing added", ErrNothingSpecified)
+	}
+	ps, err := pathspec.Parse(pathspecs, w.Prefix)
+	if err != nil {
+		return err
+	}
+	client := w.repo.client
+
+	// the files in the working tree and the files registered in the index are the candidates
+	workDir := w.repo.WorkDir()
+	filePaths, err := file.GetWorkingTreeFiles(workDir, client.Ignore)
+	if err != nil {
+		return fmt.Errorf("fail to get files: %w", err)
+	}
+	isExist := make(map[string]bool)
+	for _, filePath := range filePaths {
+		isExist[filePath] = true
+	}
+	var deletedPaths []string
+	for _, entry := range client.Idx.Entries {
+		path := string(entry.Path)
+		if isExist[path] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(path))); err == nil {
+			// the tracked file is added even if it is ignored
+			filePaths = append(filePaths, path)
+			isExist[path] = true
+		} else if len(deletedPaths) == 0 || deletedPaths[len(deletedPaths)-1] != path {
+			deletedPaths = append(deletedPaths, path)
+		}
+	}
+	filePaths = ps.Filter(filePaths)
+	deletedPaths = ps.Filter(deletedPaths)
+	for _, arg := range ps.Unmatched() {
+		// the ignored path is skipped without error
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(w.Prefix), arg)); err == nil {
+			continue
+		}
+		return fmt.Errorf("pathspec '%s' %w", arg, ErrPathspecNotMatched)
+	}
+
+	// the content is stored after the clean filter and the end-of-line conversion
+	filter, err := w.cleanFilter()
+	if err != nil {
+		return err
+	}
+	for _, filePath := range filePaths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := w.addFile(filePath, filter); err != nil {
+			return err
+		}
+	}
+
+	// If the file does not exist but is registered in the index, delete it from the index
+	for _, deletedPath := range deletedPaths {
+		if err := client.Idx.DeleteEntry(client.RootGoitPath, []byte(deletedPath)); err != nil {
+			return fmt.Errorf("fail to delete untracked file %s: %w", deletedPath, err)
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the tracked files matching the pathspecs from the working tree and the index.
+// It is an error to give no pathspec, which would otherwise remove every file.
+func (w *Worktree) Remove(ctx context.Context, pathspecs ...string) error {
+	if len(pathspecs) == 0 {
+		return ErrNothingSpecified
+	}
+	ps, err := pathspec.Parse(pathspecs, w.Prefix)
+	if err != nil {
+		return err
+	}
+	client := w.repo.client
+
+	var paths []string
+	for _, entry := range client.Idx.Entries {
+		path := string(entry.Path)
+		if len(paths) > 0 && paths[len(paths)-1] == path {
+			continue
+		}
+		if ps.Match(path) {
+			paths = append(paths, path)
+		}
+	}
+	if unmatched := ps.Unmatched(); len(unmatched) > 0 {
+		return fmt.Errorf("pathspec '%s' %w", unmatched[0], ErrPathspecNotMatched)
+	}
+
+	workDir := w.repo.WorkDir()
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// remove from the working tree
+		absPath := filepath.Join(workDir, filepath.FromSlash(path))
+		if err := os.Remove(absPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to delete %s from the working tree: %w", absPath, err)
+		}
+
+		// remove from the index
+		if err := client.Idx.DeleteEntry(client.RootGoitPath, []byte(path)); err != nil {
+			return fmt.Errorf("fail to delete '%s' from the index: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Status collects the changes of the index against HEAD and of the working tree against the index.
+func (w *Worktree) Status(ctx context.Context, opts *StatusOptions) (*Status, error) {
+	if opts == nil {
+		opts = &StatusOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ps, err := pathspec.Parse(opts.Pathspecs, w.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := w.cleanFilter()
+	if err != nil {
+		return nil, err
+	}
+	s, err := status.New(w.repo.client, &status.Options{
+		Pathspec:      ps,
+		Untracked:     opts.Untracked,
+		Ignored:       opts.Ignored,
+		DetectRenames: opts.DetectRenames,
+		Threshold:     opts.Threshold,
+		Filter:        filter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the status is computed at once, so the cancel while computing is reported afterwards
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListFilesOptions selects the files ListFiles lists. The index entries are listed if nothing is selected.
+type ListFilesOptions struct {
+	Pathspecs []string // limit the paths, which selects every path if empty
+	Cached    bool     // the index entries
+	Stage     bool     // the index entries with their stages, which implies Cached
+	Modified  bool     // the tracked files modified in the working tree, including the deleted ones
+	Deleted   bool     // the tracked files deleted from the working tree
+	Others    bool     // the untracked files
+	Ignored   bool     // only the files which the exclude rules match among the cached or the other files
+	Unmerged  bool     // only the index entries of the conflict stages, which implies Stage
+
+	ExcludeStandard bool     // use the rules of .goitignore as the exclude rule
+	Excludes        []string // the patterns of the exclude rule
+}
+
+// ListFiles returns the files selected by the options in the order ls-files shows them,
+// the untracked files first and then the index entries.
+func (w *Worktree) ListFiles(ctx context.Context, opts *ListFilesOptions) ([]*ListedFile, error) {
+	if opts == nil {
+		opts = &ListFilesOptions{}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ps, err := pathspec.Parse(opts.Pathspecs, w.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	lsOpts := &lsfiles.Options{
+		Cached:   opts.Cached,
+		Stage:    opts.Stage,
+		Modified: opts.Modified,
+		Deleted:  opts.Deleted,
+		Others:   opts.Others,
+		Ignored:  opts.Ignored,
+		Unmerged: opts.Unmerged,
+		Excludes: opts.Excludes,
+		Pathspec: ps,
+	}
+	if opts.ExcludeStandard {
+		lsOpts.Ignore = w.repo.client.Ignore
+	}
+	if opts.Modified {
+		if lsOpts.Filter, err = w.cleanFilter(); err != nil {
+			return nil, err
+		}
+	}
+	return lsfiles.List(w.repo.WorkDir(), w.repo.client.Idx, lsOpts)
+}
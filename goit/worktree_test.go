@@ -0,0 +1,326 @@
+package goit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// describe the entries of the status as "XY path" to compare.
+func dumpStatus(s *Status) []string {
+	var got []string
+	for _, entry := range s.Entries {
+		got = append(got, string([]byte{entry.X, entry.Y})+" "+entry.Path)
+	}
+	for _, path := range s.Untracked {
+		got = append(got, "?? "+path)
+	}
+	return got
+}
+
+func TestWorktreeAdd(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefix    string
+		pathspecs []string
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:      "success: all",
+			pathspecs: []string{"."},
+			want:      []string{"A  a", "A  dir/b", "A  dir/c"},
+		},
+		{
+			name:      "success: in sub directory",
+			prefix:    "dir/",
+			pathspecs: []string{"b"},
+			want:      []string{"A  dir/b", "?? a", "?? dir/c"},
+		},
+		{
+			name:    "fail: nothing specified",
+			want:    []string{"?? a", "?? dir/"},
+			wantErr: ErrNothingSpecified,
+		},
+		{
+			name:      "fail: not matched",
+			pathspecs: []string{"x"},
+			want:      []string{"?? a", "?? dir/"},
+			wantErr:   ErrPathspecNotMatched,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTestRepository(t)
+			for path, content := range map[string]string{"a": "1", "dir/b": "2", "dir/c": "3"} {
+				writeTestFile(t, repo, path, content)
+			}
+			wt := repo.Worktree()
+			wt.Prefix = tt.prefix
+			if err := wt.Add(ctx, tt.pathspecs...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			s, err := repo.Worktree().Status(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dumpStatus(s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeRemove(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	commitTestFiles(t, repo, map[string]string{"a": "1", "b": "2"}, "first")
+
+	wt := repo.Worktree()
+	if err := wt.Remove(ctx); !errors.Is(err, ErrNothingSpecified) {
+		t.Errorf("got = %v, want = %v", err, ErrNothingSpecified)
+	}
+	if err := wt.Remove(ctx, "x"); !errors.Is(err, ErrPathspecNotMatched) {
+		t.Errorf("got = %v, want = %v", err, ErrPathspecNotMatched)
+	}
+	if err := wt.Remove(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(repo.WorkDir(), "a")); !os.IsNotExist(err) {
+		t.Errorf("a is left in the working tree: %v", err)
+	}
+	s, err := wt.Status(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dumpStatus(s), []string{"D  a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
+
+func TestWorktreeCommit(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	wt := repo.Worktree()
+
+	if _, err := wt.Commit(ctx, "empty", &CommitOptions{Author: testSignature()}); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("got = %v, want = %v", err, ErrNothingToCommit)
+	}
+	writeTestFile(t, repo, "a", "1")
+	if err := wt.Add(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Commit(ctx, "no user", nil); !errors.Is(err, ErrUserNotSet) {
+		t.Errorf("got = %v, want = %v", err, ErrUserNotSet)
+	}
+	first, err := wt.Commit(ctx, "first", &CommitOptions{Author: testSignature()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head := repo.Head(); !head.Hash.Compare(first) {
+		t.Errorf("got = %s, want = %s", head.Hash, first)
+	}
+	if _, err := wt.Commit(ctx, "again", &CommitOptions{Author: testSignature()}); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("got = %v, want = %v", err, ErrNothingToCommit)
+	}
+
+	commit, err := repo.CommitObject(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "first" || len(commit.Parents) != 0 || commit.Author.Name != "test" {
+		t.Errorf("got = %+v, want the root commit by test", commit)
+	}
+}
+
+func TestWorktreeCheckout(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       *CheckoutOptions
+		wantTarget string
+		wantFiles  []string
+		wantErr    error
+	}{
+		{
+			name:       "success: existing branch",
+			opts:       &CheckoutOptions{Branch: "feat"},
+			wantTarget: "refs/heads/feat",
+			wantFiles:  []string{"a", "f"},
+		},
+		{
+			name:       "success: new branch",
+			opts:       &CheckoutOptions{Branch: "new", Create: true},
+			wantTarget: "refs/heads/new",
+			wantFiles:  []string{"a"},
+		},
+		{
+			name:       "success: orphan",
+			opts:       &CheckoutOptions{Branch: "orphan", Orphan: true},
+			wantTarget: "refs/heads/orphan",
+			wantFiles:  nil,
+		},
+		{
+			name:       "fail: unknown branch",
+			opts:       &CheckoutOptions{Branch: "nope"},
+			wantTarget: "refs/heads/main",
+			wantFiles:  []string{"a"},
+			wantErr:    ErrInvalidReference,
+		},
+		{
+			name:       "fail: branch exists",
+			opts:       &CheckoutOptions{Branch: "feat", Create: true},
+			wantTarget: "refs/heads/main",
+			wantFiles:  []string{"a"},
+			wantErr:    ErrBranchExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTestRepository(t)
+			wt := repo.Worktree()
+			commitTestFiles(t, repo, map[string]string{"a": "1"}, "first")
+			if _, err := wt.Checkout(ctx, &CheckoutOptions{Branch: "feat", Create: true}); err != nil {
+				t.Fatal(err)
+			}
+			commitTestFiles(t, repo, map[string]string{"f": "2"}, "feat")
+			if _, err := wt.Checkout(ctx, &CheckoutOptions{Branch: "main"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := wt.Checkout(ctx, tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got := repo.Head().Target; got != tt.wantTarget {
+				t.Errorf("got = %s, want = %s", got, tt.wantTarget)
+			}
+			entries, err := os.ReadDir(repo.WorkDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, entry := range entries {
+				if entry.Name() != ".goit" {
+					files = append(files, entry.Name())
+				}
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("got = %v, want = %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestWorktreeReset(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      ResetMode
+		wantIndex string // content of the blob of a in the index
+		wantFile  string
+	}{
+		{
+			name:      "success: soft",
+			mode:      ResetSoft,
+			wantIndex: "2",
+			wantFile:  "2",
+		},
+		{
+			name:      "success: mixed",
+			mode:      ResetMixed,
+			wantIndex: "1",
+			wantFile:  "2",
+		},
+		{
+			name:      "success: hard",
+			mode:      ResetHard,
+			wantIndex: "1",
+			wantFile:  "1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTestRepository(t)
+			first := commitTestFiles(t, repo, map[string]string{"a": "1"}, "first")
+			commitTestFiles(t, repo, map[string]string{"a": "2"}, "second")
+
+			if err := repo.Worktree().Reset(ctx, first, &ResetOptions{Mode: tt.mode, Revision: "HEAD~1"}); err != nil {
+				t.Fatal(err)
+			}
+			if head := repo.Head(); !head.Hash.Compare(first) {
+				t.Errorf("got = %s, want = %s", head.Hash, first)
+			}
+			entry, ok := repo.Index().Entry("a", 0)
+			if !ok {
+				t.Fatal("a is not in the index")
+			}
+			obj, err := repo.Object(entry.Hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(obj.Data); got != tt.wantIndex {
+				t.Errorf("got = %s, want = %s", got, tt.wantIndex)
+			}
+			data, err := os.ReadFile(filepath.Join(repo.WorkDir(), "a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != tt.wantFile {
+				t.Errorf("got = %s, want = %s", got, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestWorktreeReadTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *ReadTreeOptions
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "success: replace the index",
+			opts: nil,
+			want: []string{"a"},
+		},
+		{
+			name: "success: prefix",
+			opts: &ReadTreeOptions{Prefix: "sub"},
+			want: []string{"a", "b", "sub/a"},
+		},
+		{
+			name:    "fail: prefix exists",
+			opts:    &ReadTreeOptions{Prefix: "a"},
+			want:    []string{"a", "b"},
+			wantErr: ErrPrefixExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTestRepository(t)
+			first := commitTestFiles(t, repo, map[string]string{"a": "1"}, "first")
+			commitTestFiles(t, repo, map[string]string{"b": "2"}, "second")
+			commit, err := repo.CommitObject(first)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := repo.Worktree().ReadTree(ctx, tt.opts, commit.Tree); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, entry := range repo.Index().Entries() {
+				got = append(got, string(entry.Path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package approxidate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDate = errors.New("invalid date")
+)
+
+// the layouts of the absolute dates, tried in order
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon Jan 2 15:04:05 2006 -0700", // the default format of git log
+	"Mon Jan 2 15:04:05 2006",
+	"Jan 2 2006",
+	"2 Jan 2006",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Parse parses the date written loosely as git does, which is either the absolute one such as
+// "2023-10-01", "2023-10-01 12:34:56 +0900" and "@1700000000", or the relative one such as
+// "yesterday", "3 hours ago", "2.weeks.ago", "last friday" and "yesterday noon".
+// The relative date is measured from now, and the date without the timezone is in the location of now.
+func Parse(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("%w: empty", ErrInvalidDate)
+	}
+	if t, ok := parseTimestamp(s); ok {
+		return t.In(now.Location()), nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	t, err := parseRelative(s, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidDate, s)
+	}
+	return t, nil
+}
+
+// parse the unix timestamp, which is prefixed with '@' or has at least 9 digits as git assumes.
+func parseTimestamp(s string) (time.Time, bool) {
+	digits := strings.TrimPrefix(s, "@")
+	if digits == s && len(digits) < 9 {
+		return time.Time{}, false
+	}
+	unixtime, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || unixtime < 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(unixtime, 0), true
+}
+
+// return the duration unit of the word such as "days" and "hour", which is normalized to the singular.
+func unitOf(word string) (string, bool) {
+	word = strings.TrimSuffix(word, "s")
+	switch word {
+	case "second", "minute", "hour", "day", "week", "month", "year":
+		return word, true
+	default:
+		return "", false
+	}
+}
+
+// move the time back by n units.
+func subtract(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "second":
+		return t.Add(-time.Duration(n) * time.Second)
+	case "minute":
+		return t.Add(-time.Duration(n) * time.Minute)
+	case "hour":
+		return t.Add(-time.Duration(n) * time.Hour)
+	case "day":
+		return t.AddDate(0, 0, -n)
+	case "week":
+		return t.AddDate(0, 0, -7*n)
+	case "month":
+		return t.AddDate(0, -n, 0)
+	default:
+		return t.AddDate(-n, 0, 0)
+	}
+}
+
+// parse the phrases relative to now, where the words are separated by spaces, dots or underscores.
+func parseRelative(s string, now time.Time) (time.Time, error) {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == ' ' || r == '.' || r == '_' || r == ','
+	})
+
+	t := now
+	isDayMoved := false
+	timeOfDay := -1 // the hour set by "noon" and "midnight"
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case word == "now", word == "today", word == "ago":
+			// "ago" is implied since the future is never meant
+		case word == "yesterday":
+			t = t.AddDate(0, 0, -1)
+			isDayMoved = true
+		case word == "noon":
+			timeOfDay = 12
+		case word == "midnight":
+			timeOfDay = 0
+		case word == "last":
+			// "last friday" is the same as "friday", and "last week" is "1 week ago"
+			if i+1 < len(words) {
+				if unit, ok := unitOf(words[i+1]); ok {
+					t = subtract(t, 1, unit)
+					isDayMoved = true
+					i++
+				}
+			}
+		default:
+			if weekday, ok := weekdays[word]; ok {
+				// the latest weekday before today
+				days := (int(t.Weekday()) - int(weekday) + 7) % 7
+				if days == 0 {
+					days = 7
+				}
+				t = t.AddDate(0, 0, -days)
+				isDayMoved = true
+				continue
+			}
+			n, err := strconv.Atoi(word)
+			if word == "a" || word == "an" {
+				n, err = 1, nil
+			}
+			if err != nil || i+1 >= len(words) {
+				return time.Time{}, ErrInvalidDate
+			}
+			unit, ok := unitOf(words[i+1])
+			if !ok {
+				return time.Time{}, ErrInvalidDate
+			}
+			t = subtract(t, n, unit)
+			isDayMoved = true
+			i++
+		}
+	}
+
+	if timeOfDay >= 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), timeOfDay, 0, 0, 0, t.Location())
+		// the bare "noon" before noon means yesterday's
+		if !isDayMoved && t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,127 @@
+package approxidate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	// Wednesday
+	now := time.Date(2023, 10, 18, 15, 30, 0, 0, jst)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name:  "success: now",
+			value: "now",
+			want:  now,
+		},
+		{
+			name:  "success: yesterday",
+			value: "yesterday",
+			want:  time.Date(2023, 10, 17, 15, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: hours ago",
+			value: "3 hours ago",
+			want:  time.Date(2023, 10, 18, 12, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: dotted",
+			value: "2.days.ago",
+			want:  time.Date(2023, 10, 16, 15, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: combined units",
+			value: "1 day 2 hours ago",
+			want:  time.Date(2023, 10, 17, 13, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: an hour",
+			value: "an hour ago",
+			want:  time.Date(2023, 10, 18, 14, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: last friday",
+			value: "last friday",
+			want:  time.Date(2023, 10, 13, 15, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: same weekday means a week ago",
+			value: "wednesday",
+			want:  time.Date(2023, 10, 11, 15, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: last week",
+			value: "last week",
+			want:  time.Date(2023, 10, 11, 15, 30, 0, 0, jst),
+		},
+		{
+			name:  "success: yesterday noon",
+			value: "yesterday noon",
+			want:  time.Date(2023, 10, 17, 12, 0, 0, 0, jst),
+		},
+		{
+			name:  "success: midnight",
+			value: "midnight",
+			want:  time.Date(2023, 10, 18, 0, 0, 0, 0, jst),
+		},
+		{
+			name:  "success: iso date",
+			value: "2023-10-01",
+			want:  time.Date(2023, 10, 1, 0, 0, 0, 0, jst),
+		},
+		{
+			name:  "success: iso date and time with timezone",
+			value: "2023-10-01 12:34:56 +0000",
+			want:  time.Date(2023, 10, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{
+			name:  "success: rfc3339",
+			value: "2023-10-01T12:34:56Z",
+			want:  time.Date(2023, 10, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{
+			name:  "success: unix timestamp",
+			value: "@1700000000",
+			want:  time.Unix(1700000000, 0),
+		},
+		{
+			name:  "success: bare unix timestamp",
+			value: "1700000000",
+			want:  time.Unix(1700000000, 0),
+		},
+		{
+			name:    "fail: empty",
+			value:   "",
+			wantErr: ErrInvalidDate,
+		},
+		{
+			name:    "fail: unknown word",
+			value:   "someday",
+			wantErr: ErrInvalidDate,
+		},
+		{
+			name:    "fail: number without unit",
+			value:   "3 ago",
+			wantErr: ErrInvalidDate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	FormatTar   = "tar"
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown archive format")
+)
+
+// File is the blob in the tree to archive.
+type File struct {
+	Path string
+	Mode string // the mode in the tree such as 100644, 100755 and 120000
+	Hash sha.Hash
+}
+
+func (f *File) isSymlink() bool {
+	return f.Mode == "120000"
+}
+
+// return the permission of the file, where the group and others can read but not write like git.
+func (f *File) perm() os.FileMode {
+	switch {
+	case f.isSymlink():
+		return 0o777
+	case f.Mode == "100755":
+		return 0o775
+	default:
+		return 0o664
+	}
+}
+
+// Files returns the blobs in the tree sorted by their paths, which are selected by the match
+// function if it is not nil.
+func Files(tree *object.Tree, match func(path string) bool) []*File {
+	var files []*File
+	var walk func(dir string, nodes []*object.Node)
+	walk = func(dir string, nodes []*object.Node) {
+		for _, node := range nodes {
+			p := node.Name
+			if dir != "" {
+				p = dir + "/" + node.Name
+			}
+			if len(node.Children) > 0 {
+				walk(p, node.Children)
+				continue
+			}
+			if match != nil && !match(p) {
+				continue
+			}
+			files = append(files, &File{Path: p, Mode: node.Mode, Hash: node.Hash})
+		}
+	}
+	walk("", tree.Children)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// FormatFromName infers the format from the extension of the output file name.
+// It returns the tar format if the extension is unknown.
+func FormatFromName(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	default:
+		return FormatTar
+	}
+}
+
+// Options controls the entries of the archive.
+type Options struct {
+	Format   string
+	Prefix   string    // prepended to every path such as "project-1.0/"
+	ModTime  time.Time // the modification time of every entry
+	CommitID string    // embedded in the archive if it is not empty
+}
+
+// Loader reads the content of the blob.
+type Loader func(hash sha.Hash) ([]byte, error)
+
+// return the directories containing the files, which are listed before their contents.
+func directories(files []*File, prefix string) []string {
+	isAdded := make(map[string]bool)
+	var dirs []string
+	var add func(dir string)
+	add = func(dir string) {
+		if dir == "." || dir == "/" || dir == "" || isAdded[dir] {
+			return
+		}
+		add(path.Dir(dir))
+		isAdded[dir] = true
+		dirs = append(dirs, dir+"/")
+	}
+	if strings.HasSuffix(prefix, "/") {
+		add(strings.TrimSuffix(prefix, "/"))
+	}
+	for _, f := range files {
+		add(path.Dir(prefix + f.Path))
+	}
+	return dirs
+}
+
+// Write writes the files into the archive one by one, loading each blob only when it is written.
+func Write(w io.Writer, files []*File, load Loader, opts Options) error {
+	switch opts.Format {
+	case FormatTar:
+		return writeTar(w, files, load, opts)
+	case FormatTarGz:
+		gw := gzip.NewWriter(w)
+		gw.ModTime = opts.ModTime
+		if err := writeTar(gw, files, load, opts); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return fmt.Errorf("fail to close gzip: %w", err)
+		}
+		return nil
+	case FormatZip:
+		return writeZip(w, files, load, opts)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, opts.Format)
+	}
+}
+
+func writeTar(w io.Writer, files []*File, load Loader, opts Options) error {
+	tw := tar.NewWriter(w)
+	if opts.CommitID != "" {
+		// the same global header as git, by which `git get-tar-commit-id` can read the commit
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag:   tar.TypeXGlobalHeader,
+			Name:       "pax_global_header",
+			PAXRecords: map[string]string{"comment": opts.CommitID},
+			Format:     tar.FormatPAX,
+		}); err != nil {
+			return fmt.Errorf("fail to write global header: %w", err)
+		}
+	}
+
+	for _, dir := range directories(files, opts.Prefix) {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     dir,
+			Mode:     0o775,
+			ModTime:  opts.ModTime,
+		}); err != nil {
+			return fmt.Errorf("fail to write %s: %w", dir, err)
+		}
+	}
+
+	for _, f := range files {
+		data, err := load(f.Hash)
+		if err != nil {
+			return fmt.Errorf("fail to read %s: %w", f.Path, err)
+		}
+		header := &tar.Header{
+			Name:    opts.Prefix + f.Path,
+			Mode:    int64(f.perm()),
+			ModTime: opts.ModTime,
+		}
+		if f.isSymlink() {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = string(data)
+			data = nil
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(data))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("fail to write %s: %w", f.Path, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("fail to write %s: %w", f.Path, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("fail to close tar: %w", err)
+	}
+	return nil
+}
+
+func writeZip(w io.Writer, files []*File, load Loader, opts Options) error {
+	zw := zip.NewWriter(w)
+	if opts.CommitID != "" {
+		if err := zw.SetComment(opts.CommitID); err != nil {
+			return fmt.Errorf("fail to write comment: %w", err)
+		}
+	}
+
+	for _, dir := range directories(files, opts.Prefix) {
+		header := &zip.FileHeader{
+			Name:     dir,
+			Modified: opts.ModTime,
+		}
+		header.SetMode(os.ModeDir | 0o775)
+		if _, err := zw.CreateHeader(header); err != nil {
+			return fmt.Errorf("fail to write %s: %w", dir, err)
+		}
+	}
+
+	for _, f := range files {
+		data, err := load(f.Hash)
+		if err != nil {
+			return fmt.Errorf("fail to read %s: %w", f.Path, err)
+		}
+		header := &zip.FileHeader{
+			Name:     opts.Prefix + f.Path,
+			Method:   zip.Deflate,
+			Modified: opts.ModTime,
+		}
+		mode := f.perm()
+		if f.isSymlink() {
+			// the link target is stored as the content
+			mode |= os.ModeSymlink
+			header.Method = zip.Store
+		}
+		header.SetMode(mode)
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("fail to write %s: %w", f.Path, err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			return fmt.Errorf("fail to write %s: %w", f.Path, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("fail to close zip: %w", err)
+	}
+	return nil
+}
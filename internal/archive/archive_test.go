@@ -0,0 +1,179 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+type entry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+func testFiles() ([]*File, Loader) {
+	contents := map[string]string{
+		"README.md":  "readme\n",
+		"bin/run.sh": "#!/bin/sh\n",
+		"link":       "README.md",
+	}
+	blobs := make(map[string][]byte)
+	hashOf := func(p string) sha.Hash {
+		sum := sha1.Sum([]byte(contents[p]))
+		blobs[sha.Hash(sum[:]).String()] = []byte(contents[p])
+		return sha.Hash(sum[:])
+	}
+	files := []*File{
+		{Path: "README.md", Mode: "100644", Hash: hashOf("README.md")},
+		{Path: "bin/run.sh", Mode: "100755", Hash: hashOf("bin/run.sh")},
+		{Path: "link", Mode: "120000", Hash: hashOf("link")},
+	}
+	load := func(hash sha.Hash) ([]byte, error) {
+		return blobs[hash.String()], nil
+	}
+	return files, load
+}
+
+func readTar(t *testing.T, r io.Reader, modTime time.Time) ([]*entry, string) {
+	t.Helper()
+	var entries []*entry
+	commitID := ""
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			commitID = header.PAXRecords["comment"]
+			continue
+		}
+		if !header.ModTime.Equal(modTime) {
+			t.Errorf("got mtime = %v, want = %v", header.ModTime, modTime)
+		}
+		content, _ := io.ReadAll(tr)
+		if header.Typeflag == tar.TypeSymlink {
+			content = []byte(header.Linkname)
+		}
+		entries = append(entries, &entry{name: header.Name, mode: header.FileInfo().Mode(), content: string(content)})
+	}
+	return entries, commitID
+}
+
+func TestWrite(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	commitID := "87f3c49bccf2597484ece08746d3ee5defaba335"
+	want := []*entry{
+		{name: "goit-1.0/", mode: os.ModeDir | 0o775},
+		{name: "goit-1.0/bin/", mode: os.ModeDir | 0o775},
+		{name: "goit-1.0/README.md", mode: 0o664, content: "readme\n"},
+		{name: "goit-1.0/bin/run.sh", mode: 0o775, content: "#!/bin/sh\n"},
+		{name: "goit-1.0/link", mode: os.ModeSymlink | 0o777, content: "README.md"},
+	}
+	opts := Options{Prefix: "goit-1.0/", ModTime: modTime, CommitID: commitID}
+
+	t.Run("success: tar", func(t *testing.T) {
+		files, load := testFiles()
+		opts.Format = FormatTar
+		var buf bytes.Buffer
+		if err := Write(&buf, files, load, opts); err != nil {
+			t.Fatal(err)
+		}
+		got, gotCommitID := readTar(t, &buf, modTime)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want = %v", got, want)
+		}
+		if gotCommitID != commitID {
+			t.Errorf("got = %s, want = %s", gotCommitID, commitID)
+		}
+	})
+
+	t.Run("success: tar.gz", func(t *testing.T) {
+		files, load := testFiles()
+		opts.Format = FormatTarGz
+		var buf bytes.Buffer
+		if err := Write(&buf, files, load, opts); err != nil {
+			t.Fatal(err)
+		}
+		gr, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, gotCommitID := readTar(t, gr, modTime)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want = %v", got, want)
+		}
+		if gotCommitID != commitID {
+			t.Errorf("got = %s, want = %s", gotCommitID, commitID)
+		}
+	})
+
+	t.Run("success: zip", func(t *testing.T) {
+		files, load := testFiles()
+		opts.Format = FormatZip
+		var buf bytes.Buffer
+		if err := Write(&buf, files, load, opts); err != nil {
+			t.Fatal(err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if zr.Comment != commitID {
+			t.Errorf("got = %s, want = %s", zr.Comment, commitID)
+		}
+		var got []*entry
+		for _, f := range zr.File {
+			if !f.Modified.Equal(modTime) {
+				t.Errorf("got mtime = %v, want = %v", f.Modified, modTime)
+			}
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			got = append(got, &entry{name: f.Name, mode: f.Mode(), content: string(content)})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got = %v, want = %v", got, want)
+		}
+	})
+
+	t.Run("fail: unknown format", func(t *testing.T) {
+		files, load := testFiles()
+		opts.Format = "rar"
+		if err := Write(io.Discard, files, load, opts); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("got = %v, want = %v", err, ErrUnknownFormat)
+		}
+	})
+}
+
+func TestFormatFromName(t *testing.T) {
+	tests := map[string]string{
+		"release.tar":    FormatTar,
+		"release.tar.gz": FormatTarGz,
+		"release.tgz":    FormatTarGz,
+		"release.zip":    FormatZip,
+		"release":        FormatTar,
+	}
+	for name, want := range tests {
+		if got := FormatFromName(name); got != want {
+			t.Errorf("%s: got = %s, want = %s", name, got, want)
+		}
+	}
+}
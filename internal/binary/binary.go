@@ -1,6 +1,12 @@
 package binary
 
-import "io"
+import (
+	"bytes"
+	"io"
+)
+
+// CheckSize is the number of the bytes inspected to tell whether the content is binary, the same as git.
+const CheckSize = 8000
 
 func ReadNullTerminatedString(r io.Reader) (string, error) {
 	str := make([]byte, 0)
@@ -20,3 +26,11 @@ func ReadNullTerminatedString(r io.Reader) (string, error) {
 	}
 	return string(str), nil
 }
+
+// IsBinary reports whether the content is binary, which git judges by a NUL byte in its head.
+func IsBinary(data []byte) bool {
+	if len(data) > CheckSize {
+		data = data[:CheckSize]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
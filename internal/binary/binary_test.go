@@ -38,3 +38,23 @@ func TestReadNullTerminatedString(t *testing.T) {
 		})
 	}
 }
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "text", data: []byte("hello\nworld\n"), want: false},
+		{name: "empty", data: []byte{}, want: false},
+		{name: "NUL byte", data: []byte("hello\x00world"), want: true},
+		{name: "NUL byte after the head", data: append(bytes.Repeat([]byte("a"), CheckSize), 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBinary(tt.data); got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package bisect
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	startFile = "BISECT_START"
+	badFile   = "BISECT_BAD"
+	goodFile  = "BISECT_GOOD"
+	skipFile  = "BISECT_SKIP"
+	logFile   = "BISECT_LOG"
+)
+
+var (
+	ErrNotBisecting     = errors.New("not bisecting")
+	ErrGoodNotAncestor  = errors.New("the bad commit is an ancestor of a good commit")
+	ErrInvalidStateFile = errors.New("invalid bisect state file")
+)
+
+// CommitGetter reads the commit of the hash, which is implemented by graph.Graph.
+type CommitGetter interface {
+	Commit(hash sha.Hash) (*object.Commit, error)
+}
+
+// State is the bisect session persisted in the .goit/BISECT_* files.
+type State struct {
+	Start string // the branch, or the commit on the detached HEAD, checked out before the bisect
+	Bad   sha.Hash
+	Good  []sha.Hash
+	Skip  []sha.Hash
+}
+
+// IsInProgress tells whether the bisect session is started.
+func IsInProgress(rootGoitPath string) bool {
+	_, err := os.Stat(filepath.Join(rootGoitPath, startFile))
+	return err == nil
+}
+
+func readHashes(path string) ([]sha.Hash, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to read %s: %w", path, err)
+	}
+	var hashes []sha.Hash
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		hash, err := sha.ReadHash(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidStateFile, filepath.Base(path), line)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func writeHashes(path string, hashes []sha.Hash) error {
+	if len(hashes) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	var content string
+	for _, hash := range hashes {
+		content += hash.String() + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("fail to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads the state of the bisect session.
+func Load(rootGoitPath string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(rootGoitPath, startFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNotBisecting
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to read %s: %w", startFile, err)
+	}
+	state := &State{
+		Start: strings.TrimSpace(string(data)),
+	}
+
+	bad, err := readHashes(filepath.Join(rootGoitPath, badFile))
+	if err != nil {
+		return nil, err
+	}
+	if len(bad) > 1 {
+		return nil, fmt.Errorf("%w: %s has more than one commit", ErrInvalidStateFile, badFile)
+	}
+	if len(bad) == 1 {
+		state.Bad = bad[0]
+	}
+	if state.Good, err = readHashes(filepath.Join(rootGoitPath, goodFile)); err != nil {
+		return nil, err
+	}
+	if state.Skip, err = readHashes(filepath.Join(rootGoitPath, skipFile)); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Save writes the state of the bisect session.
+func (s *State) Save(rootGoitPath string) error {
+	if err := os.WriteFile(filepath.Join(rootGoitPath, startFile), []byte(s.Start+"\n"), 0o644); err != nil {
+		return fmt.Errorf("fail to write %s: %w", startFile, err)
+	}
+	var bad []sha.Hash
+	if s.Bad != nil {
+		bad = append(bad, s.Bad)
+	}
+	if err := writeHashes(filepath.Join(rootGoitPath, badFile), bad); err != nil {
+		return err
+	}
+	if err := writeHashes(filepath.Join(rootGoitPath, goodFile), s.Good); err != nil {
+		return err
+	}
+	return writeHashes(filepath.Join(rootGoitPath, skipFile), s.Skip)
+}
+
+// Clean removes the files of the bisect session.
+func Clean(rootGoitPath string) error {
+	for _, name := range []string{startFile, badFile, goodFile, skipFile, logFile} {
+		if err := os.Remove(filepath.Join(rootGoitPath, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// AppendLog records the lines to BISECT_LOG, which can be replayed later.
+func AppendLog(rootGoitPath string, lines ...string) error {
+	f, err := os.OpenFile(filepath.Join(rootGoitPath, logFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("fail to open %s: %w", logFile, err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			return fmt.Errorf("fail to write %s: %w", logFile, err)
+		}
+	}
+	return nil
+}
+
+// ReadLog returns the content of BISECT_LOG.
+func ReadLog(rootGoitPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(rootGoitPath, logFile))
+	if os.IsNotExist(err) {
+		return "", ErrNotBisecting
+	}
+	if err != nil {
+		return "", fmt.Errorf("fail to read %s: %w", logFile, err)
+	}
+	return string(data), nil
+}
+
+// IsReady tells whether both the bad commit and a good commit are known.
+func (s *State) IsReady() bool {
+	return s.Bad != nil && len(s.Good) > 0
+}
+
+// Result is the outcome of a bisection step.
+type Result struct {
+	Next      sha.Hash // the commit to test next, nil when the search is over
+	Remaining int      // the number of the revisions left to test after Next
+	Steps     int      // the rough number of the steps left after Next
+	FirstBad  sha.Hash // the first bad commit when it is found
+	// the commits which can be the first bad commit when only the skipped commits are left
+	Candidates []sha.Hash
+}
+
+// bitset of the candidate commits
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+func (b bitset) or(other bitset) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+func (b bitset) count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Next picks the commit which halves the candidates of the first bad commit best.
+// The candidates are the commits reachable from the bad commit and not reachable from any good commit.
+// The number of the candidates reachable from each candidate is computed at once over the graph in
+// the topological order with the bitsets, instead of walking the history from every candidate.
+func Next(g CommitGetter, s *State) (*Result, error) {
+	// the commits known to be good
+	goodSet := make(map[string]bool)
+	stack := append([]sha.Hash{}, s.Good...)
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if goodSet[hash.String()] {
+			continue
+		}
+		goodSet[hash.String()] = true
+		commit, err := g.Commit(hash)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, commit.Parents...)
+	}
+	if goodSet[s.Bad.String()] {
+		return nil, ErrGoodNotAncestor
+	}
+
+	// collect the candidates in the order from the bad commit, and in the topological order
+	// where every parent comes before its children
+	var order, topo []*object.Commit
+	index := make(map[string]int)
+	visited := make(map[string]bool)
+	queue := []sha.Hash{s.Bad}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if visited[hash.String()] || goodSet[hash.String()] {
+			continue
+		}
+		visited[hash.String()] = true
+		commit, err := g.Commit(hash)
+		if err != nil {
+			return nil, err
+		}
+		order = append(order, commit)
+		queue = append(queue, commit.Parents...)
+	}
+	isCandidate := make(map[string]*object.Commit)
+	for _, commit := range order {
+		isCandidate[commit.Hash.String()] = commit
+	}
+	done := make(map[string]bool)
+	var visit func(commit *object.Commit)
+	visit = func(commit *object.Commit) {
+		if done[commit.Hash.String()] {
+			return
+		}
+		done[commit.Hash.String()] = true
+		for _, parentHash := range commit.Parents {
+			if parent, ok := isCandidate[parentHash.String()]; ok {
+				visit(parent)
+			}
+		}
+		index[commit.Hash.String()] = len(topo)
+		topo = append(topo, commit)
+	}
+	visit(order[0])
+
+	n := len(topo)
+	if n == 1 {
+		return &Result{FirstBad: s.Bad}, nil
+	}
+
+	// the number of the candidates reachable from each candidate including itself
+	reach := make([]bitset, n)
+	for i, commit := range topo {
+		reach[i] = newBitset(n)
+		reach[i].set(i)
+		for _, parentHash := range commit.Parents {
+			if j, ok := index[parentHash.String()]; ok {
+				reach[i].or(reach[j])
+			}
+		}
+	}
+
+	isSkipped := make(map[string]bool)
+	for _, hash := range s.Skip {
+		isSkipped[hash.String()] = true
+	}
+	var best *object.Commit
+	bestScore, bestWeight := -1, 0
+	for _, commit := range order {
+		if commit.Hash.Compare(s.Bad) || isSkipped[commit.Hash.String()] {
+			continue
+		}
+		weight := reach[index[commit.Hash.String()]].count()
+		score := weight
+		if n-weight < score {
+			score = n - weight
+		}
+		if score > bestScore {
+			best, bestScore, bestWeight = commit, score, weight
+		}
+	}
+	if best == nil {
+		// only the skipped commits are left between the good and the bad
+		result := &Result{}
+		for _, commit := range order {
+			result.Candidates = append(result.Candidates, commit.Hash)
+		}
+		return result, nil
+	}
+
+	remaining := n - bestWeight - 1
+	if bestWeight-1 > remaining {
+		remaining = bestWeight - 1
+	}
+	return &Result{
+		Next:      best.Hash,
+		Remaining: remaining,
+		Steps:     estimateSteps(n),
+	}, nil
+}
+
+// estimate the number of the steps to bisect the candidates in the same way as git.
+func estimateSteps(all int) int {
+	if all < 3 {
+		return 0
+	}
+	n := bits.Len(uint(all)) - 1
+	e := 1 << n
+	x := all - e
+	if e < 3*x {
+		return n
+	}
+	return n - 1
+}
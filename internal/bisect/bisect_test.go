@@ -0,0 +1,175 @@
+package bisect
+
+import (
+	"crypto/sha1"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+type testGraph map[string]*object.Commit
+
+func (g testGraph) Commit(hash sha.Hash) (*object.Commit, error) {
+	return g[hash.String()], nil
+}
+
+func hashOf(name string) sha.Hash {
+	sum := sha1.Sum([]byte(name))
+	return sha.Hash(sum[:])
+}
+
+// add the commit of the name whose parents are given by the names.
+func (g testGraph) add(name string, parents ...string) {
+	commit := &object.Commit{
+		Object: &object.Object{Hash: hashOf(name)},
+	}
+	for _, parent := range parents {
+		commit.Parents = append(commit.Parents, hashOf(parent))
+	}
+	g[commit.Hash.String()] = commit
+}
+
+// make the linear history c0 <- c1 <- ... <- c9
+func linearGraph() testGraph {
+	g := testGraph{}
+	g.add("c0")
+	for i := 1; i < 10; i++ {
+		g.add("c"+string(rune('0'+i)), "c"+string(rune('0'+i-1)))
+	}
+	return g
+}
+
+func TestNext(t *testing.T) {
+	type test struct {
+		name          string
+		graph         testGraph
+		state         *State
+		wantNext      sha.Hash
+		wantFirstBad  sha.Hash
+		wantRemaining int
+		wantErr       error
+	}
+	tests := []*test{
+		{
+			name:          "success: halve the linear history",
+			graph:         linearGraph(),
+			state:         &State{Bad: hashOf("c9"), Good: []sha.Hash{hashOf("c0")}},
+			wantNext:      hashOf("c5"),
+			wantRemaining: 4,
+		},
+		{
+			name:          "success: avoid the skipped commit",
+			graph:         linearGraph(),
+			state:         &State{Bad: hashOf("c9"), Good: []sha.Hash{hashOf("c0")}, Skip: []sha.Hash{hashOf("c5")}},
+			wantNext:      hashOf("c4"),
+			wantRemaining: 4,
+		},
+		{
+			name:         "success: first bad commit",
+			graph:        linearGraph(),
+			state:        &State{Bad: hashOf("c5"), Good: []sha.Hash{hashOf("c4")}},
+			wantFirstBad: hashOf("c5"),
+		},
+		{
+			name: "success: merged branches",
+			graph: func() testGraph {
+				// base <- a1 <- a2 <- merge, base <- b1 <- b2 <- merge
+				g := testGraph{}
+				g.add("base")
+				g.add("a1", "base")
+				g.add("a2", "a1")
+				g.add("b1", "base")
+				g.add("b2", "b1")
+				g.add("merge", "a2", "b2")
+				return g
+			}(),
+			state:         &State{Bad: hashOf("merge"), Good: []sha.Hash{hashOf("base")}},
+			wantNext:      hashOf("a2"),
+			wantRemaining: 2,
+		},
+		{
+			name:    "fail: bad is an ancestor of good",
+			graph:   linearGraph(),
+			state:   &State{Bad: hashOf("c3"), Good: []sha.Hash{hashOf("c5")}},
+			wantErr: ErrGoodNotAncestor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Next(tt.graph, tt.state)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Next, tt.wantNext) {
+				t.Errorf("got next = %s, want = %s", got.Next, tt.wantNext)
+			}
+			if !reflect.DeepEqual(got.FirstBad, tt.wantFirstBad) {
+				t.Errorf("got first bad = %s, want = %s", got.FirstBad, tt.wantFirstBad)
+			}
+			if got.Remaining != tt.wantRemaining {
+				t.Errorf("got remaining = %d, want = %d", got.Remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestNextOnlySkipped(t *testing.T) {
+	state := &State{
+		Bad:  hashOf("c3"),
+		Good: []sha.Hash{hashOf("c1")},
+		Skip: []sha.Hash{hashOf("c2")},
+	}
+	got, err := Next(linearGraph(), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sha.Hash{hashOf("c3"), hashOf("c2")}
+	if got.Next != nil || !reflect.DeepEqual(got.Candidates, want) {
+		t.Errorf("got = %v, want = %v", got.Candidates, want)
+	}
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	rootGoitPath := t.TempDir()
+	if IsInProgress(rootGoitPath) {
+		t.Fatal("bisect is in progress before start")
+	}
+	if _, err := Load(rootGoitPath); !errors.Is(err, ErrNotBisecting) {
+		t.Errorf("got = %v, want = %v", err, ErrNotBisecting)
+	}
+
+	want := &State{
+		Start: "main",
+		Bad:   hashOf("c9"),
+		Good:  []sha.Hash{hashOf("c0"), hashOf("c1")},
+	}
+	if err := want.Save(rootGoitPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendLog(rootGoitPath, "goit bisect start", "goit bisect bad "+hashOf("c9").String()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(rootGoitPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+
+	if err := Clean(rootGoitPath); err != nil {
+		t.Fatal(err)
+	}
+	if IsInProgress(rootGoitPath) {
+		t.Error("bisect is in progress after clean")
+	}
+	if _, err := ReadLog(rootGoitPath); !errors.Is(err, ErrNotBisecting) {
+		t.Errorf("got = %v, want = %v", err, ErrNotBisecting)
+	}
+}
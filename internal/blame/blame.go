@@ -0,0 +1,488 @@
+package blame
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/JunNishimura/Goit/internal/checkout"
+	"github.com/JunNishimura/Goit/internal/diff"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+var (
+	ErrNoSuchPath   = errors.New("no such path")
+	ErrInvalidRange = errors.New("invalid line range")
+	ErrNotAncestor  = errors.New("not an ancestor")
+)
+
+// Repository is the history which blame walks through.
+type Repository interface {
+	Commit(hash sha.Hash) (*object.Commit, error)
+	// Files returns the blob hash of each file in the commit.
+	Files(hash sha.Hash) (map[string]sha.Hash, error)
+	Blob(hash sha.Hash) ([]byte, error)
+}
+
+type objectRepository struct {
+	rootGoitPath string
+}
+
+// NewRepository returns the repository which reads the objects under .goit.
+func NewRepository(rootGoitPath string) Repository {
+	return &objectRepository{
+		rootGoitPath: rootGoitPath,
+	}
+}
+
+func (r *objectRepository) Commit(hash sha.Hash) (*object.Commit, error) {
+	obj, err := object.GetObject(r.rootGoitPath, hash)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewCommit(obj)
+}
+
+func (r *objectRepository) Files(hash sha.Hash) (map[string]sha.Hash, error) {
+	return checkout.TreeFiles(r.rootGoitPath, hash)
+}
+
+func (r *objectRepository) Blob(hash sha.Hash) ([]byte, error) {
+	obj, err := object.GetObject(r.rootGoitPath, hash)
+	if err != nil {
+		return nil, err
+	}
+	return obj.Data, nil
+}
+
+// Options configures the blame.
+type Options struct {
+	Start            int  // the first line to blame, 1-based and 0 for the head of the file
+	End              int  // the last line to blame, inclusive and 0 for the end of the file
+	IgnoreWhitespace bool // lines differing only in whitespace are regarded as unchanged
+	Threshold        int  // similarity in percentage to follow renames
+}
+
+// Line is the line of the file with the commit it is attributed to.
+type Line struct {
+	Commit     *object.Commit
+	Path       string // the path of the file in the commit
+	OrigLine   int    // the line number in the commit, 1-based
+	FinalLine  int    // the line number in the blamed file, 1-based
+	Text       string
+	IsBoundary bool // the commit is the root of the history
+}
+
+// a line waiting to be attributed, whose number is 0-based in the version of the suspect.
+type pending struct {
+	final  int
+	lineNo int
+}
+
+// a version of the file which may have introduced the pending lines.
+type suspect struct {
+	commit *object.Commit
+	path   string
+	lines  []*pending
+}
+
+type blamer struct {
+	repo    Repository
+	opts    *Options
+	files   map[string]map[string]sha.Hash
+	commits map[string]*object.Commit
+}
+
+func newBlamer(repo Repository, opts *Options) *blamer {
+	return &blamer{
+		repo:    repo,
+		opts:    opts,
+		files:   make(map[string]map[string]sha.Hash),
+		commits: make(map[string]*object.Commit),
+	}
+}
+
+func (b *blamer) commit(hash sha.Hash) (*object.Commit, error) {
+	if commit, ok := b.commits[hash.String()]; ok {
+		return commit, nil
+	}
+	commit, err := b.repo.Commit(hash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get commit %s: %w", hash, err)
+	}
+	b.commits[hash.String()] = commit
+	return commit, nil
+}
+
+func (b *blamer) treeFiles(hash sha.Hash) (map[string]sha.Hash, error) {
+	if files, ok := b.files[hash.String()]; ok {
+		return files, nil
+	}
+	files, err := b.repo.Files(hash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get files of %s: %w", hash, err)
+	}
+	b.files[hash.String()] = files
+	return files, nil
+}
+
+func (b *blamer) lines(hash sha.Hash) ([]string, error) {
+	data, err := b.repo.Blob(hash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get blob %s: %w", hash, err)
+	}
+	return diff.SplitLines(string(data)), nil
+}
+
+// find the path in the old files which is renamed to the path in the new files.
+func (b *blamer) renamedFrom(oldFiles, newFiles map[string]sha.Hash, path string) (string, error) {
+	if _, ok := oldFiles[path]; ok {
+		return path, nil
+	}
+	changes, err := diff.DetectRenames(diff.Changes(oldFiles, newFiles), oldFiles, &diff.RenameOptions{
+		Threshold: b.opts.Threshold,
+		Load:      b.repo.Blob,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, c := range changes {
+		if c.Status == diff.Renamed && c.NewPath == path {
+			return c.OldPath, nil
+		}
+	}
+	return "", nil
+}
+
+// find the path in the new files which the path in the old files is renamed to.
+func (b *blamer) renamedTo(oldFiles, newFiles map[string]sha.Hash, path string) (string, error) {
+	if _, ok := newFiles[path]; ok {
+		return path, nil
+	}
+	changes, err := diff.DetectRenames(diff.Changes(oldFiles, newFiles), oldFiles, &diff.RenameOptions{
+		Threshold: b.opts.Threshold,
+		Load:      b.repo.Blob,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, c := range changes {
+		if c.Status == diff.Renamed && c.OldPath == path {
+			return c.NewPath, nil
+		}
+	}
+	return "", nil
+}
+
+func normalize(lines []string, ignoreWhitespace bool) []string {
+	if !ignoreWhitespace {
+		return lines
+	}
+	normalized := make([]string, len(lines))
+	for i, line := range lines {
+		normalized[i] = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, line)
+	}
+	return normalized
+}
+
+// map the unchanged lines of the new lines to the lines of the old lines.
+func (b *blamer) lineMap(oldLines, newLines []string) map[int]int {
+	m := make(map[int]int)
+	for _, e := range diff.Diff(normalize(oldLines, b.opts.IgnoreWhitespace), normalize(newLines, b.opts.IgnoreWhitespace)) {
+		if e.Type == diff.Equal {
+			m[e.NewLine] = e.OldLine
+		}
+	}
+	return m
+}
+
+// select the lines to blame by the range of the options.
+func (b *blamer) initialLines(lineCount int, path string) ([]*pending, error) {
+	start, end := b.opts.Start, b.opts.End
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 {
+		end = lineCount
+	}
+	if start > lineCount || end > lineCount || start > end {
+		return nil, fmt.Errorf("%w: file %s has only %d lines", ErrInvalidRange, path, lineCount)
+	}
+	lines := make([]*pending, 0, end-start+1)
+	for i := start - 1; i < end; i++ {
+		lines = append(lines, &pending{final: i, lineNo: i})
+	}
+	return lines, nil
+}
+
+func suspectKey(hash sha.Hash, path string) string {
+	return hash.String() + "\x00" + path
+}
+
+// Blame attributes each line of the file at the commit to the commit which introduced it.
+// The lines not changed from a parent are passed to the parent, following renames, until they reach
+// the commit which changed them. The commits are visited from the newest committer date.
+func Blame(repo Repository, commitHash sha.Hash, path string, opts *Options) ([]*Line, error) {
+	b := newBlamer(repo, opts)
+	commit, err := b.commit(commitHash)
+	if err != nil {
+		return nil, err
+	}
+	files, err := b.treeFiles(commitHash)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := files[path]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s' in %s", ErrNoSuchPath, path, commitHash)
+	}
+	finalLines, err := b.lines(hash)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := b.initialLines(len(finalLines), path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Line, len(finalLines))
+	queue := []*suspect{{commit: commit, path: path, lines: initial}}
+	waiting := map[string]*suspect{suspectKey(commit.Hash, path): queue[0]}
+	for len(queue) > 0 {
+		// pick the newest suspect
+		newest := 0
+		for i, s := range queue {
+			if s.commit.Committer.Timestamp.After(queue[newest].commit.Committer.Timestamp) {
+				newest = i
+			}
+		}
+		s := queue[newest]
+		queue = append(queue[:newest], queue[newest+1:]...)
+		delete(waiting, suspectKey(s.commit.Hash, s.path))
+
+		files, err := b.treeFiles(s.commit.Hash)
+		if err != nil {
+			return nil, err
+		}
+		lines, err := b.lines(files[s.path])
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := s.lines
+		for _, parentHash := range s.commit.Parents {
+			if len(remaining) == 0 {
+				break
+			}
+			parentFiles, err := b.treeFiles(parentHash)
+			if err != nil {
+				return nil, err
+			}
+			parentPath, err := b.renamedFrom(parentFiles, files, s.path)
+			if err != nil {
+				return nil, err
+			}
+			if parentPath == "" {
+				continue
+			}
+			parentLines, err := b.lines(parentFiles[parentPath])
+			if err != nil {
+				return nil, err
+			}
+			m := b.lineMap(parentLines, lines)
+
+			var passed, kept []*pending
+			for _, p := range remaining {
+				if old, ok := m[p.lineNo]; ok {
+					passed = append(passed, &pending{final: p.final, lineNo: old})
+				} else {
+					kept = append(kept, p)
+				}
+			}
+			remaining = kept
+			if len(passed) == 0 {
+				continue
+			}
+
+			key := suspectKey(parentHash, parentPath)
+			if ps, ok := waiting[key]; ok {
+				ps.lines = append(ps.lines, passed...)
+				continue
+			}
+			parent, err := b.commit(parentHash)
+			if err != nil {
+				return nil, err
+			}
+			ps := &suspect{commit: parent, path: parentPath, lines: passed}
+			waiting[key] = ps
+			queue = append(queue, ps)
+		}
+
+		// the lines not passed to any parent are introduced by the commit
+		for _, p := range remaining {
+			result[p.final] = &Line{
+				Commit:     s.commit,
+				Path:       s.path,
+				OrigLine:   p.lineNo + 1,
+				FinalLine:  p.final + 1,
+				Text:       finalLines[p.final],
+				IsBoundary: len(s.commit.Parents) == 0,
+			}
+		}
+	}
+
+	return collect(result), nil
+}
+
+// Reverse attributes each line of the file at the commit from to the last commit in which the line exists,
+// walking forward along the first parents to the commit to.
+func Reverse(repo Repository, from, to sha.Hash, path string, opts *Options) ([]*Line, error) {
+	b := newBlamer(repo, opts)
+
+	// the first parent chain from the commit to back to the commit from
+	var chain []*object.Commit
+	for hash := to; ; {
+		commit, err := b.commit(hash)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]*object.Commit{commit}, chain...)
+		if hash.Compare(from) {
+			break
+		}
+		if len(commit.Parents) == 0 {
+			return nil, fmt.Errorf("%w: %s is not an ancestor of %s", ErrNotAncestor, from, to)
+		}
+		hash = commit.Parents[0]
+	}
+
+	files, err := b.treeFiles(chain[0].Hash)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := files[path]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s' in %s", ErrNoSuchPath, path, from)
+	}
+	finalLines, err := b.lines(hash)
+	if err != nil {
+		return nil, err
+	}
+	alive, err := b.initialLines(len(finalLines), path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Line, len(finalLines))
+	attribute := func(commit *object.Commit, path string, lines []*pending) {
+		for _, p := range lines {
+			result[p.final] = &Line{
+				Commit:    commit,
+				Path:      path,
+				OrigLine:  p.lineNo + 1,
+				FinalLine: p.final + 1,
+				Text:      finalLines[p.final],
+			}
+		}
+	}
+
+	prevLines := finalLines
+	for i := 1; i < len(chain) && len(alive) > 0; i++ {
+		nextFiles, err := b.treeFiles(chain[i].Hash)
+		if err != nil {
+			return nil, err
+		}
+		nextPath, err := b.renamedTo(files, nextFiles, path)
+		if err != nil {
+			return nil, err
+		}
+		if nextPath == "" {
+			// the file is deleted
+			attribute(chain[i-1], path, alive)
+			alive = nil
+			break
+		}
+		nextLines, err := b.lines(nextFiles[nextPath])
+		if err != nil {
+			return nil, err
+		}
+
+		// map the old lines to the new lines
+		m := make(map[int]int)
+		for n, o := range b.lineMap(prevLines, nextLines) {
+			m[o] = n
+		}
+		var survived, dead []*pending
+		for _, p := range alive {
+			if n, ok := m[p.lineNo]; ok {
+				survived = append(survived, &pending{final: p.final, lineNo: n})
+			} else {
+				dead = append(dead, p)
+			}
+		}
+		attribute(chain[i-1], path, dead)
+		alive = survived
+		files, path, prevLines = nextFiles, nextPath, nextLines
+	}
+	attribute(chain[len(chain)-1], path, alive)
+
+	return collect(result), nil
+}
+
+func collect(result []*Line) []*Line {
+	lines := make([]*Line, 0, len(result))
+	for _, line := range result {
+		if line != nil {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// ParseRange parses the argument of -L such as "3,10", "3,+5", "3," and ",10",
+// and returns the 1-based inclusive range, where 0 stands for the head or the end of the file.
+func ParseRange(spec string) (int, int, error) {
+	startString, endString, ok := strings.Cut(spec, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, spec)
+	}
+
+	start := 0
+	if startString != "" {
+		n, err := strconv.Atoi(startString)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, spec)
+		}
+		start = n
+	}
+
+	end := 0
+	switch {
+	case endString == "":
+	case strings.HasPrefix(endString, "+"):
+		n, err := strconv.Atoi(endString[1:])
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, spec)
+		}
+		if start == 0 {
+			start = 1
+		}
+		end = start + n - 1
+	default:
+		n, err := strconv.Atoi(endString)
+		if err != nil || n < 1 || n < start {
+			return 0, 0, fmt.Errorf("%w: %s", ErrInvalidRange, spec)
+		}
+		end = n
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,190 @@
+package blame
+
+import (
+	"crypto/sha1"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// in-memory history for the tests.
+type testRepository struct {
+	commits map[string]*object.Commit
+	files   map[string]map[string]sha.Hash
+	blobs   map[string][]byte
+}
+
+func newTestRepository() *testRepository {
+	return &testRepository{
+		commits: make(map[string]*object.Commit),
+		files:   make(map[string]map[string]sha.Hash),
+		blobs:   make(map[string][]byte),
+	}
+}
+
+func hashOf(s string) sha.Hash {
+	sum := sha1.Sum([]byte(s))
+	return sha.Hash(sum[:])
+}
+
+// add the commit with the files, whose committer date is the order of the addition.
+func (r *testRepository) commit(name string, files map[string]string, parents ...*object.Commit) *object.Commit {
+	commit := &object.Commit{
+		Object:  &object.Object{Hash: hashOf(name)},
+		Message: name,
+		Committer: object.Sign{
+			Name:      "test",
+			Timestamp: time.Unix(int64(len(r.commits)+1), 0),
+		},
+	}
+	for _, parent := range parents {
+		commit.Parents = append(commit.Parents, parent.Hash)
+	}
+	treeFiles := make(map[string]sha.Hash)
+	for path, content := range files {
+		hash := hashOf("blob " + content)
+		r.blobs[hash.String()] = []byte(content)
+		treeFiles[path] = hash
+	}
+	r.commits[commit.Hash.String()] = commit
+	r.files[commit.Hash.String()] = treeFiles
+	return commit
+}
+
+func (r *testRepository) Commit(hash sha.Hash) (*object.Commit, error) {
+	return r.commits[hash.String()], nil
+}
+
+func (r *testRepository) Files(hash sha.Hash) (map[string]sha.Hash, error) {
+	return r.files[hash.String()], nil
+}
+
+func (r *testRepository) Blob(hash sha.Hash) ([]byte, error) {
+	return r.blobs[hash.String()], nil
+}
+
+// summarize the lines as "commit:path:origLine".
+func summarize(lines []*Line) []string {
+	var got []string
+	for _, line := range lines {
+		got = append(got, line.Commit.Message+":"+line.Path+":"+string(rune('0'+line.OrigLine)))
+	}
+	return got
+}
+
+func TestBlame(t *testing.T) {
+	repo := newTestRepository()
+	first := repo.commit("first", map[string]string{"a.txt": "a\nb\nc\nd\ne\nf\n"})
+	second := repo.commit("second", map[string]string{"a.txt": "a\nB\nc\nd\ne\nf\n"}, first)
+	renamed := repo.commit("renamed", map[string]string{"b.txt": "a\nB\nc\nd\ne\nf\ng\n"}, second)
+	spaced := repo.commit("spaced", map[string]string{"b.txt": "a\nB\n  c\nd\ne\nf\ng\n"}, renamed)
+
+	type test struct {
+		name    string
+		opts    *Options
+		want    []string
+		wantErr error
+	}
+	tests := []*test{
+		{
+			name: "success: follow rename",
+			opts: &Options{Threshold: 50},
+			want: []string{"first:a.txt:1", "second:a.txt:2", "spaced:b.txt:3", "first:a.txt:4", "first:a.txt:5", "first:a.txt:6", "renamed:b.txt:7"},
+		},
+		{
+			name: "success: ignore whitespace with range",
+			opts: &Options{Threshold: 50, IgnoreWhitespace: true, Start: 2, End: 3},
+			want: []string{"second:a.txt:2", "first:a.txt:3"},
+		},
+		{
+			name:    "fail: out of range",
+			opts:    &Options{Threshold: 50, Start: 5, End: 10},
+			wantErr: ErrInvalidRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Blame(repo, spaced.Hash, "b.txt", tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got := summarize(lines); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlameMerge(t *testing.T) {
+	repo := newTestRepository()
+	base := repo.commit("base", map[string]string{"a.txt": "a\nb\nc\n"})
+	left := repo.commit("left", map[string]string{"a.txt": "A\nb\nc\n"}, base)
+	right := repo.commit("right", map[string]string{"a.txt": "a\nb\nC\n"}, base)
+	merge := repo.commit("merge", map[string]string{"a.txt": "A\nb\nC\n"}, left, right)
+
+	lines, err := Blame(repo, merge.Hash, "a.txt", &Options{Threshold: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"left:a.txt:1", "base:a.txt:2", "right:a.txt:3"}
+	if got := summarize(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+	if !lines[1].IsBoundary || lines[0].IsBoundary {
+		t.Errorf("boundary is not marked on the root commit")
+	}
+}
+
+func TestReverse(t *testing.T) {
+	repo := newTestRepository()
+	first := repo.commit("first", map[string]string{"a.txt": "a\nb\nc\n"})
+	second := repo.commit("second", map[string]string{"a.txt": "a\nc\n"}, first)
+	renamed := repo.commit("renamed", map[string]string{"b.txt": "a\nc\nd\n"}, second)
+	last := repo.commit("last", map[string]string{"b.txt": "c\nd\n"}, renamed)
+
+	lines, err := Reverse(repo, first.Hash, last.Hash, "a.txt", &Options{Threshold: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"renamed:b.txt:1", "first:a.txt:2", "last:b.txt:1"}
+	if got := summarize(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+
+	if _, err := Reverse(repo, last.Hash, first.Hash, "a.txt", &Options{}); !errors.Is(err, ErrNotAncestor) {
+		t.Errorf("got = %v, want = %v", err, ErrNotAncestor)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	type test struct {
+		spec      string
+		wantStart int
+		wantEnd   int
+		wantErr   error
+	}
+	tests := []*test{
+		{spec: "3,10", wantStart: 3, wantEnd: 10},
+		{spec: "3,+5", wantStart: 3, wantEnd: 7},
+		{spec: "3,", wantStart: 3, wantEnd: 0},
+		{spec: ",10", wantStart: 0, wantEnd: 10},
+		{spec: "10,3", wantErr: ErrInvalidRange},
+		{spec: "3", wantErr: ErrInvalidRange},
+		{spec: "a,b", wantErr: ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			start, end, err := ParseRange(tt.spec)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("got = %d,%d, want = %d,%d", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
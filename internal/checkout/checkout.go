@@ -0,0 +1,425 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/diff"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+var (
+	ErrUnmergedIndex = errors.New("you need to resolve your current index first")
+)
+
+// Options controls how the local changes are handled when the working tree is switched.
+type Options struct {
+	Force       bool   // throw away the local changes
+	Merge       bool   // three-way merge the local changes into the new tree
+	OursLabel   string // conflict marker label of the new tree
+	TheirsLabel string // conflict marker label of the local changes
+}
+
+// LocalChangesError tells the paths whose local changes would be overwritten by the switch.
+type LocalChangesError struct {
+	Modified  []string
+	Untracked []string
+}
+
+func (e *LocalChangesError) Error() string {
+	var reasons []string
+	if len(e.Modified) > 0 {
+		reasons = append(reasons, fmt.Sprintf("local changes to %s would be overwritten by checkout", strings.Join(e.Modified, ", ")))
+	}
+	if len(e.Untracked) > 0 {
+		reasons = append(reasons, fmt.Sprintf("untracked working tree files %s would be overwritten by checkout", strings.Join(e.Untracked, ", ")))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Change is a local change which remains in the index or the working tree after the switch.
+type Change struct {
+	Status byte // 'A', 'M', 'D' or 'U' for the unmerged path
+	Path   string
+}
+
+// Result reports what the switch did.
+type Result struct {
+	Updated   []string  // paths written to the working tree
+	Removed   []string  // paths removed from the working tree
+	Conflicts []string  // paths left with conflict stages by the merge
+	Changes   []*Change // local changes carried to the new tree
+}
+
+type actionType int
+
+const (
+	actionKeep      actionType = iota // leave the path as it is
+	actionCheckout                    // write the new blob to the index and the working tree
+	actionIndexOnly                   // write the new blob only to the index
+	actionMerge                       // merge the local change into the new blob
+	actionUnindex                     // remove the path only from the index
+)
+
+type action struct {
+	typ  actionType
+	path string
+	head sha.Hash // nil if not in the current tree
+	to   sha.Hash // nil if not in the new tree
+	work sha.Hash // nil if not in the working tree
+}
+
+// TreeFiles returns the blobs of the tree of the commit keyed by their paths.
+// An empty map is returned if hash is nil, which is the case of the unborn branch.
+func TreeFiles(rootGoitPath string, hash sha.Hash) (map[string]sha.Hash, error) {
+	if hash == nil {
+		return map[string]sha.Hash{}, nil
+	}
+	commitObject, err := object.GetObject(rootGoitPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get commit object: %w", err)
+	}
+	commit, err := object.NewCommit(commitObject)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get commit: %w", err)
+	}
+	treeObject, err := object.GetObject(rootGoitPath, commit.Tree)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tree object: %w", err)
+	}
+	tree, err := object.NewTree(rootGoitPath, treeObject)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tree: %w", err)
+	}
+	return tree.Files(), nil
+}
+
+// Switch moves the index and the working tree from the files of the current tree to the files of the new tree.
+// Only the paths which differ between the two trees are touched, and the local changes of the other paths are carried.
+// Nothing is changed and LocalChangesError is returned if a local change would be overwritten.
+func Switch(rootGoitPath string, index *store.Index, from, to map[string]sha.Hash, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	workDir := filepath.Dir(rootGoitPath)
+
+	unmerged := make(map[string]bool)
+	for _, path := range index.UnmergedPaths() {
+		unmerged[path] = true
+	}
+	if len(unmerged) > 0 && !opts.Force {
+		return nil, ErrUnmergedIndex
+	}
+
+	// collect every path of the current tree, the new tree and the index
+	pathSet := make(map[string]struct{})
+	for path := range from {
+		pathSet[path] = struct{}{}
+	}
+	for path := range to {
+		pathSet[path] = struct{}{}
+	}
+	for _, entry := range index.Entries {
+		pathSet[string(entry.Path)] = struct{}{}
+	}
+	paths := make([]string, 0, len(pathSet))
+	for path := range pathSet {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// plan all actions before touching anything
+	var actions []*action
+	localErr := &LocalChangesError{}
+	result := &Result{}
+	for _, path := range paths {
+		h, t := from[path], to[path]
+		var i sha.Hash
+		if entry, ok := index.GetStageEntry([]byte(path), 0); ok {
+			i = entry.Hash
+		}
+		w, err := workingTreeHash(index.Format(), workDir, path)
+		if err != nil {
+			return nil, err
+		}
+		act := &action{path: path, head: h, to: t, work: w}
+
+		switch {
+		case opts.Force:
+			if !unmerged[path] && equal(i, t) && equal(w, t) {
+				continue
+			}
+			if t == nil && h == nil {
+				// the file only added to the index is left untracked
+				act.typ = actionUnindex
+			} else {
+				act.typ = actionCheckout
+			}
+		case equal(h, t):
+			// the path is not changed by the switch, so carry the local change if any
+			if change := localChange(path, h, i, w); change != nil {
+				result.Changes = append(result.Changes, change)
+			}
+			continue
+		case equal(i, h) && equal(w, i):
+			act.typ = actionCheckout
+		case equal(w, t) && (equal(i, h) || equal(i, t)):
+			act.typ = actionIndexOnly
+		case equal(i, t):
+			// the index already matches the new tree, so keep the working tree as it is
+			if change := localChange(path, t, i, w); change != nil {
+				result.Changes = append(result.Changes, change)
+			}
+			continue
+		case opts.Merge:
+			act.typ = actionMerge
+		case h == nil && i == nil:
+			localErr.Untracked = append(localErr.Untracked, path)
+			continue
+		default:
+			localErr.Modified = append(localErr.Modified, path)
+			continue
+		}
+		actions = append(actions, act)
+	}
+	if len(localErr.Modified) > 0 || len(localErr.Untracked) > 0 {
+		return nil, localErr
+	}
+
+	// remove the files first so that directories can replace them
+	for _, act := range actions {
+		if act.typ != actionCheckout || act.to != nil {
+			continue
+		}
+		index.Put([]byte(act.path))
+		if act.work != nil {
+			if err := removeFile(workDir, act.path); err != nil {
+				return nil, err
+			}
+			result.Removed = append(result.Removed, act.path)
+		}
+	}
+	for _, act := range actions {
+		switch act.typ {
+		case actionCheckout:
+			if act.to == nil {
+				continue
+			}
+			if err := writeBlob(rootGoitPath, workDir, act.path, act.to); err != nil {
+				return nil, err
+			}
+			index.Put([]byte(act.path), store.NewEntry(act.to, []byte(act.path)))
+			result.Updated = append(result.Updated, act.path)
+		case actionIndexOnly:
+			if act.to == nil {
+				index.Put([]byte(act.path))
+			} else {
+				index.Put([]byte(act.path), store.NewEntry(act.to, []byte(act.path)))
+			}
+		case actionUnindex:
+			index.Put([]byte(act.path))
+		case actionMerge:
+			if err := mergeLocalChange(rootGoitPath, workDir, index, act, opts, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := index.Write(rootGoitPath); err != nil {
+		return nil, fmt.Errorf("fail to write index: %w", err)
+	}
+
+	return result, nil
+}
+
+// merge the local change of the path into the blob of the new tree.
+func mergeLocalChange(rootGoitPath, workDir string, index *store.Index, act *action, opts *Options, result *Result) error {
+	path := []byte(act.path)
+
+	// both sides removed the file
+	if act.to == nil && act.work == nil {
+		index.Put(path)
+		return nil
+	}
+
+	var stages []*store.Entry
+	addStage := func(hash sha.Hash, stage int) {
+		if hash == nil {
+			return
+		}
+		entry := store.NewEntry(hash, path)
+		entry.Stage = stage
+		stages = append(stages, entry)
+	}
+
+	// one side removed the file which the other side modified
+	if act.to == nil || act.work == nil {
+		if act.work != nil {
+			if err := writeWorkingTreeBlob(index.Format(), rootGoitPath, workDir, act.path); err != nil {
+				return err
+			}
+		} else if err := writeBlob(rootGoitPath, workDir, act.path, act.to); err != nil {
+			return err
+		}
+		addStage(act.head, 1)
+		addStage(act.to, 2)
+		addStage(act.work, 3)
+		index.Put(path, stages...)
+		result.Conflicts = append(result.Conflicts, act.path)
+		result.Changes = append(result.Changes, &Change{Status: 'U', Path: act.path})
+		return nil
+	}
+
+	var base string
+	if act.head != nil {
+		data, err := readBlob(rootGoitPath, act.head)
+		if err != nil {
+			return err
+		}
+		base = string(data)
+	}
+	ours, err := readBlob(rootGoitPath, act.to)
+	if err != nil {
+		return err
+	}
+	theirs, err := os.ReadFile(filepath.Join(workDir, act.path))
+	if err != nil {
+		return fmt.Errorf("fail to read %s: %w", act.path, err)
+	}
+
+	merged := diff.Merge(base, string(ours), string(theirs), opts.OursLabel, opts.TheirsLabel)
+	if merged.IsConflicted() {
+		// keep the local version as a blob so that it can be restored from the stage
+		if err := writeWorkingTreeBlob(index.Format(), rootGoitPath, workDir, act.path); err != nil {
+			return err
+		}
+		addStage(act.head, 1)
+		addStage(act.to, 2)
+		addStage(act.work, 3)
+		index.Put(path, stages...)
+		result.Conflicts = append(result.Conflicts, act.path)
+		result.Changes = append(result.Changes, &Change{Status: 'U', Path: act.path})
+	} else {
+		index.Put(path, store.NewEntry(act.to, path))
+		result.Changes = append(result.Changes, &Change{Status: 'M', Path: act.path})
+	}
+	if err := writeFile(workDir, act.path, []byte(merged.Text)); err != nil {
+		return err
+	}
+	result.Updated = append(result.Updated, act.path)
+
+	return nil
+}
+
+// return the local change of the path against the tree blob, or nil if there is no change.
+func localChange(path string, tree, index, work sha.Hash) *Change {
+	switch {
+	case equal(tree, index) && equal(index, work):
+		return nil
+	case tree == nil && index != nil:
+		return &Change{Status: 'A', Path: path}
+	case index == nil || work == nil:
+		return &Change{Status: 'D', Path: path}
+	default:
+		return &Change{Status: 'M', Path: path}
+	}
+}
+
+func equal(a, b sha.Hash) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Compare(b)
+}
+
+// return the blob hash of the file in the working tree, or nil if the file does not exist.
+func workingTreeHash(format *sha.Format, workDir, path string) (sha.Hash, error) {
+	filePath := filepath.Join(workDir, path)
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		// a directory never matches any blob
+		return sha.Hash{}, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read %s: %w", path, err)
+	}
+	obj, err := object.NewObject(format, object.BlobObject, data)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get new object: %w", err)
+	}
+	return obj.Hash, nil
+}
+
+func readBlob(rootGoitPath string, hash sha.Hash) ([]byte, error) {
+	obj, err := object.GetObject(rootGoitPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get object: %w", err)
+	}
+	return obj.Data, nil
+}
+
+func writeBlob(rootGoitPath, workDir, path string, hash sha.Hash) error {
+	data, err := readBlob(rootGoitPath, hash)
+	if err != nil {
+		return err
+	}
+	return writeFile(workDir, path, data)
+}
+
+// store the file in the working tree as a blob object.
+func writeWorkingTreeBlob(format *sha.Format, rootGoitPath, workDir, path string) error {
+	data, err := os.ReadFile(filepath.Join(workDir, path))
+	if err != nil {
+		return fmt.Errorf("fail to read %s: %w", path, err)
+	}
+	obj, err := object.NewObject(format, object.BlobObject, data)
+	if err != nil {
+		return fmt.Errorf("fail to get new object: %w", err)
+	}
+	if err := obj.Write(rootGoitPath); err != nil {
+		return fmt.Errorf("fail to write object: %w", err)
+	}
+	return nil
+}
+
+func writeFile(workDir, path string, data []byte) error {
+	filePath := filepath.Join(workDir, path)
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		return fmt.Errorf("fail to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// remove the file and the directories left empty by the removal.
+func removeFile(workDir, path string) error {
+	filePath := filepath.Join(workDir, path)
+	if err := os.RemoveAll(filePath); err != nil {
+		return fmt.Errorf("fail to remove %s: %w", path, err)
+	}
+	for dir := filepath.Dir(filePath); dir != workDir && strings.HasPrefix(dir, workDir); dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package checkout
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+// write a blob object of the content and return its hash.
+func writeTestBlob(t *testing.T, rootGoitPath, content string) sha.Hash {
+	t.Helper()
+	obj, err := object.NewObject(sha.SHA1Format, object.BlobObject, []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.Write(rootGoitPath); err != nil {
+		t.Fatal(err)
+	}
+	return obj.Hash
+}
+
+type fixture struct {
+	goitDir string
+	workDir string
+	index   *store.Index
+}
+
+// set up the repository whose index and working tree match the files.
+func newFixture(t *testing.T, files map[string]string) (*fixture, map[string]sha.Hash) {
+	t.Helper()
+	workDir := t.TempDir()
+	goitDir := filepath.Join(workDir, ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "objects"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	index, err := store.NewIndex(goitDir, sha.SHA1Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fixture{goitDir: goitDir, workDir: workDir, index: index}
+	hashes := make(map[string]sha.Hash)
+	for path, content := range files {
+		hashes[path] = writeTestBlob(t, goitDir, content)
+		f.writeFile(t, path, content)
+		index.Put([]byte(path), store.NewEntry(hashes[path], []byte(path)))
+	}
+	return f, hashes
+}
+
+func (f *fixture) writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	filePath := filepath.Join(f.workDir, path)
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fixture) readFile(t *testing.T, path string) (string, bool) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(f.workDir, path))
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func TestSwitch(t *testing.T) {
+	t.Run("success: update only the changed files", func(t *testing.T) {
+		f, from := newFixture(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n", "dir/c.txt": "c\n"})
+		to := map[string]sha.Hash{
+			"a.txt":     from["a.txt"],
+			"b.txt":     writeTestBlob(t, f.goitDir, "b2\n"),
+			"new/d.txt": writeTestBlob(t, f.goitDir, "d\n"),
+		}
+		result, err := Switch(f.goitDir, f.index, from, to, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"b.txt", "new/d.txt"}; !reflect.DeepEqual(result.Updated, want) {
+			t.Errorf("got = %v, want = %v", result.Updated, want)
+		}
+		if want := []string{"dir/c.txt"}; !reflect.DeepEqual(result.Removed, want) {
+			t.Errorf("got = %v, want = %v", result.Removed, want)
+		}
+		if got, _ := f.readFile(t, "b.txt"); got != "b2\n" {
+			t.Errorf("got = %q, want = %q", got, "b2\n")
+		}
+		if _, err := os.Stat(filepath.Join(f.workDir, "dir")); !os.IsNotExist(err) {
+			t.Errorf("empty directory is not removed")
+		}
+		if len(f.index.Entries) != 3 {
+			t.Errorf("got = %d, want = %d", len(f.index.Entries), 3)
+		}
+	})
+
+	t.Run("success: carry the local change of the unchanged file", func(t *testing.T) {
+		f, from := newFixture(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+		f.writeFile(t, "a.txt", "local\n")
+		to := map[string]sha.Hash{
+			"a.txt": from["a.txt"],
+			"b.txt": writeTestBlob(t, f.goitDir, "b2\n"),
+		}
+		result, err := Switch(f.goitDir, f.index, from, to, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []*Change{{Status: 'M', Path: "a.txt"}}; !reflect.DeepEqual(result.Changes, want) {
+			t.Errorf("got = %v, want = %v", result.Changes, want)
+		}
+		if got, _ := f.readFile(t, "a.txt"); got != "local\n" {
+			t.Errorf("got = %q, want = %q", got, "local\n")
+		}
+	})
+
+	t.Run("fail: local change would be overwritten", func(t *testing.T) {
+		f, from := newFixture(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+		f.writeFile(t, "a.txt", "local\n")
+		f.writeFile(t, "c.txt", "untracked\n")
+		to := map[string]sha.Hash{
+			"a.txt": writeTestBlob(t, f.goitDir, "a2\n"),
+			"b.txt": writeTestBlob(t, f.goitDir, "b2\n"),
+			"c.txt": writeTestBlob(t, f.goitDir, "c\n"),
+		}
+		_, err := Switch(f.goitDir, f.index, from, to, nil)
+		var localErr *LocalChangesError
+		if !errors.As(err, &localErr) {
+			t.Fatalf("got = %v, want = LocalChangesError", err)
+		}
+		if want := []string{"a.txt"}; !reflect.DeepEqual(localErr.Modified, want) {
+			t.Errorf("got = %v, want = %v", localErr.Modified, want)
+		}
+		if want := []string{"c.txt"}; !reflect.DeepEqual(localErr.Untracked, want) {
+			t.Errorf("got = %v, want = %v", localErr.Untracked, want)
+		}
+		// nothing is touched
+		if got, _ := f.readFile(t, "b.txt"); got != "b\n" {
+			t.Errorf("got = %q, want = %q", got, "b\n")
+		}
+	})
+
+	t.Run("success: discard the local change by force", func(t *testing.T) {
+		f, from := newFixture(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+		f.writeFile(t, "a.txt", "local\n")
+		f.writeFile(t, "b.txt", "local\n")
+		to := map[string]sha.Hash{
+			"a.txt": from["a.txt"],
+			"b.txt": writeTestBlob(t, f.goitDir, "b2\n"),
+		}
+		if _, err := Switch(f.goitDir, f.index, from, to, &Options{Force: true}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := f.readFile(t, "a.txt"); got != "a\n" {
+			t.Errorf("got = %q, want = %q", got, "a\n")
+		}
+		if got, _ := f.readFile(t, "b.txt"); got != "b2\n" {
+			t.Errorf("got = %q, want = %q", got, "b2\n")
+		}
+	})
+
+	t.Run("success: merge the local change", func(t *testing.T) {
+		f, from := newFixture(t, map[string]string{"a.txt": "1\n2\n3\n4\n5\n"})
+		f.writeFile(t, "a.txt", "1\n2\n3\n4\nlocal\n")
+		to := map[string]sha.Hash{
+			"a.txt": writeTestBlob(t, f.goitDir, "new\n2\n3\n4\n5\n"),
+		}
+		result, err := Switch(f.goitDir, f.index, from, to, &Options{Merge: true, OursLabel: "new", TheirsLabel: "local"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Conflicts) != 0 {
+			t.Errorf("got = %v, want no conflict", result.Conflicts)
+		}
+		if got, _ := f.readFile(t, "a.txt"); got != "new\n2\n3\n4\nlocal\n" {
+			t.Errorf("got = %q, want = %q", got, "new\n2\n3\n4\nlocal\n")
+		}
+		if _, entry, _ := f.index.GetEntry([]byte("a.txt")); !entry.Hash.Compare(to["a.txt"]) || entry.Stage != 0 {
+			t.Errorf("index is not updated to the new tree")
+		}
+	})
+
+	t.Run("success: merge the local change with conflict", func(t *testing.T) {
+		f, from := newFixture(t, map[string]string{"a.txt": "a\n"})
+		f.writeFile(t, "a.txt", "local\n")
+		to := map[string]sha.Hash{
+			"a.txt": writeTestBlob(t, f.goitDir, "new\n"),
+		}
+		result, err := Switch(f.goitDir, f.index, from, to, &Options{Merge: true, OursLabel: "new", TheirsLabel: "local"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"a.txt"}; !reflect.DeepEqual(result.Conflicts, want) {
+			t.Errorf("got = %v, want = %v", result.Conflicts, want)
+		}
+		want := "<<<<<<< new\nnew\n=======\nlocal\n>>>>>>> local\n"
+		if got, _ := f.readFile(t, "a.txt"); got != want {
+			t.Errorf("got = %q, want = %q", got, want)
+		}
+		for stage := 1; stage <= 3; stage++ {
+			if _, ok := f.index.GetStageEntry([]byte("a.txt"), stage); !ok {
+				t.Errorf("stage %d is not found", stage)
+			}
+		}
+		// unmerged index blocks the next switch
+		if _, err := Switch(f.goitDir, f.index, to, from, nil); !errors.Is(err, ErrUnmergedIndex) {
+			t.Errorf("got = %v, want = %v", err, ErrUnmergedIndex)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type OpType int
+
+const (
+	Equal OpType = iota
+	Insert
+	Delete
+)
+
+// Edit is a single line operation to turn the old lines into the new lines.
+type Edit struct {
+	Type    OpType
+	OldLine int // index in the old lines, -1 for Insert
+	NewLine int // index in the new lines, -1 for Delete
+	Text    string
+}
+
+// Hunk is a region where the old lines [OldStart, OldEnd) are replaced with the new lines [NewStart, NewEnd).
+type Hunk struct {
+	OldStart int
+	OldEnd   int
+	NewStart int
+	NewEnd   int
+}
+
+// SplitLines splits the text into lines, keeping the line feed of each line.
+func SplitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Diff computes the shortest edit script from a to b with the Myers algorithm.
+func Diff(a, b []string) []*Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	// find the shortest path, remembering v of every step to backtrack later
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		vc := make([]int, len(v))
+		copy(vc, v)
+		trace = append(trace, vc)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// backtrack from the end to the start
+	edits := make([]*Edit, 0, n+m)
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		vd := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && vd[offset+k-1] < vd[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := vd[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, &Edit{Type: Equal, OldLine: x, NewLine: y, Text: a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, &Edit{Type: Insert, OldLine: -1, NewLine: y, Text: b[y]})
+		} else {
+			x--
+			edits = append(edits, &Edit{Type: Delete, OldLine: x, NewLine: -1, Text: a[x]})
+		}
+	}
+
+	// reverse into the forward order
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// Hunks groups the consecutive changes of the edit script into hunks.
+func Hunks(edits []*Edit) []*Hunk {
+	var hunks []*Hunk
+	oldPos, newPos := 0, 0
+	var cur *Hunk
+	for _, e := range edits {
+		switch e.Type {
+		case Equal:
+			if cur != nil {
+				hunks = append(hunks, cur)
+				cur = nil
+			}
+			oldPos++
+			newPos++
+		case Delete:
+			if cur == nil {
+				cur = &Hunk{OldStart: oldPos, OldEnd: oldPos, NewStart: newPos, NewEnd: newPos}
+			}
+			oldPos++
+			cur.OldEnd = oldPos
+		case Insert:
+			if cur == nil {
+				cur = &Hunk{OldStart: oldPos, OldEnd: oldPos, NewStart: newPos, NewEnd: newPos}
+			}
+			newPos++
+			cur.NewEnd = newPos
+		}
+	}
+	if cur != nil {
+		hunks = append(hunks, cur)
+	}
+	return hunks
+}
+
+// Format renders the hunk made from the old lines a to the new lines b in the unified diff format without context lines.
+func (h *Hunk) Format(a, b []string) string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldEnd), hunkRange(h.NewStart, h.NewEnd)))
+	writeLines := func(mark string, lines []string) {
+		for _, line := range lines {
+			s.WriteString(mark + line)
+			if !strings.HasSuffix(line, "\n") {
+				s.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	writeLines("-", a[h.OldStart:h.OldEnd])
+	writeLines("+", b[h.NewStart:h.NewEnd])
+	return s.String()
+}
+
+// return the range of the hunk as "start,count", where start is the line before the hunk if the hunk is empty.
+func hunkRange(start, end int) string {
+	count := end - start
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// Revert turns the selected hunks made from the old lines a to the new lines b back into the old lines
+// and returns the text of the new lines with the change.
+func Revert(a, b []string, hunks []*Hunk, selected func(i int) bool) string {
+	var s strings.Builder
+	pos := 0
+	for i, h := range hunks {
+		if !selected(i) {
+			continue
+		}
+		s.WriteString(strings.Join(b[pos:h.NewStart], ""))
+		s.WriteString(strings.Join(a[h.OldStart:h.OldEnd], ""))
+		pos = h.NewEnd
+	}
+	s.WriteString(strings.Join(b[pos:], ""))
+	return s.String()
+}
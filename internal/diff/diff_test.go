@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// apply the edit script to the old lines and return the new lines.
+func applyEdits(edits []*Edit) []string {
+	lines := []string{}
+	for _, e := range edits {
+		if e.Type != Delete {
+			lines = append(lines, e.Text)
+		}
+	}
+	return lines
+}
+
+func TestDiff(t *testing.T) {
+	type test struct {
+		name      string
+		a         string
+		b         string
+		wantEdits int // number of non-equal edits
+	}
+	tests := []*test{
+		{
+			name:      "success: same",
+			a:         "a\nb\nc\n",
+			b:         "a\nb\nc\n",
+			wantEdits: 0,
+		},
+		{
+			name:      "success: insert",
+			a:         "a\nc\n",
+			b:         "a\nb\nc\n",
+			wantEdits: 1,
+		},
+		{
+			name:      "success: delete",
+			a:         "a\nb\nc\n",
+			b:         "a\nc\n",
+			wantEdits: 1,
+		},
+		{
+			name:      "success: replace",
+			a:         "a\nb\nc\nd\n",
+			b:         "a\nx\nc\ny\n",
+			wantEdits: 4,
+		},
+		{
+			name:      "success: from empty",
+			a:         "",
+			b:         "a\nb\n",
+			wantEdits: 2,
+		},
+		{
+			name:      "success: to empty",
+			a:         "a\nb\n",
+			b:         "",
+			wantEdits: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SplitLines(tt.a), SplitLines(tt.b)
+			edits := Diff(a, b)
+			if got := applyEdits(edits); !reflect.DeepEqual(got, b) {
+				t.Errorf("got = %v, want = %v", got, b)
+			}
+			n := 0
+			for _, e := range edits {
+				if e.Type != Equal {
+					n++
+				}
+			}
+			if n != tt.wantEdits {
+				t.Errorf("got = %d, want = %d", n, tt.wantEdits)
+			}
+		})
+	}
+}
+
+func TestHunks(t *testing.T) {
+	a := SplitLines("a\nb\nc\nd\ne\n")
+	b := SplitLines("a\nx\nc\nd\ne\nf\n")
+	got := Hunks(Diff(a, b))
+	want := []*Hunk{
+		{OldStart: 1, OldEnd: 2, NewStart: 1, NewEnd: 2},
+		{OldStart: 5, OldEnd: 5, NewStart: 5, NewEnd: 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	type test struct {
+		name         string
+		base         string
+		ours         string
+		theirs       string
+		want         string
+		wantConflict int
+	}
+	tests := []*test{
+		{
+			name:         "success: separate changes",
+			base:         "a\nb\nc\nd\ne\n",
+			ours:         "A\nb\nc\nd\ne\n",
+			theirs:       "a\nb\nc\nd\nE\n",
+			want:         "A\nb\nc\nd\nE\n",
+			wantConflict: 0,
+		},
+		{
+			name:         "success: same change",
+			base:         "a\nb\nc\n",
+			ours:         "a\nB\nc\n",
+			theirs:       "a\nB\nc\n",
+			want:         "a\nB\nc\n",
+			wantConflict: 0,
+		},
+		{
+			name:         "success: insert and delete",
+			base:         "a\nb\nc\nd\ne\n",
+			ours:         "a\nb\nx\nc\nd\ne\n",
+			theirs:       "a\nb\nc\nd\n",
+			want:         "a\nb\nx\nc\nd\n",
+			wantConflict: 0,
+		},
+		{
+			name:         "fail: conflict",
+			base:         "a\nb\nc\n",
+			ours:         "a\nours\nc\n",
+			theirs:       "a\ntheirs\nc\n",
+			want:         "a\n<<<<<<< main\nours\n=======\ntheirs\n>>>>>>> local\nc\n",
+			wantConflict: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(tt.base, tt.ours, tt.theirs, "main", "local")
+			if got.Text != tt.want {
+				t.Errorf("got = %q, want = %q", got.Text, tt.want)
+			}
+			if got.ConflictCount != tt.wantConflict {
+				t.Errorf("got = %d, want = %d", got.ConflictCount, tt.wantConflict)
+			}
+			if got.IsConflicted() != strings.Contains(got.Text, "<<<<<<<") {
+				t.Errorf("conflict markers do not match the conflict count")
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	a := SplitLines("a\nb\nc\n")
+	b := SplitLines("a\nx\ny\nc\nd")
+	hunks := Hunks(Diff(a, b))
+	var got []string
+	for _, h := range hunks {
+		got = append(got, h.Format(a, b))
+	}
+	want := []string{
+		"@@ -2 +2,2 @@\n-b\n+x\n+y\n",
+		"@@ -3,0 +5 @@\n+d\n\\ No newline at end of file\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+}
+
+func TestRevert(t *testing.T) {
+	a := SplitLines("a\nb\nc\nd\n")
+	b := SplitLines("A\nb\nc\nD\n")
+	hunks := Hunks(Diff(a, b))
+	type test struct {
+		name     string
+		selected func(i int) bool
+		want     string
+	}
+	tests := []*test{
+		{
+			name:     "success: none",
+			selected: func(i int) bool { return false },
+			want:     "A\nb\nc\nD\n",
+		},
+		{
+			name:     "success: second",
+			selected: func(i int) bool { return i == 1 },
+			want:     "A\nb\nc\nd\n",
+		},
+		{
+			name:     "success: all",
+			selected: func(i int) bool { return true },
+			want:     "a\nb\nc\nd\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Revert(a, b, hunks, tt.selected); got != tt.want {
+				t.Errorf("got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
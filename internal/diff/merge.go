@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	conflictMarkerSize = 7
+)
+
+// MergeResult is the result of the three-way merge.
+type MergeResult struct {
+	Text          string
+	ConflictCount int
+}
+
+func (r *MergeResult) IsConflicted() bool {
+	return r.ConflictCount > 0
+}
+
+// the hunk of either side, which replaces base lines with the side lines.
+type sideHunk struct {
+	*Hunk
+	side int // 0 for ours, 1 for theirs
+}
+
+// Merge merges the changes from base to ours and from base to theirs line by line.
+// Overlapping changes which differ are marked with conflict markers labeled with oursLabel and theirsLabel.
+func Merge(base, ours, theirs, oursLabel, theirsLabel string) *MergeResult {
+	baseLines := SplitLines(base)
+	sideLines := [2][]string{SplitLines(ours), SplitLines(theirs)}
+
+	var hunks []*sideHunk
+	for side, lines := range sideLines {
+		for _, h := range Hunks(Diff(baseLines, lines)) {
+			hunks = append(hunks, &sideHunk{Hunk: h, side: side})
+		}
+	}
+	sort.SliceStable(hunks, func(i, j int) bool { return hunks[i].OldStart < hunks[j].OldStart })
+
+	result := &MergeResult{}
+	var b strings.Builder
+	basePos := 0
+	// difference between the side line number and the base line number outside of the hunks
+	var delta [2]int
+	for i := 0; i < len(hunks); {
+		// collect the hunks which overlap or touch each other into one region
+		regionStart, regionEnd := hunks[i].OldStart, hunks[i].OldEnd
+		j := i + 1
+		for j < len(hunks) && hunks[j].OldStart <= regionEnd {
+			if hunks[j].OldEnd > regionEnd {
+				regionEnd = hunks[j].OldEnd
+			}
+			j++
+		}
+		group := hunks[i:j]
+
+		// copy the unchanged lines before the region
+		for _, line := range baseLines[basePos:regionStart] {
+			b.WriteString(line)
+		}
+
+		// the range of each side corresponding to the region
+		var sideRange [2][2]int
+		var isChanged [2]bool
+		for side := 0; side < 2; side++ {
+			start, end := regionStart+delta[side], regionEnd+delta[side]
+			var first, last *sideHunk
+			for _, h := range group {
+				if h.side != side {
+					continue
+				}
+				if first == nil {
+					first = h
+				}
+				last = h
+			}
+			if first != nil {
+				isChanged[side] = true
+				start = first.NewStart - (first.OldStart - regionStart)
+				end = last.NewEnd + (regionEnd - last.OldEnd)
+				delta[side] = end - regionEnd
+			}
+			sideRange[side] = [2]int{start, end}
+		}
+		oursText := strings.Join(sideLines[0][sideRange[0][0]:sideRange[0][1]], "")
+		theirsText := strings.Join(sideLines[1][sideRange[1][0]:sideRange[1][1]], "")
+
+		switch {
+		case !isChanged[1]:
+			b.WriteString(oursText)
+		case !isChanged[0]:
+			b.WriteString(theirsText)
+		case oursText == theirsText:
+			// both sides made the same change
+			b.WriteString(oursText)
+		default:
+			result.ConflictCount++
+			b.WriteString(strings.Repeat("<", conflictMarkerSize) + " " + oursLabel + "\n")
+			b.WriteString(withLineFeed(oursText))
+			b.WriteString(strings.Repeat("=", conflictMarkerSize) + "\n")
+			b.WriteString(withLineFeed(theirsText))
+			b.WriteString(strings.Repeat(">", conflictMarkerSize) + " " + theirsLabel + "\n")
+		}
+
+		basePos = regionEnd
+		i = j
+	}
+	for _, line := range baseLines[basePos:] {
+		b.WriteString(line)
+	}
+
+	result.Text = b.String()
+	return result
+}
+
+// make sure the text inside conflict markers ends with a line feed.
+func withLineFeed(text string) string {
+	if text == "" || strings.HasSuffix(text, "\n") {
+		return text
+	}
+	return text + "\n"
+}
@@ -0,0 +1,282 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	Added    = 'A'
+	Deleted  = 'D'
+	Modified = 'M'
+	Renamed  = 'R'
+	Copied   = 'C'
+
+	// DefaultSimilarity is the minimum similarity in percentage to detect renames and copies.
+	DefaultSimilarity = 50
+)
+
+var (
+	ErrInvalidSimilarity = errors.New("invalid similarity")
+)
+
+// FileChange is a change of a single file between two trees.
+// OldPath is empty for Added, and NewPath is empty for Deleted.
+type FileChange struct {
+	Status  byte
+	OldPath string
+	NewPath string
+	OldHash sha.Hash
+	NewHash sha.Hash
+	Score   int // similarity in percentage for Renamed and Copied
+}
+
+// Path returns the path after the change, or the deleted path.
+func (c *FileChange) Path() string {
+	if c.Status == Deleted {
+		return c.OldPath
+	}
+	return c.NewPath
+}
+
+// NameStatus returns the change in the format of --name-status such as "M\tpath" and "R087\told\tnew".
+func (c *FileChange) NameStatus() string {
+	if c.Status == Renamed || c.Status == Copied {
+		return fmt.Sprintf("%c%03d\t%s\t%s", c.Status, c.Score, c.OldPath, c.NewPath)
+	}
+	return fmt.Sprintf("%c\t%s", c.Status, c.Path())
+}
+
+// RenameOptions configures the detection of renames and copies.
+type RenameOptions struct {
+	Threshold  int  // minimum similarity in percentage
+	FindCopies bool // detect copies from any file of the old tree as well
+	// Load returns the content of the blob, which is needed to score the similarity.
+	Load func(hash sha.Hash) ([]byte, error)
+}
+
+// Changes returns the changes from the old files to the new files, which map the path to the blob hash.
+// The changes are sorted by path.
+func Changes(oldFiles, newFiles map[string]sha.Hash) []*FileChange {
+	var changes []*FileChange
+	for path, oldHash := range oldFiles {
+		newHash, ok := newFiles[path]
+		if !ok {
+			changes = append(changes, &FileChange{Status: Deleted, OldPath: path, OldHash: oldHash})
+		} else if !oldHash.Compare(newHash) {
+			changes = append(changes, &FileChange{Status: Modified, OldPath: path, NewPath: path, OldHash: oldHash, NewHash: newHash})
+		}
+	}
+	for path, newHash := range newFiles {
+		if _, ok := oldFiles[path]; !ok {
+			changes = append(changes, &FileChange{Status: Added, NewPath: path, NewHash: newHash})
+		}
+	}
+	sortChanges(changes)
+	return changes
+}
+
+func sortChanges(changes []*FileChange) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path() < changes[j].Path()
+	})
+}
+
+// DetectRenames pairs the deleted files and the added files into renames, exact matches of the hash first
+// and then by the similarity of the contents. With FindCopies, the added files which are not renamed
+// are paired with the files in oldFiles as copies.
+func DetectRenames(changes []*FileChange, oldFiles map[string]sha.Hash, opts *RenameOptions) ([]*FileChange, error) {
+	var deleted, added []*FileChange
+	for _, c := range changes {
+		switch c.Status {
+		case Deleted:
+			deleted = append(deleted, c)
+		case Added:
+			added = append(added, c)
+		}
+	}
+	if len(added) == 0 {
+		return changes, nil
+	}
+
+	paired := make(map[*FileChange]*FileChange) // added -> source
+	score := make(map[*FileChange]int)
+	isUsed := make(map[*FileChange]bool) // deleted files already renamed
+
+	// exact renames
+	for _, a := range added {
+		for _, d := range deleted {
+			if !isUsed[d] && d.OldHash.Compare(a.NewHash) {
+				paired[a], score[a], isUsed[d] = d, 100, true
+				break
+			}
+		}
+	}
+
+	// inexact renames by the content similarity
+	contents := make(map[string][]byte)
+	load := func(hash sha.Hash) ([]byte, error) {
+		if data, ok := contents[hash.String()]; ok {
+			return data, nil
+		}
+		data, err := opts.Load(hash)
+		if err != nil {
+			return nil, err
+		}
+		contents[hash.String()] = data
+		return data, nil
+	}
+	type candidate struct {
+		src   *FileChange
+		dst   *FileChange
+		score int
+	}
+	var candidates []*candidate
+	for _, a := range added {
+		if paired[a] != nil {
+			continue
+		}
+		for _, d := range deleted {
+			if isUsed[d] {
+				continue
+			}
+			s, err := similarityOf(load, d.OldHash, a.NewHash)
+			if err != nil {
+				return nil, err
+			}
+			if s >= opts.Threshold {
+				candidates = append(candidates, &candidate{src: d, dst: a, score: s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	for _, c := range candidates {
+		if paired[c.dst] != nil || isUsed[c.src] {
+			continue
+		}
+		paired[c.dst], score[c.dst], isUsed[c.src] = c.src, c.score, true
+	}
+
+	// copies from any file of the old tree
+	copied := make(map[*FileChange]string)
+	if opts.FindCopies {
+		oldPaths := make([]string, 0, len(oldFiles))
+		for path := range oldFiles {
+			oldPaths = append(oldPaths, path)
+		}
+		sort.Strings(oldPaths)
+		for _, a := range added {
+			if paired[a] != nil {
+				continue
+			}
+			bestPath, bestScore := "", -1
+			for _, path := range oldPaths {
+				s := 100
+				if !oldFiles[path].Compare(a.NewHash) {
+					var err error
+					s, err = similarityOf(load, oldFiles[path], a.NewHash)
+					if err != nil {
+						return nil, err
+					}
+				}
+				if s >= opts.Threshold && s > bestScore {
+					bestPath, bestScore = path, s
+				}
+			}
+			if bestPath != "" {
+				copied[a], score[a] = bestPath, bestScore
+			}
+		}
+	}
+
+	var result []*FileChange
+	for _, c := range changes {
+		switch {
+		case c.Status == Deleted && isUsed[c]:
+			continue
+		case c.Status == Added && paired[c] != nil:
+			src := paired[c]
+			result = append(result, &FileChange{Status: Renamed, OldPath: src.OldPath, NewPath: c.NewPath, OldHash: src.OldHash, NewHash: c.NewHash, Score: score[c]})
+		case c.Status == Added && copied[c] != "":
+			src := copied[c]
+			result = append(result, &FileChange{Status: Copied, OldPath: src, NewPath: c.NewPath, OldHash: oldFiles[src], NewHash: c.NewHash, Score: score[c]})
+		default:
+			result = append(result, c)
+		}
+	}
+	sortChanges(result)
+	return result, nil
+}
+
+func similarityOf(load func(sha.Hash) ([]byte, error), oldHash, newHash sha.Hash) (int, error) {
+	a, err := load(oldHash)
+	if err != nil {
+		return 0, err
+	}
+	b, err := load(newHash)
+	if err != nil {
+		return 0, err
+	}
+	return Similarity(a, b), nil
+}
+
+// Similarity scores how much of the contents is kept from a to b in percentage.
+// It is the size of the lines common to both divided by the size of the larger one.
+func Similarity(a, b []byte) int {
+	if bytes.Equal(a, b) {
+		return 100
+	}
+	maxSize := len(a)
+	if len(b) > maxSize {
+		maxSize = len(b)
+	}
+	if maxSize == 0 {
+		return 100
+	}
+
+	lineCount := make(map[string]int)
+	for _, line := range SplitLines(string(a)) {
+		lineCount[line]++
+	}
+	common := 0
+	for _, line := range SplitLines(string(b)) {
+		if lineCount[line] > 0 {
+			lineCount[line]--
+			common += len(line)
+		}
+	}
+	score := common * 100 / maxSize
+	// only the identical contents score 100
+	if score >= 100 {
+		score = 99
+	}
+	return score
+}
+
+// ParseSimilarity parses the similarity given to -M and -C such as "90%" and "9".
+// The digits without "%" are read as the fraction after the decimal point like git, so "9" is 90%.
+func ParseSimilarity(s string) (int, error) {
+	if s == "" {
+		return DefaultSimilarity, nil
+	}
+	if strings.HasSuffix(s, "%") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		if err != nil || n < 0 || n > 100 {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidSimilarity, s)
+		}
+		return n, nil
+	}
+	f, err := strconv.ParseFloat("0."+s, 64)
+	if err != nil || strings.ContainsAny(s, "+-.eE") {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSimilarity, s)
+	}
+	return int(f*100 + 0.5), nil
+}
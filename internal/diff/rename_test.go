@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"crypto/sha1"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// make the hash of the content and register the content to the blobs.
+func blobHash(blobs map[string][]byte, content string) sha.Hash {
+	sum := sha1.Sum([]byte(content))
+	hash := sha.Hash(sum[:])
+	blobs[hash.String()] = []byte(content)
+	return hash
+}
+
+func TestDetectRenames(t *testing.T) {
+	blobs := make(map[string][]byte)
+	long := "line1\nline2\nline3\nline4\nline5\nline6\nline7\nline8\nline9\nline10\n"
+	opts := func(findCopies bool) *RenameOptions {
+		return &RenameOptions{
+			Threshold:  DefaultSimilarity,
+			FindCopies: findCopies,
+			Load: func(hash sha.Hash) ([]byte, error) {
+				return blobs[hash.String()], nil
+			},
+		}
+	}
+	type test struct {
+		name       string
+		oldFiles   map[string]sha.Hash
+		newFiles   map[string]sha.Hash
+		findCopies bool
+		want       []string
+	}
+	tests := []*test{
+		{
+			name:     "success: exact rename",
+			oldFiles: map[string]sha.Hash{"a.txt": blobHash(blobs, long)},
+			newFiles: map[string]sha.Hash{"b.txt": blobHash(blobs, long)},
+			want:     []string{"R100\ta.txt\tb.txt"},
+		},
+		{
+			name:     "success: inexact rename",
+			oldFiles: map[string]sha.Hash{"a.txt": blobHash(blobs, long)},
+			newFiles: map[string]sha.Hash{"dir/a.txt": blobHash(blobs, long+"line11\n")},
+			want:     []string{"R089\ta.txt\tdir/a.txt"},
+		},
+		{
+			name:     "success: below threshold",
+			oldFiles: map[string]sha.Hash{"a.txt": blobHash(blobs, long)},
+			newFiles: map[string]sha.Hash{"b.txt": blobHash(blobs, "other\n")},
+			want:     []string{"D\ta.txt", "A\tb.txt"},
+		},
+		{
+			name: "success: copy",
+			oldFiles: map[string]sha.Hash{
+				"a.txt": blobHash(blobs, long),
+			},
+			newFiles: map[string]sha.Hash{
+				"a.txt": blobHash(blobs, long),
+				"b.txt": blobHash(blobs, long),
+			},
+			findCopies: true,
+			want:       []string{"C100\ta.txt\tb.txt"},
+		},
+		{
+			name: "success: copy is not detected without option",
+			oldFiles: map[string]sha.Hash{
+				"a.txt": blobHash(blobs, long),
+			},
+			newFiles: map[string]sha.Hash{
+				"a.txt": blobHash(blobs, long),
+				"b.txt": blobHash(blobs, long),
+			},
+			want: []string{"A\tb.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := DetectRenames(Changes(tt.oldFiles, tt.newFiles), tt.oldFiles, opts(tt.findCopies))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.NameStatus())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSimilarity(t *testing.T) {
+	type test struct {
+		arg     string
+		want    int
+		wantErr error
+	}
+	tests := []*test{
+		{arg: "", want: DefaultSimilarity},
+		{arg: "90%", want: 90},
+		{arg: "9", want: 90},
+		{arg: "05", want: 5},
+		{arg: "abc", wantErr: ErrInvalidSimilarity},
+		{arg: "101%", wantErr: ErrInvalidSimilarity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := ParseSimilarity(tt.arg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %d, want = %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -83,3 +83,37 @@ func GetFilePathsUnderDirectoryWithIgnore(path string, index *store.Index, ignor
 
 	return filePaths, nil
 }
+
+// GetWorkingTreeFiles returns the files under the working tree which are not ignored.
+// The paths are slash separated and relative to the working tree root whatever the current directory is.
+func GetWorkingTreeFiles(workDir string, ignore *store.Ignore) ([]string, error) {
+	var filePaths []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		files, err := os.ReadDir(filepath.Join(workDir, filepath.FromSlash(dir)))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			filePath := file.Name()
+			if dir != "" {
+				filePath = fmt.Sprintf("%s/%s", dir, file.Name())
+			}
+			if filePath == ".goit" || ignore.Match(filePath, file.IsDir()) {
+				continue
+			}
+			if file.IsDir() {
+				if err := walk(filePath); err != nil {
+					return err
+				}
+			} else {
+				filePaths = append(filePaths, filePath)
+			}
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return filePaths, nil
+}
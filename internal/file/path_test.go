@@ -8,6 +8,7 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/JunNishimura/Goit/internal/sha"
 	"github.com/JunNishimura/Goit/internal/store"
 )
 
@@ -244,7 +245,7 @@ func TestGetFilePathsUnderDirectoryWithIgnore(t *testing.T) {
 			}
 			f.Close()
 
-			index, err := store.NewIndex(goitDir)
+			index, err := store.NewIndex(goitDir, sha.SHA1Format)
 			if err != nil {
 				t.Log(err)
 			}
@@ -274,3 +275,41 @@ func TestGetFilePathsUnderDirectoryWithIgnore(t *testing.T) {
 		})
 	}
 }
+
+func TestGetWorkingTreeFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	goitDir := filepath.Join(tmpDir, ".goit")
+	for _, dir := range []string{goitDir, filepath.Join(tmpDir, "dir", "dir2"), filepath.Join(tmpDir, "dir", "tmp")} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		".goitignore":      "tmp/\n*.log\n",
+		".goit/HEAD":       "ref: refs/heads/main",
+		"test.txt":         "",
+		"debug.log":        "",
+		"dir/test.txt":     "",
+		"dir/dir2/test.go": "",
+		"dir/tmp/cache":    "",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignore, err := store.NewIgnore(goitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the result does not depend on the current directory
+	got, err := GetWorkingTreeFiles(tmpDir, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".goitignore", "dir/dir2/test.go", "dir/test.txt", "test.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
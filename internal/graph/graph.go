@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// Graph walks the commit history, caching the commits it has read.
+type Graph struct {
+	rootGoitPath string
+	commits      map[string]*object.Commit
+}
+
+func New(rootGoitPath string) *Graph {
+	return &Graph{
+		rootGoitPath: rootGoitPath,
+		commits:      make(map[string]*object.Commit),
+	}
+}
+
+// Commit returns the commit of the hash. Annotated tags are peeled to the commit they point to.
+func (g *Graph) Commit(hash sha.Hash) (*object.Commit, error) {
+	if commit, ok := g.commits[hash.String()]; ok {
+		return commit, nil
+	}
+	obj, err := object.Peel(g.rootGoitPath, hash)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get object %s: %w", hash, err)
+	}
+	commit, err := object.NewCommit(obj)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get commit %s: %w", hash, err)
+	}
+	g.commits[hash.String()] = commit
+	g.commits[obj.Hash.String()] = commit
+	return commit, nil
+}
+
+// IsAncestor reports whether ancestor is reachable from descendant.
+// Every commit is an ancestor of itself.
+func (g *Graph) IsAncestor(ancestor, descendant sha.Hash) (bool, error) {
+	target, err := g.Commit(ancestor)
+	if err != nil {
+		return false, err
+	}
+	start, err := g.Commit(descendant)
+	if err != nil {
+		return false, err
+	}
+
+	visited := make(map[string]struct{})
+	queue := []*object.Commit{start}
+	for len(queue) > 0 {
+		commit := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[commit.Hash.String()]; ok {
+			continue
+		}
+		visited[commit.Hash.String()] = struct{}{}
+
+		if commit.Hash.Compare(target.Hash) {
+			return true, nil
+		}
+		for _, parentHash := range commit.Parents {
+			parent, err := g.Commit(parentHash)
+			if err != nil {
+				return false, err
+			}
+			queue = append(queue, parent)
+		}
+	}
+
+	return false, nil
+}
+
+// return the set of the commits reachable from the hash, including itself.
+func (g *Graph) reachable(hash sha.Hash) (map[string]struct{}, error) {
+	start, err := g.Commit(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := make(map[string]struct{})
+	stack := []*object.Commit{start}
+	for len(stack) > 0 {
+		commit := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := visited[commit.Hash.String()]; ok {
+			continue
+		}
+		visited[commit.Hash.String()] = struct{}{}
+
+		for _, parentHash := range commit.Parents {
+			parent, err := g.Commit(parentHash)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, parent)
+		}
+	}
+
+	return visited, nil
+}
+
+// AheadBehind returns the number of commits reachable from local but not from upstream,
+// and the number of commits reachable from upstream but not from local.
+func (g *Graph) AheadBehind(local, upstream sha.Hash) (int, int, error) {
+	localSet, err := g.reachable(local)
+	if err != nil {
+		return 0, 0, err
+	}
+	upstreamSet, err := g.reachable(upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ahead := 0
+	for hash := range localSet {
+		if _, ok := upstreamSet[hash]; !ok {
+			ahead++
+		}
+	}
+	behind := 0
+	for hash := range upstreamSet {
+		if _, ok := localSet[hash]; !ok {
+			behind++
+		}
+	}
+
+	return ahead, behind, nil
+}
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// write a commit object with the parents and return its hash.
+func writeCommit(t *testing.T, rootGoitPath, message string, parents ...sha.Hash) sha.Hash {
+	t.Helper()
+	data := "tree 87f3c49bccf2597484ece08746d3ee5defaba335\n"
+	for _, parent := range parents {
+		data += fmt.Sprintf("parent %s\n", parent)
+	}
+	data += "author Test Taro <test@example.com> 1700000000 +0900\ncommitter Test Taro <test@example.com> 1700000000 +0900\n\n" + message + "\n"
+	obj, err := object.NewObject(sha.SHA1Format, object.CommitObject, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.Write(rootGoitPath); err != nil {
+		t.Fatal(err)
+	}
+	return obj.Hash
+}
+
+func TestIsAncestor(t *testing.T) {
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "objects"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// root - a - merge
+	//      \ b /
+	//      \ c
+	root := writeCommit(t, goitDir, "root")
+	a := writeCommit(t, goitDir, "a", root)
+	b := writeCommit(t, goitDir, "b", root)
+	merge := writeCommit(t, goitDir, "merge", a, b)
+	c := writeCommit(t, goitDir, "c", root)
+
+	type test struct {
+		name       string
+		ancestor   sha.Hash
+		descendant sha.Hash
+		want       bool
+	}
+	tests := []*test{
+		{
+			name:       "success: itself",
+			ancestor:   a,
+			descendant: a,
+			want:       true,
+		},
+		{
+			name:       "success: first parent",
+			ancestor:   root,
+			descendant: merge,
+			want:       true,
+		},
+		{
+			name:       "success: second parent",
+			ancestor:   b,
+			descendant: merge,
+			want:       true,
+		},
+		{
+			name:       "success: not reachable",
+			ancestor:   c,
+			descendant: merge,
+			want:       false,
+		},
+		{
+			name:       "success: reversed",
+			ancestor:   merge,
+			descendant: root,
+			want:       false,
+		},
+	}
+	g := New(goitDir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.IsAncestor(tt.ancestor, tt.descendant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "objects"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// root - a1 - a2
+	//      \ b1
+	root := writeCommit(t, goitDir, "root")
+	a1 := writeCommit(t, goitDir, "a1", root)
+	a2 := writeCommit(t, goitDir, "a2", a1)
+	b1 := writeCommit(t, goitDir, "b1", root)
+
+	type test struct {
+		name       string
+		local      sha.Hash
+		upstream   sha.Hash
+		wantAhead  int
+		wantBehind int
+	}
+	tests := []*test{
+		{
+			name:       "success: same commit",
+			local:      a2,
+			upstream:   a2,
+			wantAhead:  0,
+			wantBehind: 0,
+		},
+		{
+			name:       "success: ahead",
+			local:      a2,
+			upstream:   root,
+			wantAhead:  2,
+			wantBehind: 0,
+		},
+		{
+			name:       "success: behind",
+			local:      a1,
+			upstream:   a2,
+			wantAhead:  0,
+			wantBehind: 1,
+		},
+		{
+			name:       "success: diverged",
+			local:      a2,
+			upstream:   b1,
+			wantAhead:  2,
+			wantBehind: 1,
+		},
+	}
+	g := New(goitDir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ahead, behind, err := g.AheadBehind(tt.local, tt.upstream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ahead != tt.wantAhead || behind != tt.wantBehind {
+				t.Errorf("got = (%d, %d), want = (%d, %d)", ahead, behind, tt.wantAhead, tt.wantBehind)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package grep
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/JunNishimura/Goit/internal/binary"
+)
+
+var (
+	ErrNoPattern      = errors.New("no pattern given")
+	ErrInvalidPattern = errors.New("invalid pattern")
+	ErrInvalidExpr    = errors.New("invalid expression")
+)
+
+// Options controls how each pattern is compiled.
+type Options struct {
+	IgnoreCase bool // -i
+	WordRegexp bool // -w, match only at the word boundaries
+	Extended   bool // -E, the extended regexp instead of the basic one
+	Fixed      bool // -F, the pattern is a fixed string
+}
+
+// Expr is the boolean expression of the patterns, which tells whether a line matches.
+type Expr interface {
+	Match(line string) bool
+}
+
+type patternExpr struct {
+	re *regexp.Regexp
+}
+
+func (e *patternExpr) Match(line string) bool {
+	return e.re.MatchString(line)
+}
+
+type andExpr struct {
+	left, right Expr
+}
+
+func (e *andExpr) Match(line string) bool {
+	return e.left.Match(line) && e.right.Match(line)
+}
+
+type orExpr struct {
+	left, right Expr
+}
+
+func (e *orExpr) Match(line string) bool {
+	return e.left.Match(line) || e.right.Match(line)
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+func (e *notExpr) Match(line string) bool {
+	return !e.expr.Match(line)
+}
+
+// convert the basic regexp into the extended one, where "?", "+", "{", "|", "(" and ")"
+// are ordinary characters unless they are escaped with a backslash.
+func basicToExtended(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '\\' && i+1 < len(pattern) {
+			next := pattern[i+1]
+			i++
+			if strings.IndexByte("?+{}|()", next) >= 0 {
+				sb.WriteByte(next)
+			} else {
+				sb.WriteByte('\\')
+				sb.WriteByte(next)
+			}
+			continue
+		}
+		if c == '[' {
+			// copy the bracket expression as it is
+			end := i + 1
+			if end < len(pattern) && pattern[end] == '^' {
+				end++
+			}
+			if end < len(pattern) && pattern[end] == ']' {
+				end++
+			}
+			for end < len(pattern) && pattern[end] != ']' {
+				end++
+			}
+			if end < len(pattern) {
+				sb.WriteString(pattern[i : end+1])
+				i = end
+				continue
+			}
+		}
+		if strings.IndexByte("?+{}|()", c) >= 0 {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// Compile compiles the pattern into the expression matching the lines containing it.
+func Compile(pattern string, opts Options) (Expr, error) {
+	switch {
+	case opts.Fixed:
+		pattern = regexp.QuoteMeta(pattern)
+	case !opts.Extended:
+		pattern = basicToExtended(pattern)
+	}
+	if opts.WordRegexp {
+		pattern = `(?:^|\W)(?:` + pattern + `)(?:\W|$)`
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPattern, err)
+	}
+	return &patternExpr{re: re}, nil
+}
+
+// TokenType is the kind of the element of the expression given on the command line.
+type TokenType int
+
+const (
+	PatternToken TokenType = iota
+	AndToken
+	OrToken
+	NotToken
+)
+
+// Token is the element of the expression, which is a pattern or an operator.
+type Token struct {
+	Type    TokenType
+	Pattern string
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+	opts   Options
+}
+
+// Parse builds the expression from the tokens. The operators are "--not", "--and" and "--or"
+// in the order of the precedence, and the adjacent patterns are joined by "--or" implicitly.
+func Parse(tokens []Token, opts Options) (Expr, error) {
+	if len(tokens) == 0 {
+		return nil, ErrNoPattern
+	}
+	p := &parser{tokens: tokens, opts: opts}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected operator at %d", ErrInvalidExpr, p.pos+1)
+	}
+	return expr, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) {
+		switch p.tokens[p.pos].Type {
+		case OrToken:
+			p.pos++
+		case PatternToken, NotToken:
+		default:
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && p.tokens[p.pos].Type == AndToken {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("%w: pattern expected at the end", ErrInvalidExpr)
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.Type {
+	case NotToken:
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: expr}, nil
+	case PatternToken:
+		return Compile(token.Pattern, p.opts)
+	default:
+		return nil, fmt.Errorf("%w: pattern expected at %d", ErrInvalidExpr, p.pos)
+	}
+}
+
+// Match is the line matching the expression.
+type Match struct {
+	LineNumber int // starting from 1
+	Line       string
+}
+
+// Result is the outcome of searching a file.
+type Result struct {
+	Path     string
+	IsBinary bool
+	Matches  []*Match
+}
+
+// Search returns the lines of the content matching the expression.
+func Search(expr Expr, path string, content []byte) *Result {
+	result := &Result{Path: path}
+	result.IsBinary = binary.IsBinary(content)
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		if expr.Match(line) {
+			result.Matches = append(result.Matches, &Match{
+				LineNumber: i + 1,
+				Line:       line,
+			})
+		}
+	}
+	return result
+}
+
+// Source is the file to search, whose content is loaded on demand.
+type Source struct {
+	Path string
+	Load func() ([]byte, error)
+}
+
+// Run loads and searches the sources on the goroutines, and returns the results of the
+// files which have any match in the same order as the sources.
+func Run(expr Expr, sources []*Source) ([]*Result, error) {
+	results := make([]*Result, len(sources))
+	errs := make([]error, len(sources))
+
+	workers := runtime.NumCPU()
+	if workers > len(sources) {
+		workers = len(sources)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				content, err := sources[i].Load()
+				if err != nil {
+					errs[i] = fmt.Errorf("fail to read %s: %w", sources[i].Path, err)
+					continue
+				}
+				results[i] = Search(expr, sources[i].Path, content)
+			}
+		}()
+	}
+	for i := range sources {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var matched []*Result
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if len(result.Matches) > 0 {
+			matched = append(matched, result)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,156 @@
+package grep
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	lines := []string{"foo bar", "foo", "bar", "foobar", "baz (x)"}
+	pattern := func(p string) Token {
+		return Token{Type: PatternToken, Pattern: p}
+	}
+	type args struct {
+		tokens []Token
+		opts   Options
+	}
+	type test struct {
+		name    string
+		args    args
+		want    []string
+		wantErr error
+	}
+	tests := []*test{
+		{
+			name: "success: single pattern",
+			args: args{tokens: []Token{pattern("foo")}},
+			want: []string{"foo bar", "foo", "foobar"},
+		},
+		{
+			name: "success: ignore case",
+			args: args{tokens: []Token{pattern("FOO")}, opts: Options{IgnoreCase: true}},
+			want: []string{"foo bar", "foo", "foobar"},
+		},
+		{
+			name: "success: word regexp",
+			args: args{tokens: []Token{pattern("foo")}, opts: Options{WordRegexp: true}},
+			want: []string{"foo bar", "foo"},
+		},
+		{
+			name: "success: basic regexp treats parentheses literally",
+			args: args{tokens: []Token{pattern("(x)")}},
+			want: []string{"baz (x)"},
+		},
+		{
+			name: "success: extended regexp",
+			args: args{tokens: []Token{pattern("^(foo|bar)$")}, opts: Options{Extended: true}},
+			want: []string{"foo", "bar"},
+		},
+		{
+			name: "success: fixed string",
+			args: args{tokens: []Token{pattern("(x")}, opts: Options{Fixed: true}},
+			want: []string{"baz (x)"},
+		},
+		{
+			name: "success: implicit or",
+			args: args{tokens: []Token{pattern("^foo$"), pattern("^bar$")}},
+			want: []string{"foo", "bar"},
+		},
+		{
+			name: "success: and",
+			args: args{tokens: []Token{pattern("foo"), {Type: AndToken}, pattern("bar")}},
+			want: []string{"foo bar", "foobar"},
+		},
+		{
+			name: "success: and binds tighter than or",
+			args: args{tokens: []Token{pattern("baz"), {Type: OrToken}, pattern("foo"), {Type: AndToken}, {Type: NotToken}, pattern("bar")}},
+			want: []string{"foo", "baz (x)"},
+		},
+		{
+			name:    "fail: no pattern",
+			args:    args{},
+			wantErr: ErrNoPattern,
+		},
+		{
+			name:    "fail: dangling operator",
+			args:    args{tokens: []Token{pattern("foo"), {Type: AndToken}}},
+			wantErr: ErrInvalidExpr,
+		},
+		{
+			name:    "fail: invalid regexp",
+			args:    args{tokens: []Token{pattern("a[")}},
+			wantErr: ErrInvalidPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.args.tokens, tt.args.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var got []string
+			for _, line := range lines {
+				if expr.Match(line) {
+					got = append(got, line)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	expr, err := Compile("needle", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{
+		"a.txt": "hay\nneedle\nhay\nneedle again\n",
+		"b.txt": "hay only\n",
+		"c.bin": "needle\x00",
+	}
+	var sources []*Source
+	for i := 0; i < 20; i++ {
+		for _, name := range []string{"a.txt", "b.txt", "c.bin"} {
+			content := contents[name]
+			sources = append(sources, &Source{
+				Path: fmt.Sprintf("%d/%s", i, name),
+				Load: func() ([]byte, error) { return []byte(content), nil },
+			})
+		}
+	}
+
+	results, err := Run(expr, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 40 {
+		t.Fatalf("got %d results, want 40", len(results))
+	}
+	for i := 0; i < 20; i++ {
+		a, c := results[2*i], results[2*i+1]
+		if a.Path != fmt.Sprintf("%d/a.txt", i) || c.Path != fmt.Sprintf("%d/c.bin", i) {
+			t.Fatalf("got paths %s, %s in the wrong order", a.Path, c.Path)
+		}
+		wantMatches := []*Match{{LineNumber: 2, Line: "needle"}, {LineNumber: 4, Line: "needle again"}}
+		if a.IsBinary || !reflect.DeepEqual(a.Matches, wantMatches) {
+			t.Errorf("got = %+v, want = %+v", a.Matches, wantMatches)
+		}
+		if !c.IsBinary {
+			t.Errorf("%s is not detected as binary", c.Path)
+		}
+	}
+
+	loadErr := errors.New("load error")
+	_, err = Run(expr, []*Source{{Path: "x", Load: func() ([]byte, error) { return nil, loadErr }}})
+	if !errors.Is(err, loadErr) {
+		t.Errorf("got = %v, want = %v", err, loadErr)
+	}
+}
@@ -18,23 +18,10 @@ const (
 	CheckoutRecord
 	BranchRecord
 	ResetRecord
+	UpdateRefRecord
+	CloneRecord
 )
 
-func NewRecordType(typeString string) RecordType {
-	switch typeString {
-	case "commit":
-		return CommitRecord
-	case "checkout":
-		return CheckoutRecord
-	case "branch":
-		return BranchRecord
-	case "reset":
-		return ResetRecord
-	default:
-		return UndefinedRecord
-	}
-}
-
 func (t RecordType) String() string {
 	switch t {
 	case CommitRecord:
@@ -45,6 +32,10 @@ func (t RecordType) String() string {
 		return "branch"
 	case ResetRecord:
 		return "reset"
+	case UpdateRefRecord:
+		return "update-ref"
+	case CloneRecord:
+		return "clone"
 	default:
 		return "undefined"
 	}
@@ -52,8 +43,8 @@ func (t RecordType) String() string {
 
 type record struct {
 	recType  RecordType
-	from     sha.SHA1
-	to       sha.SHA1
+	from     sha.Hash
+	to       sha.Hash
 	name     string
 	email    string
 	unixtime string
@@ -61,7 +52,7 @@ type record struct {
 	message  string
 }
 
-func NewRecord(recType RecordType, from, to sha.SHA1, name, email string, t time.Time, message string) *record {
+func NewRecord(recType RecordType, from, to sha.Hash, name, email string, t time.Time, message string) *record {
 	unixtime := fmt.Sprint(t.Unix())
 	_, offset := t.Zone()
 	offsetMinutes := offset / 60
@@ -80,16 +71,13 @@ func NewRecord(recType RecordType, from, to sha.SHA1, name, email string, t time
 }
 
 func (r *record) String() string {
-	var fromStr, toStr string
+	// the missing side is the zero hash of the same format as the other side
+	fromStr, toStr := r.from.String(), r.to.String()
 	if r.from == nil {
-		fromStr = strings.Repeat("0", 40)
-	} else {
-		fromStr = r.from.String()
+		fromStr = strings.Repeat("0", r.to.Format().HexSize())
 	}
 	if r.to == nil {
-		toStr = strings.Repeat("0", 40)
-	} else {
-		toStr = r.to.String()
+		toStr = strings.Repeat("0", r.from.Format().HexSize())
 	}
 	return fmt.Sprintf("%s %s %s <%s> %s %s\t%s: %s\n", fromStr, toStr, r.name, r.email, r.unixtime, r.timeDiff, r.recType, r.message)
 }
@@ -105,67 +93,105 @@ func NewGoitLogger(rootGoitPath string) *GoitLogger {
 }
 
 func (l *GoitLogger) WriteHEAD(r *record) error {
-	// init logs
-	logsPath := filepath.Join(l.rootGoitPath, "logs")
-	if _, err := os.Stat(logsPath); os.IsNotExist(err) {
-		if err := os.Mkdir(logsPath, os.ModePerm); err != nil {
-			return fmt.Errorf("fail to make dir %s: %w", logsPath, err)
+	return l.WriteRef(r, "HEAD")
+}
+
+func (l *GoitLogger) WriteBranch(r *record, branchName string) error {
+	return l.WriteRef(r, fmt.Sprintf("refs/heads/%s", branchName))
+}
+
+// WriteRef appends the record to the log of the ref such as HEAD or refs/tags/v1.0.0.
+func (l *GoitLogger) WriteRef(r *record, refName string) error {
+	// init logs, ref name might contain slashes such as refs/heads/feature/login
+	logPath := filepath.Join(l.rootGoitPath, "logs", filepath.FromSlash(refName))
+	logDir := filepath.Dir(logPath)
+	if _, err := os.Stat(logDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
+			return fmt.Errorf("fail to make dir %s: %w", logDir, err)
 		}
 	}
 
-	// write HEAD log
-	headPath := filepath.Join(logsPath, "HEAD")
-	f, err := os.OpenFile(headPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	// write ref log
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		return fmt.Errorf("fail to create %s: %w", headPath, err)
+		return fmt.Errorf("fail to create %s: %w", logPath, err)
 	}
 	defer f.Close()
 
 	if _, err := f.WriteString(r.String()); err != nil {
-		return fmt.Errorf("fail to write %s: %w", headPath, err)
+		return fmt.Errorf("fail to write %s: %w", logPath, err)
 	}
 
 	return nil
 }
 
-func (l *GoitLogger) WriteBranch(r *record, branchName string) error {
-	// init logs
-	logsPath := filepath.Join(l.rootGoitPath, "logs")
-	if _, err := os.Stat(logsPath); os.IsNotExist(err) {
-		if err := os.Mkdir(logsPath, os.ModePerm); err != nil {
-			return fmt.Errorf("fail to make dir %s: %w", logsPath, err)
-		}
-	}
-	logsRefsPath := filepath.Join(logsPath, "refs")
-	if _, err := os.Stat(logsRefsPath); os.IsNotExist(err) {
-		if err := os.Mkdir(logsRefsPath, os.ModePerm); err != nil {
-			return fmt.Errorf("fail to make dir %s: %w", logsRefsPath, err)
-		}
-		logsHeadsPath := filepath.Join(logsRefsPath, "heads")
-		if err := os.Mkdir(logsHeadsPath, os.ModePerm); err != nil {
-			return fmt.Errorf("fail to make dir %s: %w", logsHeadsPath, err)
-		}
+func (l *GoitLogger) DeleteBranch(branchName string) error {
+	return l.DeleteRef(fmt.Sprintf("refs/heads/%s", branchName))
+}
+
+// DeleteRef removes the log of the ref if it exists.
+func (l *GoitLogger) DeleteRef(refName string) error {
+	logPath := filepath.Join(l.rootGoitPath, "logs", filepath.FromSlash(refName))
+	if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("fail to delete %s: %w", logPath, err)
 	}
+	return nil
+}
 
-	// write branch log
-	branchPath := filepath.Join(logsRefsPath, "heads", branchName)
-	f, err := os.OpenFile(branchPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+// CopyRef copies the log of srcRefName to dstRefName, doing nothing if srcRefName has no log.
+func (l *GoitLogger) CopyRef(srcRefName, dstRefName string) error {
+	srcPath := filepath.Join(l.rootGoitPath, "logs", filepath.FromSlash(srcRefName))
+	content, err := os.ReadFile(srcPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("fail to create %s: %w", branchPath, err)
+		return fmt.Errorf("fail to read %s: %w", srcPath, err)
 	}
-	defer f.Close()
 
-	if _, err := f.WriteString(r.String()); err != nil {
-		return fmt.Errorf("fail to write %s: %w", branchPath, err)
+	dstPath := filepath.Join(l.rootGoitPath, "logs", filepath.FromSlash(dstRefName))
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make dir %s: %w", filepath.Dir(dstPath), err)
+	}
+	if err := os.WriteFile(dstPath, content, 0666); err != nil {
+		return fmt.Errorf("fail to write %s: %w", dstPath, err)
 	}
-
 	return nil
 }
 
-func (l *GoitLogger) DeleteBranch(branchName string) error {
-	branchPath := filepath.Join(l.rootGoitPath, "logs", "refs", "heads", branchName)
-	if err := os.Remove(branchPath); err != nil {
-		return fmt.Errorf("fail to delete %s: %w", branchPath, err)
+// RenameRef moves the log of srcRefName to dstRefName, doing nothing if srcRefName has no log.
+func (l *GoitLogger) RenameRef(srcRefName, dstRefName string) error {
+	srcPath := filepath.Join(l.rootGoitPath, "logs", filepath.FromSlash(srcRefName))
+	content, err := os.ReadFile(srcPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fail to read %s: %w", srcPath, err)
+	}
+
+	// the source goes first, since the destination may be under the directory of the same name
+	if err := os.Remove(srcPath); err != nil {
+		return fmt.Errorf("fail to delete %s: %w", srcPath, err)
+	}
+	// remove the directories left empty, keeping the category directory such as logs/refs/heads
+	for dir := filepath.Dir(srcPath); ; dir = filepath.Dir(dir) {
+		relPath, err := filepath.Rel(filepath.Join(l.rootGoitPath, "logs"), dir)
+		if err != nil || len(strings.Split(filepath.ToSlash(relPath), "/")) < 3 {
+			break
+		}
+		if err := os.Remove(dir); err != nil {
+			// the directory is not empty
+			break
+		}
+	}
+
+	dstPath := filepath.Join(l.rootGoitPath, "logs", filepath.FromSlash(dstRefName))
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make dir %s: %w", filepath.Dir(dstPath), err)
+	}
+	if err := os.WriteFile(dstPath, content, 0666); err != nil {
+		return fmt.Errorf("fail to write %s: %w", dstPath, err)
 	}
 	return nil
 }
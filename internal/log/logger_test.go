@@ -15,8 +15,8 @@ import (
 func TestNewRecord(t *testing.T) {
 	type args struct {
 		recType RecordType
-		from    sha.SHA1
-		to      sha.SHA1
+		from    sha.Hash
+		to      sha.Hash
 		name    string
 		email   string
 		t       time.Time
@@ -40,8 +40,8 @@ func TestNewRecord(t *testing.T) {
 				name: "success: commit record",
 				args: args{
 					recType: CommitRecord,
-					from:    sha.SHA1(hash),
-					to:      sha.SHA1(hash),
+					from:    sha.Hash(hash),
+					to:      sha.Hash(hash),
 					name:    "Test Taro",
 					email:   "test@example.com",
 					t:       now,
@@ -49,8 +49,8 @@ func TestNewRecord(t *testing.T) {
 				},
 				want: &record{
 					recType:  CommitRecord,
-					from:     sha.SHA1(hash),
-					to:       sha.SHA1(hash),
+					from:     sha.Hash(hash),
+					to:       sha.Hash(hash),
 					name:     "Test Taro",
 					email:    "test@example.com",
 					unixtime: unixtime,
@@ -71,8 +71,8 @@ func TestNewRecord(t *testing.T) {
 				name: "success: branch record",
 				args: args{
 					recType: BranchRecord,
-					from:    sha.SHA1(hash),
-					to:      sha.SHA1(hash),
+					from:    sha.Hash(hash),
+					to:      sha.Hash(hash),
 					name:    "Test Taro",
 					email:   "test@example.com",
 					t:       now,
@@ -80,8 +80,8 @@ func TestNewRecord(t *testing.T) {
 				},
 				want: &record{
 					recType:  BranchRecord,
-					from:     sha.SHA1(hash),
-					to:       sha.SHA1(hash),
+					from:     sha.Hash(hash),
+					to:       sha.Hash(hash),
 					name:     "Test Taro",
 					email:    "test@example.com",
 					unixtime: unixtime,
@@ -102,8 +102,8 @@ func TestNewRecord(t *testing.T) {
 				name: "success: checkout record",
 				args: args{
 					recType: CheckoutRecord,
-					from:    sha.SHA1(hash),
-					to:      sha.SHA1(hash),
+					from:    sha.Hash(hash),
+					to:      sha.Hash(hash),
 					name:    "Test Taro",
 					email:   "test@example.com",
 					t:       now,
@@ -111,8 +111,8 @@ func TestNewRecord(t *testing.T) {
 				},
 				want: &record{
 					recType:  CheckoutRecord,
-					from:     sha.SHA1(hash),
-					to:       sha.SHA1(hash),
+					from:     sha.Hash(hash),
+					to:       sha.Hash(hash),
 					name:     "Test Taro",
 					email:    "test@example.com",
 					unixtime: unixtime,
@@ -133,8 +133,8 @@ func TestNewRecord(t *testing.T) {
 				name: "success: reset record",
 				args: args{
 					recType: ResetRecord,
-					from:    sha.SHA1(hash),
-					to:      sha.SHA1(hash),
+					from:    sha.Hash(hash),
+					to:      sha.Hash(hash),
 					name:    "Test Taro",
 					email:   "test@example.com",
 					t:       now,
@@ -142,8 +142,8 @@ func TestNewRecord(t *testing.T) {
 				},
 				want: &record{
 					recType:  ResetRecord,
-					from:     sha.SHA1(hash),
-					to:       sha.SHA1(hash),
+					from:     sha.Hash(hash),
+					to:       sha.Hash(hash),
 					name:     "Test Taro",
 					email:    "test@example.com",
 					unixtime: unixtime,
@@ -399,3 +399,72 @@ func TestDeleteBranch(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordString(t *testing.T) {
+	sha1Hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	sha256Hash, _ := hex.DecodeString("7924fb0f23c883233d0b9d6699dd0df672b64b319e080f0b3d5285ee41bc7106")
+	tm := time.Unix(1700000000, 0).In(time.FixedZone("", 9*60*60))
+	tests := []struct {
+		name string
+		to   sha.Hash
+		want string
+	}{
+		{
+			name: "success: sha1",
+			to:   sha.Hash(sha1Hash),
+			want: "0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 Test Taro <test@example.com> 1700000000 +0900\tcommit: test\n",
+		},
+		{
+			name: "success: sha256",
+			to:   sha.Hash(sha256Hash),
+			want: "0000000000000000000000000000000000000000000000000000000000000000 7924fb0f23c883233d0b9d6699dd0df672b64b319e080f0b3d5285ee41bc7106 Test Taro <test@example.com> 1700000000 +0900\tcommit: test\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewRecord(CommitRecord, nil, tt.to, "Test Taro", "test@example.com", tm, "test").String()
+			if got != tt.want {
+				t.Errorf("got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameRef(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		dst  string
+	}{
+		{name: "success", src: "refs/heads/main", dst: "refs/heads/trunk"},
+		{name: "success: into own directory", src: "refs/heads/feat", dst: "refs/heads/feat/login"},
+		{name: "success: out of own directory", src: "refs/heads/feat/login", dst: "refs/heads/feat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			gLogger := NewGoitLogger(tmpDir)
+			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+			rec := NewRecord(CommitRecord, nil, hash, "Test Taro", "test@example.com", time.Now(), "test")
+			if err := gLogger.WriteRef(rec, tt.src); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := gLogger.RenameRef(tt.src, tt.dst); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(tmpDir, "logs", filepath.FromSlash(tt.dst)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != rec.String() {
+				t.Errorf("got = %s, want = %s", data, rec.String())
+			}
+			if info, err := os.Stat(filepath.Join(tmpDir, "logs", filepath.FromSlash(tt.src))); err == nil && info.Mode().IsRegular() {
+				t.Errorf("got the log of %s, want it moved", tt.src)
+			}
+		})
+	}
+}
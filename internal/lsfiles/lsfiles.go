@@ -0,0 +1,145 @@
+package lsfiles
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/JunNishimura/Goit/internal/file"
+	"github.com/JunNishimura/Goit/internal/pathspec"
+	"github.com/JunNishimura/Goit/internal/status"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+var (
+	ErrIgnoredWithoutMode    = errors.New("ls-files -i must be used with either -o or -c")
+	ErrIgnoredWithoutExclude = errors.New("ls-files --ignored needs some exclude pattern")
+)
+
+// the tags of ls-files -t, which tell the state of the path
+const (
+	TagCached   = "H"
+	TagUnmerged = "M"
+	TagDeleted  = "R"
+	TagModified = "C"
+	TagOther    = "?"
+)
+
+// Options selects the files to be listed. The index entries are listed if nothing is selected.
+type Options struct {
+	Cached   bool // the index entries
+	Stage    bool // the index entries with their stages, which implies Cached
+	Modified bool // the tracked files modified in the working tree, including the deleted ones
+	Deleted  bool // the tracked files deleted from the working tree
+	Others   bool // the untracked files
+	Ignored  bool // only the files which the exclude rules match among the cached or the other files
+	Unmerged bool // only the index entries of the conflict stages, which implies Stage
+
+	Ignore   *store.Ignore      // the rules of .goitignore as the exclude rule, not used if nil
+	Excludes []string           // the patterns of the exclude rule
+	Pathspec *pathspec.Pathspec // limit the paths, which selects every path if nil
+	Filter   *store.CleanFilter // convert the working tree files to see if they are modified
+}
+
+// File is the listed path, relative to the working tree root.
+type File struct {
+	Tag   string
+	Path  string
+	Entry *store.Entry // the index entry, nil for the untracked file
+}
+
+// List returns the files selected by the options in the order ls-files shows them,
+// the untracked files first and then the index entries.
+func List(workDir string, idx *store.Index, opts *Options) ([]*File, error) {
+	if opts.Ignored && !opts.Others && !opts.Cached {
+		return nil, ErrIgnoredWithoutMode
+	}
+	if opts.Ignored && opts.Ignore == nil && len(opts.Excludes) == 0 {
+		return nil, ErrIgnoredWithoutExclude
+	}
+	showCached := opts.Cached || opts.Stage || opts.Unmerged
+	if !showCached && !opts.Modified && !opts.Deleted && !opts.Others {
+		showCached = true
+	}
+	ps := opts.Pathspec
+	if ps == nil {
+		var err error
+		if ps, err = pathspec.Parse(nil, ""); err != nil {
+			return nil, err
+		}
+	}
+	excludes := store.NewIgnoreFromPatterns(opts.Excludes)
+	isExcluded := func(path string) bool {
+		if opts.Ignore != nil && opts.Ignore.Match(path, false) {
+			return true
+		}
+		return len(opts.Excludes) > 0 && excludes.Match(path, false)
+	}
+
+	var files []*File
+	if opts.Others {
+		others, err := file.GetWorkingTreeFiles(workDir, store.NewIgnoreFromPatterns(nil))
+		if err != nil {
+			return nil, fmt.Errorf("fail to get files: %w", err)
+		}
+		sort.Strings(others)
+		for _, other := range ps.Filter(others) {
+			if _, _, isRegistered := idx.GetEntry([]byte(other)); isRegistered {
+				continue
+			}
+			// with -i, only the untracked files which the exclude rules match are shown, and the others without it
+			if isExcluded(other) != opts.Ignored {
+				continue
+			}
+			files = append(files, &File{Tag: TagOther, Path: other})
+		}
+	}
+
+	for _, entry := range idx.Entries {
+		path := string(entry.Path)
+		if !ps.Match(path) {
+			continue
+		}
+		// with -i, only the tracked files which the exclude rules match are shown
+		if opts.Ignored && !isExcluded(path) {
+			continue
+		}
+
+		if showCached && (!opts.Unmerged || entry.Stage != 0) {
+			tag := TagCached
+			if entry.Stage != 0 {
+				tag = TagUnmerged
+			}
+			files = append(files, &File{Tag: tag, Path: path, Entry: entry})
+		}
+		if !opts.Deleted && !opts.Modified {
+			continue
+		}
+
+		// the deleted file is reported as modified as well
+		isDeleted := false
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(path))); os.IsNotExist(err) {
+			isDeleted = true
+		}
+		if opts.Deleted && isDeleted {
+			files = append(files, &File{Tag: TagDeleted, Path: path, Entry: entry})
+		}
+		if !opts.Modified {
+			continue
+		}
+		isModified := isDeleted
+		if !isDeleted && entry.Stage == 0 {
+			var err error
+			if isModified, err = status.IsWorktreeModified(workDir, idx.Format(), opts.Filter, entry); err != nil {
+				return nil, err
+			}
+		}
+		if isModified {
+			files = append(files, &File{Tag: TagModified, Path: path, Entry: entry})
+		}
+	}
+
+	return files, nil
+}
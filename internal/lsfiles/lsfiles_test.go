@@ -0,0 +1,141 @@
+package lsfiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/pathspec"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *Options
+		pathspecs []string
+		want      []string
+		wantErr   error
+	}{
+		{
+			name: "success: cached by default",
+			opts: &Options{},
+			want: []string{"H a", "H b", "H c", "M d", "M d", "M d", "H dir/g"},
+		},
+		{
+			name: "success: modified",
+			opts: &Options{Modified: true},
+			want: []string{"C b", "C c"},
+		},
+		{
+			name: "success: deleted",
+			opts: &Options{Deleted: true},
+			want: []string{"R c"},
+		},
+		{
+			name: "success: cached and deleted",
+			opts: &Options{Cached: true, Deleted: true},
+			want: []string{"H a", "H b", "H c", "R c", "M d", "M d", "M d", "H dir/g"},
+		},
+		{
+			name: "success: others",
+			opts: &Options{Others: true},
+			want: []string{"? e.log", "? f"},
+		},
+		{
+			name: "success: others without excluded",
+			opts: &Options{Others: true, Excludes: []string{"*.log"}},
+			want: []string{"? f"},
+		},
+		{
+			name: "success: others without ignored",
+			opts: &Options{Others: true, Ignore: store.NewIgnoreFromPatterns([]string{"*.log"})},
+			want: []string{"? f"},
+		},
+		{
+			name: "success: ignored others",
+			opts: &Options{Others: true, Ignored: true, Excludes: []string{"*.log"}},
+			want: []string{"? e.log"},
+		},
+		{
+			name: "success: ignored cached",
+			opts: &Options{Cached: true, Ignored: true, Excludes: []string{"b"}},
+			want: []string{"H b"},
+		},
+		{
+			name: "success: unmerged",
+			opts: &Options{Unmerged: true},
+			want: []string{"M d", "M d", "M d"},
+		},
+		{
+			name:      "success: pathspec",
+			opts:      &Options{Cached: true, Others: true},
+			pathspecs: []string{"dir"},
+			want:      []string{"H dir/g"},
+		},
+		{
+			name:    "fail: ignored without mode",
+			opts:    &Options{Ignored: true, Excludes: []string{"*.log"}},
+			wantErr: ErrIgnoredWithoutMode,
+		},
+		{
+			name:    "fail: ignored without exclude",
+			opts:    &Options{Others: true, Ignored: true},
+			wantErr: ErrIgnoredWithoutExclude,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			idx, err := store.NewIndex(filepath.Join(workDir, ".goit"), sha.SHA1Format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a is unchanged, b is modified, c is deleted and d is unmerged
+			for path, content := range map[string]string{"a": "a", "b": "b", "c": "c", "dir/g": "g"} {
+				obj, err := object.NewObject(sha.SHA1Format, object.BlobObject, []byte(content))
+				if err != nil {
+					t.Fatal(err)
+				}
+				idx.Put([]byte(path), store.NewEntry(obj.Hash, []byte(path)))
+			}
+			var stages []*store.Entry
+			for stage := 1; stage <= 3; stage++ {
+				entry := store.NewEntry(sha.Hash{byte(stage)}, []byte("d"))
+				entry.Stage = stage
+				stages = append(stages, entry)
+			}
+			idx.Put([]byte("d"), stages...)
+			for path, content := range map[string]string{"a": "a", "b": "modified", "d": "d", "dir/g": "g", "e.log": "e", "f": "f"} {
+				absPath := filepath.Join(workDir, filepath.FromSlash(path))
+				if err := os.MkdirAll(filepath.Dir(absPath), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(absPath, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.pathspecs != nil {
+				if tt.opts.Pathspec, err = pathspec.Parse(tt.pathspecs, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			files, err := List(workDir, idx, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, f := range files {
+				got = append(got, f.Tag+" "+f.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
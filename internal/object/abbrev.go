@@ -0,0 +1,240 @@
+package object
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	// MinAbbrev is the minimum length of the short object ID.
+	MinAbbrev = 4
+	// DefaultAbbrev is the length of the short object ID used when core.abbrev is not set.
+	DefaultAbbrev = 7
+)
+
+// Candidate is the object which a short object ID can refer to.
+type Candidate struct {
+	Hash sha.Hash
+	Type Type
+}
+
+// AmbiguousError is returned when the short object ID matches more than one object.
+type AmbiguousError struct {
+	Prefix     string
+	Candidates []*Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("short object ID %s is ambiguous\nhint: The candidates are:", e.Prefix))
+	for _, c := range e.Candidates {
+		b.WriteString(fmt.Sprintf("\nhint:   %s %s", c.Hash, c.Type))
+	}
+	return b.String()
+}
+
+func (e *AmbiguousError) Is(target error) bool {
+	return target == ErrAmbiguousHash
+}
+
+// IsHexPrefix tells whether the string can be a short object ID of the format.
+func IsHexPrefix(s string, format *sha.Format) bool {
+	if len(s) < MinAbbrev || len(s) > format.HexSize() {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// return the names of the loose objects in the fan-out directory of the two hex digits.
+func looseObjects(rootGoitPath, dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(rootGoitPath, "objects", dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrIOHandling, dir)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, dir+entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// ListHashes returns the hashes of all the objects, both loose and packed, sorted in hexadecimal order.
+func ListHashes(rootGoitPath string, format *sha.Format) ([]sha.Hash, error) {
+	isFound := make(map[string]bool)
+	var hashes []sha.Hash
+	for i := 0; i < 256; i++ {
+		names, err := looseObjects(rootGoitPath, fmt.Sprintf("%02x", i))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			hash, err := sha.ReadHash(name)
+			if err != nil || len(hash) != format.Size || isFound[name] {
+				continue
+			}
+			isFound[name] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	packs, err := readPackIndexes(rootGoitPath, format)
+	if err != nil {
+		return nil, err
+	}
+	for _, pack := range packs {
+		for _, hash := range pack.hashes {
+			if !isFound[hash.String()] {
+				isFound[hash.String()] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].String() < hashes[j].String()
+	})
+	return hashes, nil
+}
+
+// ResolvePrefix finds the object whose hash starts with the prefix of at least MinAbbrev hex digits,
+// looking up both the loose objects and the pack indexes.
+// AmbiguousError listing the candidates is returned if more than one object matches.
+func ResolvePrefix(rootGoitPath string, format *sha.Format, prefix string) (sha.Hash, error) {
+	if !IsHexPrefix(prefix, format) {
+		return nil, fmt.Errorf("%w: %s", sha.ErrInvalidHash, prefix)
+	}
+	prefix = strings.ToLower(prefix)
+
+	names, err := looseObjects(rootGoitPath, prefix[:2])
+	if err != nil {
+		return nil, err
+	}
+	isFound := make(map[string]bool)
+	var candidates []*Candidate
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) || isFound[name] {
+			continue
+		}
+		hash, err := sha.ReadHash(name)
+		if err != nil || len(hash) != format.Size {
+			continue
+		}
+		isFound[name] = true
+		candidate := &Candidate{Hash: hash}
+		if obj, err := GetObject(rootGoitPath, hash); err == nil {
+			candidate.Type = obj.Type
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	packs, err := readPackIndexes(rootGoitPath, format)
+	if err != nil {
+		return nil, err
+	}
+	for _, pack := range packs {
+		for _, hash := range pack.findPrefix(prefix) {
+			if isFound[hash.String()] {
+				continue
+			}
+			isFound[hash.String()] = true
+			candidate := &Candidate{Hash: hash}
+			if objType, err := pack.objectType(hash); err == nil {
+				candidate.Type = objType
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, prefix)
+	case 1:
+		return candidates[0].Hash, nil
+	default:
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].Hash.String() < candidates[j].Hash.String()
+		})
+		return nil, &AmbiguousError{Prefix: prefix, Candidates: candidates}
+	}
+}
+
+// Abbreviator shortens the hashes to the unique prefixes, which are at least the minimum length
+// and lengthened automatically as long as another object shares the prefix.
+type Abbreviator struct {
+	rootGoitPath string
+	format       *sha.Format
+	minLength    int
+	names        map[string][]string // the object names keyed by the fan-out directory
+	packs        []*packIndex
+	isPackRead   bool
+}
+
+func NewAbbreviator(rootGoitPath string, format *sha.Format, minLength int) *Abbreviator {
+	return &Abbreviator{
+		rootGoitPath: rootGoitPath,
+		format:       format,
+		minLength:    minLength,
+		names:        make(map[string][]string),
+	}
+}
+
+// return the names of the objects in the same fan-out directory as the hash, caching the result.
+func (a *Abbreviator) neighbors(dir string) []string {
+	if names, ok := a.names[dir]; ok {
+		return names
+	}
+	// the object which cannot be listed is ignored since the abbreviation is only for display
+	names, _ := looseObjects(a.rootGoitPath, dir)
+	if !a.isPackRead {
+		a.packs, _ = readPackIndexes(a.rootGoitPath, a.format)
+		a.isPackRead = true
+	}
+	for _, pack := range a.packs {
+		for _, hash := range pack.findPrefix(dir) {
+			names = append(names, hash.String())
+		}
+	}
+	a.names[dir] = names
+	return names
+}
+
+// Abbrev returns the shortest unique prefix of the hash which is not shorter than the minimum length.
+func (a *Abbreviator) Abbrev(hash sha.Hash) string {
+	full := hash.String()
+	length := a.minLength
+	if length < MinAbbrev {
+		length = MinAbbrev
+	}
+	if length >= len(full) {
+		return full
+	}
+	for _, name := range a.neighbors(full[:2]) {
+		if name == full {
+			continue
+		}
+		common := 0
+		for common < len(full) && common < len(name) && full[common] == name[common] {
+			common++
+		}
+		if common+1 > length {
+			length = common + 1
+		}
+	}
+	if length > len(full) {
+		return full
+	}
+	return full[:length]
+}
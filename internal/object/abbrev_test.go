@@ -0,0 +1,223 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// write the blobs until two of them share the first four hex digits, and return the pair.
+func writeCollidingBlobs(t *testing.T, goitDir string, format *sha.Format) (*Object, *Object) {
+	t.Helper()
+	seen := make(map[string]*Object)
+	for i := 0; ; i++ {
+		obj, err := NewObject(format, BlobObject, []byte(fmt.Sprintf("blob %d\n", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix := obj.Hash.String()[:MinAbbrev]
+		if other, ok := seen[prefix]; ok {
+			if err := other.Write(goitDir); err != nil {
+				t.Fatal(err)
+			}
+			if err := obj.Write(goitDir); err != nil {
+				t.Fatal(err)
+			}
+			return other, obj
+		}
+		seen[prefix] = obj
+	}
+}
+
+func newObjectsDir(t *testing.T) string {
+	t.Helper()
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "objects"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	return goitDir
+}
+
+func TestResolvePrefix(t *testing.T) {
+	for _, format := range []*sha.Format{sha.SHA1Format, sha.SHA256Format} {
+		t.Run(format.Name, func(t *testing.T) {
+			goitDir := newObjectsDir(t)
+			obj1, obj2 := writeCollidingBlobs(t, goitDir, format)
+			commit, err := NewObject(format, CommitObject, []byte("tree 0\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := commit.Write(goitDir); err != nil {
+				t.Fatal(err)
+			}
+			full1, full2 := obj1.Hash.String(), obj2.Hash.String()
+			common := 0
+			for full1[common] == full2[common] {
+				common++
+			}
+
+			tests := []struct {
+				name    string
+				prefix  string
+				want    sha.Hash
+				wantErr error
+			}{
+				{
+					name:   "success: unique prefix",
+					prefix: full1[:common+1],
+					want:   obj1.Hash,
+				},
+				{
+					name:   "success: upper case",
+					prefix: strings.ToUpper(full2[:common+1]),
+					want:   obj2.Hash,
+				},
+				{
+					name:   "success: full hash",
+					prefix: commit.Hash.String(),
+					want:   commit.Hash,
+				},
+				{
+					name:    "fail: ambiguous",
+					prefix:  full1[:common],
+					wantErr: ErrAmbiguousHash,
+				},
+				{
+					name:    "fail: too short",
+					prefix:  full1[:3],
+					wantErr: sha.ErrInvalidHash,
+				},
+				{
+					name:    "fail: not hex",
+					prefix:  "zzzz",
+					wantErr: sha.ErrInvalidHash,
+				},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := ResolvePrefix(goitDir, format, tt.prefix)
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+					}
+					if got.String() != tt.want.String() {
+						t.Errorf("got = %s, want = %s", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestResolvePrefixNotFound(t *testing.T) {
+	goitDir := newObjectsDir(t)
+	if _, err := ResolvePrefix(goitDir, sha.SHA1Format, "abcd"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("got = %v, want = %v", err, ErrObjectNotFound)
+	}
+}
+
+func TestAmbiguousError(t *testing.T) {
+	goitDir := newObjectsDir(t)
+	obj1, obj2 := writeCollidingBlobs(t, goitDir, sha.SHA1Format)
+	prefix := obj1.Hash.String()[:MinAbbrev]
+
+	_, err := ResolvePrefix(goitDir, sha.SHA1Format, prefix)
+	var ambiguousErr *AmbiguousError
+	if !errors.As(err, &ambiguousErr) {
+		t.Fatalf("got = %v, want = %T", err, ambiguousErr)
+	}
+	first, second := obj1.Hash.String(), obj2.Hash.String()
+	if second < first {
+		first, second = second, first
+	}
+	want := fmt.Sprintf("short object ID %s is ambiguous\nhint: The candidates are:\nhint:   %s blob\nhint:   %s blob", prefix, first, second)
+	if got := err.Error(); got != want {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+}
+
+func TestAbbrev(t *testing.T) {
+	goitDir := newObjectsDir(t)
+	obj1, obj2 := writeCollidingBlobs(t, goitDir, sha.SHA1Format)
+	full1, full2 := obj1.Hash.String(), obj2.Hash.String()
+	common := 0
+	for full1[common] == full2[common] {
+		common++
+	}
+	unique, err := NewObject(sha.SHA1Format, BlobObject, []byte("unique\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		minLength int
+		hash      sha.Hash
+		want      string
+	}{
+		{
+			name:      "success: minimum length",
+			minLength: common + 3,
+			hash:      obj1.Hash,
+			want:      full1[:common+3],
+		},
+		{
+			name:      "success: lengthened",
+			minLength: MinAbbrev,
+			hash:      obj2.Hash,
+			want:      full2[:common+1],
+		},
+		{
+			name:      "success: not written object",
+			minLength: DefaultAbbrev,
+			hash:      unique.Hash,
+			want:      unique.Hash.String()[:DefaultAbbrev],
+		},
+		{
+			name:      "success: raised to the minimum",
+			minLength: 1,
+			hash:      unique.Hash,
+			want:      unique.Hash.String()[:MinAbbrev],
+		},
+		{
+			name:      "success: full length",
+			minLength: 40,
+			hash:      obj1.Hash,
+			want:      full1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			abbrev := NewAbbreviator(goitDir, sha.SHA1Format, tt.minLength)
+			if got := abbrev.Abbrev(tt.hash); got != tt.want {
+				t.Errorf("got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListHashes(t *testing.T) {
+	goitDir := newObjectsDir(t)
+	obj1, obj2 := writeCollidingBlobs(t, goitDir, sha.SHA1Format)
+	packed := mustHash(t, "1234560000000000000000000000000000000000")
+	writePack(t, goitDir, []*packEntry{{hash: packed, data: []byte{packBlob << 4}}})
+
+	got, err := ListHashes(goitDir, sha.SHA1Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{obj1.Hash.String(), obj2.Hash.String(), packed.String()}
+	sort.Strings(want)
+	var gotStrings []string
+	for _, hash := range got {
+		gotStrings = append(gotStrings, hash.String())
+	}
+	if strings.Join(gotStrings, ",") != strings.Join(want, ",") {
+		t.Errorf("got = %v, want = %v", gotStrings, want)
+	}
+}
@@ -0,0 +1,136 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+// the modes which the entry of the tree can have
+var treeEntryModes = map[string]bool{
+	"100644": true,
+	"100755": true,
+	"120000": true,
+	"040000": true,
+	"40000":  true,
+	"160000": true,
+}
+
+// Check validates the format of the object as git fsck does before the object is written,
+// so that the tree, the commit and the tag which goit cannot read are rejected.
+// The blob can hold anything.
+func (o *Object) Check() error {
+	format := o.Hash.Format()
+	switch o.Type {
+	case BlobObject:
+		return nil
+	case TreeObject:
+		return checkTree(format, o.Data)
+	case CommitObject:
+		return checkCommit(format, o.Data)
+	case TagObject:
+		return checkTag(format, o.Data)
+	default:
+		return ErrInvalidObject
+	}
+}
+
+// the entries of the tree are "<mode> <name>\x00<hash>", sorted by the name where the name of the directory
+// is compared as if it ended with a slash.
+func checkTree(format *sha.Format, data []byte) error {
+	prevName := ""
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, 0)
+		if i < 0 || len(data[i+1:]) < format.Size {
+			return fmt.Errorf("%w: truncated entry", ErrInvalidTreeObject)
+		}
+		mode, name, ok := strings.Cut(string(data[:i]), " ")
+		if !ok || !treeEntryModes[mode] {
+			return fmt.Errorf("%w: bad file mode '%s'", ErrInvalidTreeObject, mode)
+		}
+		if name == "" || name == "." || name == ".." || name == ".goit" || strings.Contains(name, "/") {
+			return fmt.Errorf("%w: bad entry name '%s'", ErrInvalidTreeObject, name)
+		}
+		if mode == "040000" || mode == "40000" {
+			name += "/"
+		}
+		if prevName != "" && strings.TrimSuffix(prevName, "/") == strings.TrimSuffix(name, "/") {
+			return fmt.Errorf("%w: duplicate entry '%s'", ErrInvalidTreeObject, strings.TrimSuffix(name, "/"))
+		}
+		if prevName >= name {
+			return fmt.Errorf("%w: entries not sorted", ErrInvalidTreeObject)
+		}
+		prevName = name
+		data = data[i+1+format.Size:]
+	}
+	return nil
+}
+
+// read the header line "<key> <value>" at the head of the data, returning the value and the rest.
+func readHeaderLine(data []byte, key string) (string, []byte, bool) {
+	prefix := key + " "
+	if !bytes.HasPrefix(data, []byte(prefix)) {
+		return "", data, false
+	}
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return "", data, false
+	}
+	return string(data[len(prefix):i]), data[i+1:], true
+}
+
+func checkHash(format *sha.Format, hashString string) bool {
+	hash, err := sha.ReadHash(hashString)
+	return err == nil && len(hashString) == format.HexSize() && len(hash) == format.Size
+}
+
+// the commit starts with the tree, the parents, the author and the committer in this order.
+func checkCommit(format *sha.Format, data []byte) error {
+	value, data, ok := readHeaderLine(data, "tree")
+	if !ok || !checkHash(format, value) {
+		return fmt.Errorf("%w: invalid 'tree' line", ErrInvalidCommitObject)
+	}
+	for bytes.HasPrefix(data, []byte("parent ")) {
+		value, data, ok = readHeaderLine(data, "parent")
+		if !ok || !checkHash(format, value) {
+			return fmt.Errorf("%w: invalid 'parent' line", ErrInvalidCommitObject)
+		}
+	}
+	for _, key := range []string{"author", "committer"} {
+		value, data, ok = readHeaderLine(data, key)
+		if !ok {
+			return fmt.Errorf("%w: missing '%s' line", ErrInvalidCommitObject, key)
+		}
+		if _, err := readSign(value); err != nil {
+			return fmt.Errorf("%w: invalid '%s' line", ErrInvalidCommitObject, key)
+		}
+	}
+	return nil
+}
+
+// the tag starts with the object, the type, the tag name and optionally the tagger in this order.
+func checkTag(format *sha.Format, data []byte) error {
+	value, data, ok := readHeaderLine(data, "object")
+	if !ok || !checkHash(format, value) {
+		return fmt.Errorf("%w: invalid 'object' line", ErrInvalidTagObject)
+	}
+	value, data, ok = readHeaderLine(data, "type")
+	if !ok {
+		return fmt.Errorf("%w: missing 'type' line", ErrInvalidTagObject)
+	}
+	if _, err := NewType(value); err != nil {
+		return fmt.Errorf("%w: invalid 'type' value '%s'", ErrInvalidTagObject, value)
+	}
+	value, data, ok = readHeaderLine(data, "tag")
+	if !ok || value == "" {
+		return fmt.Errorf("%w: missing 'tag' line", ErrInvalidTagObject)
+	}
+	if value, _, ok = readHeaderLine(data, "tagger"); ok {
+		if _, err := readSign(value); err != nil {
+			return fmt.Errorf("%w: invalid 'tagger' line", ErrInvalidTagObject)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package object
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+func TestCheck(t *testing.T) {
+	hashString := "87f3c49bccf2597484ece08746d3ee5defaba335"
+	hash, _ := hex.DecodeString(hashString)
+	treeEntry := func(mode, name string) string {
+		return mode + " " + name + "\x00" + string(hash)
+	}
+	sign := "Test Taro <test@example.com> 1700000000 +0900"
+
+	type test struct {
+		name    string
+		format  *sha.Format
+		objType Type
+		data    string
+		wantErr error
+	}
+	tests := []*test{
+		{
+			name:    "success: blob",
+			format:  sha.SHA1Format,
+			objType: BlobObject,
+			data:    "anything\x00goes",
+			wantErr: nil,
+		},
+		{
+			name:    "success: tree",
+			format:  sha.SHA1Format,
+			objType: TreeObject,
+			data:    treeEntry("100644", "a") + treeEntry("40000", "a-b") + treeEntry("100755", "b.sh") + treeEntry("040000", "b"),
+			wantErr: nil,
+		},
+		{
+			name:    "success: empty tree",
+			format:  sha.SHA1Format,
+			objType: TreeObject,
+			data:    "",
+			wantErr: nil,
+		},
+		{
+			name:    "fail: tree with bad mode",
+			format:  sha.SHA1Format,
+			objType: TreeObject,
+			data:    treeEntry("100600", "a"),
+			wantErr: ErrInvalidTreeObject,
+		},
+		{
+			name:    "fail: tree with bad name",
+			format:  sha.SHA1Format,
+			objType: TreeObject,
+			data:    treeEntry("100644", "a/b"),
+			wantErr: ErrInvalidTreeObject,
+		},
+		{
+			name:    "fail: tree not sorted",
+			format:  sha.SHA1Format,
+			objType: TreeObject,
+			data:    treeEntry("100644", "b") + treeEntry("100644", "a"),
+			wantErr: ErrInvalidTreeObject,
+		},
+		{
+			name:    "fail: tree with duplicate entry",
+			format:  sha.SHA1Format,
+			objType: TreeObject,
+			data:    treeEntry("100644", "a") + treeEntry("40000", "a"),
+			wantErr: ErrInvalidTreeObject,
+		},
+		{
+			name:    "fail: tree with short hash",
+			format:  sha.SHA256Format,
+			objType: TreeObject,
+			data:    treeEntry("100644", "a"),
+			wantErr: ErrInvalidTreeObject,
+		},
+		{
+			name:    "success: commit",
+			format:  sha.SHA1Format,
+			objType: CommitObject,
+			data:    "tree " + hashString + "\nparent " + hashString + "\nparent " + hashString + "\nauthor " + sign + "\ncommitter " + sign + "\n\nmessage\n",
+			wantErr: nil,
+		},
+		{
+			name:    "fail: commit without tree",
+			format:  sha.SHA1Format,
+			objType: CommitObject,
+			data:    "author " + sign + "\ncommitter " + sign + "\n\nmessage\n",
+			wantErr: ErrInvalidCommitObject,
+		},
+		{
+			name:    "fail: commit with hash of other format",
+			format:  sha.SHA256Format,
+			objType: CommitObject,
+			data:    "tree " + hashString + "\nauthor " + sign + "\ncommitter " + sign + "\n\nmessage\n",
+			wantErr: ErrInvalidCommitObject,
+		},
+		{
+			name:    "fail: commit without committer",
+			format:  sha.SHA1Format,
+			objType: CommitObject,
+			data:    "tree " + hashString + "\nauthor " + sign + "\n\nmessage\n",
+			wantErr: ErrInvalidCommitObject,
+		},
+		{
+			name:    "fail: commit with bad author",
+			format:  sha.SHA1Format,
+			objType: CommitObject,
+			data:    "tree " + hashString + "\nauthor Test Taro\ncommitter " + sign + "\n\nmessage\n",
+			wantErr: ErrInvalidCommitObject,
+		},
+		{
+			name:    "success: tag",
+			format:  sha.SHA1Format,
+			objType: TagObject,
+			data:    "object " + hashString + "\ntype commit\ntag v1.0.0\ntagger " + sign + "\n\nrelease\n",
+			wantErr: nil,
+		},
+		{
+			name:    "fail: tag with bad type",
+			format:  sha.SHA1Format,
+			objType: TagObject,
+			data:    "object " + hashString + "\ntype note\ntag v1.0.0\ntagger " + sign + "\n\nrelease\n",
+			wantErr: ErrInvalidTagObject,
+		},
+		{
+			name:    "fail: tag without name",
+			format:  sha.SHA1Format,
+			objType: TagObject,
+			data:    "object " + hashString + "\ntype commit\ntagger " + sign + "\n\nrelease\n",
+			wantErr: ErrInvalidTagObject,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, _ := NewObject(tt.format, tt.objType, []byte(tt.data))
+			err := obj.Check()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
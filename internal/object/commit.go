@@ -49,8 +49,8 @@ func NewSign(name, email string) *Sign {
 
 type Commit struct {
 	*Object
-	Tree      sha.SHA1
-	Parents   []sha.SHA1
+	Tree      sha.Hash
+	Parents   []sha.Hash
 	Author    Sign
 	Committer Sign
 	Message   string
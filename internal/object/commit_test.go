@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/JunNishimura/Goit/internal/sha"
 )
 
 func TestNewSign(t *testing.T) {
@@ -55,7 +57,7 @@ func TestNewCommit(t *testing.T) {
 	}
 	tests := []*test{
 		func() *test {
-			obj, _ := NewObject(BlobObject, []byte("blob 12\x00Hello, World"))
+			obj, _ := NewObject(sha.SHA1Format, BlobObject, []byte("blob 12\x00Hello, World"))
 
 			return &test{
 				name: "fail: blob object",
@@ -7,5 +7,10 @@ var (
 	ErrInvalidTreeObject   = errors.New("invalid tree object")
 	ErrInvalidCommitObject = errors.New("invalid commit object")
 	ErrNotCommitObject     = errors.New("not commit object")
+	ErrInvalidTagObject    = errors.New("invalid tag object")
+	ErrNotTagObject        = errors.New("not tag object")
 	ErrIOHandling          = errors.New("IO handling error")
+	ErrObjectNotFound      = errors.New("object not found")
+	ErrAmbiguousHash       = errors.New("short object ID is ambiguous")
+	ErrInvalidPackIndex    = errors.New("invalid pack index")
 )
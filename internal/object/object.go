@@ -3,7 +3,6 @@ package object
 import (
 	"bytes"
 	"compress/zlib"
-	"crypto/sha1"
 	"fmt"
 	"io"
 	"os"
@@ -16,17 +15,18 @@ import (
 
 type Object struct {
 	Type Type
-	Hash sha.SHA1
+	Hash sha.Hash
 	Size int
 	Data []byte
 }
 
-func NewObject(objType Type, data []byte) (*Object, error) {
+// NewObject makes the object whose hash is computed in the object format of the repository.
+func NewObject(format *sha.Format, objType Type, data []byte) (*Object, error) {
 	// get size of data
 	size := len(data)
 
 	// get hash of object
-	checkSum := sha1.New()
+	checkSum := format.New()
 	content := fmt.Sprintf("%s %d\x00%s", objType, size, data)
 	_, err := io.WriteString(checkSum, content)
 	if err != nil {
@@ -45,7 +45,7 @@ func NewObject(objType Type, data []byte) (*Object, error) {
 	return object, nil
 }
 
-func GetObject(rootGoitPath string, hash sha.SHA1) (*Object, error) {
+func GetObject(rootGoitPath string, hash sha.Hash) (*Object, error) {
 	hashString := hash.String()
 	objPath := filepath.Join(rootGoitPath, "objects", hashString[:2], hashString[2:])
 	objFile, err := os.Open(objPath)
@@ -60,7 +60,7 @@ func GetObject(rootGoitPath string, hash sha.SHA1) (*Object, error) {
 	}
 	defer zr.Close()
 
-	checkSum := sha1.New()
+	checkSum := hash.Format().New()
 	tr := io.TeeReader(zr, checkSum)
 
 	objType, size, err := readHeader(tr)
@@ -15,6 +15,7 @@ import (
 
 func TestNewObject(t *testing.T) {
 	type args struct {
+		format  *sha.Format
 		objType Type
 		data    []byte
 	}
@@ -31,12 +32,32 @@ func TestNewObject(t *testing.T) {
 			return &test{
 				name: "success",
 				args: args{
+					format:  sha.SHA1Format,
 					objType: BlobObject,
 					data:    []byte("Hello, World"),
 				},
 				want: &Object{
 					Type: BlobObject,
-					Hash: sha.SHA1(hash),
+					Hash: sha.Hash(hash),
+					Size: len("Hello, World"),
+					Data: []byte("Hello, World"),
+				},
+				wantErr: nil,
+			}
+		}(),
+		func() *test {
+			hash, _ := hex.DecodeString("7924fb0f23c883233d0b9d6699dd0df672b64b319e080f0b3d5285ee41bc7106")
+
+			return &test{
+				name: "success: sha256",
+				args: args{
+					format:  sha.SHA256Format,
+					objType: BlobObject,
+					data:    []byte("Hello, World"),
+				},
+				want: &Object{
+					Type: BlobObject,
+					Hash: sha.Hash(hash),
 					Size: len("Hello, World"),
 					Data: []byte("Hello, World"),
 				},
@@ -46,7 +67,7 @@ func TestNewObject(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewObject(tt.args.objType, tt.args.data)
+			got, err := NewObject(tt.args.format, tt.args.objType, tt.args.data)
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("got = %v, want = %v", err, tt.wantErr)
 			}
@@ -59,7 +80,7 @@ func TestNewObject(t *testing.T) {
 
 func TestGetObject(t *testing.T) {
 	type args struct {
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name      string
@@ -84,7 +105,7 @@ func TestGetObject(t *testing.T) {
 				isCreated: true,
 				want: &Object{
 					Type: BlobObject,
-					Hash: sha.SHA1(hash),
+					Hash: sha.Hash(hash),
 					Size: len("Hello, World"),
 					Data: []byte("Hello, World"),
 				},
@@ -156,7 +177,7 @@ func TestGetObject(t *testing.T) {
 
 			// make object
 			if tt.isCreated {
-				obj, _ := NewObject(tt.objType, tt.data)
+				obj, _ := NewObject(sha.SHA1Format, tt.objType, tt.data)
 				_ = obj.Write(goitDir)
 			}
 
@@ -251,7 +272,7 @@ func TestHeader(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			obj, _ := NewObject(tt.objType, tt.data)
+			obj, _ := NewObject(sha.SHA1Format, tt.objType, tt.data)
 			b := obj.Header()
 			if !reflect.DeepEqual(b, tt.want) {
 				t.Errorf("got = %v, want = %v", b, tt.want)
@@ -322,7 +343,7 @@ func TestWrite(t *testing.T) {
 			}
 
 			// make object
-			obj, _ := NewObject(tt.objType, tt.data)
+			obj, _ := NewObject(sha.SHA1Format, tt.objType, tt.data)
 			err = obj.Write(goitDir)
 
 			if !errors.Is(err, tt.wantErr) {
@@ -383,7 +404,7 @@ func TestReflectToWorkingTree(t *testing.T) {
 				t.Logf("%v: %s", err, objectsDir)
 			}
 
-			obj, err := NewObject(BlobObject, []byte(tt.fields.data))
+			obj, err := NewObject(sha.SHA1Format, BlobObject, []byte(tt.fields.data))
 			if err != nil {
 				t.Log(err)
 			}
@@ -0,0 +1,184 @@
+package object
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+var packIndexMagic = []byte{0xff, 't', 'O', 'c'}
+
+// the object types in the pack, where the deltas refer to their base objects
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+// packIndex is the version 2 index of the pack under objects/pack, which lists the objects
+// in the pack sorted by their hashes with their offsets.
+type packIndex struct {
+	packPath string
+	hashes   []sha.Hash
+	offsets  []int64
+}
+
+// read the pack indexes under objects/pack. Nothing is returned if there is no pack.
+func readPackIndexes(rootGoitPath string, format *sha.Format) ([]*packIndex, error) {
+	idxPaths, err := filepath.Glob(filepath.Join(rootGoitPath, "objects", "pack", "pack-*.idx"))
+	if err != nil {
+		return nil, fmt.Errorf("fail to find pack indexes: %w", err)
+	}
+	var indexes []*packIndex
+	for _, idxPath := range idxPaths {
+		data, err := os.ReadFile(idxPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrIOHandling, idxPath)
+		}
+		index, err := parsePackIndex(data, format)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, filepath.Base(idxPath))
+		}
+		index.packPath = strings.TrimSuffix(idxPath, ".idx") + ".pack"
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+func parsePackIndex(data []byte, format *sha.Format) (*packIndex, error) {
+	const fanoutEnd = 8 + 256*4
+	if len(data) < fanoutEnd || !bytes.Equal(data[:4], packIndexMagic) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, ErrInvalidPackIndex
+	}
+	n := int(binary.BigEndian.Uint32(data[fanoutEnd-4 : fanoutEnd]))
+	hashStart := fanoutEnd
+	crcStart := hashStart + n*format.Size
+	offsetStart := crcStart + n*4
+	largeOffsetStart := offsetStart + n*4
+	if len(data) < largeOffsetStart {
+		return nil, ErrInvalidPackIndex
+	}
+
+	index := &packIndex{
+		hashes:  make([]sha.Hash, n),
+		offsets: make([]int64, n),
+	}
+	for i := 0; i < n; i++ {
+		index.hashes[i] = sha.Hash(data[hashStart+i*format.Size : hashStart+(i+1)*format.Size])
+		offset := binary.BigEndian.Uint32(data[offsetStart+i*4:])
+		if offset&0x80000000 == 0 {
+			index.offsets[i] = int64(offset)
+			continue
+		}
+		// the offset over 2GB is stored in the table of the 8 bytes offsets
+		pos := largeOffsetStart + int(offset&0x7fffffff)*8
+		if len(data) < pos+8 {
+			return nil, ErrInvalidPackIndex
+		}
+		index.offsets[i] = int64(binary.BigEndian.Uint64(data[pos:]))
+	}
+	return index, nil
+}
+
+// return the objects whose hash starts with the prefix in hexadecimal.
+func (p *packIndex) findPrefix(prefix string) []sha.Hash {
+	start := sort.Search(len(p.hashes), func(i int) bool {
+		return p.hashes[i].String() >= prefix
+	})
+	var hashes []sha.Hash
+	for i := start; i < len(p.hashes) && strings.HasPrefix(p.hashes[i].String(), prefix); i++ {
+		hashes = append(hashes, p.hashes[i])
+	}
+	return hashes
+}
+
+func (p *packIndex) offset(hash sha.Hash) (int64, bool) {
+	i := sort.Search(len(p.hashes), func(i int) bool {
+		return p.hashes[i].String() >= hash.String()
+	})
+	if i < len(p.hashes) && p.hashes[i].Compare(hash) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// return the type of the packed object, following the deltas to their base objects.
+func (p *packIndex) objectType(hash sha.Hash) (Type, error) {
+	offset, ok := p.offset(hash)
+	if !ok {
+		return UndefinedObject, fmt.Errorf("%w: %s is not in the pack", ErrInvalidObject, hash)
+	}
+	f, err := os.Open(p.packPath)
+	if err != nil {
+		return UndefinedObject, fmt.Errorf("%w: %s", ErrIOHandling, p.packPath)
+	}
+	defer f.Close()
+
+	for {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return UndefinedObject, fmt.Errorf("%w: %s", ErrIOHandling, p.packPath)
+		}
+		header := make([]byte, 1)
+		if _, err := io.ReadFull(f, header); err != nil {
+			return UndefinedObject, ErrInvalidObject
+		}
+		c := header[0]
+		packType := (c >> 4) & 0x7
+		// skip the rest of the size
+		for c&0x80 != 0 {
+			if _, err := io.ReadFull(f, header); err != nil {
+				return UndefinedObject, ErrInvalidObject
+			}
+			c = header[0]
+		}
+
+		switch packType {
+		case packCommit:
+			return CommitObject, nil
+		case packTree:
+			return TreeObject, nil
+		case packBlob:
+			return BlobObject, nil
+		case packTag:
+			return TagObject, nil
+		case packOfsDelta:
+			// the base is at the negative offset encoded in the variable length
+			if _, err := io.ReadFull(f, header); err != nil {
+				return UndefinedObject, ErrInvalidObject
+			}
+			c = header[0]
+			distance := int64(c & 0x7f)
+			for c&0x80 != 0 {
+				if _, err := io.ReadFull(f, header); err != nil {
+					return UndefinedObject, ErrInvalidObject
+				}
+				c = header[0]
+				distance = ((distance + 1) << 7) | int64(c&0x7f)
+			}
+			if distance <= 0 || distance > offset {
+				return UndefinedObject, ErrInvalidObject
+			}
+			offset -= distance
+		case packRefDelta:
+			base := make(sha.Hash, len(hash))
+			if _, err := io.ReadFull(f, base); err != nil {
+				return UndefinedObject, ErrInvalidObject
+			}
+			if offset, ok = p.offset(base); !ok {
+				return UndefinedObject, fmt.Errorf("%w: base %s is not in the pack", ErrInvalidObject, base)
+			}
+		default:
+			return UndefinedObject, ErrInvalidObject
+		}
+	}
+}
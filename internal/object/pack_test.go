@@ -0,0 +1,163 @@
+package object
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+type packEntry struct {
+	hash sha.Hash
+	data []byte // the entry header in the pack, whose compressed data is omitted
+}
+
+// write the pack and its version 2 index under objects/pack.
+func writePack(t *testing.T, goitDir string, entries []*packEntry) {
+	t.Helper()
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	_ = binary.Write(&pack, binary.BigEndian, uint32(2))
+	_ = binary.Write(&pack, binary.BigEndian, uint32(len(entries)))
+	offsets := make(map[string]uint32)
+	for _, entry := range entries {
+		offsets[entry.hash.String()] = uint32(pack.Len())
+		pack.Write(entry.data)
+	}
+
+	sorted := make([]*packEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].hash.String() < sorted[j].hash.String()
+	})
+	var idx bytes.Buffer
+	idx.Write(packIndexMagic)
+	_ = binary.Write(&idx, binary.BigEndian, uint32(2))
+	for i := 0; i < 256; i++ {
+		count := 0
+		for _, entry := range sorted {
+			if int(entry.hash[0]) <= i {
+				count++
+			}
+		}
+		_ = binary.Write(&idx, binary.BigEndian, uint32(count))
+	}
+	for _, entry := range sorted {
+		idx.Write(entry.hash)
+	}
+	for range sorted {
+		_ = binary.Write(&idx, binary.BigEndian, uint32(0))
+	}
+	for _, entry := range sorted {
+		_ = binary.Write(&idx, binary.BigEndian, offsets[entry.hash.String()])
+	}
+
+	packDir := filepath.Join(goitDir, "objects", "pack")
+	if err := os.MkdirAll(packDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packDir, "pack-test.pack"), pack.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packDir, "pack-test.idx"), idx.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustHash(t *testing.T, s string) sha.Hash {
+	t.Helper()
+	hash, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestPackObjectType(t *testing.T) {
+	goitDir := newObjectsDir(t)
+	commitHash := mustHash(t, "1234560000000000000000000000000000000000")
+	treeHash := mustHash(t, "1234570000000000000000000000000000000000")
+	blobHash := mustHash(t, "abcdef0000000000000000000000000000000000")
+	ofsDeltaHash := mustHash(t, "abcdef1000000000000000000000000000000000")
+	refDeltaHash := mustHash(t, "fedcba0000000000000000000000000000000000")
+	// the entries are placed after the 12 bytes pack header, each of which has the size of zero
+	writePack(t, goitDir, []*packEntry{
+		{hash: commitHash, data: []byte{packCommit << 4}},                          // offset 12
+		{hash: treeHash, data: []byte{packTree << 4}},                              // offset 13
+		{hash: blobHash, data: []byte{packBlob << 4}},                              // offset 14
+		{hash: ofsDeltaHash, data: []byte{packOfsDelta << 4, 2}},                   // offset 15, based on 13
+		{hash: refDeltaHash, data: append([]byte{packRefDelta << 4}, blobHash...)}, // offset 17
+	})
+
+	packs, err := readPackIndexes(goitDir, sha.SHA1Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 1 {
+		t.Fatalf("got = %d, want = 1", len(packs))
+	}
+
+	tests := []struct {
+		name    string
+		hash    sha.Hash
+		want    Type
+		wantErr error
+	}{
+		{name: "success: commit", hash: commitHash, want: CommitObject},
+		{name: "success: blob", hash: blobHash, want: BlobObject},
+		{name: "success: ofs delta", hash: ofsDeltaHash, want: TreeObject},
+		{name: "success: ref delta", hash: refDeltaHash, want: BlobObject},
+		{name: "fail: not in the pack", hash: mustHash(t, "0000000000000000000000000000000000000001"), want: UndefinedObject, wantErr: ErrInvalidObject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := packs[0].objectType(tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("success: resolve packed object", func(t *testing.T) {
+		got, err := ResolvePrefix(goitDir, sha.SHA1Format, "fedc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Compare(refDeltaHash) {
+			t.Errorf("got = %s, want = %s", got, refDeltaHash)
+		}
+	})
+
+	t.Run("fail: ambiguous packed objects", func(t *testing.T) {
+		_, err := ResolvePrefix(goitDir, sha.SHA1Format, "abcdef")
+		var ambiguousErr *AmbiguousError
+		if !errors.As(err, &ambiguousErr) {
+			t.Fatalf("got = %v, want = %T", err, ambiguousErr)
+		}
+		if len(ambiguousErr.Candidates) != 2 || ambiguousErr.Candidates[0].Type != BlobObject || ambiguousErr.Candidates[1].Type != TreeObject {
+			t.Errorf("got = %v, want = blob and tree", ambiguousErr.Candidates)
+		}
+	})
+
+	t.Run("success: abbreviate packed object", func(t *testing.T) {
+		abbrev := NewAbbreviator(goitDir, sha.SHA1Format, MinAbbrev)
+		if got, want := abbrev.Abbrev(commitHash), "123456"; got != want {
+			t.Errorf("got = %s, want = %s", got, want)
+		}
+	})
+}
+
+func TestParsePackIndex(t *testing.T) {
+	if _, err := parsePackIndex([]byte("not an index"), sha.SHA1Format); !errors.Is(err, ErrInvalidPackIndex) {
+		t.Errorf("got = %v, want = %v", err, ErrInvalidPackIndex)
+	}
+}
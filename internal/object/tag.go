@@ -0,0 +1,94 @@
+package object
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+type Tag struct {
+	*Object
+	Target     sha.Hash
+	TargetType Type
+	Name       string
+	Tagger     Sign
+	Message    string
+}
+
+func NewTag(o *Object) (*Tag, error) {
+	if o.Type != TagObject {
+		return nil, ErrNotTagObject
+	}
+
+	tag := &Tag{
+		Object: o,
+	}
+
+	buf := bytes.NewReader(o.Data)
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		text := scanner.Text()
+		splitText := strings.SplitN(text, " ", 2)
+		if len(splitText) != 2 {
+			break
+		}
+
+		lineType := splitText[0]
+		body := splitText[1]
+
+		switch lineType {
+		case "object":
+			hash, err := sha.ReadHash(body)
+			if err != nil {
+				return nil, ErrInvalidTagObject
+			}
+			tag.Target = hash
+		case "type":
+			targetType, err := NewType(body)
+			if err != nil {
+				return nil, ErrInvalidTagObject
+			}
+			tag.TargetType = targetType
+		case "tag":
+			tag.Name = body
+		case "tagger":
+			sign, err := readSign(body)
+			if err != nil {
+				return nil, ErrInvalidTagObject
+			}
+			tag.Tagger = sign
+		}
+	}
+	if tag.Target == nil || tag.TargetType == UndefinedObject || tag.Name == "" {
+		return nil, ErrInvalidTagObject
+	}
+
+	message := make([]string, 0)
+	for scanner.Scan() {
+		message = append(message, scanner.Text())
+	}
+	tag.Message = strings.Join(message, "\n")
+
+	return tag, nil
+}
+
+// Peel follows tag objects until it reaches an object which is not a tag.
+func Peel(rootGoitPath string, hash sha.Hash) (*Object, error) {
+	obj, err := GetObject(rootGoitPath, hash)
+	if err != nil {
+		return nil, err
+	}
+	for obj.Type == TagObject {
+		tag, err := NewTag(obj)
+		if err != nil {
+			return nil, err
+		}
+		obj, err = GetObject(rootGoitPath, tag.Target)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
@@ -0,0 +1,67 @@
+package object
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+func TestNewTag(t *testing.T) {
+	type test struct {
+		name     string
+		data     string
+		objType  Type
+		wantName string
+		wantErr  error
+	}
+	tests := []*test{
+		{
+			name:     "success",
+			data:     "object 87f3c49bccf2597484ece08746d3ee5defaba335\ntype commit\ntag v1.0.0\ntagger Test Taro <test@example.com> 1700000000 +0900\n\nrelease v1.0.0\n",
+			objType:  TagObject,
+			wantName: "v1.0.0",
+			wantErr:  nil,
+		},
+		{
+			name:     "fail: not tag object",
+			data:     "Hello, World",
+			objType:  BlobObject,
+			wantName: "",
+			wantErr:  ErrNotTagObject,
+		},
+		{
+			name:     "fail: missing object line",
+			data:     "type commit\ntag v1.0.0\n\nrelease v1.0.0\n",
+			objType:  TagObject,
+			wantName: "",
+			wantErr:  ErrInvalidTagObject,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, _ := NewObject(sha.SHA1Format, tt.objType, []byte(tt.data))
+			got, err := NewTag(obj)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("got = %s, want = %s", got.Name, tt.wantName)
+			}
+			wantTarget, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+			if !got.Target.Compare(wantTarget) {
+				t.Errorf("got = %s, want = %x", got.Target, wantTarget)
+			}
+			if got.TargetType != CommitObject {
+				t.Errorf("got = %s, want = %s", got.TargetType, CommitObject)
+			}
+			if got.Message != "release v1.0.0" {
+				t.Errorf("got = %s, want = %s", got.Message, "release v1.0.0")
+			}
+		})
+	}
+}
@@ -12,39 +12,12 @@ import (
 )
 
 type Node struct {
-	Hash     sha.SHA1
+	Hash     sha.Hash
 	Name     string
+	Mode     string // the file mode such as 100644, 100755 and 120000
 	Children []*Node
 }
 
-func (n *Node) GetPaths() []string {
-	if len(n.Children) == 0 {
-		return []string{n.Name}
-	}
-
-	var paths []string
-	for _, child := range n.Children {
-		ps := child.getPaths(n.Name)
-		paths = append(paths, ps...)
-	}
-
-	return paths
-}
-
-func (n *Node) getPaths(parentDir string) []string {
-	if len(n.Children) == 0 {
-		return []string{fmt.Sprintf("%s/%s", parentDir, n.Name)}
-	}
-
-	var paths []string
-	for _, child := range n.Children {
-		ps := child.getPaths(fmt.Sprintf("%s/%s", parentDir, n.Name))
-		paths = append(paths, ps...)
-	}
-
-	return paths
-}
-
 type Tree struct {
 	object   *Object
 	Children []*Node
@@ -80,7 +53,7 @@ func (t *Tree) load(rootGoitPath string) error {
 func walkTree(rootGoitPath string, object *Object) ([]*Node, error) {
 	var nodes []*Node
 	var isDir bool
-	var nodeName string
+	var nodeName, nodeMode string
 	isFirstLine := true
 
 	buf := bytes.NewReader(object.Data)
@@ -98,16 +71,18 @@ func walkTree(rootGoitPath string, object *Object) ([]*Node, error) {
 				isDir = true
 			}
 			nodeName = lineSplit[1]
+			nodeMode = mode
 
 			isFirstLine = false
 		} else {
-			// get 20 bytes to read hash
-			hashBytes := make([]byte, 20)
+			// read the hash, whose length is the same as the hash of the tree
+			hashSize := object.Hash.Format().Size
+			hashBytes := make([]byte, hashSize)
 			n, err := buf.Read(hashBytes)
 			if err != nil {
 				return nil, err
 			}
-			if n != 20 {
+			if n != hashSize {
 				return nil, errors.New("fail to read hash")
 			}
 
@@ -147,6 +122,7 @@ func walkTree(rootGoitPath string, object *Object) ([]*Node, error) {
 			node := &Node{
 				Hash:     hash,
 				Name:     nodeName,
+				Mode:     nodeMode,
 				Children: children,
 			}
 			nodes = append(nodes, node)
@@ -161,19 +137,29 @@ func walkTree(rootGoitPath string, object *Object) ([]*Node, error) {
 				isDir = true
 			}
 			nodeName = lineSplit[2]
+			nodeMode = mode
 		}
 	}
 
 	return nodes, nil
 }
 
+// Hash returns the hash of the tree object.
+func (t *Tree) Hash() sha.Hash {
+	return t.object.Hash
+}
+
 func (t *Tree) String() string {
 	var lines []string
 
 	for _, childNode := range t.Children {
 		var line string
 		if len(childNode.Children) == 0 {
-			line = fmt.Sprintf("100644 blob %s\t%s", childNode.Hash, childNode.Name)
+			mode := childNode.Mode
+			if mode == "" {
+				mode = "100644"
+			}
+			line = fmt.Sprintf("%s blob %s\t%s", mode, childNode.Hash, childNode.Name)
 		} else {
 			line = fmt.Sprintf("040000 tree %s\t%s", childNode.Hash, childNode.Name)
 		}
@@ -219,3 +205,24 @@ func GetNode(children []*Node, path string) (*Node, bool) {
 
 	return nil, false
 }
+
+// Files returns the hashes of the blobs in the tree keyed by their paths such as dir/file.txt.
+func (t *Tree) Files() map[string]sha.Hash {
+	files := make(map[string]sha.Hash)
+	var walk func(dir string, nodes []*Node)
+	walk = func(dir string, nodes []*Node) {
+		for _, node := range nodes {
+			path := node.Name
+			if dir != "" {
+				path = fmt.Sprintf("%s/%s", dir, node.Name)
+			}
+			if len(node.Children) == 0 {
+				files[path] = node.Hash
+			} else {
+				walk(path, node.Children)
+			}
+		}
+	}
+	walk("", t.Children)
+	return files
+}
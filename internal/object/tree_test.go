@@ -5,90 +5,9 @@ import (
 	"errors"
 	"reflect"
 	"testing"
-)
-
-func TestNewGetPaths(t *testing.T) {
-	type fields struct {
-		node Node
-	}
-	type test struct {
-		name   string
-		fields fields
-		want   []string
-	}
-	tests := []*test{
-		func() *test {
-			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-
-			return &test{
-				name: "success: empty",
-				fields: fields{
-					node: Node{
-						Hash:     hash,
-						Name:     "test.txt",
-						Children: nil,
-					},
-				},
-				want: []string{"test.txt"},
-			}
-		}(),
-		func() *test {
-			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
 
-			return &test{
-				name: "success: multiple nodes",
-				fields: fields{
-					node: Node{
-						Hash: hash,
-						Name: "dir",
-						Children: []*Node{
-							{
-								Hash: hash,
-								Name: "dir2",
-								Children: []*Node{
-									{
-										Hash:     hash,
-										Name:     "test.txt",
-										Children: nil,
-									},
-									{
-										Hash:     hash,
-										Name:     "test2.txt",
-										Children: nil,
-									},
-								},
-							},
-							{
-								Hash:     hash,
-								Name:     "test.txt",
-								Children: nil,
-							},
-							{
-								Hash:     hash,
-								Name:     "test2.txt",
-								Children: nil,
-							},
-						},
-					},
-				},
-				want: []string{
-					"dir/dir2/test.txt",
-					"dir/dir2/test2.txt",
-					"dir/test.txt",
-					"dir/test2.txt",
-				},
-			}
-		}(),
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := tt.fields.node.GetPaths()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("got = %v, want = %v", got, tt.want)
-			}
-		})
-	}
-}
+	"github.com/JunNishimura/Goit/internal/sha"
+)
 
 func TestNewTree(t *testing.T) {
 	type args struct {
@@ -109,11 +28,12 @@ func TestNewTree(t *testing.T) {
 			data = append(data, 0x00)
 			data = append(data, hash...)
 
-			object, _ := NewObject(TreeObject, data)
+			object, _ := NewObject(sha.SHA1Format, TreeObject, data)
 
 			node := &Node{
 				Hash:     hash,
 				Name:     "test.txt",
+				Mode:     "100644",
 				Children: []*Node{},
 			}
 
@@ -130,7 +50,36 @@ func TestNewTree(t *testing.T) {
 			}
 		}(),
 		func() *test {
-			object, _ := NewObject(BlobObject, []byte("blob 12\x00Hello, World"))
+			hash, _ := hex.DecodeString("7924fb0f23c883233d0b9d6699dd0df672b64b319e080f0b3d5285ee41bc7106")
+
+			var data []byte
+			data = append(data, []byte("100755 run.sh")...)
+			data = append(data, 0x00)
+			data = append(data, hash...)
+
+			object, _ := NewObject(sha.SHA256Format, TreeObject, data)
+
+			node := &Node{
+				Hash:     hash,
+				Name:     "run.sh",
+				Mode:     "100755",
+				Children: []*Node{},
+			}
+
+			return &test{
+				name: "success: sha256",
+				args: args{
+					object: object,
+				},
+				want: &Tree{
+					object:   object,
+					Children: []*Node{node},
+				},
+				wantErr: nil,
+			}
+		}(),
+		func() *test {
+			object, _ := NewObject(sha.SHA1Format, BlobObject, []byte("blob 12\x00Hello, World"))
 
 			return &test{
 				name: "fail: invalid tree object",
@@ -166,6 +115,9 @@ func TestNewTree(t *testing.T) {
 				if gotChild.Name != wantChild.Name {
 					t.Errorf("got = %v, want = %v", gotChild.Name, wantChild.Name)
 				}
+				if gotChild.Mode != wantChild.Mode {
+					t.Errorf("got = %v, want = %v", gotChild.Mode, wantChild.Mode)
+				}
 				if len(gotChild.Children) != len(wantChild.Children) {
 					t.Errorf("got = %v, want = %v", len(gotChild.Children), len(wantChild.Children))
 				}
@@ -193,7 +145,7 @@ func TestString(t *testing.T) {
 			data = append(data, 0x00)
 			data = append(data, hash...)
 
-			object, _ := NewObject(TreeObject, data)
+			object, _ := NewObject(sha.SHA1Format, TreeObject, data)
 
 			return &test{
 				name: "success",
@@ -0,0 +1,326 @@
+package pathspec
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrOutsideRepository = errors.New("outside repository")
+	ErrInvalidMagic      = errors.New("invalid pathspec magic")
+)
+
+// item is a single pathspec element resolved against the repository root.
+type item struct {
+	original string // the argument as given on the command line
+	pattern  string // the path from the repository root
+	exclude  bool
+	icase    bool // match case-insensitively
+	literal  bool // treat the wildcards as ordinary characters
+	glob     bool // wildcards do not match "/" except "**"
+	hasGlob  bool
+}
+
+// Pathspec is a list of patterns which selects paths in the repository.
+// The paths are slash separated and relative to the repository root.
+type Pathspec struct {
+	items   []*item
+	matched map[*item]bool
+}
+
+// Parse parses the pathspec arguments given in the directory prefix, which is the
+// current directory relative to the repository root ("" for the root itself).
+func Parse(args []string, prefix string) (*Pathspec, error) {
+	ps := &Pathspec{
+		matched: make(map[*item]bool),
+	}
+	for _, arg := range args {
+		it, err := parseItem(arg, prefix)
+		if err != nil {
+			return nil, err
+		}
+		ps.items = append(ps.items, it)
+	}
+	return ps, nil
+}
+
+func parseItem(arg, prefix string) (*item, error) {
+	it := &item{original: arg}
+	pattern := arg
+	isTop := false
+
+	// parse the magic signature
+	if strings.HasPrefix(pattern, ":(") {
+		end := strings.Index(pattern, ")")
+		if end < 0 {
+			return nil, fmt.Errorf("%w: missing ')' at the end of pathspec magic in '%s'", ErrInvalidMagic, arg)
+		}
+		for _, magic := range strings.Split(pattern[2:end], ",") {
+			switch strings.TrimSpace(magic) {
+			case "top":
+				isTop = true
+			case "exclude":
+				it.exclude = true
+			case "icase":
+				it.icase = true
+			case "literal":
+				it.literal = true
+			case "glob":
+				it.glob = true
+			case "":
+			default:
+				return nil, fmt.Errorf("%w: '%s' in '%s'", ErrInvalidMagic, magic, arg)
+			}
+		}
+		if it.literal && it.glob {
+			return nil, fmt.Errorf("%w: 'literal' and 'glob' are incompatible in '%s'", ErrInvalidMagic, arg)
+		}
+		pattern = pattern[end+1:]
+	} else if strings.HasPrefix(pattern, ":") {
+		// short form such as :/path and :!path
+		i := 1
+	loop:
+		for ; i < len(pattern); i++ {
+			switch pattern[i] {
+			case '/':
+				isTop = true
+			case '!', '^':
+				it.exclude = true
+			case ':':
+				i++
+				break loop
+			default:
+				break loop
+			}
+		}
+		pattern = pattern[i:]
+	}
+
+	if !isTop && prefix != "" {
+		pattern = prefix + "/" + pattern
+	}
+	pattern = path.Clean(pattern)
+	if pattern == ".." || strings.HasPrefix(pattern, "../") {
+		return nil, fmt.Errorf("fatal: %s: '%s' is %w", arg, arg, ErrOutsideRepository)
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "." {
+		pattern = ""
+	}
+	if it.icase {
+		pattern = strings.ToLower(pattern)
+	}
+	it.pattern = pattern
+	it.hasGlob = !it.literal && strings.ContainsAny(pattern, "*?[")
+
+	return it, nil
+}
+
+// IsEmpty tells whether no pathspec is given, which matches every path.
+func (ps *Pathspec) IsEmpty() bool {
+	return len(ps.items) == 0
+}
+
+// Match tells whether the path is selected by the pathspec.
+// A path is selected if any including item matches it and no excluding item does.
+func (ps *Pathspec) Match(p string) bool {
+	isIncluded := false
+	hasInclude := false
+	for _, it := range ps.items {
+		if it.exclude {
+			continue
+		}
+		hasInclude = true
+		if it.match(p) {
+			isIncluded = true
+			ps.matched[it] = true
+		}
+	}
+	if !hasInclude {
+		// only the excluding items are given, so everything else is included
+		isIncluded = true
+	}
+	if !isIncluded {
+		return false
+	}
+	for _, it := range ps.items {
+		if it.exclude && it.match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the paths selected by the pathspec.
+func (ps *Pathspec) Filter(paths []string) []string {
+	var filtered []string
+	for _, p := range paths {
+		if ps.Match(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// Unmatched returns the including arguments which have not matched any path passed to Match so far.
+func (ps *Pathspec) Unmatched() []string {
+	var unmatched []string
+	for _, it := range ps.items {
+		if !it.exclude && !ps.matched[it] {
+			unmatched = append(unmatched, it.original)
+		}
+	}
+	return unmatched
+}
+
+func (it *item) match(p string) bool {
+	if it.icase {
+		p = strings.ToLower(p)
+	}
+	// empty pattern comes from "." at the root and matches everything
+	if it.pattern == "" || p == it.pattern || strings.HasPrefix(p, it.pattern+"/") {
+		return true
+	}
+	if !it.hasGlob {
+		return false
+	}
+	return Wildmatch(it.pattern, p, it.glob)
+}
+
+// Wildmatch matches the name against the shell glob pattern.
+// The wildcards match "/" as well, which is the default of git pathspec, unless pathname is set.
+// With pathname, only "**" as a whole path component matches across directories.
+func Wildmatch(pattern, name string, pathname bool) bool {
+	return match(pattern, name, pathname, true)
+}
+
+// match the pattern against the name, where segStart tells the pattern starts at the head of a path component.
+func match(pattern, name string, pathname, segStart bool) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			if pathname && segStart && strings.HasPrefix(pattern, "**") && (len(pattern) == 2 || pattern[2] == '/') {
+				rest := strings.TrimPrefix(pattern[2:], "/")
+				if pattern == "**" {
+					return true
+				}
+				// "**/" matches zero or more directories
+				if match(rest, name, pathname, true) {
+					return true
+				}
+				for i := 0; i < len(name); i++ {
+					if name[i] == '/' && match(rest, name[i+1:], pathname, true) {
+						return true
+					}
+				}
+				return false
+			}
+			// collapse consecutive stars
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return !pathname || !strings.Contains(name, "/")
+			}
+			for i := 0; i <= len(name); i++ {
+				if match(pattern, name[i:], pathname, false) {
+					return true
+				}
+				if pathname && i < len(name) && name[i] == '/' {
+					break
+				}
+			}
+			return false
+		case '?':
+			if name == "" || (pathname && name[0] == '/') {
+				return false
+			}
+			segStart = name[0] == '/'
+			pattern, name = pattern[1:], name[1:]
+		case '[':
+			if name == "" || (pathname && name[0] == '/') {
+				return false
+			}
+			matched, rest, ok := matchClass(pattern, name[0])
+			if !ok {
+				// unterminated class is compared literally
+				if name[0] != '[' {
+					return false
+				}
+				segStart = false
+				pattern, name = pattern[1:], name[1:]
+				continue
+			}
+			if !matched {
+				return false
+			}
+			segStart = false
+			pattern, name = rest, name[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if name == "" || pattern[0] != name[0] {
+				return false
+			}
+			segStart = name[0] == '/'
+			pattern, name = pattern[1:], name[1:]
+		}
+	}
+	return name == ""
+}
+
+// match the character against the bracket expression at the head of the pattern,
+// returning the rest of the pattern after the expression.
+func matchClass(pattern string, c byte) (matched bool, rest string, ok bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negate, pattern[i+1:], true
+		}
+		first = false
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+		i++
+	}
+	return false, "", false
+}
+
+// Prefix returns the directory relative to the working tree root, slash separated
+// and "" for the root itself, which is the prefix to parse the pathspec given in the directory.
+func Prefix(workDir, dir string) (string, error) {
+	rel, err := filepath.Rel(workDir, dir)
+	if err != nil {
+		return "", fmt.Errorf("fail to get relative path: %w", err)
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("'%s' is %w", dir, ErrOutsideRepository)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return rel, nil
+}
@@ -0,0 +1,164 @@
+package pathspec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	paths := []string{"README.md", "cmd/main.go", "cmd/sub/sub.go", "internal/a.go", "vendor/lib.go", "docs/a.txt", "docs/*.txt"}
+	type args struct {
+		args   []string
+		prefix string
+	}
+	type test struct {
+		name string
+		args args
+		want []string
+	}
+	tests := []*test{
+		{
+			name: "success: file",
+			args: args{args: []string{"README.md"}},
+			want: []string{"README.md"},
+		},
+		{
+			name: "success: directory",
+			args: args{args: []string{"cmd"}},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go"},
+		},
+		{
+			name: "success: dot in sub directory",
+			args: args{args: []string{"."}, prefix: "cmd"},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go"},
+		},
+		{
+			name: "success: parent directory",
+			args: args{args: []string{"../README.md"}, prefix: "cmd"},
+			want: []string{"README.md"},
+		},
+		{
+			name: "success: glob across directories",
+			args: args{args: []string{"*.go"}},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go", "internal/a.go", "vendor/lib.go"},
+		},
+		{
+			name: "success: glob in sub directory",
+			args: args{args: []string{"*.go"}, prefix: "cmd"},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go"},
+		},
+		{
+			name: "success: character class",
+			args: args{args: []string{"[a-c]*/*.go"}},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go"},
+		},
+		{
+			name: "success: exclude",
+			args: args{args: []string{"*.go", ":(exclude)vendor"}},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go", "internal/a.go"},
+		},
+		{
+			name: "success: exclude only",
+			args: args{args: []string{":!*.go"}},
+			want: []string{"README.md", "docs/a.txt", "docs/*.txt"},
+		},
+		{
+			name: "success: top",
+			args: args{args: []string{":(top)docs", ":/README.md"}, prefix: "cmd"},
+			want: []string{"README.md", "docs/a.txt", "docs/*.txt"},
+		},
+		{
+			name: "success: icase",
+			args: args{args: []string{":(icase)readme.MD", ":(icase)CMD/*.GO"}},
+			want: []string{"README.md", "cmd/main.go", "cmd/sub/sub.go"},
+		},
+		{
+			name: "success: literal",
+			args: args{args: []string{":(literal)docs/*.txt"}},
+			want: []string{"docs/*.txt"},
+		},
+		{
+			name: "success: glob does not cross directories",
+			args: args{args: []string{":(glob)cmd/*.go"}},
+			want: []string{"cmd/main.go"},
+		},
+		{
+			name: "success: glob with leading double star",
+			args: args{args: []string{":(glob)**/sub.go"}},
+			want: []string{"cmd/sub/sub.go"},
+		},
+		{
+			name: "success: glob with inner double star",
+			args: args{args: []string{":(glob)cmd/**/*.go"}},
+			want: []string{"cmd/main.go", "cmd/sub/sub.go"},
+		},
+		{
+			name: "success: glob with trailing double star",
+			args: args{args: []string{":(glob)docs/**"}},
+			want: []string{"docs/a.txt", "docs/*.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := Parse(tt.args.args, tt.args.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ps.Filter(paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmatched(t *testing.T) {
+	ps, err := Parse([]string{"cmd", "nothing", ":!vendor"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.Filter([]string{"cmd/main.go", "vendor/lib.go"})
+	if got, want := ps.Unmatched(), []string{"nothing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	type test struct {
+		name    string
+		arg     string
+		prefix  string
+		wantErr error
+	}
+	tests := []*test{
+		{
+			name:    "success",
+			arg:     "../a.go",
+			prefix:  "cmd",
+			wantErr: nil,
+		},
+		{
+			name:    "fail: outside repository",
+			arg:     "../a.go",
+			prefix:  "",
+			wantErr: ErrOutsideRepository,
+		},
+		{
+			name:    "fail: literal and glob",
+			arg:     ":(literal,glob)a.go",
+			wantErr: ErrInvalidMagic,
+		},
+		{
+			name:    "fail: unknown magic",
+			arg:     ":(unknown)a.go",
+			wantErr: ErrInvalidMagic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]string{tt.arg}, tt.prefix); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
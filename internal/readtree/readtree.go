@@ -0,0 +1,191 @@
+package readtree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+var (
+	ErrUnmergedIndex = errors.New("you need to resolve your current index first")
+	ErrPrefixExists  = errors.New("subdirectory already exists")
+)
+
+// WouldOverwriteError tells the path whose entry in the index differs from the trees
+// in the way the merge cannot carry over.
+type WouldOverwriteError struct {
+	Path string
+}
+
+func (e *WouldOverwriteError) Error() string {
+	return fmt.Sprintf("Entry '%s' would be overwritten by merge. Cannot merge.", e.Path)
+}
+
+// Files is the blobs of the tree keyed by their paths such as dir/file.txt.
+type Files map[string]sha.Hash
+
+func sameHash(a, b sha.Hash) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Compare(b)
+}
+
+func stageEntry(path string, hash sha.Hash, stage int) *store.Entry {
+	entry := store.NewEntry(hash, []byte(path))
+	entry.Stage = stage
+	return entry
+}
+
+// return the sorted union of the paths of the trees and the merged entries of the index.
+func unionPaths(index *store.Index, trees ...Files) []string {
+	isAdded := make(map[string]bool)
+	var paths []string
+	add := func(path string) {
+		if !isAdded[path] {
+			isAdded[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, entry := range index.Entries {
+		add(string(entry.Path))
+	}
+	for _, tree := range trees {
+		for path := range tree {
+			add(path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// return the hash of the merged entry of the path in the index, or nil if it is not registered.
+func indexHash(index *store.Index, path string) sha.Hash {
+	entry, ok := index.GetStageEntry([]byte(path), 0)
+	if !ok {
+		return nil
+	}
+	return entry.Hash
+}
+
+// apply the entries decided for each path, where no entry removes the path from the index.
+func apply(index *store.Index, result map[string][]*store.Entry) {
+	for path, entries := range result {
+		index.Put([]byte(path), entries...)
+	}
+}
+
+// Read replaces the index with the blobs of the tree.
+func Read(index *store.Index, tree Files) {
+	result := make(map[string][]*store.Entry)
+	for _, path := range unionPaths(index, tree) {
+		if hash, ok := tree[path]; ok {
+			result[path] = []*store.Entry{stageEntry(path, hash, 0)}
+		} else {
+			result[path] = nil
+		}
+	}
+	apply(index, result)
+}
+
+// ReadPrefix reads the blobs of the tree into the directory of the prefix, keeping the rest of the index.
+// The directory must not be registered in the index yet.
+func ReadPrefix(index *store.Index, prefix string, tree Files) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return fmt.Errorf("%w: empty prefix", ErrPrefixExists)
+	}
+	if _, _, ok := index.GetEntry([]byte(prefix)); ok || index.IsRegisteredAsDirectory(prefix) {
+		return fmt.Errorf("%w: '%s/'", ErrPrefixExists, prefix)
+	}
+	result := make(map[string][]*store.Entry)
+	for path, hash := range tree {
+		fullPath := prefix + "/" + path
+		result[fullPath] = []*store.Entry{stageEntry(fullPath, hash, 0)}
+	}
+	apply(index, result)
+	return nil
+}
+
+// TwoWay moves the index from the head tree to the merge tree, carrying the changes in the index over
+// as git read-tree -m <head> <merge> does. The index is left untouched if any change would be lost.
+func TwoWay(index *store.Index, head, merge Files) error {
+	if len(index.UnmergedPaths()) > 0 {
+		return ErrUnmergedIndex
+	}
+	result := make(map[string][]*store.Entry)
+	for _, path := range unionPaths(index, head, merge) {
+		i, h, m := indexHash(index, path), head[path], merge[path]
+		var take sha.Hash
+		switch {
+		case sameHash(h, m):
+			// the path is not changed between the trees, so the index is kept
+			take = i
+		case sameHash(i, h):
+			// the index is not changed from the head, so the merge tree is taken
+			take = m
+		case sameHash(i, m):
+			// the index already has the change of the merge tree
+			take = i
+		default:
+			return &WouldOverwriteError{Path: path}
+		}
+		if take == nil {
+			result[path] = nil
+		} else {
+			result[path] = []*store.Entry{stageEntry(path, take, 0)}
+		}
+	}
+	apply(index, result)
+	return nil
+}
+
+// ThreeWay merges the ours tree and the theirs tree on the base tree into the index as git read-tree -m
+// <base> <ours> <theirs> does. The trivial merges are collapsed into stage 0 and the others are left
+// in the stages 1 (base), 2 (ours) and 3 (theirs) for the paths present in each tree.
+// The index must match the ours tree for the paths in the trees.
+func ThreeWay(index *store.Index, base, ours, theirs Files) error {
+	if len(index.UnmergedPaths()) > 0 {
+		return ErrUnmergedIndex
+	}
+	result := make(map[string][]*store.Entry)
+	for _, path := range unionPaths(index, base, ours, theirs) {
+		i := indexHash(index, path)
+		o, a, b := base[path], ours[path], theirs[path]
+		if o == nil && a == nil && b == nil {
+			// the path only in the index is kept as it is
+			continue
+		}
+		if i != nil && !sameHash(i, a) {
+			return &WouldOverwriteError{Path: path}
+		}
+
+		var merged sha.Hash
+		switch {
+		case a != nil && sameHash(a, b):
+			merged = a
+		case o != nil && a != nil && b != nil && sameHash(o, a):
+			merged = b
+		case o != nil && a != nil && b != nil && sameHash(o, b):
+			merged = a
+		}
+		if merged != nil {
+			result[path] = []*store.Entry{stageEntry(path, merged, 0)}
+			continue
+		}
+
+		var entries []*store.Entry
+		for stage, hash := range []sha.Hash{o, a, b} {
+			if hash != nil {
+				entries = append(entries, stageEntry(path, hash, stage+1))
+			}
+		}
+		result[path] = entries
+	}
+	apply(index, result)
+	return nil
+}
@@ -0,0 +1,244 @@
+package readtree
+
+import (
+	"crypto/sha1"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+func testHash(content string) sha.Hash {
+	sum := sha1.Sum([]byte(content))
+	return sum[:]
+}
+
+// make the index whose merged entries have the hashes of the contents.
+func newTestIndex(t *testing.T, files map[string]string) *store.Index {
+	t.Helper()
+	index, err := store.NewIndex(t.TempDir(), sha.SHA1Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		index.Put([]byte(path), store.NewEntry(testHash(content), []byte(path)))
+	}
+	return index
+}
+
+func testFiles(files map[string]string) Files {
+	tree := make(Files)
+	for path, content := range files {
+		tree[path] = testHash(content)
+	}
+	return tree
+}
+
+// describe the entries of the index as "path:stage:content" to compare.
+func dumpIndex(index *store.Index, contents ...string) []string {
+	names := make(map[string]string)
+	for _, content := range contents {
+		names[testHash(content).String()] = content
+	}
+	var got []string
+	for _, entry := range index.Entries {
+		got = append(got, string(entry.Path)+":"+string(rune('0'+entry.Stage))+":"+names[entry.Hash.String()])
+	}
+	return got
+}
+
+func TestRead(t *testing.T) {
+	index := newTestIndex(t, map[string]string{"a": "1", "old": "2"})
+	Read(index, testFiles(map[string]string{"a": "3", "dir/b": "4"}))
+	want := []string{"a:0:3", "dir/b:0:4"}
+	if got := dumpIndex(index, "1", "2", "3", "4"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
+
+func TestReadPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		index   map[string]string
+		prefix  string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "success",
+			index:  map[string]string{"a": "1"},
+			prefix: "sub/",
+			want:   []string{"a:0:1", "sub/a:0:2", "sub/dir/b:0:3"},
+		},
+		{
+			name:    "fail: directory exists",
+			index:   map[string]string{"sub/x": "1"},
+			prefix:  "sub/",
+			want:    []string{"sub/x:0:1"},
+			wantErr: ErrPrefixExists,
+		},
+		{
+			name:    "fail: file exists",
+			index:   map[string]string{"sub": "1"},
+			prefix:  "sub",
+			want:    []string{"sub:0:1"},
+			wantErr: ErrPrefixExists,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := newTestIndex(t, tt.index)
+			err := ReadPrefix(index, tt.prefix, testFiles(map[string]string{"a": "2", "dir/b": "3"}))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got := dumpIndex(index, "1", "2", "3"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwoWay(t *testing.T) {
+	tests := []struct {
+		name        string
+		index       map[string]string
+		head        map[string]string
+		merge       map[string]string
+		want        []string
+		wantErrPath string
+	}{
+		{
+			name:  "success: take merge for unchanged index",
+			index: map[string]string{"a": "1", "b": "1", "c": "1"},
+			head:  map[string]string{"a": "1", "b": "1", "c": "1"},
+			merge: map[string]string{"a": "2", "c": "1", "d": "2"},
+			want:  []string{"a:0:2", "c:0:1", "d:0:2"},
+		},
+		{
+			name:  "success: keep index for unchanged path",
+			index: map[string]string{"a": "3", "new": "3"},
+			head:  map[string]string{"a": "1"},
+			merge: map[string]string{"a": "1"},
+			want:  []string{"a:0:3", "new:0:3"},
+		},
+		{
+			name:  "success: index already has the change",
+			index: map[string]string{"a": "2"},
+			head:  map[string]string{"a": "1"},
+			merge: map[string]string{"a": "2"},
+			want:  []string{"a:0:2"},
+		},
+		{
+			name:        "fail: modified in both",
+			index:       map[string]string{"a": "3", "b": "1"},
+			head:        map[string]string{"a": "1", "b": "1"},
+			merge:       map[string]string{"a": "2", "b": "2"},
+			want:        []string{"a:0:3", "b:0:1"},
+			wantErrPath: "a",
+		},
+		{
+			name:        "fail: removed from index but changed",
+			index:       map[string]string{},
+			head:        map[string]string{"a": "1"},
+			merge:       map[string]string{"a": "2"},
+			want:        nil,
+			wantErrPath: "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := newTestIndex(t, tt.index)
+			err := TwoWay(index, testFiles(tt.head), testFiles(tt.merge))
+			var overwriteErr *WouldOverwriteError
+			if tt.wantErrPath == "" && err != nil {
+				t.Fatalf("got = %v, want = nil", err)
+			}
+			if tt.wantErrPath != "" && (!errors.As(err, &overwriteErr) || overwriteErr.Path != tt.wantErrPath) {
+				t.Fatalf("got = %v, want error on %s", err, tt.wantErrPath)
+			}
+			if got := dumpIndex(index, "1", "2", "3"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreeWay(t *testing.T) {
+	tests := []struct {
+		name        string
+		index       map[string]string
+		base        map[string]string
+		ours        map[string]string
+		theirs      map[string]string
+		want        []string
+		wantErrPath string
+	}{
+		{
+			name:   "success: trivial merges",
+			index:  map[string]string{"same": "1", "ours": "2", "theirs": "1", "both": "2"},
+			base:   map[string]string{"same": "1", "ours": "1", "theirs": "1", "both": "1"},
+			ours:   map[string]string{"same": "1", "ours": "2", "theirs": "1", "both": "2"},
+			theirs: map[string]string{"same": "1", "ours": "1", "theirs": "3", "both": "2"},
+			want:   []string{"both:0:2", "ours:0:2", "same:0:1", "theirs:0:3"},
+		},
+		{
+			name:   "success: conflicts into stages",
+			index:  map[string]string{"conflict": "2", "deleted": "1"},
+			base:   map[string]string{"conflict": "1", "deleted": "1"},
+			ours:   map[string]string{"conflict": "2", "deleted": "1"},
+			theirs: map[string]string{"conflict": "3", "added": "3"},
+			want: []string{
+				"added:3:3",
+				"conflict:1:1", "conflict:2:2", "conflict:3:3",
+				"deleted:1:1", "deleted:2:1",
+			},
+		},
+		{
+			name:   "success: path only in index is kept",
+			index:  map[string]string{"local": "3"},
+			base:   map[string]string{},
+			ours:   map[string]string{},
+			theirs: map[string]string{"a": "1"},
+			want:   []string{"a:3:1", "local:0:3"},
+		},
+		{
+			name:        "fail: index differs from ours",
+			index:       map[string]string{"a": "3"},
+			base:        map[string]string{"a": "1"},
+			ours:        map[string]string{"a": "1"},
+			theirs:      map[string]string{"a": "2"},
+			want:        []string{"a:0:3"},
+			wantErrPath: "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := newTestIndex(t, tt.index)
+			err := ThreeWay(index, testFiles(tt.base), testFiles(tt.ours), testFiles(tt.theirs))
+			var overwriteErr *WouldOverwriteError
+			if tt.wantErrPath == "" && err != nil {
+				t.Fatalf("got = %v, want = nil", err)
+			}
+			if tt.wantErrPath != "" && (!errors.As(err, &overwriteErr) || overwriteErr.Path != tt.wantErrPath) {
+				t.Fatalf("got = %v, want error on %s", err, tt.wantErrPath)
+			}
+			if got := dumpIndex(index, "1", "2", "3"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmergedIndex(t *testing.T) {
+	index := newTestIndex(t, map[string]string{})
+	index.Put([]byte("a"), &store.Entry{Hash: testHash("1"), NameLength: 1, Path: []byte("a"), Stage: 2})
+	if err := TwoWay(index, Files{}, Files{}); !errors.Is(err, ErrUnmergedIndex) {
+		t.Errorf("got = %v, want = %v", err, ErrUnmergedIndex)
+	}
+	if err := ThreeWay(index, Files{}, Files{}, Files{}); !errors.Is(err, ErrUnmergedIndex) {
+		t.Errorf("got = %v, want = %v", err, ErrUnmergedIndex)
+	}
+}
@@ -0,0 +1,493 @@
+package refformat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	DefaultFormat = "%(objectname) %(objecttype)\t%(refname)"
+	defaultAbbrev = 7
+)
+
+var (
+	ErrInvalidFormat = errors.New("fatal: invalid format")
+	ErrUnknownAtom   = errors.New("fatal: unknown field name")
+)
+
+// Ref holds everything the atoms of a format can refer to.
+type Ref struct {
+	Name     string // full ref name such as refs/heads/main
+	Hash     sha.Hash
+	Symref   string         // the target of the symbolic ref, empty otherwise
+	Upstream string         // full ref name of the upstream, empty if not set
+	IsHead   bool           // true if HEAD points to the ref
+	Object   *object.Object // the object the ref points to
+	Peeled   *object.Object // the object the annotated tag points to, nil otherwise
+
+	commit *object.Commit
+	tag    *object.Tag
+}
+
+func (r *Ref) getCommit() *object.Commit {
+	if r.commit == nil && r.Object != nil && r.Object.Type == object.CommitObject {
+		commit, err := object.NewCommit(r.Object)
+		if err == nil {
+			r.commit = commit
+		}
+	}
+	return r.commit
+}
+
+func (r *Ref) getTag() *object.Tag {
+	if r.tag == nil && r.Object != nil && r.Object.Type == object.TagObject {
+		tag, err := object.NewTag(r.Object)
+		if err == nil {
+			r.tag = tag
+		}
+	}
+	return r.tag
+}
+
+type atom struct {
+	name     string // atom name without the modifier, such as "refname"
+	modifier string // the part after the colon, such as "short"
+	isDeref  bool   // true if the atom starts with '*'
+}
+
+func parseAtom(text string) (*atom, error) {
+	a := &atom{}
+	if strings.HasPrefix(text, "*") {
+		a.isDeref = true
+		text = text[1:]
+	}
+	name, modifier, _ := strings.Cut(text, ":")
+	a.name = name
+	a.modifier = modifier
+
+	switch a.name {
+	case "refname", "upstream", "symref":
+		if a.modifier != "" && a.modifier != "short" &&
+			!strings.HasPrefix(a.modifier, "lstrip=") && !strings.HasPrefix(a.modifier, "rstrip=") {
+			return nil, fmt.Errorf("%w: unrecognized %%(%s) argument: %s", ErrInvalidFormat, a.name, a.modifier)
+		}
+	case "objectname":
+		if a.modifier != "" && a.modifier != "short" && !strings.HasPrefix(a.modifier, "short=") {
+			return nil, fmt.Errorf("%w: unrecognized %%(objectname) argument: %s", ErrInvalidFormat, a.modifier)
+		}
+	case "authordate", "committerdate", "taggerdate", "creatordate":
+		if _, err := formatDate(time.Time{}, a.modifier); err != nil {
+			return nil, err
+		}
+	case "objecttype", "objectsize", "tree", "parent",
+		"author", "authorname", "authoremail",
+		"committer", "committername", "committeremail",
+		"tagger", "taggername", "taggeremail", "creator",
+		"subject", "body", "contents", "HEAD":
+		if a.modifier != "" {
+			return nil, fmt.Errorf("%w: %%(%s) does not take arguments", ErrInvalidFormat, a.name)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAtom, text)
+	}
+
+	return a, nil
+}
+
+func (a *atom) isDate() bool {
+	return strings.HasSuffix(a.name, "date")
+}
+
+func formatDate(t time.Time, modifier string) (string, error) {
+	switch modifier {
+	case "", "default":
+		return t.Format("Mon Jan 2 15:04:05 2006 -0700"), nil
+	case "iso", "iso8601":
+		return t.Format("2006-01-02 15:04:05 -0700"), nil
+	case "iso-strict", "iso8601-strict":
+		return t.Format(time.RFC3339), nil
+	case "rfc", "rfc2822":
+		return t.Format("Mon, 2 Jan 2006 15:04:05 -0700"), nil
+	case "short":
+		return t.Format("2006-01-02"), nil
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "raw":
+		return fmt.Sprintf("%d %s", t.Unix(), t.Format("-0700")), nil
+	default:
+		return "", fmt.Errorf("%w: unknown date format: %s", ErrInvalidFormat, modifier)
+	}
+}
+
+func stripRefName(name, modifier string) (string, error) {
+	switch {
+	case modifier == "":
+		return name, nil
+	case modifier == "short":
+		return shortenRefName(name), nil
+	case strings.HasPrefix(modifier, "lstrip="), strings.HasPrefix(modifier, "rstrip="):
+		n, err := strconv.Atoi(modifier[len("lstrip="):])
+		if err != nil {
+			return "", fmt.Errorf("%w: integer value expected: %s", ErrInvalidFormat, modifier)
+		}
+		components := strings.Split(name, "/")
+		// negative number means how many components to keep
+		if n < 0 {
+			n = len(components) + n
+			if n < 0 {
+				n = 0
+			}
+		}
+		if n > len(components) {
+			n = len(components)
+		}
+		if strings.HasPrefix(modifier, "lstrip=") {
+			return strings.Join(components[n:], "/"), nil
+		}
+		return strings.Join(components[:len(components)-n], "/"), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidFormat, modifier)
+}
+
+func shortenRefName(name string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/", "refs/"} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
+
+// split the message into the subject and the body, which are separated by a blank line.
+func splitMessage(message string) (string, string) {
+	message = strings.TrimLeft(message, "\n")
+	subject, body, _ := strings.Cut(message, "\n\n")
+	subject = strings.Join(strings.Fields(subject), " ")
+	return subject, strings.TrimLeft(body, "\n")
+}
+
+func signPart(sign object.Sign, part string) string {
+	switch part {
+	case "name":
+		return sign.Name
+	case "email":
+		return fmt.Sprintf("<%s>", sign.Email)
+	default:
+		return fmt.Sprintf("%s <%s> %d %s", sign.Name, sign.Email, sign.Timestamp.Unix(), sign.Timestamp.Format("-0700"))
+	}
+}
+
+// return the sign for the atom such as "committername", or false if the object has no such sign.
+func (a *atom) sign(r *Ref) (object.Sign, string, bool) {
+	for _, role := range []string{"author", "committer", "tagger", "creator"} {
+		if !strings.HasPrefix(a.name, role) {
+			continue
+		}
+		part := strings.TrimPrefix(a.name, role)
+		if part == "date" {
+			part = ""
+		}
+		commit, tag := r.getCommit(), r.getTag()
+		switch {
+		case commit != nil && role == "author":
+			return commit.Author, part, true
+		case commit != nil && (role == "committer" || role == "creator"):
+			return commit.Committer, part, true
+		case tag != nil && (role == "tagger" || role == "creator"):
+			return tag.Tagger, part, true
+		}
+		return object.Sign{}, "", false
+	}
+	return object.Sign{}, "", false
+}
+
+func (a *atom) value(r *Ref) (string, error) {
+	if a.isDeref {
+		if r.Peeled == nil {
+			return "", nil
+		}
+		deref := &atom{name: a.name, modifier: a.modifier}
+		return deref.value(&Ref{Name: r.Name, Hash: r.Peeled.Hash, Object: r.Peeled})
+	}
+
+	switch a.name {
+	case "refname":
+		return stripRefName(r.Name, a.modifier)
+	case "upstream":
+		if r.Upstream == "" {
+			return "", nil
+		}
+		return stripRefName(r.Upstream, a.modifier)
+	case "symref":
+		if r.Symref == "" {
+			return "", nil
+		}
+		return stripRefName(r.Symref, a.modifier)
+	case "HEAD":
+		if r.IsHead {
+			return "*", nil
+		}
+		return " ", nil
+	case "objectname":
+		hash := r.Hash.String()
+		if a.modifier == "" {
+			return hash, nil
+		}
+		length := defaultAbbrev
+		if strings.HasPrefix(a.modifier, "short=") {
+			n, err := strconv.Atoi(strings.TrimPrefix(a.modifier, "short="))
+			if err != nil {
+				return "", fmt.Errorf("%w: integer value expected: %s", ErrInvalidFormat, a.modifier)
+			}
+			length = n
+		}
+		if length < 4 {
+			length = 4
+		}
+		if length > len(hash) {
+			length = len(hash)
+		}
+		return hash[:length], nil
+	}
+
+	if r.Object == nil {
+		return "", nil
+	}
+	switch a.name {
+	case "objecttype":
+		return r.Object.Type.String(), nil
+	case "objectsize":
+		return strconv.Itoa(r.Object.Size), nil
+	case "tree":
+		if commit := r.getCommit(); commit != nil {
+			return commit.Tree.String(), nil
+		}
+		return "", nil
+	case "parent":
+		if commit := r.getCommit(); commit != nil {
+			parents := make([]string, len(commit.Parents))
+			for i, parent := range commit.Parents {
+				parents[i] = parent.String()
+			}
+			return strings.Join(parents, " "), nil
+		}
+		return "", nil
+	case "subject", "body", "contents":
+		var message string
+		if commit := r.getCommit(); commit != nil {
+			message = commit.Message
+		} else if tag := r.getTag(); tag != nil {
+			message = tag.Message
+		} else {
+			return "", nil
+		}
+		subject, body := splitMessage(message)
+		switch a.name {
+		case "subject":
+			return subject, nil
+		case "body":
+			return body, nil
+		default:
+			return strings.TrimLeft(message, "\n"), nil
+		}
+	}
+
+	sign, part, ok := a.sign(r)
+	if !ok {
+		return "", nil
+	}
+	if a.isDate() {
+		return formatDate(sign.Timestamp, a.modifier)
+	}
+	return signPart(sign, part), nil
+}
+
+// element of the format, either a literal text or an atom.
+type element struct {
+	literal string
+	atom    *atom
+}
+
+// Format is a parsed --format string of for-each-ref.
+type Format struct {
+	elements []*element
+}
+
+// Parse parses the format string. %(atom) is replaced with the value of the atom,
+// %% is a literal percent sign and %xx is the byte whose hex code is xx.
+func Parse(format string) (*Format, error) {
+	f := &Format{
+		elements: make([]*element, 0),
+	}
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			f.elements = append(f.elements, &element{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+		rest := format[i+1:]
+		switch {
+		case strings.HasPrefix(rest, "%"):
+			literal.WriteByte('%')
+			i++
+		case strings.HasPrefix(rest, "("):
+			end := strings.Index(rest, ")")
+			if end == -1 {
+				return nil, fmt.Errorf("%w: malformed format string %s", ErrInvalidFormat, format[i:])
+			}
+			a, err := parseAtom(rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			f.elements = append(f.elements, &element{atom: a})
+			i += end + 1
+		case len(rest) >= 2 && isHex(rest[0]) && isHex(rest[1]):
+			b, _ := strconv.ParseUint(rest[:2], 16, 8)
+			literal.WriteByte(byte(b))
+			i += 2
+		default:
+			literal.WriteByte('%')
+		}
+	}
+	flush()
+
+	return f, nil
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// Expand returns the format filled with the values of the ref.
+func (f *Format) Expand(r *Ref) (string, error) {
+	var b strings.Builder
+	for _, e := range f.elements {
+		if e.atom == nil {
+			b.WriteString(e.literal)
+			continue
+		}
+		v, err := e.atom.value(r)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
+
+type sortKey struct {
+	atom      *atom
+	isReverse bool
+}
+
+func parseSortKey(key string) (*sortKey, error) {
+	k := &sortKey{}
+	if strings.HasPrefix(key, "-") {
+		k.isReverse = true
+		key = key[1:]
+	}
+	a, err := parseAtom(key)
+	if err != nil {
+		return nil, err
+	}
+	k.atom = a
+	return k, nil
+}
+
+// compare two refs by the key, returning a negative number if x comes first.
+func (k *sortKey) compare(x, y *Ref) (int, error) {
+	var result int
+	switch {
+	case k.atom.isDate():
+		xSign, _, xOK := k.atom.sign(x)
+		ySign, _, yOK := k.atom.sign(y)
+		var xTime, yTime int64
+		if xOK {
+			xTime = xSign.Timestamp.Unix()
+		}
+		if yOK {
+			yTime = ySign.Timestamp.Unix()
+		}
+		result = compareInt(xTime, yTime)
+	case k.atom.name == "objectsize" && !k.atom.isDeref:
+		var xSize, ySize int64
+		if x.Object != nil {
+			xSize = int64(x.Object.Size)
+		}
+		if y.Object != nil {
+			ySize = int64(y.Object.Size)
+		}
+		result = compareInt(xSize, ySize)
+	default:
+		xValue, err := k.atom.value(x)
+		if err != nil {
+			return 0, err
+		}
+		yValue, err := k.atom.value(y)
+		if err != nil {
+			return 0, err
+		}
+		result = strings.Compare(xValue, yValue)
+	}
+	if k.isReverse {
+		result = -result
+	}
+	return result, nil
+}
+
+func compareInt(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Sort sorts the refs by the keys such as "refname" or "-committerdate".
+// Just like git, the last key is the primary one and refname breaks the ties.
+func Sort(refs []*Ref, keys []string) error {
+	sortKeys := make([]*sortKey, 0, len(keys)+1)
+	for i := len(keys) - 1; i >= 0; i-- {
+		k, err := parseSortKey(keys[i])
+		if err != nil {
+			return err
+		}
+		sortKeys = append(sortKeys, k)
+	}
+	sortKeys = append(sortKeys, &sortKey{atom: &atom{name: "refname"}})
+
+	var sortErr error
+	sort.SliceStable(refs, func(i, j int) bool {
+		for _, k := range sortKeys {
+			result, err := k.compare(refs[i], refs[j])
+			if err != nil {
+				sortErr = err
+				return false
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+		return false
+	})
+
+	return sortErr
+}
@@ -0,0 +1,168 @@
+package refformat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+func newTestRef(t *testing.T, name, data string, objType object.Type) *Ref {
+	t.Helper()
+	obj, err := object.NewObject(sha.SHA1Format, objType, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Ref{
+		Name:   name,
+		Hash:   obj.Hash,
+		Object: obj,
+	}
+}
+
+func TestExpand(t *testing.T) {
+	commitData := "tree 87f3c49bccf2597484ece08746d3ee5defaba335\nauthor Test Taro <test@example.com> 1700000000 +0900\ncommitter Test Jiro <jiro@example.com> 1700000100 +0900\n\nfirst line\n\nbody line\n"
+	tagData := "object 87f3c49bccf2597484ece08746d3ee5defaba335\ntype commit\ntag v1.0.0\ntagger Test Taro <test@example.com> 1700000200 +0900\n\nrelease v1.0.0\n"
+
+	type test struct {
+		name    string
+		format  string
+		ref     func(t *testing.T) *Ref
+		want    string
+		wantErr error
+	}
+	tests := []*test{
+		{
+			name:   "success: refname",
+			format: "%(refname) %(refname:short) %(refname:lstrip=2) %(refname:rstrip=-2)",
+			ref: func(t *testing.T) *Ref {
+				return newTestRef(t, "refs/heads/feature/a", commitData, object.CommitObject)
+			},
+			want:    "refs/heads/feature/a feature/a feature/a refs/heads",
+			wantErr: nil,
+		},
+		{
+			name:   "success: commit atoms",
+			format: "%(objecttype) %(committername) %(authoremail) %(committerdate:unix) %(subject)|%(body)",
+			ref: func(t *testing.T) *Ref {
+				return newTestRef(t, "refs/heads/main", commitData, object.CommitObject)
+			},
+			want:    "commit Test Jiro <test@example.com> 1700000100 first line|body line",
+			wantErr: nil,
+		},
+		{
+			name:   "success: tag atoms",
+			format: "%(objecttype) %(taggername) %(creatordate:short) %(subject) %(committername)",
+			ref: func(t *testing.T) *Ref {
+				return newTestRef(t, "refs/tags/v1.0.0", tagData, object.TagObject)
+			},
+			want:    "tag Test Taro 2023-11-15 release v1.0.0 ",
+			wantErr: nil,
+		},
+		{
+			name:   "success: upstream and HEAD",
+			format: "%(HEAD) %(upstream) %(upstream:short)%%%0a",
+			ref: func(t *testing.T) *Ref {
+				r := newTestRef(t, "refs/heads/main", commitData, object.CommitObject)
+				r.Upstream = "refs/remotes/origin/main"
+				r.IsHead = true
+				return r
+			},
+			want:    "* refs/remotes/origin/main origin/main%\n",
+			wantErr: nil,
+		},
+		{
+			name:   "fail: unknown atom",
+			format: "%(unknown)",
+			ref: func(t *testing.T) *Ref {
+				return newTestRef(t, "refs/heads/main", commitData, object.CommitObject)
+			},
+			want:    "",
+			wantErr: ErrUnknownAtom,
+		},
+		{
+			name:   "fail: unclosed atom",
+			format: "%(refname",
+			ref: func(t *testing.T) *Ref {
+				return newTestRef(t, "refs/heads/main", commitData, object.CommitObject)
+			},
+			want:    "",
+			wantErr: ErrInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := f.Expand(tt.ref(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	older := "tree 87f3c49bccf2597484ece08746d3ee5defaba335\nauthor Test Taro <test@example.com> 1700000000 +0900\ncommitter Test Taro <test@example.com> 1700000000 +0900\n\nolder\n"
+	newer := "tree 87f3c49bccf2597484ece08746d3ee5defaba335\nauthor Test Taro <test@example.com> 1700000100 +0900\ncommitter Test Taro <test@example.com> 1700000100 +0900\n\nnewer\n"
+
+	type test struct {
+		name    string
+		keys    []string
+		want    []string
+		wantErr error
+	}
+	tests := []*test{
+		{
+			name:    "success: default",
+			keys:    nil,
+			want:    []string{"refs/heads/a", "refs/heads/b", "refs/heads/c"},
+			wantErr: nil,
+		},
+		{
+			name:    "success: reverse committerdate",
+			keys:    []string{"-committerdate"},
+			want:    []string{"refs/heads/b", "refs/heads/a", "refs/heads/c"},
+			wantErr: nil,
+		},
+		{
+			name:    "success: last key is primary",
+			keys:    []string{"-refname", "committerdate"},
+			want:    []string{"refs/heads/c", "refs/heads/a", "refs/heads/b"},
+			wantErr: nil,
+		},
+		{
+			name:    "fail: unknown key",
+			keys:    []string{"unknown"},
+			want:    []string{"refs/heads/c", "refs/heads/a", "refs/heads/b"},
+			wantErr: ErrUnknownAtom,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := []*Ref{
+				newTestRef(t, "refs/heads/c", older, object.CommitObject),
+				newTestRef(t, "refs/heads/a", older, object.CommitObject),
+				newTestRef(t, "refs/heads/b", newer, object.CommitObject),
+			}
+			err := Sort(refs, tt.keys)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			for i, ref := range refs {
+				if ref.Name != tt.want[i] {
+					t.Errorf("got[%d] = %s, want = %s", i, ref.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
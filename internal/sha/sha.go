@@ -1,28 +1,104 @@
 package sha
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"hash"
 	"regexp"
 )
 
-type SHA1 []byte
+// Hash is the object name, whose length depends on the format of the repository.
+type Hash []byte
 
 var (
-	sha1Regexp     = regexp.MustCompile("[0-9a-f]{40}")
-	ErrInvalidHash = errors.New("invalid hash")
+	hashRegexp       = regexp.MustCompile("^(?:[0-9a-f]{40}|[0-9a-f]{64})$")
+	ErrInvalidHash   = errors.New("invalid hash")
+	ErrUnknownFormat = errors.New("unknown object format")
 )
 
-func (s SHA1) String() string {
+// Format is the hash algorithm which names the objects, set by extensions.objectFormat.
+type Format struct {
+	Name    string
+	Size    int // the number of the bytes of the hash
+	newHash func() hash.Hash
+}
+
+var (
+	SHA1Format = &Format{
+		Name:    "sha1",
+		Size:    sha1.Size,
+		newHash: sha1.New,
+	}
+	SHA256Format = &Format{
+		Name:    "sha256",
+		Size:    sha256.Size,
+		newHash: sha256.New,
+	}
+)
+
+// FormatByName returns the format of the name such as "sha1" and "sha256".
+func FormatByName(name string) (*Format, error) {
+	switch name {
+	case SHA1Format.Name:
+		return SHA1Format, nil
+	case SHA256Format.Name:
+		return SHA256Format, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+}
+
+// New returns the hash.Hash computing the object name.
+func (f *Format) New() hash.Hash {
+	return f.newHash()
+}
+
+// HexSize returns the length of the hash in hexadecimal.
+func (f *Format) HexSize() int {
+	return f.Size * 2
+}
+
+// ZeroHash returns the hash consisting only of zeros, which git uses for "no object".
+func (f *Format) ZeroHash() Hash {
+	return make(Hash, f.Size)
+}
+
+// Format returns the format of the hash judging from its length. SHA-1 is returned for the unknown length.
+func (s Hash) Format() *Format {
+	if len(s) == SHA256Format.Size {
+		return SHA256Format
+	}
+	return SHA1Format
+}
+
+func (s Hash) String() string {
 	return hex.EncodeToString(s)
 }
 
-func (s SHA1) Compare(other SHA1) bool {
+func (s Hash) Compare(other Hash) bool {
 	return s.String() == other.String()
 }
 
-func ReadHash(hashString string) (SHA1, error) {
-	if ok := sha1Regexp.MatchString(hashString); !ok {
+// IsZero reports whether the hash consists only of zeros, which git uses for "no object".
+func (s Hash) IsZero() bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, b := range s {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadHash reads the hash in hexadecimal, which is either a SHA-1 or a SHA-256 one.
+// Which of them is valid depends on the format of the repository, so it is checked by the caller.
+func ReadHash(hashString string) (Hash, error) {
+	if ok := hashRegexp.MatchString(hashString); !ok {
 		return nil, ErrInvalidHash
 	}
 	hash, err := hex.DecodeString(hashString)
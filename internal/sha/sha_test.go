@@ -9,10 +9,10 @@ import (
 
 func TestCompare(t *testing.T) {
 	type args struct {
-		sha SHA1
+		sha Hash
 	}
 	type fields struct {
-		sha SHA1
+		sha Hash
 	}
 	type test struct {
 		name   string
@@ -68,7 +68,7 @@ func TestReadHash(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		want    SHA1
+		want    Hash
 		wantErr error
 	}{
 		{
@@ -76,7 +76,15 @@ func TestReadHash(t *testing.T) {
 			args: args{
 				hashString: "1856e9be02756984c385482a07e42f42efd5d2f3",
 			},
-			want:    SHA1([]byte{24, 86, 233, 190, 2, 117, 105, 132, 195, 133, 72, 42, 7, 228, 47, 66, 239, 213, 210, 243}),
+			want:    Hash([]byte{24, 86, 233, 190, 2, 117, 105, 132, 195, 133, 72, 42, 7, 228, 47, 66, 239, 213, 210, 243}),
+			wantErr: nil,
+		},
+		{
+			name: "success: sha256",
+			args: args{
+				hashString: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			},
+			want:    Hash([]byte{44, 242, 77, 186, 95, 176, 163, 14, 38, 232, 59, 42, 197, 185, 226, 158, 27, 22, 30, 92, 31, 167, 66, 94, 115, 4, 51, 98, 147, 139, 152, 36}),
 			wantErr: nil,
 		},
 		{
@@ -84,7 +92,23 @@ func TestReadHash(t *testing.T) {
 			args: args{
 				hashString: "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx",
 			},
-			want:    SHA1([]byte{}),
+			want:    Hash([]byte{}),
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name: "failure: too long",
+			args: args{
+				hashString: "1856e9be02756984c385482a07e42f42efd5d2f3ab",
+			},
+			want:    Hash([]byte{}),
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name: "failure: surrounded",
+			args: args{
+				hashString: " 1856e9be02756984c385482a07e42f42efd5d2f3",
+			},
+			want:    Hash([]byte{}),
 			wantErr: ErrInvalidHash,
 		},
 	}
@@ -100,3 +124,92 @@ func TestReadHash(t *testing.T) {
 		})
 	}
 }
+
+func TestIsZero(t *testing.T) {
+	tests := []struct {
+		name       string
+		hashString string
+		want       bool
+	}{
+		{
+			name:       "zero",
+			hashString: strings.Repeat("0", 40),
+			want:       true,
+		},
+		{
+			name:       "not zero",
+			hashString: "87f3c49bccf2597484ece08746d3ee5defaba335",
+			want:       false,
+		},
+		{
+			name:       "empty",
+			hashString: "",
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := hex.DecodeString(tt.hashString)
+			if got := Hash(s).IsZero(); got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		data     string
+		want     string
+		wantSize int
+		wantErr  error
+	}{
+		{
+			name:     "success: sha1",
+			format:   "sha1",
+			data:     "hello",
+			want:     "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+			wantSize: 40,
+		},
+		{
+			name:     "success: sha256",
+			format:   "sha256",
+			data:     "hello",
+			want:     "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			wantSize: 64,
+		},
+		{
+			name:    "fail: unknown format",
+			format:  "md5",
+			wantErr: ErrUnknownFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := FormatByName(tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			h := format.New()
+			h.Write([]byte(tt.data))
+			got := Hash(h.Sum(nil))
+			if got.String() != tt.want {
+				t.Errorf("got = %s, want = %s", got, tt.want)
+			}
+			if format.HexSize() != tt.wantSize {
+				t.Errorf("got = %d, want = %d", format.HexSize(), tt.wantSize)
+			}
+			if got.Format() != format {
+				t.Errorf("got = %s, want = %s", got.Format().Name, format.Name)
+			}
+			if zero := format.ZeroHash(); !zero.IsZero() || len(zero) != format.Size {
+				t.Errorf("got = %s, which is not the zero hash of %s", zero, format.Name)
+			}
+		})
+	}
+}
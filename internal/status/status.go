@@ -0,0 +1,430 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/JunNishimura/Goit/internal/file"
+	"github.com/JunNishimura/Goit/internal/graph"
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/pathspec"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+var (
+	ErrInvalidUntrackedMode = errors.New("invalid untracked files mode")
+)
+
+// UntrackedMode tells how the untracked files are listed.
+type UntrackedMode int
+
+const (
+	UntrackedNormal UntrackedMode = iota // the directory whose files are all untracked is shown as "dir/"
+	UntrackedNo                          // the untracked files are not shown
+	UntrackedAll                         // every untracked file is shown
+)
+
+// ParseUntrackedMode parses the value of --untracked-files, which is "no", "normal" or "all".
+func ParseUntrackedMode(mode string) (UntrackedMode, error) {
+	switch mode {
+	case "no":
+		return UntrackedNo, nil
+	case "normal":
+		return UntrackedNormal, nil
+	case "all":
+		return UntrackedAll, nil
+	default:
+		return UntrackedNormal, fmt.Errorf("%w '%s'", ErrInvalidUntrackedMode, mode)
+	}
+}
+
+// Options controls which changes are collected.
+type Options struct {
+	Pathspec      *pathspec.Pathspec // limit the paths, which selects every path if nil
+	Untracked     UntrackedMode
+	Ignored       bool               // list the ignored files as well
+	DetectRenames bool               // pair the deleted and the added paths in the index
+	Threshold     int                // similarity in percentage for the rename detection
+	Filter        *store.CleanFilter // convert the working tree files as goit add does, nothing is converted if nil
+}
+
+// Entry is the change of the tracked path. X is the status of the index against HEAD and Y is the status
+// of the working tree against the index, which are ' ' (unmodified), 'M', 'A', 'D', 'R' or 'U' (unmerged).
+type Entry struct {
+	X, Y       byte
+	Path       string
+	OrigPath   string      // the path in HEAD of the renamed path
+	Score      int         // similarity of the rename in percentage
+	HeadHash   sha.Hash    // nil if the path is not in HEAD
+	IndexHash  sha.Hash    // nil if the path is not in the index or unmerged
+	Unmerged   bool        // the path has conflict stages, whose XY tells which sides have it
+	Stages     [3]sha.Hash // the base, ours and theirs of the unmerged path
+	InWorktree bool        // the file exists in the working tree
+}
+
+// Branch is the state of HEAD.
+type Branch struct {
+	Name         string   // the current branch, empty on the detached HEAD
+	OID          sha.Hash // the commit of HEAD, nil on the unborn branch
+	Upstream     string   // the full name of the upstream, empty if it is not set
+	UpstreamGone bool     // the upstream is set but does not exist
+	Ahead        int
+	Behind       int
+}
+
+// Status is the state of the working tree, whose paths are relative to the working tree root and sorted.
+type Status struct {
+	Branch    *Branch
+	Entries   []*Entry
+	Untracked []string // the directory ends with a slash
+	Ignored   []string // the directory ends with a slash
+}
+
+func (o *Options) match(p string) bool {
+	return o.Pathspec == nil || o.Pathspec.Match(p)
+}
+
+// IsWorktreeModified tells whether the file in the working tree differs from the entry, hashing it as
+// goit add would store it.
+func IsWorktreeModified(workDir string, format *sha.Format, filter *store.CleanFilter, entry *store.Entry) (bool, error) {
+	p := string(entry.Path)
+	data, err := os.ReadFile(filepath.Join(workDir, filepath.FromSlash(p)))
+	if err != nil {
+		return false, fmt.Errorf("fail to read %s: %w", p, err)
+	}
+	if filter != nil {
+		if data, err = filter.Clean(p, data); err != nil {
+			return false, err
+		}
+	}
+	obj, err := object.NewObject(format, object.BlobObject, data)
+	if err != nil {
+		return false, fmt.Errorf("fail to get new object: %w", err)
+	}
+	return !entry.Hash.Compare(obj.Hash), nil
+}
+
+// New collects the status of the repository of the client.
+func New(client *store.Client, opts *Options) (*Status, error) {
+	branch, err := newBranch(client)
+	if err != nil {
+		return nil, err
+	}
+	s := &Status{Branch: branch}
+
+	workDir := filepath.Dir(client.RootGoitPath)
+	entries := make(map[string]*Entry)
+	getEntry := func(p string) *Entry {
+		if entry, ok := entries[p]; ok {
+			return entry
+		}
+		entry := &Entry{X: ' ', Y: ' ', Path: p}
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(p))); err == nil {
+			entry.InWorktree = true
+		}
+		entries[p] = entry
+		return entry
+	}
+
+	// unmerged paths
+	isUnmerged := make(map[string]bool)
+	for _, p := range client.Idx.UnmergedPaths() {
+		isUnmerged[p] = true
+		if !opts.match(p) {
+			continue
+		}
+		entry := getEntry(p)
+		entry.Unmerged = true
+		for stage := 1; stage <= 3; stage++ {
+			if stageEntry, ok := client.Idx.GetStageEntry([]byte(p), stage); ok {
+				entry.Stages[stage-1] = stageEntry.Hash
+			}
+		}
+		entry.X, entry.Y = unmergedCode(entry.Stages)
+	}
+
+	// compare the index with HEAD, which has no tree on the unborn branch
+	tree := &object.Tree{}
+	if client.Head.Commit != nil {
+		if tree, err = headTree(client); err != nil {
+			return nil, err
+		}
+	}
+	headFiles := tree.Files()
+	diffEntries, err := client.Idx.DiffWithTree(tree)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get diff entries: %w", err)
+	}
+	var stagedEntries []*store.DiffEntry
+	for _, diffEntry := range diffEntries {
+		if p := string(diffEntry.Entry.Path); !isUnmerged[p] && opts.match(p) {
+			stagedEntries = append(stagedEntries, diffEntry)
+		}
+	}
+	if opts.DetectRenames {
+		if stagedEntries, err = store.DetectRenames(client.RootGoitPath, stagedEntries, opts.Threshold); err != nil {
+			return nil, fmt.Errorf("fail to detect renames: %w", err)
+		}
+	}
+	for _, diffEntry := range stagedEntries {
+		p := string(diffEntry.Entry.Path)
+		entry := getEntry(p)
+		entry.X = diffEntry.Status()
+		entry.HeadHash = headFiles[p]
+		if diffEntry.From != nil {
+			entry.OrigPath = string(diffEntry.From.Path)
+			entry.Score = diffEntry.Score
+			entry.HeadHash = diffEntry.From.Hash
+		}
+	}
+
+	// compare the working tree with the index
+	for _, indexEntry := range client.Idx.Entries {
+		p := string(indexEntry.Path)
+		if isUnmerged[p] || !opts.match(p) {
+			continue
+		}
+		fileInfo, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(p)))
+		isDeleted := os.IsNotExist(err) || (err == nil && fileInfo.IsDir())
+		isModified := false
+		if !isDeleted {
+			if isModified, err = IsWorktreeModified(workDir, client.Format, opts.Filter, indexEntry); err != nil {
+				return nil, err
+			}
+		}
+		if !isDeleted && !isModified {
+			continue
+		}
+		entry := getEntry(p)
+		entry.Y = 'M'
+		if isDeleted {
+			entry.Y = 'D'
+		}
+	}
+
+	// fill the hashes of the changed paths
+	for p, entry := range entries {
+		if entry.X == ' ' {
+			entry.HeadHash = headFiles[p]
+		}
+		if _, indexEntry, ok := client.Idx.GetEntry([]byte(p)); ok && indexEntry.Stage == 0 {
+			entry.IndexHash = indexEntry.Hash
+		}
+		s.Entries = append(s.Entries, entry)
+	}
+	sort.Slice(s.Entries, func(i, j int) bool { return s.Entries[i].Path < s.Entries[j].Path })
+
+	if err := s.collectUntracked(client, opts, workDir); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// the XY code of the unmerged path from the existence of the base, ours and theirs.
+func unmergedCode(stages [3]sha.Hash) (byte, byte) {
+	hasBase, hasOurs, hasTheirs := stages[0] != nil, stages[1] != nil, stages[2] != nil
+	switch {
+	case hasBase && !hasOurs && !hasTheirs:
+		return 'D', 'D'
+	case !hasBase && hasOurs && !hasTheirs:
+		return 'A', 'U'
+	case hasBase && hasOurs && !hasTheirs:
+		return 'U', 'D'
+	case !hasBase && !hasOurs && hasTheirs:
+		return 'U', 'A'
+	case hasBase && !hasOurs && hasTheirs:
+		return 'D', 'U'
+	case !hasBase && hasOurs && hasTheirs:
+		return 'A', 'A'
+	default:
+		return 'U', 'U'
+	}
+}
+
+func headTree(client *store.Client) (*object.Tree, error) {
+	treeObj, err := object.GetObject(client.RootGoitPath, client.Head.Commit.Tree)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tree object: %w", err)
+	}
+	tree, err := object.NewTree(client.RootGoitPath, treeObj)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tree: %w", err)
+	}
+	return tree, nil
+}
+
+func newBranch(client *store.Client) (*Branch, error) {
+	branch := &Branch{Name: client.Head.Reference}
+	if client.Head.IsDetached() {
+		branch.Name = ""
+	}
+	if client.Head.Commit != nil {
+		branch.OID = client.Head.Commit.Hash
+	}
+	if branch.Name == "" {
+		return branch, nil
+	}
+
+	upstream, ok := client.Conf.GetUpstream(branch.Name)
+	if !ok {
+		return branch, nil
+	}
+	branch.Upstream = upstream
+	upstreamHash, err := store.ReadRef(client.RootGoitPath, upstream)
+	if errors.Is(err, store.ErrRefNotFound) {
+		branch.UpstreamGone = true
+		return branch, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to read %s: %w", upstream, err)
+	}
+	if branch.OID == nil {
+		return branch, nil
+	}
+	ahead, behind, err := graph.New(client.RootGoitPath).AheadBehind(branch.OID, upstreamHash)
+	if err != nil {
+		return nil, err
+	}
+	branch.Ahead, branch.Behind = ahead, behind
+	return branch, nil
+}
+
+// collect the files which are not in the index, which are either untracked or ignored.
+func (s *Status) collectUntracked(client *store.Client, opts *Options, workDir string) error {
+	if opts.Untracked == UntrackedNo && !opts.Ignored {
+		return nil
+	}
+	filePaths, err := file.GetWorkingTreeFiles(workDir, store.NewIgnoreFromPatterns(nil))
+	if err != nil {
+		return fmt.Errorf("fail to get files: %w", err)
+	}
+
+	isAdded := make(map[string]bool)
+	add := func(list *[]string, p string) {
+		if !isAdded[p] {
+			isAdded[p] = true
+			*list = append(*list, p)
+		}
+	}
+	for _, filePath := range filePaths {
+		if _, _, isRegistered := client.Idx.GetEntry([]byte(filePath)); isRegistered || !opts.match(filePath) {
+			continue
+		}
+		ignoredDir := ignoredDirectory(client.Ignore, filePath)
+		switch {
+		case ignoredDir != "" || client.Ignore.Match(filePath, false):
+			if !opts.Ignored {
+				continue
+			}
+			if ignoredDir != "" && opts.Untracked != UntrackedAll {
+				add(&s.Ignored, ignoredDir+"/")
+			} else {
+				add(&s.Ignored, filePath)
+			}
+		case opts.Untracked == UntrackedNo:
+			continue
+		case opts.Untracked == UntrackedNormal:
+			if dir := untrackedDirectory(client.Idx, filePath); dir != "" {
+				add(&s.Untracked, dir+"/")
+			} else {
+				add(&s.Untracked, filePath)
+			}
+		default:
+			add(&s.Untracked, filePath)
+		}
+	}
+	sort.Strings(s.Untracked)
+	sort.Strings(s.Ignored)
+	return nil
+}
+
+// return the topmost ancestor directory of the path which is ignored, or "" if there is not.
+func ignoredDirectory(ignore *store.Ignore, p string) string {
+	for _, dir := range ancestors(p) {
+		if ignore.Match(dir, true) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// return the topmost ancestor directory of the path which has no tracked file, or "" if there is not.
+func untrackedDirectory(index *store.Index, p string) string {
+	for _, dir := range ancestors(p) {
+		if !index.IsRegisteredAsDirectory(dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// return the ancestor directories of the path from the top, such as "a" and "a/b" for "a/b/c".
+func ancestors(p string) []string {
+	var dirs []string
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs
+}
+
+// Staged returns the entries changed in the index, excluding the unmerged ones.
+func (s *Status) Staged() []*Entry {
+	var entries []*Entry
+	for _, entry := range s.Entries {
+		if entry.X != ' ' && !entry.Unmerged {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Unstaged returns the entries changed in the working tree, excluding the unmerged ones.
+func (s *Status) Unstaged() []*Entry {
+	var entries []*Entry
+	for _, entry := range s.Entries {
+		if entry.Y != ' ' && !entry.Unmerged {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Unmerged returns the unmerged entries.
+func (s *Status) Unmerged() []*Entry {
+	var entries []*Entry
+	for _, entry := range s.Entries {
+		if entry.Unmerged {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// UnmergedDescription describes how the path is unmerged, such as "both modified".
+func (e *Entry) UnmergedDescription() string {
+	switch string([]byte{e.X, e.Y}) {
+	case "DD":
+		return "both deleted"
+	case "AU":
+		return "added by us"
+	case "UD":
+		return "deleted by them"
+	case "UA":
+		return "added by them"
+	case "DU":
+		return "deleted by us"
+	case "AA":
+		return "both added"
+	default:
+		return "both modified"
+	}
+}
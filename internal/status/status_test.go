@@ -0,0 +1,216 @@
+package status
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+	"github.com/JunNishimura/Goit/internal/store"
+)
+
+func TestParseUntrackedMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		want    UntrackedMode
+		wantErr error
+	}{
+		{
+			name: "success: no",
+			mode: "no",
+			want: UntrackedNo,
+		},
+		{
+			name: "success: normal",
+			mode: "normal",
+			want: UntrackedNormal,
+		},
+		{
+			name: "success: all",
+			mode: "all",
+			want: UntrackedAll,
+		},
+		{
+			name:    "fail: unknown mode",
+			mode:    "some",
+			want:    UntrackedNormal,
+			wantErr: ErrInvalidUntrackedMode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUntrackedMode(tt.mode)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmergedCode(t *testing.T) {
+	h := sha.Hash{1}
+	tests := []struct {
+		name     string
+		stages   [3]sha.Hash
+		wantCode string
+		wantDesc string
+	}{
+		{
+			name:     "success: both deleted",
+			stages:   [3]sha.Hash{h, nil, nil},
+			wantCode: "DD",
+			wantDesc: "both deleted",
+		},
+		{
+			name:     "success: added by us",
+			stages:   [3]sha.Hash{nil, h, nil},
+			wantCode: "AU",
+			wantDesc: "added by us",
+		},
+		{
+			name:     "success: deleted by them",
+			stages:   [3]sha.Hash{h, h, nil},
+			wantCode: "UD",
+			wantDesc: "deleted by them",
+		},
+		{
+			name:     "success: added by them",
+			stages:   [3]sha.Hash{nil, nil, h},
+			wantCode: "UA",
+			wantDesc: "added by them",
+		},
+		{
+			name:     "success: deleted by us",
+			stages:   [3]sha.Hash{h, nil, h},
+			wantCode: "DU",
+			wantDesc: "deleted by us",
+		},
+		{
+			name:     "success: both added",
+			stages:   [3]sha.Hash{nil, h, h},
+			wantCode: "AA",
+			wantDesc: "both added",
+		},
+		{
+			name:     "success: both modified",
+			stages:   [3]sha.Hash{h, h, h},
+			wantCode: "UU",
+			wantDesc: "both modified",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := unmergedCode(tt.stages)
+			if got := string([]byte{x, y}); got != tt.wantCode {
+				t.Errorf("got = %s, want = %s", got, tt.wantCode)
+			}
+			entry := &Entry{X: x, Y: y, Unmerged: true}
+			if got := entry.UnmergedDescription(); got != tt.wantDesc {
+				t.Errorf("got = %s, want = %s", got, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			name: "success: top level",
+			path: "a",
+			want: nil,
+		},
+		{
+			name: "success: nested",
+			path: "a/b/c",
+			want: []string{"a", "a/b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ancestors(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusGroups(t *testing.T) {
+	staged := &Entry{X: 'A', Y: ' ', Path: "a"}
+	both := &Entry{X: 'M', Y: 'D', Path: "b"}
+	unstaged := &Entry{X: ' ', Y: 'M', Path: "c"}
+	unmerged := &Entry{X: 'U', Y: 'U', Path: "d", Unmerged: true}
+	s := &Status{Entries: []*Entry{staged, both, unstaged, unmerged}}
+
+	if got, want := s.Staged(), []*Entry{staged, both}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+	if got, want := s.Unstaged(), []*Entry{both, unstaged}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+	if got, want := s.Unmerged(), []*Entry{unmerged}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
+
+func TestIsWorktreeModified(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		stored  string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "success: unmodified",
+			content: "hello\n",
+			stored:  "hello\n",
+			want:    false,
+		},
+		{
+			name:    "success: modified",
+			content: "hello world\n",
+			stored:  "hello\n",
+			want:    true,
+		},
+		{
+			name:    "fail: missing file",
+			stored:  "hello\n",
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(workDir, "file"), []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			obj, err := object.NewObject(sha.SHA1Format, object.BlobObject, []byte(tt.stored))
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry := store.NewEntry(obj.Hash, []byte("file"))
+
+			got, err := IsWorktreeModified(workDir, sha.SHA1Format, nil, entry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got = %v, want error = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
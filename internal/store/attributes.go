@@ -0,0 +1,121 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/pathspec"
+)
+
+// the states of the attribute, where the value is returned as it is for "name=value"
+const (
+	AttributeSet   = "set"
+	AttributeUnset = "unset"
+)
+
+type attributeRule struct {
+	pattern string
+	attrs   map[string]string
+}
+
+// Attributes is the list of the rules in .goitattributes, which is written in the gitattributes format.
+//
+//	<pattern> <attr>...
+//
+// where <attr> is either "name" to set, "-name" to unset, "!name" to leave unspecified or "name=value".
+type Attributes struct {
+	rules []*attributeRule
+}
+
+func NewAttributes(rootGoitPath string) (*Attributes, error) {
+	a := newAttributes()
+	for _, attrPath := range []string{
+		filepath.Join(filepath.Dir(rootGoitPath), ".goitattributes"),
+		filepath.Join(rootGoitPath, "info", "attributes"),
+	} {
+		if err := a.load(attrPath); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func newAttributes() *Attributes {
+	return &Attributes{
+		rules: make([]*attributeRule, 0),
+	}
+}
+
+func (a *Attributes) load(attrPath string) error {
+	f, err := os.Open(attrPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fail to open %s: %w", attrPath, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		a.AddRule(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("fail to read %s: %w", attrPath, err)
+	}
+	return nil
+}
+
+// AddRule adds the line of the attributes file. The blank lines and the comments are ignored.
+func (a *Attributes) AddRule(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return
+	}
+	rule := &attributeRule{
+		pattern: strings.TrimPrefix(fields[0], "/"),
+		attrs:   make(map[string]string),
+	}
+	for _, field := range fields[1:] {
+		switch {
+		case strings.HasPrefix(field, "-"):
+			rule.attrs[field[1:]] = AttributeUnset
+		case strings.HasPrefix(field, "!"):
+			rule.attrs[field[1:]] = ""
+		default:
+			name, value, ok := strings.Cut(field, "=")
+			if !ok {
+				value = AttributeSet
+			}
+			rule.attrs[name] = value
+		}
+	}
+	a.rules = append(a.rules, rule)
+}
+
+// the pattern without a slash matches the base name in any directory, as .goitignore does.
+func (r *attributeRule) match(p string) bool {
+	if !strings.Contains(r.pattern, "/") {
+		return pathspec.Wildmatch(r.pattern, path.Base(p), true)
+	}
+	return pathspec.Wildmatch(r.pattern, p, true)
+}
+
+// Get returns the state of the attribute of the path relative to the working tree root,
+// which is AttributeSet, AttributeUnset or the value. The later rule takes precedence.
+// False is returned if the attribute is unspecified.
+func (a *Attributes) Get(p, name string) (string, bool) {
+	for i := len(a.rules) - 1; i >= 0; i-- {
+		rule := a.rules[i]
+		value, ok := rule.attrs[name]
+		if !ok || !rule.match(p) {
+			continue
+		}
+		return value, value != ""
+	}
+	return "", false
+}
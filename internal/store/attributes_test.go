@@ -0,0 +1,79 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttributesGet(t *testing.T) {
+	lines := []string{
+		"# comment",
+		"",
+		"*.txt text eol=lf",
+		"*.png -text binary",
+		"docs/*.md diff=markdown",
+		"/root.md diff=root",
+		"special.txt !eol",
+		"**/gen/*.txt -diff",
+	}
+	type want struct {
+		value string
+		ok    bool
+	}
+	tests := []struct {
+		name string
+		path string
+		attr string
+		want want
+	}{
+		{name: "success: set", path: "a/b.txt", attr: "text", want: want{value: AttributeSet, ok: true}},
+		{name: "success: value", path: "b.txt", attr: "eol", want: want{value: "lf", ok: true}},
+		{name: "success: unset", path: "img/logo.png", attr: "text", want: want{value: AttributeUnset, ok: true}},
+		{name: "success: unspecified", path: "main.go", attr: "text", want: want{}},
+		{name: "success: pattern with slash", path: "docs/a.md", attr: "diff", want: want{value: "markdown", ok: true}},
+		{name: "success: pattern with slash in subdirectory", path: "x/docs/a.md", attr: "diff", want: want{}},
+		{name: "success: anchored pattern", path: "root.md", attr: "diff", want: want{value: "root", ok: true}},
+		{name: "success: later rule takes precedence", path: "special.txt", attr: "eol", want: want{}},
+		{name: "success: double star", path: "a/b/gen/c.txt", attr: "diff", want: want{value: AttributeUnset, ok: true}},
+	}
+
+	a := newAttributes()
+	for _, line := range lines {
+		a.AddRule(line)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := a.Get(tt.path, tt.attr)
+			if got := (want{value: value, ok: ok}); got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	goitDir := filepath.Join(tmpDir, ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "info"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".goitattributes"), []byte("*.txt diff=text\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// info/attributes takes precedence over .goitattributes
+	if err := os.WriteFile(filepath.Join(goitDir, "info", "attributes"), []byte("local.txt diff=local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewAttributes(goitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := a.Get("a.txt", "diff"); value != "text" {
+		t.Errorf("got = %s, want = text", value)
+	}
+	if value, _ := a.Get("local.txt", "diff"); value != "local" {
+		t.Errorf("got = %s, want = local", value)
+	}
+}
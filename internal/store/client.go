@@ -1,21 +1,55 @@
 package store
 
+import "github.com/JunNishimura/Goit/internal/sha"
+
 type Client struct {
 	Conf         *Config
 	Idx          *Index
 	Head         *Head
 	Refs         *Refs
 	Ignore       *Ignore
+	Format       *sha.Format // the hash algorithm of the objects
 	RootGoitPath string
 }
 
-func NewClient(config *Config, index *Index, head *Head, refs *Refs, ignore *Ignore, rootGoitPath string) *Client {
+func NewClient(config *Config, index *Index, head *Head, refs *Refs, ignore *Ignore, format *sha.Format, rootGoitPath string) *Client {
 	return &Client{
 		Conf:         config,
 		Idx:          index,
 		Head:         head,
 		Refs:         refs,
 		Ignore:       ignore,
+		Format:       format,
 		RootGoitPath: rootGoitPath,
 	}
 }
+
+// LoadClient reads the config, the index, HEAD, the refs and the ignore patterns of the repository.
+// Only the global config is read if rootGoitPath is empty.
+func LoadClient(rootGoitPath string) (*Client, error) {
+	config, err := NewConfig(rootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	format, err := config.ObjectFormat()
+	if err != nil {
+		return nil, err
+	}
+	index, err := NewIndex(rootGoitPath, format)
+	if err != nil {
+		return nil, err
+	}
+	head, err := NewHead(rootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := NewRefs(rootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := NewIgnore(rootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(config, index, head, refs, ignore, format, rootGoitPath), nil
+}
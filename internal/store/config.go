@@ -8,12 +8,20 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
 )
 
 var (
-	identRegexp          = regexp.MustCompile(`^\[.*\]$`)
-	ErrInvalidIdentifier = errors.New("fatal: invalid identifier")
+	identRegexp           = regexp.MustCompile(`^\[.*\]$`)
+	ErrInvalidIdentifier  = errors.New("fatal: invalid identifier")
+	ErrInvalidConfigLine  = errors.New("fatal: bad config line")
+	ErrInvalidConfigKey   = errors.New("error: key does not contain a section")
+	ErrInvalidConfigValue = errors.New("fatal: bad config value")
 )
 
 type kv map[string]string
@@ -66,7 +74,10 @@ func (c *Config) load(configPath string, isGlobal bool) error {
 	buf := bytes.NewReader(b)
 	scanner := bufio.NewScanner(buf)
 	for scanner.Scan() {
-		text := scanner.Text()
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
 		if identRegexp.MatchString(text) {
 			if len(text) <= 2 {
 				return ErrInvalidIdentifier
@@ -78,7 +89,10 @@ func (c *Config) load(configPath string, isGlobal bool) error {
 				c.local[ident] = make(kv)
 			}
 		} else {
-			splitText := strings.Split(strings.Replace(text, "\t", "", -1), "=")
+			splitText := strings.SplitN(strings.Replace(text, "\t", "", -1), "=", 2)
+			if len(splitText) != 2 {
+				return fmt.Errorf("%w: %s", ErrInvalidConfigLine, text)
+			}
 			key := strings.TrimSpace(splitText[0])
 			value := strings.TrimSpace(splitText[1])
 			if isGlobal {
@@ -145,6 +159,96 @@ func (c *Config) GetEmail() string {
 	return ""
 }
 
+// ConfigIdent converts the config name such as "branch.main.merge" into
+// the identifier and the key, which are `branch "main"` and "merge".
+func ConfigIdent(name string) (string, string, error) {
+	first := strings.Index(name, ".")
+	last := strings.LastIndex(name, ".")
+	if first <= 0 || last == len(name)-1 {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidConfigKey, name)
+	}
+	key := name[last+1:]
+	if first == last {
+		return name[:first], key, nil
+	}
+	return fmt.Sprintf(`%s "%s"`, name[:first], name[first+1:last]), key, nil
+}
+
+// Get returns the value of the config name such as "core.abbrev" or "branch.main.merge".
+// local config takes precedence over global config.
+func (c *Config) Get(name string) (string, bool) {
+	ident, key, err := ConfigIdent(name)
+	if err != nil {
+		return "", false
+	}
+	if localKV, ok := c.local[ident]; ok {
+		if v, ok := localKV[key]; ok {
+			return v, true
+		}
+	}
+	if globalKV, ok := c.global[ident]; ok {
+		if v, ok := globalKV[key]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ObjectFormat returns the hash algorithm set by extensions.objectFormat in the local config.
+// SHA-1 is returned if it is not set.
+func (c *Config) ObjectFormat() (*sha.Format, error) {
+	extensionsKV, ok := c.local["extensions"]
+	if !ok {
+		return sha.SHA1Format, nil
+	}
+	name, ok := extensionsKV["objectFormat"]
+	if !ok {
+		return sha.SHA1Format, nil
+	}
+	return sha.FormatByName(name)
+}
+
+// AbbrevLength returns the minimum length of the abbreviated object name set by core.abbrev.
+// "auto" and the unset value mean the default length, and "no" means the full length.
+func (c *Config) AbbrevLength(format *sha.Format) (int, error) {
+	value, ok := c.Get("core.abbrev")
+	if !ok || value == "auto" {
+		return object.DefaultAbbrev, nil
+	}
+	if value == "no" {
+		return format.HexSize(), nil
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil || length < object.MinAbbrev {
+		return 0, fmt.Errorf("%w: core.abbrev=%s", ErrInvalidConfigValue, value)
+	}
+	if length > format.HexSize() {
+		length = format.HexSize()
+	}
+	return length, nil
+}
+
+// GetUpstream returns the full ref name of the upstream of the branch,
+// which is built from branch.<name>.remote and branch.<name>.merge.
+func (c *Config) GetUpstream(branchName string) (string, bool) {
+	remote, ok := c.Get(fmt.Sprintf("branch.%s.remote", branchName))
+	if !ok {
+		return "", false
+	}
+	merge, ok := c.Get(fmt.Sprintf("branch.%s.merge", branchName))
+	if !ok {
+		return "", false
+	}
+	// "." means the upstream is a branch of the local repository
+	if remote == "." {
+		return merge, true
+	}
+	if !strings.HasPrefix(merge, "refs/heads/") {
+		return "", false
+	}
+	return fmt.Sprintf("refs/remotes/%s/%s", remote, strings.TrimPrefix(merge, "refs/heads/")), true
+}
+
 func (c *Config) Add(ident, key, value string, isGlobal bool) {
 	if isGlobal {
 		if _, ok := c.global[ident]; ok {
@@ -163,6 +267,56 @@ func (c *Config) Add(ident, key, value string, isGlobal bool) {
 	}
 }
 
+// Remove deletes the key from the identifier, and the identifier itself once it becomes empty.
+// It reports whether the key existed.
+func (c *Config) Remove(ident, key string, isGlobal bool) bool {
+	kvs := c.local
+	if isGlobal {
+		kvs = c.global
+	}
+	keyValue, ok := kvs[ident]
+	if !ok {
+		return false
+	}
+	if _, ok := keyValue[key]; !ok {
+		return false
+	}
+	delete(keyValue, key)
+	if len(keyValue) == 0 {
+		delete(kvs, ident)
+	}
+	return true
+}
+
+// RemoveIdent deletes the whole identifier such as `branch "main"`, reporting whether it existed.
+func (c *Config) RemoveIdent(ident string, isGlobal bool) bool {
+	kvs := c.local
+	if isGlobal {
+		kvs = c.global
+	}
+	if _, ok := kvs[ident]; !ok {
+		return false
+	}
+	delete(kvs, ident)
+	return true
+}
+
+// CopyIdent copies every key of the identifier srcIdent to dstIdent, reporting whether srcIdent existed.
+func (c *Config) CopyIdent(srcIdent, dstIdent string, isGlobal bool) bool {
+	kvs := c.local
+	if isGlobal {
+		kvs = c.global
+	}
+	keyValue, ok := kvs[srcIdent]
+	if !ok {
+		return false
+	}
+	for k, v := range keyValue {
+		c.Add(dstIdent, k, v, isGlobal)
+	}
+	return true
+}
+
 func (c *Config) Write(configPath string, isGlobal bool) error {
 	f, err := os.Create(configPath)
 	if err != nil {
@@ -177,10 +331,22 @@ func (c *Config) Write(configPath string, isGlobal bool) error {
 	} else {
 		kvs = c.local
 	}
-	for ident, keyValue := range kvs {
+	// sort identifiers and keys so that the file does not change between writes
+	idents := make([]string, 0, len(kvs))
+	for ident := range kvs {
+		idents = append(idents, ident)
+	}
+	sort.Strings(idents)
+	for _, ident := range idents {
+		keyValue := kvs[ident]
+		keys := make([]string, 0, len(keyValue))
+		for k := range keyValue {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 		content += fmt.Sprintf("[%s]\n", ident)
-		for k, v := range keyValue {
-			content += fmt.Sprintf("\t%s = %s\n", k, v)
+		for _, k := range keys {
+			content += fmt.Sprintf("\t%s = %s\n", k, keyValue[k])
 		}
 	}
 
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -707,3 +709,183 @@ func TestWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigGet(t *testing.T) {
+	config := newConfig()
+	config.Add("core", "abbrev", "10", false)
+	config.Add(`branch "main"`, "remote", "origin", false)
+	config.Add(`branch "main"`, "merge", "refs/heads/main", false)
+	config.Add(`branch "feature/a"`, "remote", ".", false)
+	config.Add(`branch "feature/a"`, "merge", "refs/heads/main", false)
+	config.Add("user", "name", "global taro", true)
+
+	tests := []struct {
+		name       string
+		configName string
+		want       string
+		wantOK     bool
+	}{
+		{
+			name:       "success: section",
+			configName: "core.abbrev",
+			want:       "10",
+			wantOK:     true,
+		},
+		{
+			name:       "success: subsection",
+			configName: "branch.main.merge",
+			want:       "refs/heads/main",
+			wantOK:     true,
+		},
+		{
+			name:       "success: global",
+			configName: "user.name",
+			want:       "global taro",
+			wantOK:     true,
+		},
+		{
+			name:       "fail: not found",
+			configName: "branch.dev.merge",
+			want:       "",
+			wantOK:     false,
+		},
+		{
+			name:       "fail: no section",
+			configName: "abbrev",
+			want:       "",
+			wantOK:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := config.Get(tt.configName)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got = (%s, %v), want = (%s, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	upstreamTests := []struct {
+		branchName string
+		want       string
+		wantOK     bool
+	}{
+		{"main", "refs/remotes/origin/main", true},
+		{"feature/a", "refs/heads/main", true},
+		{"dev", "", false},
+	}
+	for _, tt := range upstreamTests {
+		t.Run("upstream of "+tt.branchName, func(t *testing.T) {
+			got, ok := config.GetUpstream(tt.branchName)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got = (%s, %v), want = (%s, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestObjectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *sha.Format
+		wantErr error
+	}{
+		{
+			name: "success: default",
+			want: sha.SHA1Format,
+		},
+		{
+			name:  "success: sha256",
+			value: "sha256",
+			want:  sha.SHA256Format,
+		},
+		{
+			name:    "fail: unknown format",
+			value:   "md5",
+			wantErr: sha.ErrUnknownFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newConfig()
+			if tt.value != "" {
+				config.Add("extensions", "objectFormat", tt.value, false)
+			}
+			got, err := config.ObjectFormat()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbbrevLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		format  *sha.Format
+		want    int
+		wantErr error
+	}{
+		{
+			name:   "success: default",
+			format: sha.SHA1Format,
+			want:   7,
+		},
+		{
+			name:   "success: auto",
+			value:  "auto",
+			format: sha.SHA1Format,
+			want:   7,
+		},
+		{
+			name:   "success: no",
+			value:  "no",
+			format: sha.SHA256Format,
+			want:   64,
+		},
+		{
+			name:   "success: number",
+			value:  "12",
+			format: sha.SHA1Format,
+			want:   12,
+		},
+		{
+			name:   "success: clamped to the full length",
+			value:  "50",
+			format: sha.SHA1Format,
+			want:   40,
+		},
+		{
+			name:    "fail: too short",
+			value:   "3",
+			format:  sha.SHA1Format,
+			wantErr: ErrInvalidConfigValue,
+		},
+		{
+			name:    "fail: not a number",
+			value:   "short",
+			format:  sha.SHA1Format,
+			wantErr: ErrInvalidConfigValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newConfig()
+			if tt.value != "" {
+				config.Add("core", "abbrev", tt.value, false)
+			}
+			got, err := config.AbbrevLength(tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %d, want = %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/binary"
+)
+
+// CleanFilter converts the content of the file in the working tree into the blob to store,
+// applying the clean command of the filter attribute and then the end-of-line conversion.
+type CleanFilter struct {
+	conf  *Config
+	attrs *Attributes
+}
+
+func NewCleanFilter(conf *Config, attrs *Attributes) *CleanFilter {
+	return &CleanFilter{
+		conf:  conf,
+		attrs: attrs,
+	}
+}
+
+// Clean returns the content of the file at the path, relative to the working tree root, to store as the blob.
+func (f *CleanFilter) Clean(p string, data []byte) ([]byte, error) {
+	data, err := f.runDriver(p, data)
+	if err != nil {
+		return nil, err
+	}
+	if f.isText(p, data) {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	}
+	return data, nil
+}
+
+// run filter.<driver>.clean given by the filter attribute, feeding the content to stdin.
+// The content is kept as it is if the command fails unless filter.<driver>.required is set.
+func (f *CleanFilter) runDriver(p string, data []byte) ([]byte, error) {
+	driver, ok := f.attrs.Get(p, "filter")
+	if !ok || driver == AttributeSet || driver == AttributeUnset {
+		return data, nil
+	}
+	isRequired := false
+	if required, ok := f.conf.Get(fmt.Sprintf("filter.%s.required", driver)); ok && required == "true" {
+		isRequired = true
+	}
+	command, ok := f.conf.Get(fmt.Sprintf("filter.%s.clean", driver))
+	if !ok {
+		if isRequired {
+			return nil, fmt.Errorf("fatal: %s: clean filter '%s' is not configured", p, driver)
+		}
+		return data, nil
+	}
+
+	// %f is replaced with the path of the file being filtered
+	command = strings.ReplaceAll(command, "%f", shellQuote(p))
+	var stdout, stderr bytes.Buffer
+	c := exec.Command("sh", "-c", command)
+	c.Stdin = bytes.NewReader(data)
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		if isRequired {
+			return nil, fmt.Errorf("fatal: %s: clean filter '%s' failed: %w: %s", p, driver, err, strings.TrimSpace(stderr.String()))
+		}
+		return data, nil
+	}
+	return stdout.Bytes(), nil
+}
+
+// tell whether the line endings of the file are normalized, which is decided by the text and eol
+// attributes, falling back on core.autocrlf for the file without them.
+func (f *CleanFilter) isText(p string, data []byte) bool {
+	if binary, ok := f.attrs.Get(p, "binary"); ok && binary == AttributeSet {
+		return false
+	}
+	text, ok := f.attrs.Get(p, "text")
+	switch {
+	case ok && text == AttributeSet:
+		return true
+	case ok && text == AttributeUnset:
+		return false
+	case ok && text == "auto":
+		return !binary.IsBinary(data)
+	}
+	// eol implies text unless text is given
+	if eol, ok := f.attrs.Get(p, "eol"); ok && (eol == "lf" || eol == "crlf") {
+		return true
+	}
+	if autocrlf, ok := f.conf.Get("core.autocrlf"); ok && (autocrlf == "true" || autocrlf == "input") {
+		return !binary.IsBinary(data)
+	}
+	return false
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
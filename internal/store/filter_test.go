@@ -0,0 +1,124 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestCleanFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		config  map[string]string
+		path    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success: no conversion",
+			path: "a.txt",
+			data: "a\r\nb\r\n",
+			want: "a\r\nb\r\n",
+		},
+		{
+			name:   "success: autocrlf",
+			config: map[string]string{"core.autocrlf": "true"},
+			path:   "a.txt",
+			data:   "a\r\nb\r\n",
+			want:   "a\nb\n",
+		},
+		{
+			name:   "success: autocrlf input skips binary",
+			config: map[string]string{"core.autocrlf": "input"},
+			path:   "a.bin",
+			data:   "a\r\n\x00",
+			want:   "a\r\n\x00",
+		},
+		{
+			name:  "success: text attribute",
+			rules: []string{"*.txt text"},
+			path:  "a.txt",
+			data:  "a\r\nb",
+			want:  "a\nb",
+		},
+		{
+			name:   "success: -text overrides autocrlf",
+			rules:  []string{"*.txt -text"},
+			config: map[string]string{"core.autocrlf": "true"},
+			path:   "a.txt",
+			data:   "a\r\n",
+			want:   "a\r\n",
+		},
+		{
+			name:  "success: eol implies text",
+			rules: []string{"*.bat eol=crlf"},
+			path:  "run.bat",
+			data:  "echo\r\n",
+			want:  "echo\n",
+		},
+		{
+			name:   "success: clean filter",
+			rules:  []string{"*.txt filter=upper"},
+			config: map[string]string{"filter.upper.clean": "tr a-z A-Z"},
+			path:   "a.txt",
+			data:   "abc\n",
+			want:   "ABC\n",
+		},
+		{
+			name:   "success: clean filter with path",
+			rules:  []string{"*.txt filter=name"},
+			config: map[string]string{"filter.name.clean": "cat; echo %f"},
+			path:   "dir/a b.txt",
+			data:   "abc\n",
+			want:   "abc\ndir/a b.txt\n",
+		},
+		{
+			name:   "success: failed filter is skipped",
+			rules:  []string{"*.txt filter=broken"},
+			config: map[string]string{"filter.broken.clean": "exit 1"},
+			path:   "a.txt",
+			data:   "abc\n",
+			want:   "abc\n",
+		},
+		{
+			name:    "fail: required filter",
+			rules:   []string{"*.txt filter=broken"},
+			config:  map[string]string{"filter.broken.clean": "exit 1", "filter.broken.required": "true"},
+			path:    "a.txt",
+			data:    "abc\n",
+			wantErr: true,
+		},
+		{
+			name:    "fail: required filter not configured",
+			rules:   []string{"*.txt filter=missing"},
+			config:  map[string]string{"filter.missing.required": "true"},
+			path:    "a.txt",
+			data:    "abc\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := newAttributes()
+			for _, rule := range tt.rules {
+				attrs.AddRule(rule)
+			}
+			config := newConfig()
+			for name, value := range tt.config {
+				ident, key, err := ConfigIdent(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				config.Add(ident, key, value, false)
+			}
+
+			got, err := NewCleanFilter(config, attrs).Clean(tt.path, []byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got = %v, want error = %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
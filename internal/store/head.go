@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/JunNishimura/Goit/internal/object"
@@ -13,26 +12,20 @@ import (
 )
 
 type Head struct {
-	Reference string
+	Reference string // empty on the detached HEAD
 	Commit    *object.Commit
+	detached  bool
 }
 
 var (
-	headRegexp     = regexp.MustCompile("ref: refs/heads/.+")
 	ErrInvalidHead = errors.New("error: invalid HEAD format")
 	ErrIOHandling  = errors.New("IO handling error")
 )
 
 func getHeadCommit(branch, rootGoitPath string) (*object.Commit, error) {
-	branchPath := filepath.Join(rootGoitPath, "refs", "heads", branch)
-	hashBytes, err := os.ReadFile(branchPath)
+	hash, err := ReadRef(rootGoitPath, branchRefName(branch))
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrIOHandling, branchPath)
-	}
-	hashString := string(hashBytes)
-	hash, err := sha.ReadHash(hashString)
-	if err != nil {
-		return nil, fmt.Errorf("fail to decode hash string: %w", err)
+		return nil, fmt.Errorf("fail to read branch %s: %w", branch, err)
 	}
 	commitObject, err := object.GetObject(rootGoitPath, hash)
 	if err != nil {
@@ -47,36 +40,42 @@ func getHeadCommit(branch, rootGoitPath string) (*object.Commit, error) {
 
 func NewHead(rootGoitPath string) (*Head, error) {
 	head := newHead()
+	if rootGoitPath == "" {
+		return head, nil
+	}
 
-	headPath := filepath.Join(rootGoitPath, "HEAD")
-	if _, err := os.Stat(headPath); !os.IsNotExist(err) {
-		// get branch
-		headByte, err := os.ReadFile(headPath)
+	// get branch
+	target, err := ReadSymbolicRef(rootGoitPath, "HEAD")
+	if errors.Is(err, ErrRefNotFound) {
+		return head, nil
+	}
+	if errors.Is(err, ErrNotSymbolicRef) {
+		// detached HEAD points to the commit directly
+		hash, err := readLooseRef(rootGoitPath, "HEAD")
 		if err != nil {
-			return nil, fmt.Errorf("fail to read file: %s", headPath)
-		}
-		headString := string(headByte)
-		if ok := headRegexp.MatchString(headString); !ok {
 			return nil, ErrInvalidHead
 		}
-		headSplit := strings.Split(headString, ": ")
-		slashSplit := strings.Split(headSplit[1], "/")
-		branch := slashSplit[len(slashSplit)-1]
-		head.Reference = branch
-
-		// get commit from branch
-		branchPath := filepath.Join(rootGoitPath, "refs", "heads", branch)
-		if _, err := os.Stat(branchPath); os.IsNotExist(err) {
-			return head, nil
-		}
-		commit, err := getHeadCommit(branch, rootGoitPath)
-		if err != nil {
+		if err := head.setCommit(rootGoitPath, hash); err != nil {
 			return nil, ErrInvalidHead
 		}
-		head.Commit = commit
+		head.detached = true
+		return head, nil
+	}
+	if err != nil || !strings.HasPrefix(target, "refs/heads/") {
+		return nil, ErrInvalidHead
+	}
+	branch := strings.TrimPrefix(target, "refs/heads/")
+	head.Reference = branch
 
+	// get commit from branch
+	if _, err := ReadRef(rootGoitPath, branchRefName(branch)); errors.Is(err, ErrRefNotFound) {
 		return head, nil
 	}
+	commit, err := getHeadCommit(branch, rootGoitPath)
+	if err != nil {
+		return nil, ErrInvalidHead
+	}
+	head.Commit = commit
 
 	return head, nil
 }
@@ -85,6 +84,37 @@ func newHead() *Head {
 	return &Head{}
 }
 
+func (h *Head) setCommit(rootGoitPath string, hash sha.Hash) error {
+	commitObject, err := object.GetObject(rootGoitPath, hash)
+	if err != nil {
+		return fmt.Errorf("fail to get commit object: %w", err)
+	}
+	commit, err := object.NewCommit(commitObject)
+	if err != nil {
+		return fmt.Errorf("fail to get commit: %w", err)
+	}
+	h.Commit = commit
+	return nil
+}
+
+// IsDetached tells whether HEAD points to the commit directly instead of the branch.
+func (h *Head) IsDetached() bool {
+	return h.detached
+}
+
+// Detach points HEAD to the commit directly, which is the detached HEAD.
+func (h *Head) Detach(rootGoitPath string, hash sha.Hash) error {
+	if err := h.setCommit(rootGoitPath, hash); err != nil {
+		return err
+	}
+	if err := writeLooseRef(rootGoitPath, "HEAD", hash); err != nil {
+		return fmt.Errorf("fail to write HEAD: %w", err)
+	}
+	h.Reference = ""
+	h.detached = true
+	return nil
+}
+
 func (h *Head) Update(refs *Refs, rootGoitPath, newRef string) error {
 	// check if branch exists
 	n := refs.getBranchPos(newRef)
@@ -96,23 +126,14 @@ func (h *Head) Update(refs *Refs, rootGoitPath, newRef string) error {
 	if _, err := os.Stat(headPath); os.IsNotExist(err) {
 		return errors.New("fail to find HEAD, cannot update")
 	}
-	f, err := os.Create(headPath)
-	if err != nil {
-		return fmt.Errorf("fail to create HEAD: %w", err)
-	}
-	defer f.Close()
-
-	if _, err := f.WriteString(fmt.Sprintf("ref: refs/heads/%s", newRef)); err != nil {
+	if err := WriteSymbolicRef(rootGoitPath, "HEAD", branchRefName(newRef)); err != nil {
 		return fmt.Errorf("fail to write HEAD: %w", err)
 	}
 
 	h.Reference = newRef
+	h.detached = false
 
 	// get commit from branch
-	branchPath := filepath.Join(rootGoitPath, "refs", "heads", newRef)
-	if _, err := os.Stat(branchPath); os.IsNotExist(err) {
-		return fmt.Errorf("fail to find branch %s: %w", newRef, err)
-	}
 	commit, err := getHeadCommit(newRef, rootGoitPath)
 	if err != nil {
 		return ErrInvalidHead
@@ -124,7 +145,11 @@ func (h *Head) Update(refs *Refs, rootGoitPath, newRef string) error {
 
 // reset Head to the specified state by hash
 // This method does not change Head.Reference, just change Commit
-func (h *Head) Reset(rootGoitPath string, refs *Refs, hash sha.SHA1) error {
+func (h *Head) Reset(rootGoitPath string, refs *Refs, hash sha.Hash) error {
+	if h.detached {
+		return h.Detach(rootGoitPath, hash)
+	}
+
 	// write branch hash
 	if err := refs.UpdateBranchHash(rootGoitPath, h.Reference, hash); err != nil {
 		return fmt.Errorf("fail to update branch hash: %w", err)
@@ -147,3 +172,23 @@ func (h *Head) Reset(rootGoitPath string, refs *Refs, hash sha.SHA1) error {
 
 	return nil
 }
+
+// Orphan points HEAD to the new branch which has no commit yet.
+// The branch is created by the next commit.
+func (h *Head) Orphan(refs *Refs, rootGoitPath, newRef string) error {
+	if err := ValidateRefName(branchRefName(newRef)); err != nil {
+		return fmt.Errorf("'%s' is not a valid branch name: %w", newRef, err)
+	}
+	if refs.IsBranchExist(newRef) {
+		return fmt.Errorf("a branch named '%s' already exists", newRef)
+	}
+	if err := WriteSymbolicRef(rootGoitPath, "HEAD", branchRefName(newRef)); err != nil {
+		return fmt.Errorf("fail to write HEAD: %w", err)
+	}
+
+	h.Reference = newRef
+	h.Commit = nil
+	h.detached = false
+
+	return nil
+}
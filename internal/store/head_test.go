@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
 )
 
 func TestNewHead(t *testing.T) {
@@ -25,6 +28,15 @@ func TestNewHead(t *testing.T) {
 				Commit:    nil,
 			},
 			wantErr: nil,
+		}, {
+			name:      "success: nested branch",
+			content:   "ref: refs/heads/feature/login\n",
+			isCreated: true,
+			want: &Head{
+				Reference: "feature/login",
+				Commit:    nil,
+			},
+			wantErr: nil,
 		}, {
 			name:      "invalid HEAD format",
 			content:   "ref: ***",
@@ -101,3 +113,35 @@ func TestNewHead(t *testing.T) {
 		})
 	}
 }
+
+func TestDetach(t *testing.T) {
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "objects"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("tree 87f3c49bccf2597484ece08746d3ee5defaba335\nauthor a <a@b.com> 1700000000 +0900\ncommitter a <a@b.com> 1700000000 +0900\n\ninit\n")
+	commitObject, err := object.NewObject(sha.SHA1Format, object.CommitObject, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := commitObject.Write(goitDir); err != nil {
+		t.Fatal(err)
+	}
+
+	head := newHead()
+	if err := head.Detach(goitDir, commitObject.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if !head.IsDetached() || head.Reference != "" {
+		t.Errorf("got = %v %q, want = detached", head.IsDetached(), head.Reference)
+	}
+
+	// the detached HEAD is read back from the file
+	got, err := NewHead(goitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsDetached() || got.Commit == nil || !got.Commit.Hash.Compare(commitObject.Hash) {
+		t.Errorf("got = %v, want = detached at %s", got, commitObject.Hash)
+	}
+}
@@ -44,20 +44,34 @@ func (i *Ignore) load(rootGoitPath string) error {
 
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		text := scanner.Text()
-		var replacedText string
-		if directoryRegexp.MatchString(text) {
-			replacedText = fmt.Sprintf("%s.*", text)
-		} else {
-			replacedText = strings.ReplaceAll(text, ".", `\.`)
-			replacedText = strings.ReplaceAll(replacedText, "*", ".*")
-		}
-		i.paths = append(i.paths, replacedText)
+		i.AddPattern(scanner.Text())
 	}
 
 	return nil
 }
 
+// NewIgnoreFromPatterns makes the ignore list from the patterns written in the .goitignore format,
+// such as the extra exclude patterns given on the command line.
+func NewIgnoreFromPatterns(patterns []string) *Ignore {
+	i := newIgnore()
+	for _, pattern := range patterns {
+		i.AddPattern(pattern)
+	}
+	return i
+}
+
+// AddPattern adds the pattern written in the .goitignore format to the ignore list.
+func (i *Ignore) AddPattern(text string) {
+	var replacedText string
+	if directoryRegexp.MatchString(text) {
+		replacedText = fmt.Sprintf("%s.*", text)
+	} else {
+		replacedText = strings.ReplaceAll(text, ".", `\.`)
+		replacedText = strings.ReplaceAll(replacedText, "*", ".*")
+	}
+	i.paths = append(i.paths, replacedText)
+}
+
 // return true if the parameter is included in ignore list
 func (i *Ignore) IsIncluded(path string, index *Index) bool {
 	target := path
@@ -71,6 +85,19 @@ func (i *Ignore) IsIncluded(path string, index *Index) bool {
 			target = fmt.Sprintf("%s/", path)
 		}
 	}
+	return i.match(target)
+}
+
+// Match tells whether the path relative to the working tree root is ignored.
+// Unlike IsIncluded, the file system is not looked up, so isDir tells the path is a directory.
+func (i *Ignore) Match(path string, isDir bool) bool {
+	if isDir {
+		path = fmt.Sprintf("%s/", strings.TrimSuffix(path, "/"))
+	}
+	return i.match(path)
+}
+
+func (i *Ignore) match(target string) bool {
 	for _, exFile := range i.paths {
 		exRegexp := regexp.MustCompile(exFile)
 		if exRegexp.MatchString(target) {
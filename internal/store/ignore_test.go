@@ -64,3 +64,22 @@ func TestNewIgnore(t *testing.T) {
 		})
 	}
 }
+
+func TestNewIgnoreFromPatterns(t *testing.T) {
+	i := NewIgnoreFromPatterns([]string{"*.o", "build/"})
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "main.o", want: true},
+		{path: "main.go", want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "build", isDir: false, want: false},
+	}
+	for _, tt := range tests {
+		if got := i.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%s: got = %v, want = %v", tt.path, got, tt.want)
+		}
+	}
+}
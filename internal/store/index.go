@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
+	"strings"
 
+	"github.com/JunNishimura/Goit/internal/diff"
 	"github.com/JunNishimura/Goit/internal/object"
 	"github.com/JunNishimura/Goit/internal/sha"
 )
@@ -17,16 +18,25 @@ const (
 	diffDelete diffType = iota
 	diffNew
 	diffModified
+	diffRenamed
 	newEntryFlag = -1
 )
 
+const (
+	// the name length and the stage share the 16 bits flags of the entry just like git
+	nameLengthMask = 0x0fff
+	stageShift     = 12
+	stageMask      = 0x3
+)
+
 type Entry struct {
-	Hash       sha.SHA1
+	Hash       sha.Hash
 	NameLength uint16
 	Path       []byte
+	Stage      int // 0 for merged entries, 1 (base), 2 (ours) and 3 (theirs) for conflicts
 }
 
-func NewEntry(hash sha.SHA1, path []byte) *Entry {
+func NewEntry(hash sha.Hash, path []byte) *Entry {
 	return &Entry{
 		Hash:       hash,
 		NameLength: uint16(len(path)),
@@ -44,6 +54,8 @@ func (t diffType) String() string {
 		return "new file:"
 	case diffModified:
 		return "modified:"
+	case diffRenamed:
+		return "renamed:"
 	default:
 		return ""
 	}
@@ -52,6 +64,24 @@ func (t diffType) String() string {
 type DiffEntry struct {
 	Dt    diffType
 	Entry *Entry
+	From  *Entry // the source entry of the rename
+	Score int    // similarity of the rename in percentage
+}
+
+// Status returns the letter of the change used by the short status, which is 'D', 'A', 'M' or 'R'.
+func (e *DiffEntry) Status() byte {
+	switch e.Dt {
+	case diffDelete:
+		return 'D'
+	case diffNew:
+		return 'A'
+	case diffModified:
+		return 'M'
+	case diffRenamed:
+		return 'R'
+	default:
+		return ' '
+	}
 }
 
 type Header struct {
@@ -62,11 +92,13 @@ type Header struct {
 
 type Index struct {
 	Header
-	Entries []*Entry // sorted entries
+	Entries []*Entry    // sorted entries
+	format  *sha.Format // the hash algorithm of the entries
 }
 
-func NewIndex(rootGoitPath string) (*Index, error) {
+func NewIndex(rootGoitPath string, format *sha.Format) (*Index, error) {
 	index := newIndex()
+	index.format = format
 	indexPath := filepath.Join(rootGoitPath, "index")
 	if _, err := os.Stat(indexPath); !os.IsNotExist(err) {
 		if err := index.read(rootGoitPath); err != nil {
@@ -83,92 +115,127 @@ func newIndex() *Index {
 			Version:   uint32(1),
 			EntryNum:  uint32(0),
 		},
+		format: sha.SHA1Format,
 	}
 }
 
-// return the position of entry, entry, and flag to tell the entry is found or not
-func (idx *Index) GetEntry(path []byte) (int, *Entry, bool) {
-	if idx.EntryNum == 0 {
-		return newEntryFlag, nil, false
-	}
-
-	left := 0
-	right := int(idx.EntryNum)
-	for {
-		middle := (left + right) / 2
-		entry := idx.Entries[middle]
-		if string(entry.Path) == string(path) {
-			return middle, entry, true
-		} else if string(entry.Path) < string(path) {
-			left = middle + 1
-		} else {
-			right = middle
-		}
+// Format returns the hash algorithm of the entries, which is the object format of the repository.
+func (idx *Index) Format() *sha.Format {
+	return idx.format
+}
 
-		if right-left < 1 {
-			break
-		}
+// return the position of the first entry of the path, which is not a stage entry
+// unless the path is unmerged, the entry, and flag to tell the entry is found or not
+func (idx *Index) GetEntry(path []byte) (int, *Entry, bool) {
+	pos := idx.lowerBound(path)
+	if pos < len(idx.Entries) && string(idx.Entries[pos].Path) == string(path) {
+		return pos, idx.Entries[pos], true
 	}
-
 	return newEntryFlag, nil, false
 }
 
-func (idx *Index) GetEntriesByDirectory(dirName string) []*Entry {
-	var entries []*Entry
+// return the position of the first entry whose path is not less than the path.
+func (idx *Index) lowerBound(path []byte) int {
+	return sort.Search(len(idx.Entries), func(i int) bool {
+		return string(idx.Entries[i].Path) >= string(path)
+	})
+}
+
+// GetStageEntry returns the entry of the path at the stage.
+func (idx *Index) GetStageEntry(path []byte, stage int) (*Entry, bool) {
+	for pos := idx.lowerBound(path); pos < len(idx.Entries) && string(idx.Entries[pos].Path) == string(path); pos++ {
+		if idx.Entries[pos].Stage == stage {
+			return idx.Entries[pos], true
+		}
+	}
+	return nil, false
+}
 
-	dirRegexp := regexp.MustCompile(fmt.Sprintf(`%s\/.+`, dirName))
+// UnmergedPaths returns the paths which have conflict stages, sorted by path.
+func (idx *Index) UnmergedPaths() []string {
+	var paths []string
 	for _, entry := range idx.Entries {
-		if dirRegexp.Match(entry.Path) {
-			entries = append(entries, entry)
+		if entry.Stage == 0 {
+			continue
 		}
+		if len(paths) > 0 && paths[len(paths)-1] == string(entry.Path) {
+			continue
+		}
+		paths = append(paths, string(entry.Path))
 	}
+	return paths
+}
 
-	return entries
+// Put replaces every entry of the path with the entries, which must have the same path.
+// The path is removed from the index if no entry is given.
+// The index file is not written until Write is called.
+func (idx *Index) Put(path []byte, entries ...*Entry) {
+	start := idx.lowerBound(path)
+	end := start
+	for end < len(idx.Entries) && string(idx.Entries[end].Path) == string(path) {
+		end++
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Stage < entries[j].Stage })
+
+	newEntries := make([]*Entry, 0, len(idx.Entries)-(end-start)+len(entries))
+	newEntries = append(newEntries, idx.Entries[:start]...)
+	newEntries = append(newEntries, entries...)
+	newEntries = append(newEntries, idx.Entries[end:]...)
+	idx.Entries = newEntries
+	idx.EntryNum = uint32(len(idx.Entries))
 }
 
-func (idx *Index) IsRegisteredAsDirectory(dirName string) bool {
-	if idx.EntryNum == 0 {
+// Move renames the entries of the path, including the conflict stages, to the new path.
+// The index file is not written until Write is called.
+func (idx *Index) Move(oldPath, newPath []byte) bool {
+	start := idx.lowerBound(oldPath)
+	var entries []*Entry
+	for pos := start; pos < len(idx.Entries) && string(idx.Entries[pos].Path) == string(oldPath); pos++ {
+		entry := NewEntry(idx.Entries[pos].Hash, newPath)
+		entry.Stage = idx.Entries[pos].Stage
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
 		return false
 	}
+	idx.Put(oldPath)
+	idx.Put(newPath, entries...)
+	return true
+}
 
-	dirRegexp := regexp.MustCompile(fmt.Sprintf(`%s\/.+`, dirName))
+// Write writes the index to .goit/index.
+func (idx *Index) Write(rootGoitPath string) error {
+	return idx.write(rootGoitPath)
+}
 
-	left := 0
-	right := int(idx.EntryNum)
-	for {
-		middle := (left + right) / 2
-		entry := idx.Entries[middle]
-		if dirRegexp.MatchString(string(entry.Path)) {
-			return true
-		} else if string(entry.Path) < dirName {
-			left = middle + 1
-		} else {
-			right = middle
-		}
+func (idx *Index) GetEntriesByDirectory(dirName string) []*Entry {
+	var entries []*Entry
 
-		if right-left < 1 {
+	dirPrefix := fmt.Sprintf("%s/", strings.TrimSuffix(dirName, "/"))
+	for pos := idx.lowerBound([]byte(dirPrefix)); pos < len(idx.Entries); pos++ {
+		if !bytes.HasPrefix(idx.Entries[pos].Path, []byte(dirPrefix)) {
 			break
 		}
+		entries = append(entries, idx.Entries[pos])
 	}
 
-	return false
+	return entries
 }
 
-func (idx *Index) Update(rootGoitPath string, hash sha.SHA1, path []byte) (bool, error) {
-	pos, gotEntry, isFound := idx.GetEntry(path)
-	if isFound && string(gotEntry.Hash) == string(hash) && string(gotEntry.Path) == string(path) {
+func (idx *Index) IsRegisteredAsDirectory(dirName string) bool {
+	dirPrefix := fmt.Sprintf("%s/", strings.TrimSuffix(dirName, "/"))
+	pos := idx.lowerBound([]byte(dirPrefix))
+	return pos < len(idx.Entries) && bytes.HasPrefix(idx.Entries[pos].Path, []byte(dirPrefix))
+}
+
+func (idx *Index) Update(rootGoitPath string, hash sha.Hash, path []byte) (bool, error) {
+	_, gotEntry, isFound := idx.GetEntry(path)
+	if isFound && gotEntry.Stage == 0 && string(gotEntry.Hash) == string(hash) {
 		return false, nil
 	}
 
-	// add new entry and update index entries
-	entry := NewEntry(hash, path)
-	if pos != newEntryFlag {
-		// remove existing entry
-		idx.Entries = append(idx.Entries[:pos], idx.Entries[pos+1:]...)
-	}
-	idx.Entries = append(idx.Entries, entry)
-	idx.EntryNum = uint32(len(idx.Entries))
-	sort.Slice(idx.Entries, func(i, j int) bool { return string(idx.Entries[i].Path) < string(idx.Entries[j].Path) })
+	// replace the existing entry, including the conflict stages
+	idx.Put(path, NewEntry(hash, path))
 
 	if err := idx.write(rootGoitPath); err != nil {
 		return false, err
@@ -178,16 +245,13 @@ func (idx *Index) Update(rootGoitPath string, hash sha.SHA1, path []byte) (bool,
 }
 
 func (idx *Index) DeleteEntry(rootGoitPath string, path []byte) error {
-	pos, _, isFound := idx.GetEntry(path)
+	_, _, isFound := idx.GetEntry(path)
 	if !isFound {
 		return fmt.Errorf("'%s' is not registered in index, so fail to delete", path)
 	}
 
-	// delete target entry
-	idx.Entries = append(idx.Entries[:pos], idx.Entries[pos+1:]...)
-
-	// reset entry num
-	idx.EntryNum = uint32(len(idx.Entries))
+	// delete target entry with its conflict stages
+	idx.Put(path)
 
 	// write index
 	if err := idx.write(rootGoitPath); err != nil {
@@ -217,18 +281,20 @@ func (idx *Index) read(rootGoitPath string) error {
 	// variable length decoding
 	for i := 0; i < int(idx.EntryNum); i++ {
 		// read hash
-		hash := make(sha.SHA1, 20)
+		hash := make(sha.Hash, idx.format.Size)
 		err = binary.Read(buf, binary.BigEndian, &hash)
 		if err != nil {
 			return fmt.Errorf("fail to read hash from index: %w", err)
 		}
 
-		// read file name length
-		var nameLength uint16
-		err = binary.Read(buf, binary.BigEndian, &nameLength)
+		// read flags which consist of the stage and the file name length
+		var flags uint16
+		err = binary.Read(buf, binary.BigEndian, &flags)
 		if err != nil {
 			return fmt.Errorf("fail to read file name length from index: %w", err)
 		}
+		nameLength := flags & nameLengthMask
+		stage := int(flags>>stageShift) & stageMask
 
 		// read file path
 		path := make([]byte, nameLength)
@@ -238,6 +304,7 @@ func (idx *Index) read(rootGoitPath string) error {
 		}
 
 		entry := NewEntry(hash, path)
+		entry.Stage = stage
 		idx.Entries = append(idx.Entries, entry)
 	}
 
@@ -261,7 +328,7 @@ func (idx *Index) write(rootGoitPath string) error {
 	var data []byte
 	for _, entry := range idx.Entries {
 		bNameLength := make([]byte, 2)
-		binary.BigEndian.PutUint16(bNameLength, entry.NameLength)
+		binary.BigEndian.PutUint16(bNameLength, uint16(entry.Stage&stageMask)<<stageShift|entry.NameLength&nameLengthMask)
 		data = append(data, entry.Hash...)
 		data = append(data, bNameLength...)
 		data = append(data, entry.Path...)
@@ -308,7 +375,7 @@ func getEntriesFromTree(rootName string, nodes []*object.Node) ([]*Entry, error)
 	return entries, nil
 }
 
-func (idx *Index) Reset(rootGoitPath string, hash sha.SHA1) error {
+func (idx *Index) Reset(rootGoitPath string, hash sha.Hash) error {
 	// get commit
 	commitObject, err := object.GetObject(rootGoitPath, hash)
 	if err != nil {
@@ -385,3 +452,63 @@ func (idx *Index) DiffWithTree(tree *object.Tree) ([]*DiffEntry, error) {
 
 	return diffEntries, nil
 }
+
+// DetectRenames pairs the deleted entries and the new entries of the diff into the renamed entries,
+// whose contents are the same or similar at least the threshold in percentage.
+func DetectRenames(rootGoitPath string, diffEntries []*DiffEntry, threshold int) ([]*DiffEntry, error) {
+	var changes []*diff.FileChange
+	deletedEntries := make(map[string]*Entry)
+	for _, diffEntry := range diffEntries {
+		path := string(diffEntry.Entry.Path)
+		switch diffEntry.Dt {
+		case diffDelete:
+			changes = append(changes, &diff.FileChange{Status: diff.Deleted, OldPath: path, OldHash: diffEntry.Entry.Hash})
+			deletedEntries[path] = diffEntry.Entry
+		case diffNew:
+			changes = append(changes, &diff.FileChange{Status: diff.Added, NewPath: path, NewHash: diffEntry.Entry.Hash})
+		}
+	}
+
+	detected, err := diff.DetectRenames(changes, nil, &diff.RenameOptions{
+		Threshold: threshold,
+		Load: func(hash sha.Hash) ([]byte, error) {
+			obj, err := object.GetObject(rootGoitPath, hash)
+			if err != nil {
+				return nil, fmt.Errorf("fail to get object %s: %w", hash, err)
+			}
+			return obj.Data, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	renamedTo := make(map[string]*diff.FileChange)
+	renamedFrom := make(map[string]bool)
+	for _, c := range detected {
+		if c.Status == diff.Renamed {
+			renamedTo[c.NewPath] = c
+			renamedFrom[c.OldPath] = true
+		}
+	}
+
+	var result []*DiffEntry
+	for _, diffEntry := range diffEntries {
+		path := string(diffEntry.Entry.Path)
+		switch {
+		case diffEntry.Dt == diffDelete && renamedFrom[path]:
+			continue
+		case diffEntry.Dt == diffNew && renamedTo[path] != nil:
+			c := renamedTo[path]
+			result = append(result, &DiffEntry{
+				Dt:    diffRenamed,
+				Entry: diffEntry.Entry,
+				From:  deletedEntries[c.OldPath],
+				Score: c.Score,
+			})
+		default:
+			result = append(result, diffEntry)
+		}
+	}
+
+	return result, nil
+}
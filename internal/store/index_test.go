@@ -3,6 +3,7 @@ package store
 import (
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -14,7 +15,7 @@ import (
 
 func TestNewEntry(t *testing.T) {
 	type args struct {
-		hash       sha.SHA1
+		hash       sha.Hash
 		nameLength uint16
 		path       []byte
 	}
@@ -27,7 +28,7 @@ func TestNewEntry(t *testing.T) {
 
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 			nameLength := uint16(len(path))
 
@@ -72,6 +73,7 @@ func TestNewIndex(t *testing.T) {
 						Version:   uint32(1),
 						EntryNum:  uint32(0),
 					},
+					format: sha.SHA1Format,
 				},
 				wantErr: nil,
 			}
@@ -124,7 +126,7 @@ func TestNewIndex(t *testing.T) {
 				t.Logf("%v: %s", err, tagsDir)
 			}
 
-			index, err := NewIndex(goitDir)
+			index, err := NewIndex(goitDir, sha.SHA1Format)
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("got = %v, want = %v", err, tt.wantErr)
 			}
@@ -137,7 +139,7 @@ func TestNewIndex(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	type args struct {
-		hash sha.SHA1
+		hash sha.Hash
 		path []byte
 	}
 	type test struct {
@@ -150,7 +152,7 @@ func TestUpdate(t *testing.T) {
 	tests := []*test{
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 
 			return &test{
@@ -166,7 +168,7 @@ func TestUpdate(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 
 			return &test{
@@ -228,7 +230,7 @@ func TestUpdate(t *testing.T) {
 				t.Logf("%v: %s", err, tagsDir)
 			}
 
-			index, err := NewIndex(goitDir)
+			index, err := NewIndex(goitDir, sha.SHA1Format)
 			if err != nil {
 				t.Log(err)
 			}
@@ -252,7 +254,7 @@ func TestUpdate(t *testing.T) {
 
 func TestGetEntry(t *testing.T) {
 	type fields struct {
-		hash sha.SHA1
+		hash sha.Hash
 		path []byte
 	}
 	type args struct {
@@ -269,7 +271,7 @@ func TestGetEntry(t *testing.T) {
 	tests := []*test{
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 
 			entry := NewEntry(hash, path)
@@ -290,7 +292,7 @@ func TestGetEntry(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 
 			return &test{
@@ -355,7 +357,7 @@ func TestGetEntry(t *testing.T) {
 				t.Logf("%v: %s", err, tagsDir)
 			}
 
-			index, err := NewIndex(goitDir)
+			index, err := NewIndex(goitDir, sha.SHA1Format)
 			if err != nil {
 				t.Log(err)
 			}
@@ -407,7 +409,7 @@ func TestGetEntriesByDirectory(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			return &test{
 				name: "success: directory",
 				args: args{
@@ -428,7 +430,7 @@ func TestGetEntriesByDirectory(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			return &test{
 				name: "true: sub directory",
 				args: args{
@@ -446,6 +448,25 @@ func TestGetEntriesByDirectory(t *testing.T) {
 				},
 			}
 		}(),
+		func() *test {
+			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+			hash = sha.Hash(hash)
+			return &test{
+				name: "success: regexp meta characters",
+				args: args{
+					dirName: "a.b+",
+				},
+				fields: fields{
+					entries: []*Entry{
+						NewEntry(hash, []byte("a.b+/test.txt")),
+						NewEntry(hash, []byte("axbb/test.txt")),
+					},
+				},
+				want: []*Entry{
+					NewEntry(hash, []byte("a.b+/test.txt")),
+				},
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -456,7 +477,7 @@ func TestGetEntriesByDirectory(t *testing.T) {
 				t.Logf("%v: %s", err, goitDir)
 			}
 
-			index, err := NewIndex(goitDir)
+			index, err := NewIndex(goitDir, sha.SHA1Format)
 			if err != nil {
 				t.Log(err)
 			}
@@ -492,7 +513,7 @@ func TestIsRegisteredAsDirectory(t *testing.T) {
 	tests := []*test{
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			return &test{
 				name: "true",
 				args: args{
@@ -510,7 +531,7 @@ func TestIsRegisteredAsDirectory(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			return &test{
 				name: "true: sub directory",
 				args: args{
@@ -528,7 +549,7 @@ func TestIsRegisteredAsDirectory(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			return &test{
 				name: "false",
 				args: args{
@@ -554,7 +575,7 @@ func TestIsRegisteredAsDirectory(t *testing.T) {
 				t.Logf("%v: %s", err, goitDir)
 			}
 
-			index, err := NewIndex(goitDir)
+			index, err := NewIndex(goitDir, sha.SHA1Format)
 			if err != nil {
 				t.Log(err)
 			}
@@ -576,7 +597,7 @@ func TestIsRegisteredAsDirectory(t *testing.T) {
 
 func TestDeleteEntry(t *testing.T) {
 	type fields struct {
-		hash sha.SHA1
+		hash sha.Hash
 		path []byte
 	}
 	type args struct {
@@ -591,7 +612,7 @@ func TestDeleteEntry(t *testing.T) {
 	tests := []*test{
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 
 			return &test{
@@ -608,7 +629,7 @@ func TestDeleteEntry(t *testing.T) {
 		}(),
 		func() *test {
 			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			hash = sha.SHA1(hash)
+			hash = sha.Hash(hash)
 			path := []byte("cmd/main.go")
 
 			return &test{
@@ -671,7 +692,7 @@ func TestDeleteEntry(t *testing.T) {
 				t.Logf("%v: %s", err, tagsDir)
 			}
 
-			index, err := NewIndex(goitDir)
+			index, err := NewIndex(goitDir, sha.SHA1Format)
 			if err != nil {
 				t.Log(err)
 			}
@@ -900,3 +921,141 @@ func TestDiffWithTree(t *testing.T) {
 		})
 	}
 }
+
+func TestPut(t *testing.T) {
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.Mkdir(goitDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	hash := sha.Hash(b)
+
+	index, err := NewIndex(goitDir, sha.SHA1Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Put([]byte("b.txt"), NewEntry(hash, []byte("b.txt")))
+	index.Put([]byte("a.txt"), NewEntry(hash, []byte("a.txt")))
+	stages := []*Entry{NewEntry(hash, []byte("a.txt")), NewEntry(hash, []byte("a.txt")), NewEntry(hash, []byte("a.txt"))}
+	stages[0].Stage, stages[1].Stage, stages[2].Stage = 3, 1, 2
+	index.Put([]byte("a.txt"), stages...)
+	if err := index.Write(goitDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// the stages survive the round trip
+	got, err := NewIndex(goitDir, sha.SHA1Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotStages []string
+	for _, entry := range got.Entries {
+		gotStages = append(gotStages, fmt.Sprintf("%s:%d", entry.Path, entry.Stage))
+	}
+	if want := []string{"a.txt:1", "a.txt:2", "a.txt:3", "b.txt:0"}; !reflect.DeepEqual(gotStages, want) {
+		t.Errorf("got = %v, want = %v", gotStages, want)
+	}
+	if want := []string{"a.txt"}; !reflect.DeepEqual(got.UnmergedPaths(), want) {
+		t.Errorf("got = %v, want = %v", got.UnmergedPaths(), want)
+	}
+	if _, ok := got.GetStageEntry([]byte("a.txt"), 0); ok {
+		t.Errorf("stage 0 entry is found for the unmerged path")
+	}
+
+	// resolving the path replaces every stage
+	got.Put([]byte("a.txt"), NewEntry(hash, []byte("a.txt")))
+	if len(got.Entries) != 2 || len(got.UnmergedPaths()) != 0 {
+		t.Errorf("got = %v, want 2 merged entries", got.Entries)
+	}
+}
+
+func TestIndexSHA256(t *testing.T) {
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.Mkdir(goitDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := hex.DecodeString("7924fb0f23c883233d0b9d6699dd0df672b64b319e080f0b3d5285ee41bc7106")
+	hash := sha.Hash(b)
+
+	index, err := NewIndex(goitDir, sha.SHA256Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.Put([]byte("a.txt"), NewEntry(hash, []byte("a.txt")))
+	index.Put([]byte("dir/b.txt"), NewEntry(hash, []byte("dir/b.txt")))
+	if err := index.Write(goitDir); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewIndex(goitDir, sha.SHA256Format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Format() != sha.SHA256Format {
+		t.Errorf("got = %s, want = %s", got.Format().Name, sha.SHA256Format.Name)
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got.Entries))
+	}
+	for i, path := range []string{"a.txt", "dir/b.txt"} {
+		if string(got.Entries[i].Path) != path || !got.Entries[i].Hash.Compare(hash) {
+			t.Errorf("got = %s %s, want = %s %s", got.Entries[i].Hash, got.Entries[i].Path, hash, path)
+		}
+	}
+}
+
+func TestMove(t *testing.T) {
+	b, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	hash := sha.Hash(b)
+
+	index := newIndex()
+	index.Put([]byte("a.txt"), NewEntry(hash, []byte("a.txt")))
+	index.Put([]byte("dir/b.txt"), NewEntry(hash, []byte("dir/b.txt")))
+	stage := NewEntry(hash, []byte("c.txt"))
+	stage.Stage = 2
+	index.Put([]byte("c.txt"), stage)
+
+	if !index.Move([]byte("a.txt"), []byte("z.txt")) {
+		t.Fatal("a.txt is not moved")
+	}
+	if !index.Move([]byte("c.txt"), []byte("dir/c.txt")) {
+		t.Fatal("c.txt is not moved")
+	}
+	if index.Move([]byte("not_exist.txt"), []byte("x.txt")) {
+		t.Error("not_exist.txt is moved")
+	}
+
+	var got []string
+	for _, entry := range index.Entries {
+		got = append(got, fmt.Sprintf("%s:%d", entry.Path, entry.Stage))
+	}
+	if want := []string{"dir/b.txt:0", "dir/c.txt:2", "z.txt:0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	b, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	hash := sha.Hash(b)
+	b, _ = hex.DecodeString("3b18e512dba79e4c8300dd08aeb37f8e728b8dad")
+	otherHash := sha.Hash(b)
+
+	diffEntries := []*DiffEntry{
+		{Dt: diffDelete, Entry: NewEntry(hash, []byte("a.txt"))},
+		{Dt: diffModified, Entry: NewEntry(otherHash, []byte("b.txt"))},
+		{Dt: diffNew, Entry: NewEntry(hash, []byte("dir/a.txt"))},
+	}
+	got, err := DetectRenames(filepath.Join(t.TempDir(), ".goit"), diffEntries, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got len = %d, want len = %d", len(got), 2)
+	}
+	if got[0].Dt != diffModified {
+		t.Errorf("got dt = %v, want dt = %v", got[0].Dt, diffModified)
+	}
+	if got[1].Dt != diffRenamed || string(got[1].From.Path) != "a.txt" || string(got[1].Entry.Path) != "dir/a.txt" || got[1].Score != 100 {
+		t.Errorf("got = %v %s -> %s (%d), want = renamed a.txt -> dir/a.txt (100)", got[1].Dt, got[1].From.Path, got[1].Entry.Path, got[1].Score)
+	}
+}
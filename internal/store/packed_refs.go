@@ -0,0 +1,174 @@
+package store
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	packedRefsHeader = "# pack-refs with: peeled fully-peeled sorted "
+)
+
+var (
+	ErrInvalidPackedRefs = errors.New("fatal: unexpected line in packed-refs")
+)
+
+type packedRef struct {
+	name   string
+	hash   sha.Hash
+	peeled sha.Hash // the object an annotated tag points to, nil otherwise
+}
+
+type packedRefs struct {
+	refs []*packedRef // sorted by name
+}
+
+func newPackedRefs() *packedRefs {
+	return &packedRefs{
+		refs: make([]*packedRef, 0),
+	}
+}
+
+func readPackedRefs(rootGoitPath string) (*packedRefs, error) {
+	p := newPackedRefs()
+
+	packedRefsPath := filepath.Join(rootGoitPath, "packed-refs")
+	f, err := os.Open(packedRefsPath)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to open %s: %w", packedRefsPath, err)
+	}
+	defer f.Close()
+
+	var last *packedRef
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// peeled line belongs to the ref on the previous line
+		if strings.HasPrefix(text, "^") {
+			if last == nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidPackedRefs, text)
+			}
+			hash, err := sha.ReadHash(text[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidPackedRefs, text)
+			}
+			last.peeled = hash
+			continue
+		}
+
+		sp := strings.SplitN(text, " ", 2)
+		if len(sp) != 2 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPackedRefs, text)
+		}
+		hash, err := sha.ReadHash(sp[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPackedRefs, text)
+		}
+		last = &packedRef{
+			name: sp[1],
+			hash: hash,
+		}
+		p.refs = append(p.refs, last)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fail to read %s: %w", packedRefsPath, err)
+	}
+	sort.Slice(p.refs, func(i, j int) bool { return p.refs[i].name < p.refs[j].name })
+
+	return p, nil
+}
+
+// return the position of the ref, or -1 if not found.
+func (p *packedRefs) getPos(name string) int {
+	pos := sort.Search(len(p.refs), func(i int) bool { return p.refs[i].name >= name })
+	if pos < len(p.refs) && p.refs[pos].name == name {
+		return pos
+	}
+	return -1
+}
+
+func (p *packedRefs) get(name string) (*packedRef, bool) {
+	pos := p.getPos(name)
+	if pos == -1 {
+		return nil, false
+	}
+	return p.refs[pos], true
+}
+
+func (p *packedRefs) set(ref *packedRef) {
+	pos := p.getPos(ref.name)
+	if pos != -1 {
+		p.refs[pos] = ref
+		return
+	}
+	p.refs = append(p.refs, ref)
+	sort.Slice(p.refs, func(i, j int) bool { return p.refs[i].name < p.refs[j].name })
+}
+
+// remove the ref and report whether it was packed.
+func (p *packedRefs) remove(name string) bool {
+	pos := p.getPos(name)
+	if pos == -1 {
+		return false
+	}
+	p.refs = append(p.refs[:pos], p.refs[pos+1:]...)
+	return true
+}
+
+func (p *packedRefs) String() string {
+	var b strings.Builder
+	b.WriteString(packedRefsHeader + "\n")
+	for _, ref := range p.refs {
+		b.WriteString(fmt.Sprintf("%s %s\n", ref.hash, ref.name))
+		if ref.peeled != nil {
+			b.WriteString(fmt.Sprintf("^%s\n", ref.peeled))
+		}
+	}
+	return b.String()
+}
+
+// write packed-refs through a lock file so that readers never see a half-written file.
+func (p *packedRefs) write(rootGoitPath string) error {
+	packedRefsPath := filepath.Join(rootGoitPath, "packed-refs")
+	if len(p.refs) == 0 {
+		if err := os.Remove(packedRefsPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("fail to remove %s: %w", packedRefsPath, err)
+		}
+		return nil
+	}
+
+	lockPath := packedRefsPath + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return fmt.Errorf("fail to lock %s: %w", packedRefsPath, err)
+	}
+	if _, err := f.WriteString(p.String()); err != nil {
+		f.Close()
+		os.Remove(lockPath)
+		return fmt.Errorf("fail to write %s: %w", lockPath, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(lockPath)
+		return fmt.Errorf("fail to close %s: %w", lockPath, err)
+	}
+	if err := os.Rename(lockPath, packedRefsPath); err != nil {
+		os.Remove(lockPath)
+		return fmt.Errorf("fail to rename %s: %w", lockPath, err)
+	}
+
+	return nil
+}
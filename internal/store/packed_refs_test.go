@@ -0,0 +1,142 @@
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+func TestReadPackedRefs(t *testing.T) {
+	type test struct {
+		name    string
+		content string
+		want    *packedRefs
+		wantErr error
+	}
+	tests := []*test{
+		func() *test {
+			return &test{
+				name:    "success: no packed-refs",
+				content: "",
+				want:    newPackedRefs(),
+				wantErr: nil,
+			}
+		}(),
+		func() *test {
+			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+			peeled, _ := hex.DecodeString("bc835249d19fb16ece41eef7913b4dde40b93845")
+			return &test{
+				name: "success: peeled tag",
+				content: packedRefsHeader + "\n" +
+					"87f3c49bccf2597484ece08746d3ee5defaba335 refs/tags/v1.0.0\n" +
+					"^bc835249d19fb16ece41eef7913b4dde40b93845\n" +
+					"87f3c49bccf2597484ece08746d3ee5defaba335 refs/heads/feature/login\n",
+				want: &packedRefs{
+					refs: []*packedRef{
+						{
+							name: "refs/heads/feature/login",
+							hash: sha.Hash(hash),
+						},
+						{
+							name:   "refs/tags/v1.0.0",
+							hash:   sha.Hash(hash),
+							peeled: sha.Hash(peeled),
+						},
+					},
+				},
+				wantErr: nil,
+			}
+		}(),
+		func() *test {
+			return &test{
+				name:    "fail: peeled line without ref",
+				content: "^bc835249d19fb16ece41eef7913b4dde40b93845\n",
+				want:    nil,
+				wantErr: ErrInvalidPackedRefs,
+			}
+		}(),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.Mkdir(goitDir, os.ModePerm); err != nil {
+				t.Logf("%v: %s", err, goitDir)
+			}
+			if tt.content != "" {
+				packedRefsPath := filepath.Join(goitDir, "packed-refs")
+				if err := os.WriteFile(packedRefsPath, []byte(tt.content), os.ModePerm); err != nil {
+					t.Logf("%v: %s", err, packedRefsPath)
+				}
+			}
+
+			got, err := readPackedRefs(goitDir)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackedRefsWrite(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	peeled, _ := hex.DecodeString("bc835249d19fb16ece41eef7913b4dde40b93845")
+	tests := []struct {
+		name string
+		refs []*packedRef
+		want string
+	}{
+		{
+			name: "success",
+			refs: []*packedRef{
+				{
+					name:   "refs/tags/v1.0.0",
+					hash:   sha.Hash(hash),
+					peeled: sha.Hash(peeled),
+				},
+				{
+					name: "refs/heads/main",
+					hash: sha.Hash(hash),
+				},
+			},
+			want: packedRefsHeader + "\n" +
+				"87f3c49bccf2597484ece08746d3ee5defaba335 refs/heads/main\n" +
+				"87f3c49bccf2597484ece08746d3ee5defaba335 refs/tags/v1.0.0\n" +
+				"^bc835249d19fb16ece41eef7913b4dde40b93845\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.Mkdir(goitDir, os.ModePerm); err != nil {
+				t.Logf("%v: %s", err, goitDir)
+			}
+
+			p := newPackedRefs()
+			for _, ref := range tt.refs {
+				p.set(ref)
+			}
+			if err := p.write(goitDir); err != nil {
+				t.Errorf("got = %v, want = nil", err)
+			}
+
+			b, err := os.ReadFile(filepath.Join(goitDir, "packed-refs"))
+			if err != nil {
+				t.Log(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got = %s, want = %s", string(b), tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(goitDir, "packed-refs.lock")); !os.IsNotExist(err) {
+				t.Errorf("lock file is left")
+			}
+		})
+	}
+}
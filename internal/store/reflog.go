@@ -2,31 +2,51 @@ package store
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/JunNishimura/Goit/internal/log"
+	"github.com/JunNishimura/Goit/internal/approxidate"
 	"github.com/JunNishimura/Goit/internal/sha"
-	"github.com/fatih/color"
 )
 
+var (
+	ErrInvalidReflogLine   = errors.New("invalid reflog line")
+	ErrReflogNotFound      = errors.New("reflog not found")
+	ErrReflogEmpty         = errors.New("reflog is empty")
+	ErrReflogEntryNotFound = errors.New("reflog entry not found")
+	ErrInvalidExpiry       = errors.New("invalid expiry date")
+)
+
+// LogRecord is the entry of the reflog, which records the update of the ref from OldHash to Hash.
 type LogRecord struct {
-	Hash       sha.SHA1
-	Head       string
-	references []string
-	recType    log.RecordType
-	message    string
+	OldHash sha.Hash
+	Hash    sha.Hash
+	Name    string
+	Email   string
+	Time    time.Time
+	Message string // the message including the type of the update such as "commit: initial commit"
 }
 
+// Reflog is the log of the ref under logs, whose records are ordered from the oldest.
 type Reflog struct {
+	refName string
 	records []*LogRecord
+	exists  bool // whether the log file exists, which may have no records after the expiry
 }
 
-func NewReflog(rootGoitPath string, head *Head, refs *Refs) (*Reflog, error) {
+// NewReflog loads the log of the ref such as HEAD and refs/heads/main.
+// The reflog is empty if the ref has no log.
+func NewReflog(rootGoitPath, refName string) (*Reflog, error) {
 	reflog := newReflog()
-	if err := reflog.load(rootGoitPath, head, refs); err != nil {
+	reflog.refName = refName
+	if err := reflog.load(rootGoitPath); err != nil {
 		return nil, err
 	}
 
@@ -39,98 +59,231 @@ func newReflog() *Reflog {
 	}
 }
 
-func (r *Reflog) load(rootGoitPath string, head *Head, refs *Refs) error {
-	headPath := filepath.Join(rootGoitPath, "logs", "HEAD")
-	f, err := os.Open(headPath)
+func reflogPath(rootGoitPath, refName string) string {
+	return filepath.Join(rootGoitPath, "logs", filepath.FromSlash(refName))
+}
+
+// ReflogExists reports whether the ref has the log.
+func ReflogExists(rootGoitPath, refName string) bool {
+	info, err := os.Stat(reflogPath(rootGoitPath, refName))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// ReflogRefNames returns the names of all the refs which have the log, sorted by name.
+func ReflogRefNames(rootGoitPath string) ([]string, error) {
+	logsDir := filepath.Join(rootGoitPath, "logs")
+	var refNames []string
+	err := filepath.WalkDir(logsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(logsDir, path)
+		if err != nil {
+			return err
+		}
+		refNames = append(refNames, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("fail to walk %s: %w", logsDir, err)
+	}
+	sort.Strings(refNames)
+	return refNames, nil
+}
+
+func (r *Reflog) load(rootGoitPath string) error {
+	logPath := reflogPath(rootGoitPath, r.refName)
+	f, err := os.Open(logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("fail to open %s: %w", headPath, err)
+		return fmt.Errorf("fail to open %s: %w", logPath, err)
 	}
 	defer f.Close()
+	r.exists = true
 
 	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		record := &LogRecord{
-			references: make([]string, 0),
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		record, err := parseLogRecord(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%w: %s:%d", err, logPath, lineNum)
 		}
+		r.records = append(r.records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("fail to read %s: %w", logPath, err)
+	}
 
-		text := scanner.Text()
+	return nil
+}
 
-		// extract hash
-		sp1 := strings.SplitN(text, " ", 3)
-		if len(sp1) != 3 {
-			continue
-		}
-		if sp1[1] == strings.Repeat("0", 40) {
-			record.Hash = nil
-		} else {
-			hash, err := sha.ReadHash(sp1[1])
-			if err != nil {
-				return fmt.Errorf("fail to read hash %s: %w", sp1[1], err)
-			}
-			record.Hash = hash
-
-			// references
-			if head.Commit.Hash.Compare(hash) {
-				record.Head = color.GreenString(head.Reference)
-			}
-			branches := refs.getBranchesByHash(hash)
-			for _, branch := range branches {
-				record.references = append(record.references, color.GreenString(branch.Name))
-			}
-		}
+// parse the line of the reflog in the format below, where the message is optional.
+//
+//	<old> SP <new> SP <name> SP <<email>> SP <unixtime> SP <timezone> TAB <message>
+func parseLogRecord(text string) (*LogRecord, error) {
+	header, message, _ := strings.Cut(text, "\t")
 
-		// extract recType
-		sp2 := strings.Split(sp1[2], "\t")
-		if len(sp2) != 2 {
-			continue
-		}
-		sp3 := strings.Split(sp2[1], ": ")
-		if len(sp3) != 2 {
-			continue
-		}
-		recType := log.NewRecordType(sp3[0])
-		if recType == log.UndefinedRecord {
-			continue
-		}
-		record.recType = recType
-		record.message = sp3[1]
+	oldString, rest, ok := strings.Cut(header, " ")
+	if !ok {
+		return nil, ErrInvalidReflogLine
+	}
+	newString, rest, ok := strings.Cut(rest, " ")
+	if !ok {
+		return nil, ErrInvalidReflogLine
+	}
+	if len(oldString) != len(newString) || (len(oldString) != sha.SHA1Format.HexSize() && len(oldString) != sha.SHA256Format.HexSize()) {
+		return nil, ErrInvalidReflogLine
+	}
+	oldHash, err := sha.ReadHash(oldString)
+	if err != nil {
+		return nil, ErrInvalidReflogLine
+	}
+	newHash, err := sha.ReadHash(newString)
+	if err != nil {
+		return nil, ErrInvalidReflogLine
+	}
 
-		r.records = append(r.records, record)
+	// the name might be empty or contain spaces, so the email is found by the brackets
+	lt := strings.Index(rest, "<")
+	gt := strings.LastIndex(rest, "> ")
+	if lt < 0 || gt < lt {
+		return nil, ErrInvalidReflogLine
+	}
+	unixString, timezone, ok := strings.Cut(rest[gt+2:], " ")
+	if !ok {
+		return nil, ErrInvalidReflogLine
+	}
+	unixtime, err := strconv.ParseInt(unixString, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidReflogLine
+	}
+	zone, err := time.Parse("-0700", timezone)
+	if err != nil {
+		return nil, ErrInvalidReflogLine
 	}
 
-	return nil
+	return &LogRecord{
+		OldHash: oldHash,
+		Hash:    newHash,
+		Name:    strings.TrimSpace(rest[:lt]),
+		Email:   rest[lt+1 : gt],
+		Time:    time.Unix(unixtime, 0).In(zone.Location()),
+		Message: message,
+	}, nil
+}
+
+func (r *LogRecord) String() string {
+	line := fmt.Sprintf("%s %s %s <%s> %d %s", r.OldHash, r.Hash, r.Name, r.Email, r.Time.Unix(), r.Time.Format("-0700"))
+	if r.Message != "" {
+		line += "\t" + r.Message
+	}
+	return line + "\n"
+}
+
+// Len returns the number of the records.
+func (r *Reflog) Len() int {
+	return len(r.records)
 }
 
+// GetRecord returns the record of <ref>@{num}, where 0 is the latest one.
 func (r *Reflog) GetRecord(num int) (*LogRecord, error) {
-	if num >= len(r.records) {
-		return nil, fmt.Errorf("num %d needs to be below %d", num, len(r.records)-1)
+	if num < 0 || num >= len(r.records) {
+		return nil, fmt.Errorf("%w: %s has only %d entries", ErrReflogEntryNotFound, r.refName, len(r.records))
 	}
 	return r.records[len(r.records)-1-num], nil
 }
 
-func (r *Reflog) Show() {
-	for i := range r.records {
-		idx := len(r.records) - i - 1
-		record := r.records[idx]
-
-		var referenceString string
-		if len(record.references) > 0 {
-			var refsExceptHead []string
-			for _, ref := range record.references {
-				if ref != record.Head {
-					refsExceptHead = append(refsExceptHead, ref)
-				}
-			}
-			referenceString = strings.Join(refsExceptHead, ", ")
-		}
-		if record.Head != "" {
-			referenceString = color.BlueString("HEAD -> ") + fmt.Sprintf("%s, ", record.Head) + referenceString
+// HashAt returns the value of the ref at the time, which is the new hash of the latest record not after the time.
+// If the time is before all the records, the value before the oldest record is returned with false,
+// or the value after it if the ref was created by it.
+func (r *Reflog) HashAt(t time.Time) (sha.Hash, bool, error) {
+	if len(r.records) == 0 && r.exists {
+		return nil, false, fmt.Errorf("%w: %s", ErrReflogEmpty, r.refName)
+	}
+	if len(r.records) == 0 {
+		return nil, false, fmt.Errorf("%w: %s", ErrReflogNotFound, r.refName)
+	}
+	for i := len(r.records) - 1; i >= 0; i-- {
+		if !r.records[i].Time.After(t) {
+			return r.records[i].Hash, true, nil
 		}
+	}
+	oldest := r.records[0]
+	if oldest.OldHash.IsZero() {
+		return oldest.Hash, false, nil
+	}
+	return oldest.OldHash, false, nil
+}
+
+// OldestTime returns the time of the oldest record.
+func (r *Reflog) OldestTime() (time.Time, bool) {
+	if len(r.records) == 0 {
+		return time.Time{}, false
+	}
+	return r.records[0].Time, true
+}
 
-		if referenceString == "" {
-			fmt.Printf("%s HEAD@{%d}: %s: %s\n", color.YellowString(record.Hash.String()[:7]), i, record.recType, record.message)
-		} else {
-			fmt.Printf("%s (%s) HEAD@{%d}: %s: %s\n", color.YellowString(record.Hash.String()[:7]), referenceString, i, record.recType, record.message)
+// Delete removes the record of <ref>@{num}, where 0 is the latest one.
+func (r *Reflog) Delete(num int) error {
+	if num < 0 || num >= len(r.records) {
+		return fmt.Errorf("%w: %s@{%d}", ErrReflogEntryNotFound, r.refName, num)
+	}
+	idx := len(r.records) - 1 - num
+	r.records = append(r.records[:idx], r.records[idx+1:]...)
+	return nil
+}
+
+// Expire removes the records older than expire, and the records older than expireUnreachable
+// whose commit is not reachable from the tip of the ref. The zero time expires nothing.
+// The number of the removed records is returned.
+func (r *Reflog) Expire(expire, expireUnreachable time.Time, isReachable func(hash sha.Hash) bool) int {
+	isExpired := func(t, limit time.Time) bool {
+		return !limit.IsZero() && !t.After(limit)
+	}
+	kept := make([]*LogRecord, 0, len(r.records))
+	for _, record := range r.records {
+		if isExpired(record.Time, expire) {
+			continue
+		}
+		if isExpired(record.Time, expireUnreachable) && (record.Hash.IsZero() || !isReachable(record.Hash)) {
+			continue
 		}
+		kept = append(kept, record)
+	}
+	expired := len(r.records) - len(kept)
+	r.records = kept
+	return expired
+}
+
+// Write rewrites the log of the ref with the records.
+func (r *Reflog) Write(rootGoitPath string) error {
+	var b strings.Builder
+	for _, record := range r.records {
+		b.WriteString(record.String())
+	}
+	logPath := reflogPath(rootGoitPath, r.refName)
+	if err := os.WriteFile(logPath, []byte(b.String()), 0666); err != nil {
+		return fmt.Errorf("fail to write %s: %w", logPath, err)
+	}
+	return nil
+}
+
+// ParseExpiry parses the expiry date of the reflog such as "90.days.ago", "2023-01-02", "now" and "never".
+// "never" returns the zero time, which expires nothing.
+func ParseExpiry(value string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "never", "false":
+		return time.Time{}, nil
+	case "all":
+		return now, nil
+	}
+	t, err := approxidate.Parse(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidExpiry, value)
 	}
+	return t, nil
 }
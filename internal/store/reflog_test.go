@@ -1,20 +1,21 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/JunNishimura/Goit/internal/sha"
 )
 
 func TestReflogLoad(t *testing.T) {
-	type args struct {
-		head *Head
-		refs *Refs
-	}
 	type test struct {
 		name    string
-		args    args
 		fields  string
 		want    *Reflog
 		wantErr bool
@@ -22,18 +23,52 @@ func TestReflogLoad(t *testing.T) {
 	tests := []*test{
 		func() *test {
 			return &test{
-				name: "success",
-				args: args{
-					head: &Head{},
-					refs: &Refs{},
-				},
+				name:   "success",
 				fields: "",
 				want: &Reflog{
+					refName: "HEAD",
 					records: make([]*LogRecord, 0),
+					exists:  true,
+				},
+				wantErr: false,
+			}
+		}(),
+		func() *test {
+			return &test{
+				name: "success: records",
+				fields: "0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 Test User <test@example.com> 1700000000 +0900\tcommit (initial): first: commit\n" +
+					"87f3c49bccf2597484ece08746d3ee5defaba335 0000000000000000000000000000000000000000  <test@example.com> 1700000060 -0130\n",
+				want: &Reflog{
+					refName: "HEAD",
+					records: []*LogRecord{
+						{
+							OldHash: mustReadHash("0000000000000000000000000000000000000000"),
+							Hash:    mustReadHash("87f3c49bccf2597484ece08746d3ee5defaba335"),
+							Name:    "Test User",
+							Email:   "test@example.com",
+							Time:    time.Unix(1700000000, 0).In(time.FixedZone("", 9*60*60)),
+							Message: "commit (initial): first: commit",
+						},
+						{
+							OldHash: mustReadHash("87f3c49bccf2597484ece08746d3ee5defaba335"),
+							Hash:    mustReadHash("0000000000000000000000000000000000000000"),
+							Email:   "test@example.com",
+							Time:    time.Unix(1700000060, 0).In(time.FixedZone("", -90*60)),
+						},
+					},
+					exists: true,
 				},
 				wantErr: false,
 			}
 		}(),
+		func() *test {
+			return &test{
+				name:    "fail: broken line",
+				fields:  "0000000000000000000000000000000000000000 87f3c49b Test User <test@example.com> 1700000000 +0900\tcommit: broken\n",
+				want:    nil,
+				wantErr: true,
+			}
+		}(),
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,9 +95,22 @@ func TestReflogLoad(t *testing.T) {
 			}
 			f.Close()
 
-			reflog := newReflog()
-			if err := reflog.load(goitDir, tt.args.head, tt.args.refs); (err != nil) != tt.wantErr {
-				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			reflog, err := NewReflog(goitDir, "HEAD")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(reflog.records) != len(tt.want.records) {
+				t.Fatalf("got = %d records, want = %d", len(reflog.records), len(tt.want.records))
+			}
+			for i, record := range reflog.records {
+				want := tt.want.records[i]
+				if !record.Time.Equal(want.Time) || record.Time.Format("-0700") != want.Time.Format("-0700") {
+					t.Errorf("got = %v, want = %v", record.Time, want.Time)
+				}
+				record.Time = want.Time
 			}
 			if !reflect.DeepEqual(reflog, tt.want) {
 				t.Errorf("got = %v, want = %v", reflog, tt.want)
@@ -70,3 +118,249 @@ func TestReflogLoad(t *testing.T) {
 		})
 	}
 }
+
+func mustReadHash(s string) sha.Hash {
+	hash, err := sha.ReadHash(s)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+func TestLogRecordString(t *testing.T) {
+	tests := []string{
+		"0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 Test User <test@example.com> 1700000000 +0900\tcommit (initial): first\n",
+		"87f3c49bccf2597484ece08746d3ee5defaba335 0000000000000000000000000000000000000000  <test@example.com> 1700000060 -0130\n",
+	}
+	for _, line := range tests {
+		record, err := parseLogRecord(strings.TrimSuffix(line, "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := record.String(); got != line {
+			t.Errorf("got = %q, want = %q", got, line)
+		}
+	}
+}
+
+func TestParseLogRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "fail: empty", line: ""},
+		{name: "fail: short hash", line: "0000 87f3c49bccf2597484ece08746d3ee5defaba335 a <b> 1700000000 +0900\tcommit: x"},
+		{name: "fail: different lengths", line: "0000000000000000000000000000000000000000 7924fb0f23c883233d0b9d6699dd0df672b64b319e080f0b3d5285ee41bc7106 a <b> 1700000000 +0900\tcommit: x"},
+		{name: "fail: no email", line: "0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 a 1700000000 +0900\tcommit: x"},
+		{name: "fail: bad time", line: "0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 a <b> yesterday +0900\tcommit: x"},
+		{name: "fail: bad timezone", line: "0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 a <b> 1700000000 JST\tcommit: x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseLogRecord(tt.line); !errors.Is(err, ErrInvalidReflogLine) {
+				t.Errorf("got = %v, want = %v", err, ErrInvalidReflogLine)
+			}
+		})
+	}
+}
+
+func testReflog(times ...int64) *Reflog {
+	reflog := &Reflog{refName: "HEAD"}
+	for i, unixtime := range times {
+		hash := make(sha.Hash, 20)
+		hash[0] = byte(i + 1)
+		reflog.records = append(reflog.records, &LogRecord{Hash: hash, Time: time.Unix(unixtime, 0), Message: fmt.Sprintf("commit: %d", i)})
+	}
+	return reflog
+}
+
+func reflogMessages(reflog *Reflog) []string {
+	var messages []string
+	for _, record := range reflog.records {
+		messages = append(messages, record.Message)
+	}
+	return messages
+}
+
+func TestReflogDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		num     int
+		want    []string
+		wantErr error
+	}{
+		{name: "success: latest", num: 0, want: []string{"commit: 0", "commit: 1"}},
+		{name: "success: oldest", num: 2, want: []string{"commit: 1", "commit: 2"}},
+		{name: "fail: out of range", num: 3, want: []string{"commit: 0", "commit: 1", "commit: 2"}, wantErr: ErrReflogEntryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reflog := testReflog(100, 200, 300)
+			if err := reflog.Delete(tt.num); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got := reflogMessages(reflog); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReflogExpire(t *testing.T) {
+	// only the first commit is reachable from the tip
+	isReachable := func(hash sha.Hash) bool {
+		return hash[0] == 1
+	}
+	tests := []struct {
+		name              string
+		expire            time.Time
+		expireUnreachable time.Time
+		want              []string
+	}{
+		{
+			name: "success: never",
+			want: []string{"commit: 0", "commit: 1", "commit: 2"},
+		},
+		{
+			name:   "success: expire",
+			expire: time.Unix(200, 0),
+			want:   []string{"commit: 2"},
+		},
+		{
+			name:              "success: expire unreachable",
+			expireUnreachable: time.Unix(250, 0),
+			want:              []string{"commit: 0", "commit: 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reflog := testReflog(100, 200, 300)
+			expired := reflog.Expire(tt.expire, tt.expireUnreachable, isReachable)
+			got := reflogMessages(reflog)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+			if expired != 3-len(tt.want) {
+				t.Errorf("got = %d, want = %d", expired, 3-len(tt.want))
+			}
+		})
+	}
+}
+
+func TestReflogWrite(t *testing.T) {
+	goitDir := filepath.Join(t.TempDir(), ".goit")
+	if err := os.MkdirAll(filepath.Join(goitDir, "logs", "refs", "heads"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	line := "0000000000000000000000000000000000000000 87f3c49bccf2597484ece08746d3ee5defaba335 Test User <test@example.com> 1700000000 +0900\tbranch: Created from HEAD\n"
+	logPath := filepath.Join(goitDir, "logs", "refs", "heads", "main")
+	if err := os.WriteFile(logPath, []byte(line+line), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if !ReflogExists(goitDir, "refs/heads/main") || ReflogExists(goitDir, "refs/heads/feature") {
+		t.Errorf("ReflogExists reports wrong existence")
+	}
+	refNames, err := ReflogRefNames(goitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"refs/heads/main"}; !reflect.DeepEqual(refNames, want) {
+		t.Errorf("got = %v, want = %v", refNames, want)
+	}
+
+	reflog, err := NewReflog(goitDir, "refs/heads/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reflog.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := reflog.Write(goitDir); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != line {
+		t.Errorf("got = %q, want = %q", got, line)
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr error
+	}{
+		{name: "success: never", value: "never", want: time.Time{}},
+		{name: "success: now", value: "now", want: now},
+		{name: "success: all", value: "all", want: now},
+		{name: "success: days ago", value: "90.days.ago", want: now.AddDate(0, 0, -90)},
+		{name: "success: weeks without ago", value: "2.weeks", want: now.AddDate(0, 0, -14)},
+		{name: "success: hours with spaces", value: "1 hour ago", want: now.Add(-time.Hour)},
+		{name: "success: date", value: "2023-01-02", want: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "fail: unknown", value: "someday", wantErr: ErrInvalidExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExpiry(tt.value, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReflogHashAt(t *testing.T) {
+	reflog := testReflog(100, 200, 300)
+	reflog.records[0].OldHash = make(sha.Hash, 20)
+	tests := []struct {
+		name      string
+		reflog    *Reflog
+		time      time.Time
+		want      byte
+		wantExact bool
+		wantErr   error
+	}{
+		{name: "success: exact time", reflog: reflog, time: time.Unix(200, 0), want: 2, wantExact: true},
+		{name: "success: between records", reflog: reflog, time: time.Unix(250, 0), want: 2, wantExact: true},
+		{name: "success: after the latest", reflog: reflog, time: time.Unix(1000, 0), want: 3, wantExact: true},
+		{name: "success: before the created record", reflog: reflog, time: time.Unix(50, 0), want: 1, wantExact: false},
+		{name: "fail: no reflog", reflog: &Reflog{refName: "HEAD"}, time: time.Unix(50, 0), wantErr: ErrReflogNotFound},
+		{name: "fail: empty reflog", reflog: &Reflog{refName: "HEAD", exists: true}, time: time.Unix(50, 0), wantErr: ErrReflogEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isExact, err := tt.reflog.HashAt(tt.time)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got[0] != tt.want || isExact != tt.wantExact {
+				t.Errorf("got = (%d, %v), want = (%d, %v)", got[0], isExact, tt.want, tt.wantExact)
+			}
+		})
+	}
+
+	t.Run("success: before the updated record", func(t *testing.T) {
+		reflog := testReflog(100)
+		reflog.records[0].OldHash = make(sha.Hash, 20)
+		reflog.records[0].OldHash[0] = 9
+		got, isExact, err := reflog.HashAt(time.Unix(50, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0] != 9 || isExact {
+			t.Errorf("got = (%d, %v), want = (9, false)", got[0], isExact)
+		}
+	})
+}
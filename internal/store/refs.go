@@ -1,84 +1,336 @@
 package store
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"syscall"
 
+	"github.com/JunNishimura/Goit/internal/object"
 	"github.com/JunNishimura/Goit/internal/sha"
-	"github.com/fatih/color"
 )
 
 const (
 	NewBranchFlag = -1
 )
 
+var (
+	ErrRefNotFound    = errors.New("reference not found")
+	ErrInvalidRefName = errors.New("not a valid reference name")
+)
+
 type branch struct {
 	Name string
-	hash sha.SHA1
+	hash sha.Hash
 }
 
-func newBranch(name string, hash sha.SHA1) *branch {
+func newBranch(name string, hash sha.Hash) *branch {
 	return &branch{
 		Name: name,
 		hash: hash,
 	}
 }
 
-func (b *branch) loadHash(rootGoitPath string) error {
-	branchPath := filepath.Join(rootGoitPath, "refs", "heads", b.Name)
-	hashByte, err := os.ReadFile(branchPath)
+func (b *branch) write(rootGoitPath string) error {
+	return writeLooseRef(rootGoitPath, branchRefName(b.Name), b.hash)
+}
+
+type reference struct {
+	name   string
+	hash   sha.Hash
+	target string // the ref a symbolic ref points to, empty otherwise
+}
+
+func branchRefName(branchName string) string {
+	return fmt.Sprintf("refs/heads/%s", branchName)
+}
+
+func refPath(rootGoitPath, refName string) string {
+	return filepath.Join(rootGoitPath, filepath.FromSlash(refName))
+}
+
+// ValidateRefName checks refName against the rules of git check-ref-format.
+func ValidateRefName(refName string) error {
+	if refName == "" ||
+		refName == "@" ||
+		strings.HasPrefix(refName, "/") ||
+		strings.HasSuffix(refName, "/") ||
+		strings.HasSuffix(refName, ".") ||
+		strings.Contains(refName, "..") ||
+		strings.Contains(refName, "//") ||
+		strings.Contains(refName, "@{") ||
+		strings.ContainsAny(refName, " ~^:?*[\\") {
+		return fmt.Errorf("%w: '%s'", ErrInvalidRefName, refName)
+	}
+	for _, r := range refName {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("%w: '%s'", ErrInvalidRefName, refName)
+		}
+	}
+	for _, component := range strings.Split(refName, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return fmt.Errorf("%w: '%s'", ErrInvalidRefName, refName)
+		}
+	}
+	return nil
+}
+
+// ReadRef returns the hash refName points to, following symbolic refs.
+// A loose ref file always takes precedence over the entry in packed-refs.
+func ReadRef(rootGoitPath, refName string) (sha.Hash, error) {
+	_, hash, err := ResolveRef(rootGoitPath, refName)
+	return hash, err
+}
+
+// read the hash of the non-symbolic ref.
+func readDirectRef(rootGoitPath, refName string) (sha.Hash, error) {
+	hash, err := readLooseRef(rootGoitPath, refName)
+	if err == nil {
+		return hash, nil
+	}
+	if !errors.Is(err, ErrRefNotFound) {
+		return nil, err
+	}
+
+	packed, err := readPackedRefs(rootGoitPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if ref, ok := packed.get(refName); ok {
+		return ref.hash, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrRefNotFound, refName)
+}
+
+func readLooseRefContent(rootGoitPath, refName string) (string, error) {
+	path := refPath(rootGoitPath, refName)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) || (err == nil && info.IsDir()) {
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, refName)
 	}
-	hashString := string(hashByte)
-	hash, err := sha.ReadHash(hashString)
+	contentBytes, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("%w: %s", ErrIOHandling, path)
 	}
-	b.hash = hash
+	return strings.TrimSpace(string(contentBytes)), nil
+}
 
-	return nil
+func readLooseRef(rootGoitPath, refName string) (sha.Hash, error) {
+	content, err := readLooseRefContent(rootGoitPath, refName)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(content, symbolicRefPrefix) {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolicRef, refName)
+	}
+	hash, err := sha.ReadHash(content)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read hash of %s: %w", refName, err)
+	}
+	return hash, nil
 }
 
-func (b *branch) write(rootGoitPath string) error {
-	branchPath := filepath.Join(rootGoitPath, "refs", "heads", b.Name)
-	f, err := os.Create(branchPath)
+func writeLooseRef(rootGoitPath, refName string, hash sha.Hash) error {
+	path := refPath(rootGoitPath, refName)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make directory for %s: %w", refName, err)
+	}
+	f, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("fail to create %s: %w", branchPath, err)
+		return fmt.Errorf("fail to create %s: %w", path, err)
 	}
 	defer f.Close()
 
-	if _, err := f.WriteString(b.hash.String()); err != nil {
-		return fmt.Errorf("fail to write hash(%s): %w", b.hash, err)
+	if _, err := f.WriteString(hash.String()); err != nil {
+		return fmt.Errorf("fail to write hash(%s): %w", hash, err)
 	}
 
 	return nil
 }
 
+// deleteRef removes refName from both the loose refs and packed-refs.
+func deleteRef(rootGoitPath, refName string) error {
+	path := refPath(rootGoitPath, refName)
+	isLoose := false
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("fail to delete %s: %w", path, err)
+		}
+		pruneEmptyRefDirs(rootGoitPath, filepath.Dir(path))
+		isLoose = true
+	}
+
+	packed, err := readPackedRefs(rootGoitPath)
+	if err != nil {
+		return err
+	}
+	isPacked := packed.remove(refName)
+	if isPacked {
+		if err := packed.write(rootGoitPath); err != nil {
+			return err
+		}
+	}
+
+	if !isLoose && !isPacked {
+		return fmt.Errorf("%w: %s", ErrRefNotFound, refName)
+	}
+
+	return nil
+}
+
+// remove directories left empty by deleting a nested ref such as refs/heads/feature/login.
+// refs/ and the category directories directly under it are always kept.
+func pruneEmptyRefDirs(rootGoitPath, dir string) {
+	for {
+		relPath, err := filepath.Rel(rootGoitPath, dir)
+		if err != nil {
+			return
+		}
+		if len(strings.Split(filepath.ToSlash(relPath), "/")) < 3 {
+			return
+		}
+		if err := os.Remove(dir); err != nil {
+			// the directory is not empty
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// listLooseRefs returns the loose refs under the prefix such as "refs/heads/".
+func listLooseRefs(rootGoitPath, prefix string) ([]*reference, error) {
+	var refs []*reference
+
+	dirPath := refPath(rootGoitPath, prefix)
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		return refs, nil
+	}
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(d.Name(), ".lock") {
+			return nil
+		}
+		relPath, err := filepath.Rel(rootGoitPath, path)
+		if err != nil {
+			return err
+		}
+		refName := filepath.ToSlash(relPath)
+		target, err := ReadSymbolicRef(rootGoitPath, refName)
+		if err == nil {
+			// symbolic ref whose target does not exist is ignored just like git does
+			hash, err := ReadRef(rootGoitPath, refName)
+			if err != nil {
+				return nil
+			}
+			refs = append(refs, &reference{
+				name:   refName,
+				hash:   hash,
+				target: target,
+			})
+			return nil
+		}
+		if !errors.Is(err, ErrNotSymbolicRef) {
+			return err
+		}
+		hash, err := readLooseRef(rootGoitPath, refName)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, &reference{
+			name: refName,
+			hash: hash,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to walk %s: %w", dirPath, err)
+	}
+
+	return refs, nil
+}
+
+// listRefs returns the refs under the prefix, sorted by name.
+// Loose refs shadow packed refs with the same name.
+func listRefs(rootGoitPath, prefix string) ([]*reference, error) {
+	refMap := make(map[string]*reference)
+
+	packed, err := readPackedRefs(rootGoitPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range packed.refs {
+		if strings.HasPrefix(ref.name, prefix) {
+			refMap[ref.name] = &reference{
+				name: ref.name,
+				hash: ref.hash,
+			}
+		}
+	}
+
+	looseRefs, err := listLooseRefs(rootGoitPath, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range looseRefs {
+		refMap[ref.name] = ref
+	}
+
+	refs := make([]*reference, 0, len(refMap))
+	for _, ref := range refMap {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+
+	return refs, nil
+}
+
+// Ref is a reference found by ListRefs.
+type Ref struct {
+	Name   string // full ref name such as refs/heads/main
+	Hash   sha.Hash
+	Target string // the ref pointed by the symbolic ref, empty otherwise
+}
+
+// ListRefs returns the loose and packed refs under the prefix such as "refs/tags/", sorted by name.
+func ListRefs(rootGoitPath, prefix string) ([]*Ref, error) {
+	refs, err := listRefs(rootGoitPath, prefix)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Ref, len(refs))
+	for i, ref := range refs {
+		result[i] = &Ref{
+			Name:   ref.name,
+			Hash:   ref.hash,
+			Target: ref.target,
+		}
+	}
+	return result, nil
+}
+
 type Refs struct {
 	Heads []*branch
 }
 
 func NewRefs(rootGoitPath string) (*Refs, error) {
 	r := newRefs()
-	headsPath := filepath.Join(rootGoitPath, "refs", "heads")
-	if _, err := os.Stat(headsPath); os.IsNotExist(err) {
+	if rootGoitPath == "" {
 		return r, nil
 	}
-	files, err := os.ReadDir(headsPath)
+	refs, err := listRefs(rootGoitPath, "refs/heads/")
 	if err != nil {
 		return nil, err
 	}
-	for _, file := range files {
-		b := newBranch(file.Name(), nil)
-		if err := b.loadHash(rootGoitPath); err != nil {
-			return nil, err
-		}
-		r.Heads = append(r.Heads, b)
+	for _, ref := range refs {
+		r.Heads = append(r.Heads, newBranch(strings.TrimPrefix(ref.name, "refs/heads/"), ref.hash))
 	}
-	sort.Slice(r.Heads, func(i, j int) bool { return r.Heads[i].Name < r.Heads[j].Name })
 	return r, nil
 }
 
@@ -88,27 +340,39 @@ func newRefs() *Refs {
 	}
 }
 
-func (r *Refs) ListBranches(headBranchName string) {
+func (r *Refs) IsBranchExist(branchName string) bool {
+	p := r.getBranchPos(branchName)
+	return p != NewBranchFlag
+}
+
+// return the branch whose name conflicts with branchName as a directory,
+// such as 'feature' and 'feature/login'.
+func (r *Refs) getConflictBranch(branchName, exceptBranchName string) (string, bool) {
 	for _, b := range r.Heads {
-		if b.Name == headBranchName {
-			color.Green("* %s", b.Name)
-		} else {
-			fmt.Println(b.Name)
+		if b.Name == exceptBranchName {
+			continue
+		}
+		if strings.HasPrefix(branchName, b.Name+"/") || strings.HasPrefix(b.Name, branchName+"/") {
+			return b.Name, true
 		}
 	}
+	return "", false
 }
 
-func (r *Refs) IsBranchExist(branchName string) bool {
-	p := r.getBranchPos(branchName)
-	return p != NewBranchFlag
-}
+func (r *Refs) AddBranch(rootGoitPath, newBranchName string, newBranchHash sha.Hash) error {
+	// check if branch name is valid
+	if err := ValidateRefName(branchRefName(newBranchName)); err != nil {
+		return fmt.Errorf("'%s' is not a valid branch name", newBranchName)
+	}
 
-func (r *Refs) AddBranch(rootGoitPath, newBranchName string, newBranchHash sha.SHA1) error {
 	// check if branch already exists
 	n := r.getBranchPos(newBranchName)
 	if n != NewBranchFlag {
 		return fmt.Errorf("a branch named '%s' already exists", newBranchName)
 	}
+	if conflictName, ok := r.getConflictBranch(newBranchName, ""); ok {
+		return fmt.Errorf("cannot lock ref '%s': '%s' exists", branchRefName(newBranchName), branchRefName(conflictName))
+	}
 
 	b := newBranch(newBranchName, newBranchHash)
 	r.Heads = append(r.Heads, b)
@@ -125,11 +389,19 @@ func (r *Refs) AddBranch(rootGoitPath, newBranchName string, newBranchHash sha.S
 }
 
 func (r *Refs) RenameBranch(rootGoitPath, curBranchName, newBranchName string) error {
+	// check if branch name is valid
+	if err := ValidateRefName(branchRefName(newBranchName)); err != nil {
+		return fmt.Errorf("'%s' is not a valid branch name", newBranchName)
+	}
+
 	// check if new branch name is not used for other branches
 	n := r.getBranchPos(newBranchName)
 	if n != NewBranchFlag {
 		return fmt.Errorf("branch named '%s' already exists", newBranchName)
 	}
+	if conflictName, ok := r.getConflictBranch(newBranchName, curBranchName); ok {
+		return fmt.Errorf("cannot lock ref '%s': '%s' exists", branchRefName(newBranchName), branchRefName(conflictName))
+	}
 
 	// get current branch
 	curNum := r.getBranchPos(curBranchName)
@@ -137,17 +409,34 @@ func (r *Refs) RenameBranch(rootGoitPath, curBranchName, newBranchName string) e
 		return fmt.Errorf("head branch '%s' does not exist", curBranchName)
 	}
 
+	b := r.Heads[curNum]
+	curRefName, newRefName := branchRefName(curBranchName), branchRefName(newBranchName)
+	if strings.HasPrefix(newRefName, curRefName+"/") || strings.HasPrefix(curRefName, newRefName+"/") {
+		// the old ref is in the way of the new one as a file or a directory,
+		// so it is deleted first and written back if the new one cannot be written
+		if err := deleteRef(rootGoitPath, curRefName); err != nil {
+			return fmt.Errorf("fail to delete branch '%s': %w", curBranchName, err)
+		}
+		if err := writeLooseRef(rootGoitPath, newRefName, b.hash); err != nil {
+			if restoreErr := writeLooseRef(rootGoitPath, curRefName, b.hash); restoreErr != nil {
+				return fmt.Errorf("fail to write branch: %w, and fail to restore '%s': %v", err, curBranchName, restoreErr)
+			}
+			return fmt.Errorf("fail to write branch: %w", err)
+		}
+	} else {
+		// write the new ref first so that the branch is never lost
+		if err := writeLooseRef(rootGoitPath, newRefName, b.hash); err != nil {
+			return fmt.Errorf("fail to write branch: %w", err)
+		}
+		if err := deleteRef(rootGoitPath, curRefName); err != nil {
+			return fmt.Errorf("fail to delete branch '%s': %w", curBranchName, err)
+		}
+	}
+
 	// rename branch
-	r.Heads[curNum].Name = newBranchName
+	b.Name = newBranchName
 	sort.Slice(r.Heads, func(i, j int) bool { return r.Heads[i].Name < r.Heads[j].Name })
 
-	// rename file
-	oldPath := filepath.Join(rootGoitPath, "refs", "heads", curBranchName)
-	newPath := filepath.Join(rootGoitPath, "refs", "heads", newBranchName)
-	if err := os.Rename(oldPath, newPath); err != nil {
-		return fmt.Errorf("fail to rename file: %w", err)
-	}
-
 	return nil
 }
 
@@ -182,17 +471,6 @@ func (r *Refs) getBranchPos(branchName string) int {
 	return NewBranchFlag
 }
 
-func (r *Refs) getBranchesByHash(hash sha.SHA1) []*branch {
-	var branches []*branch
-	for _, branch := range r.Heads {
-		if branch.hash.Compare(hash) {
-			branches = append(branches, branch)
-		}
-	}
-
-	return branches
-}
-
 func (r *Refs) DeleteBranch(rootGoitPath, headBranchName, deleteBranchName string) error {
 	// branch validation
 	if deleteBranchName == headBranchName {
@@ -207,9 +485,8 @@ func (r *Refs) DeleteBranch(rootGoitPath, headBranchName, deleteBranchName strin
 	// delete from refs
 	r.Heads = append(r.Heads[:n], r.Heads[n+1:]...)
 
-	// delete branch file
-	branchPath := filepath.Join(rootGoitPath, "refs", "heads", deleteBranchName)
-	if err := os.Remove(branchPath); err != nil {
+	// delete loose and packed ref
+	if err := deleteRef(rootGoitPath, branchRefName(deleteBranchName)); err != nil {
 		return fmt.Errorf("fail to delete branch file: %w", err)
 	}
 
@@ -219,7 +496,7 @@ func (r *Refs) DeleteBranch(rootGoitPath, headBranchName, deleteBranchName strin
 	return nil
 }
 
-func (r *Refs) UpdateBranchHash(rootGoitPath, branchName string, newHash sha.SHA1) error {
+func (r *Refs) UpdateBranchHash(rootGoitPath, branchName string, newHash sha.Hash) error {
 	n := r.getBranchPos(branchName)
 	if n == NewBranchFlag {
 		return fmt.Errorf("branch '%s' does not exist", branchName)
@@ -235,3 +512,85 @@ func (r *Refs) UpdateBranchHash(rootGoitPath, branchName string, newHash sha.SHA
 
 	return nil
 }
+
+// Pack moves loose refs into packed-refs. Tags and already packed refs are always packed,
+// and every ref under refs/ is packed when isAll is true. The loose files are removed when isPrune is true.
+func (r *Refs) Pack(rootGoitPath string, isAll, isPrune bool) error {
+	packed, err := readPackedRefs(rootGoitPath)
+	if err != nil {
+		return err
+	}
+
+	looseRefs, err := listLooseRefs(rootGoitPath, "refs/")
+	if err != nil {
+		return err
+	}
+	var packedRefs []*reference
+	for _, ref := range looseRefs {
+		// symbolic refs are never packed
+		if ref.target != "" {
+			continue
+		}
+		_, isPacked := packed.get(ref.name)
+		if !isAll && !isPacked && !strings.HasPrefix(ref.name, "refs/tags/") {
+			continue
+		}
+		packed.set(&packedRef{
+			name: ref.name,
+			hash: ref.hash,
+		})
+		packedRefs = append(packedRefs, ref)
+	}
+
+	// keep packed-refs fully peeled
+	for _, ref := range packed.refs {
+		ref.peeled = nil
+		obj, err := object.GetObject(rootGoitPath, ref.hash)
+		if err != nil || obj.Type != object.TagObject {
+			continue
+		}
+		peeledObj, err := object.Peel(rootGoitPath, ref.hash)
+		if err != nil {
+			return fmt.Errorf("fail to peel %s: %w", ref.name, err)
+		}
+		ref.peeled = peeledObj.Hash
+	}
+
+	if err := packed.write(rootGoitPath); err != nil {
+		return err
+	}
+
+	if isPrune {
+		for _, ref := range packedRefs {
+			if err := pruneLooseRef(rootGoitPath, ref.name, ref.hash); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// remove the loose ref which has been packed with the hash. The ref is locked while it is checked,
+// and it is kept if it is locked by another process or it has been updated since it was packed.
+func pruneLooseRef(rootGoitPath, refName string, hash sha.Hash) error {
+	path := refPath(rootGoitPath, refName)
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return nil
+	}
+	f.Close()
+	defer os.Remove(lockPath)
+
+	curHash, err := readLooseRef(rootGoitPath, refName)
+	if err != nil || !curHash.Compare(hash) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("fail to prune %s: %w", path, err)
+	}
+	os.Remove(lockPath)
+	pruneEmptyRefDirs(rootGoitPath, filepath.Dir(path))
+	return nil
+}
@@ -15,7 +15,7 @@ import (
 func TestNewBanch(t *testing.T) {
 	type args struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name string
@@ -29,11 +29,11 @@ func TestNewBanch(t *testing.T) {
 				name: "success",
 				args: args{
 					name: "main",
-					hash: sha.SHA1(hash),
+					hash: sha.Hash(hash),
 				},
 				want: &branch{
 					Name: "main",
-					hash: sha.SHA1(hash),
+					hash: sha.Hash(hash),
 				},
 			}
 		}(),
@@ -48,75 +48,10 @@ func TestNewBanch(t *testing.T) {
 	}
 }
 
-func TestLoadHash(t *testing.T) {
-	type fields struct {
-		name string
-		hash sha.SHA1
-	}
-	type test struct {
-		name    string
-		fields  fields
-		want    sha.SHA1
-		wantErr error
-	}
-	tests := []*test{
-		func() *test {
-			hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
-			return &test{
-				name: "success",
-				fields: fields{
-					name: "main",
-					hash: sha.SHA1(hash),
-				},
-				want:    sha.SHA1(hash),
-				wantErr: nil,
-			}
-		}(),
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tmpDir := t.TempDir()
-			// .goit initialization
-			goitDir := filepath.Join(tmpDir, ".goit")
-			if err := os.Mkdir(goitDir, os.ModePerm); err != nil {
-				t.Logf("%v: %s", err, goitDir)
-			}
-			// make .goit/refs directory
-			refsDir := filepath.Join(goitDir, "refs")
-			if err := os.Mkdir(refsDir, os.ModePerm); err != nil {
-				t.Logf("%v: %s", err, refsDir)
-			}
-			// make .goit/refs/heads directory
-			headsDir := filepath.Join(refsDir, "heads")
-			if err := os.Mkdir(headsDir, os.ModePerm); err != nil {
-				t.Logf("%v: %s", err, headsDir)
-			}
-			// make main branch
-			mainBranchPath := filepath.Join(headsDir, tt.fields.name)
-			f, err := os.Create(mainBranchPath)
-			if err != nil {
-				t.Logf("%v: %s", err, mainBranchPath)
-			}
-			if _, err := f.WriteString(tt.fields.hash.String()); err != nil {
-				t.Log(err)
-			}
-			f.Close()
-
-			b := newBranch(tt.fields.name, nil)
-			if err := b.loadHash(goitDir); !errors.Is(err, tt.wantErr) {
-				t.Errorf("got = %v, want = %v", err, tt.wantErr)
-			}
-			if !b.hash.Compare(tt.want) {
-				t.Errorf("got = %s, want = %s", b.hash, tt.want)
-			}
-		})
-	}
-}
-
 func TestBranchWrite(t *testing.T) {
 	type fields struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name            string
@@ -133,7 +68,7 @@ func TestBranchWrite(t *testing.T) {
 				name: "success",
 				fields: fields{
 					name: "main",
-					hash: sha.SHA1(hash),
+					hash: sha.Hash(hash),
 				},
 				wantFileName:    "main",
 				wantFileContent: "87f3c49bccf2597484ece08746d3ee5defaba335",
@@ -184,7 +119,7 @@ func TestBranchWrite(t *testing.T) {
 func TestNewRefs(t *testing.T) {
 	type fields struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name    string
@@ -210,22 +145,22 @@ func TestNewRefs(t *testing.T) {
 				fields: []*fields{
 					{
 						name: "main",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 					{
 						name: "test",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 				},
 				want: &Refs{
 					Heads: []*branch{
 						{
 							Name: "main",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 						{
 							Name: "test",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 					},
 				},
@@ -278,11 +213,11 @@ func TestNewRefs(t *testing.T) {
 func TestAddBranch(t *testing.T) {
 	type args struct {
 		newBranchName string
-		newBranchHash sha.SHA1
+		newBranchHash sha.Hash
 	}
 	type fields struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name    string
@@ -298,21 +233,21 @@ func TestAddBranch(t *testing.T) {
 				name: "success",
 				args: args{
 					newBranchName: "main",
-					newBranchHash: sha.SHA1(hash),
+					newBranchHash: sha.Hash(hash),
 				},
 				fields: fields{
 					name: "test",
-					hash: sha.SHA1(hash),
+					hash: sha.Hash(hash),
 				},
 				want: &Refs{
 					Heads: []*branch{
 						{
 							Name: "main",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 						{
 							Name: "test",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 					},
 				},
@@ -325,17 +260,17 @@ func TestAddBranch(t *testing.T) {
 				name: "failure",
 				args: args{
 					newBranchName: "main",
-					newBranchHash: sha.SHA1(hash),
+					newBranchHash: sha.Hash(hash),
 				},
 				fields: fields{
 					name: "main",
-					hash: sha.SHA1(hash),
+					hash: sha.Hash(hash),
 				},
 				want: &Refs{
 					Heads: []*branch{
 						{
 							Name: "main",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 					},
 				},
@@ -393,7 +328,7 @@ func TestGetBranchPos(t *testing.T) {
 	}
 	type fields struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name       string
@@ -412,11 +347,11 @@ func TestGetBranchPos(t *testing.T) {
 				fieldsList: []*fields{
 					{
 						name: "main",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 					{
 						name: "test",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 				},
 				want: 0,
@@ -432,11 +367,11 @@ func TestGetBranchPos(t *testing.T) {
 				fieldsList: []*fields{
 					{
 						name: "main",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 					{
 						name: "test",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 				},
 				want: NewBranchFlag,
@@ -493,7 +428,7 @@ func TestDeleteBranch(t *testing.T) {
 	}
 	type fields struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
 	type test struct {
 		name       string
@@ -514,18 +449,18 @@ func TestDeleteBranch(t *testing.T) {
 				fieldsList: []*fields{
 					{
 						name: "main",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 					{
 						name: "test",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 				},
 				want: &Refs{
 					Heads: []*branch{
 						{
 							Name: "main",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 					},
 				},
@@ -543,22 +478,22 @@ func TestDeleteBranch(t *testing.T) {
 				fieldsList: []*fields{
 					{
 						name: "main",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 					{
 						name: "test",
-						hash: sha.SHA1(hash),
+						hash: sha.Hash(hash),
 					},
 				},
 				want: &Refs{
 					Heads: []*branch{
 						{
 							Name: "main",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 						{
 							Name: "test",
-							hash: sha.SHA1(hash),
+							hash: sha.Hash(hash),
 						},
 					},
 				},
@@ -614,12 +549,14 @@ func TestDeleteBranch(t *testing.T) {
 func TestUpdateBranchHash(t *testing.T) {
 	type args struct {
 		branchName string
-		newHash    sha.SHA1
+		newHash    sha.Hash
 	}
 	type fields struct {
 		name string
-		hash sha.SHA1
+		hash sha.Hash
 	}
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	newHash, _ := hex.DecodeString(strings.Repeat("4", 40))
 	tests := []struct {
 		name       string
 		args       args
@@ -631,27 +568,27 @@ func TestUpdateBranchHash(t *testing.T) {
 			name: "success",
 			args: args{
 				branchName: "main",
-				newHash:    sha.SHA1([]byte(strings.Repeat("4", 40))),
+				newHash:    sha.Hash(newHash),
 			},
 			fieldsList: []*fields{
 				{
 					name: "main",
-					hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+					hash: sha.Hash(hash),
 				},
 				{
 					name: "test",
-					hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+					hash: sha.Hash(hash),
 				},
 			},
 			want: &Refs{
 				Heads: []*branch{
 					{
 						Name: "main",
-						hash: sha.SHA1([]byte(strings.Repeat("4", 40))),
+						hash: sha.Hash(newHash),
 					},
 					{
 						Name: "test",
-						hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+						hash: sha.Hash(hash),
 					},
 				},
 			},
@@ -661,27 +598,27 @@ func TestUpdateBranchHash(t *testing.T) {
 			name: "failure",
 			args: args{
 				branchName: "xxxx",
-				newHash:    sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+				newHash:    sha.Hash(hash),
 			},
 			fieldsList: []*fields{
 				{
 					name: "main",
-					hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+					hash: sha.Hash(hash),
 				},
 				{
 					name: "test",
-					hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+					hash: sha.Hash(hash),
 				},
 			},
 			want: &Refs{
 				Heads: []*branch{
 					{
 						Name: "main",
-						hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+						hash: sha.Hash(hash),
 					},
 					{
 						Name: "test",
-						hash: sha.SHA1([]byte("87f3c49bccf2597484ece08746d3ee5defaba335")),
+						hash: sha.Hash(hash),
 					},
 				},
 			},
@@ -732,3 +669,372 @@ func TestUpdateBranchHash(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRefsWithPackedRefs(t *testing.T) {
+	looseHash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	packedHash, _ := hex.DecodeString("bc835249d19fb16ece41eef7913b4dde40b93845")
+	tests := []struct {
+		name       string
+		looseRefs  map[string]sha.Hash
+		packedRefs string
+		want       *Refs
+	}{
+		{
+			name: "success: nested loose ref and packed ref",
+			looseRefs: map[string]sha.Hash{
+				"feature/login": sha.Hash(looseHash),
+			},
+			packedRefs: "bc835249d19fb16ece41eef7913b4dde40b93845 refs/heads/main\n",
+			want: &Refs{
+				Heads: []*branch{
+					{
+						Name: "feature/login",
+						hash: sha.Hash(looseHash),
+					},
+					{
+						Name: "main",
+						hash: sha.Hash(packedHash),
+					},
+				},
+			},
+		},
+		{
+			name: "success: loose ref shadows packed ref",
+			looseRefs: map[string]sha.Hash{
+				"main": sha.Hash(looseHash),
+			},
+			packedRefs: "bc835249d19fb16ece41eef7913b4dde40b93845 refs/heads/main\n",
+			want: &Refs{
+				Heads: []*branch{
+					{
+						Name: "main",
+						hash: sha.Hash(looseHash),
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			headsDir := filepath.Join(goitDir, "refs", "heads")
+			if err := os.MkdirAll(headsDir, os.ModePerm); err != nil {
+				t.Logf("%v: %s", err, headsDir)
+			}
+			for name, hash := range tt.looseRefs {
+				if err := writeLooseRef(goitDir, branchRefName(name), hash); err != nil {
+					t.Log(err)
+				}
+			}
+			packedRefsPath := filepath.Join(goitDir, "packed-refs")
+			if err := os.WriteFile(packedRefsPath, []byte(tt.packedRefs), os.ModePerm); err != nil {
+				t.Logf("%v: %s", err, packedRefsPath)
+			}
+
+			got, err := NewRefs(goitDir)
+			if err != nil {
+				t.Errorf("got = %v, want = nil", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRefName(t *testing.T) {
+	tests := []struct {
+		name    string
+		refName string
+		wantErr error
+	}{
+		{
+			name:    "success",
+			refName: "refs/heads/main",
+			wantErr: nil,
+		},
+		{
+			name:    "success: nested",
+			refName: "refs/heads/feature/login",
+			wantErr: nil,
+		},
+		{
+			name:    "fail: double dots",
+			refName: "refs/heads/a..b",
+			wantErr: ErrInvalidRefName,
+		},
+		{
+			name:    "fail: lock suffix",
+			refName: "refs/heads/main.lock",
+			wantErr: ErrInvalidRefName,
+		},
+		{
+			name:    "fail: trailing slash",
+			refName: "refs/heads/feature/",
+			wantErr: ErrInvalidRefName,
+		},
+		{
+			name:    "fail: component starts with dot",
+			refName: "refs/heads/.hidden",
+			wantErr: ErrInvalidRefName,
+		},
+		{
+			name:    "fail: special character",
+			refName: "refs/heads/a~1",
+			wantErr: ErrInvalidRefName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRefName(tt.refName); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddBranchConflict(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	tests := []struct {
+		name          string
+		branchName    string
+		newBranchName string
+		wantErr       bool
+	}{
+		{
+			name:          "fail: existing branch is directory",
+			branchName:    "feature/login",
+			newBranchName: "feature",
+			wantErr:       true,
+		},
+		{
+			name:          "fail: existing branch is file",
+			branchName:    "feature",
+			newBranchName: "feature/login",
+			wantErr:       true,
+		},
+		{
+			name:          "success: sibling",
+			branchName:    "feature/login",
+			newBranchName: "feature/signup",
+			wantErr:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.MkdirAll(filepath.Join(goitDir, "refs", "heads"), os.ModePerm); err != nil {
+				t.Log(err)
+			}
+			r := newRefs()
+			if err := r.AddBranch(goitDir, tt.branchName, sha.Hash(hash)); err != nil {
+				t.Log(err)
+			}
+
+			if err := r.AddBranch(goitDir, tt.newBranchName, sha.Hash(hash)); (err != nil) != tt.wantErr {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPack(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	tests := []struct {
+		name       string
+		isAll      bool
+		looseRefs  []string
+		wantPacked []string
+		wantLoose  []string
+	}{
+		{
+			name:       "success: tags only",
+			isAll:      false,
+			looseRefs:  []string{"refs/heads/main", "refs/tags/v1.0.0"},
+			wantPacked: []string{"refs/tags/v1.0.0"},
+			wantLoose:  []string{"refs/heads/main"},
+		},
+		{
+			name:       "success: all",
+			isAll:      true,
+			looseRefs:  []string{"refs/heads/feature/login", "refs/heads/main", "refs/tags/v1.0.0"},
+			wantPacked: []string{"refs/heads/feature/login", "refs/heads/main", "refs/tags/v1.0.0"},
+			wantLoose:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.MkdirAll(filepath.Join(goitDir, "refs", "heads"), os.ModePerm); err != nil {
+				t.Log(err)
+			}
+			for _, refName := range tt.looseRefs {
+				if err := writeLooseRef(goitDir, refName, sha.Hash(hash)); err != nil {
+					t.Log(err)
+				}
+			}
+
+			r, err := NewRefs(goitDir)
+			if err != nil {
+				t.Log(err)
+			}
+			if err := r.Pack(goitDir, tt.isAll, true); err != nil {
+				t.Errorf("got = %v, want = nil", err)
+			}
+
+			packed, err := readPackedRefs(goitDir)
+			if err != nil {
+				t.Log(err)
+			}
+			var gotPacked []string
+			for _, ref := range packed.refs {
+				gotPacked = append(gotPacked, ref.name)
+			}
+			if !reflect.DeepEqual(gotPacked, tt.wantPacked) {
+				t.Errorf("got = %v, want = %v", gotPacked, tt.wantPacked)
+			}
+
+			looseRefs, err := listLooseRefs(goitDir, "refs/")
+			if err != nil {
+				t.Log(err)
+			}
+			var gotLoose []string
+			for _, ref := range looseRefs {
+				gotLoose = append(gotLoose, ref.name)
+			}
+			if !reflect.DeepEqual(gotLoose, tt.wantLoose) {
+				t.Errorf("got = %v, want = %v", gotLoose, tt.wantLoose)
+			}
+			if _, err := os.Stat(filepath.Join(goitDir, "refs", "heads")); os.IsNotExist(err) {
+				t.Errorf("refs/heads should not be pruned")
+			}
+		})
+	}
+}
+
+func TestRenameBranch(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	tests := []struct {
+		name          string
+		branchName    string
+		newBranchName string
+		blocker       string // the file under refs/heads/ which is not a branch but in the way of the new ref
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:          "success",
+			branchName:    "main",
+			newBranchName: "trunk",
+			want:          []string{"refs/heads/trunk"},
+			wantErr:       false,
+		},
+		{
+			name:          "success: into directory of the old one",
+			branchName:    "feature",
+			newBranchName: "feature/login",
+			want:          []string{"refs/heads/feature/login"},
+			wantErr:       false,
+		},
+		{
+			name:          "success: out of directory of the old one",
+			branchName:    "feature/login",
+			newBranchName: "feature",
+			want:          []string{"refs/heads/feature"},
+			wantErr:       false,
+		},
+		{
+			name:          "fail: new ref cannot be written",
+			branchName:    "main",
+			newBranchName: "trunk",
+			blocker:       "trunk/x.lock",
+			want:          []string{"refs/heads/main"},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.MkdirAll(filepath.Join(goitDir, "refs", "heads"), os.ModePerm); err != nil {
+				t.Log(err)
+			}
+			if tt.blocker != "" {
+				blockerPath := filepath.Join(goitDir, "refs", "heads", filepath.FromSlash(tt.blocker))
+				if err := os.MkdirAll(filepath.Dir(blockerPath), os.ModePerm); err != nil {
+					t.Log(err)
+				}
+				if err := os.WriteFile(blockerPath, nil, 0o644); err != nil {
+					t.Log(err)
+				}
+			}
+			r := newRefs()
+			if err := r.AddBranch(goitDir, tt.branchName, sha.Hash(hash)); err != nil {
+				t.Log(err)
+			}
+
+			if err := r.RenameBranch(goitDir, tt.branchName, tt.newBranchName); (err != nil) != tt.wantErr {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			refs, err := listRefs(goitDir, "refs/heads/")
+			if err != nil {
+				t.Log(err)
+			}
+			var got []string
+			for _, ref := range refs {
+				got = append(got, ref.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneLooseRef(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	otherHash, _ := hex.DecodeString("1111111111111111111111111111111111111111")
+	tests := []struct {
+		name       string
+		packedHash sha.Hash
+		isLocked   bool
+		wantPruned bool
+	}{
+		{
+			name:       "success: pruned",
+			packedHash: sha.Hash(hash),
+			wantPruned: true,
+		},
+		{
+			name:       "success: updated since packed",
+			packedHash: sha.Hash(otherHash),
+			wantPruned: false,
+		},
+		{
+			name:       "success: locked",
+			packedHash: sha.Hash(hash),
+			isLocked:   true,
+			wantPruned: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := writeLooseRef(goitDir, "refs/heads/main", sha.Hash(hash)); err != nil {
+				t.Log(err)
+			}
+			if tt.isLocked {
+				if err := os.WriteFile(refPath(goitDir, "refs/heads/main")+".lock", nil, 0o644); err != nil {
+					t.Log(err)
+				}
+			}
+
+			if err := pruneLooseRef(goitDir, "refs/heads/main", tt.packedHash); err != nil {
+				t.Errorf("got = %v, want = nil", err)
+			}
+			_, err := readLooseRef(goitDir, "refs/heads/main")
+			if isPruned := errors.Is(err, ErrRefNotFound); isPruned != tt.wantPruned {
+				t.Errorf("got = %v, want = %v", isPruned, tt.wantPruned)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+const (
+	symbolicRefPrefix = "ref: "
+	maxSymrefDepth    = 5
+)
+
+var (
+	ErrSymbolicRef    = errors.New("ref is a symbolic ref")
+	ErrNotSymbolicRef = errors.New("ref is not a symbolic ref")
+	ErrSymrefCycle    = errors.New("symbolic ref cycle detected")
+	ErrSymrefTooDeep  = errors.New("symbolic ref chain is too deep")
+)
+
+// ReadSymbolicRef returns the name of the ref which the symbolic ref points to.
+// Symbolic refs are always loose, so packed-refs is not consulted.
+func ReadSymbolicRef(rootGoitPath, refName string) (string, error) {
+	content, err := readLooseRefContent(rootGoitPath, refName)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(content, symbolicRefPrefix) {
+		return "", fmt.Errorf("%w: %s", ErrNotSymbolicRef, refName)
+	}
+	return strings.TrimPrefix(content, symbolicRefPrefix), nil
+}
+
+func WriteSymbolicRef(rootGoitPath, refName, target string) error {
+	path := refPath(rootGoitPath, refName)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("fail to make directory for %s: %w", refName, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("fail to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(symbolicRefPrefix + target); err != nil {
+		return fmt.Errorf("fail to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func DeleteSymbolicRef(rootGoitPath, refName string) error {
+	if _, err := ReadSymbolicRef(rootGoitPath, refName); err != nil {
+		return err
+	}
+	path := refPath(rootGoitPath, refName)
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("fail to delete %s: %w", path, err)
+	}
+	pruneEmptyRefDirs(rootGoitPath, filepath.Dir(path))
+
+	return nil
+}
+
+// ResolveRefName follows the chain of symbolic refs starting from refName
+// and returns the name of the first non-symbolic ref, which might not exist yet.
+func ResolveRefName(rootGoitPath, refName string) (string, error) {
+	visited := make(map[string]struct{})
+	name := refName
+	for depth := 0; ; depth++ {
+		if _, ok := visited[name]; ok {
+			return "", fmt.Errorf("%w: %s", ErrSymrefCycle, refName)
+		}
+		if depth > maxSymrefDepth {
+			return "", fmt.Errorf("%w: %s", ErrSymrefTooDeep, refName)
+		}
+		visited[name] = struct{}{}
+
+		target, err := ReadSymbolicRef(rootGoitPath, name)
+		if errors.Is(err, ErrNotSymbolicRef) || errors.Is(err, ErrRefNotFound) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		name = target
+	}
+}
+
+// ResolveRef follows symbolic refs from refName and returns the name and the hash of the final ref.
+func ResolveRef(rootGoitPath, refName string) (string, sha.Hash, error) {
+	name, err := ResolveRefName(rootGoitPath, refName)
+	if err != nil {
+		return "", nil, err
+	}
+	hash, err := readDirectRef(rootGoitPath, name)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, hash, nil
+}
+
+// return true if the name looks like HEAD, ORIG_HEAD and so on.
+func isPseudoRefName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(('A' <= r && r <= 'Z') || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpandRefName finds the full ref name for the short name in the same order as git,
+// e.g. 'main' is expanded to 'refs/heads/main' and 'origin' to 'refs/remotes/origin/HEAD'.
+func ExpandRefName(rootGoitPath, name string) (string, error) {
+	var candidates []string
+	if strings.HasPrefix(name, "refs/") || isPseudoRefName(name) {
+		candidates = append(candidates, name)
+	}
+	candidates = append(candidates,
+		fmt.Sprintf("refs/%s", name),
+		fmt.Sprintf("refs/tags/%s", name),
+		fmt.Sprintf("refs/heads/%s", name),
+		fmt.Sprintf("refs/remotes/%s", name),
+		fmt.Sprintf("refs/remotes/%s/HEAD", name),
+	)
+	for _, candidate := range candidates {
+		if _, _, err := ResolveRef(rootGoitPath, candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrRefNotFound, name)
+}
+
+// ShortenRefName strips the well-known prefix from the full ref name.
+func ShortenRefName(refName string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/"} {
+		if strings.HasPrefix(refName, prefix) {
+			return strings.TrimPrefix(refName, prefix)
+		}
+	}
+	return strings.TrimPrefix(refName, "refs/")
+}
@@ -0,0 +1,195 @@
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+func TestResolveRef(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	tests := []struct {
+		name     string
+		refName  string
+		symrefs  map[string]string
+		wantName string
+		wantHash sha.Hash
+		wantErr  error
+	}{
+		{
+			name:     "success: direct ref",
+			refName:  "refs/heads/main",
+			symrefs:  nil,
+			wantName: "refs/heads/main",
+			wantHash: sha.Hash(hash),
+			wantErr:  nil,
+		},
+		{
+			name:    "success: chain",
+			refName: "HEAD",
+			symrefs: map[string]string{
+				"HEAD":                     "refs/remotes/origin/HEAD",
+				"refs/remotes/origin/HEAD": "refs/heads/main",
+			},
+			wantName: "refs/heads/main",
+			wantHash: sha.Hash(hash),
+			wantErr:  nil,
+		},
+		{
+			name:    "fail: cycle",
+			refName: "refs/heads/a",
+			symrefs: map[string]string{
+				"refs/heads/a": "refs/heads/b",
+				"refs/heads/b": "refs/heads/a",
+			},
+			wantName: "",
+			wantHash: nil,
+			wantErr:  ErrSymrefCycle,
+		},
+		{
+			name:    "fail: dangling",
+			refName: "refs/heads/a",
+			symrefs: map[string]string{
+				"refs/heads/a": "refs/heads/xxx",
+			},
+			wantName: "",
+			wantHash: nil,
+			wantErr:  ErrRefNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.MkdirAll(filepath.Join(goitDir, "refs", "heads"), os.ModePerm); err != nil {
+				t.Log(err)
+			}
+			if err := writeLooseRef(goitDir, "refs/heads/main", sha.Hash(hash)); err != nil {
+				t.Log(err)
+			}
+			for name, target := range tt.symrefs {
+				if err := WriteSymbolicRef(goitDir, name, target); err != nil {
+					t.Log(err)
+				}
+			}
+
+			gotName, gotHash, err := ResolveRef(goitDir, tt.refName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if gotName != tt.wantName {
+				t.Errorf("got = %s, want = %s", gotName, tt.wantName)
+			}
+			if !gotHash.Compare(tt.wantHash) {
+				t.Errorf("got = %s, want = %s", gotHash, tt.wantHash)
+			}
+		})
+	}
+}
+
+func TestExpandRefName(t *testing.T) {
+	hash, _ := hex.DecodeString("87f3c49bccf2597484ece08746d3ee5defaba335")
+	tests := []struct {
+		name    string
+		refName string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "success: branch",
+			refName: "main",
+			want:    "refs/heads/main",
+			wantErr: nil,
+		},
+		{
+			name:    "success: tag comes before branch",
+			refName: "v1",
+			want:    "refs/tags/v1",
+			wantErr: nil,
+		},
+		{
+			name:    "success: remote HEAD",
+			refName: "origin",
+			want:    "refs/remotes/origin/HEAD",
+			wantErr: nil,
+		},
+		{
+			name:    "success: HEAD",
+			refName: "HEAD",
+			want:    "HEAD",
+			wantErr: nil,
+		},
+		{
+			name:    "fail: not found",
+			refName: "xxx",
+			want:    "",
+			wantErr: ErrRefNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.MkdirAll(filepath.Join(goitDir, "refs", "heads"), os.ModePerm); err != nil {
+				t.Log(err)
+			}
+			for _, refName := range []string{"refs/heads/main", "refs/heads/v1", "refs/tags/v1", "refs/remotes/origin/main"} {
+				if err := writeLooseRef(goitDir, refName, sha.Hash(hash)); err != nil {
+					t.Log(err)
+				}
+			}
+			if err := WriteSymbolicRef(goitDir, "refs/remotes/origin/HEAD", "refs/remotes/origin/main"); err != nil {
+				t.Log(err)
+			}
+			if err := WriteSymbolicRef(goitDir, "HEAD", "refs/heads/main"); err != nil {
+				t.Log(err)
+			}
+
+			got, err := ExpandRefName(goitDir, tt.refName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortenRefName(t *testing.T) {
+	tests := []struct {
+		name    string
+		refName string
+		want    string
+	}{
+		{
+			name:    "branch",
+			refName: "refs/heads/feature/login",
+			want:    "feature/login",
+		},
+		{
+			name:    "tag",
+			refName: "refs/tags/v1.0.0",
+			want:    "v1.0.0",
+		},
+		{
+			name:    "remote",
+			refName: "refs/remotes/origin/HEAD",
+			want:    "origin/HEAD",
+		},
+		{
+			name:    "stash",
+			refName: "refs/stash",
+			want:    "stash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShortenRefName(tt.refName); got != tt.want {
+				t.Errorf("got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+}
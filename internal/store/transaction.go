@@ -0,0 +1,421 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+type updateOperation int
+
+const (
+	opUpdate updateOperation = iota
+	opDelete
+	opVerify
+)
+
+var (
+	ErrRefLocked         = errors.New("cannot lock ref")
+	ErrRefOldValue       = errors.New("reference is not at the expected value")
+	ErrDuplicateRef      = errors.New("multiple updates for the same ref are not allowed")
+	ErrRefNameConflict   = errors.New("ref name conflicts with an existing ref")
+	ErrNonexistentObject = errors.New("trying to write ref with nonexistent object")
+)
+
+type refUpdate struct {
+	operation updateOperation
+	name      string   // the name given by the caller
+	refName   string   // the name after following symbolic refs
+	newHash   sha.Hash // nil for delete and verify
+	oldHash   sha.Hash // expected value, zero hash means the ref must not exist
+	hasOld    bool
+	curHash   sha.Hash // the value found while locking
+	lockPath  string
+	loose     []byte // the content of the loose ref found while locking, nil if there is none
+}
+
+// RefUpdateResult describes the change of a ref applied by a transaction.
+type RefUpdateResult struct {
+	Name    string // the name given by the caller, such as HEAD
+	RefName string // the ref actually updated, such as refs/heads/main
+	OldHash sha.Hash
+	NewHash sha.Hash // nil if the ref is deleted
+}
+
+// RefTransaction updates several refs at once. Every ref is locked with
+// a <ref>.lock file and its old value is verified before anything is written,
+// so either all of the updates are applied or none of them.
+type RefTransaction struct {
+	rootGoitPath   string
+	format         *sha.Format // the hash algorithm of the repository
+	updates        []*refUpdate
+	packedLockPath string // packed-refs.lock, which is held when a ref is deleted
+}
+
+func NewRefTransaction(rootGoitPath string, format *sha.Format) *RefTransaction {
+	return &RefTransaction{
+		rootGoitPath: rootGoitPath,
+		format:       format,
+		updates:      make([]*refUpdate, 0),
+	}
+}
+
+func validateUpdateRefName(refName string) error {
+	if !isPseudoRefName(refName) && !strings.HasPrefix(refName, "refs/") {
+		return fmt.Errorf("%w: '%s'", ErrInvalidRefName, refName)
+	}
+	return ValidateRefName(refName)
+}
+
+func (t *RefTransaction) add(operation updateOperation, refName string, newHash, oldHash sha.Hash, hasOld bool) error {
+	if err := validateUpdateRefName(refName); err != nil {
+		return err
+	}
+	// the hash of the other format never names the object of the repository
+	for _, hash := range []sha.Hash{newHash, oldHash} {
+		if hash != nil && len(hash) != t.format.Size {
+			return fmt.Errorf("%w for %s: %s", sha.ErrInvalidHash, t.format.Name, hash)
+		}
+	}
+	t.updates = append(t.updates, &refUpdate{
+		operation: operation,
+		name:      refName,
+		newHash:   newHash,
+		oldHash:   oldHash,
+		hasOld:    hasOld,
+	})
+	return nil
+}
+
+// Update sets the ref to newHash. If hasOld is true, the ref must currently be at oldHash.
+func (t *RefTransaction) Update(refName string, newHash, oldHash sha.Hash, hasOld bool) error {
+	if newHash == nil || newHash.IsZero() {
+		return t.Delete(refName, oldHash, hasOld)
+	}
+	return t.add(opUpdate, refName, newHash, oldHash, hasOld)
+}
+
+// Create sets the ref to newHash, which must not exist yet.
+func (t *RefTransaction) Create(refName string, newHash sha.Hash) error {
+	if newHash == nil || newHash.IsZero() {
+		return fmt.Errorf("create %s: zero hash is not allowed", refName)
+	}
+	return t.add(opUpdate, refName, newHash, make(sha.Hash, len(newHash)), true)
+}
+
+// Delete removes the ref. If hasOld is true, the ref must currently be at oldHash.
+func (t *RefTransaction) Delete(refName string, oldHash sha.Hash, hasOld bool) error {
+	if hasOld && oldHash.IsZero() {
+		return fmt.Errorf("delete %s: zero hash is not allowed as old value", refName)
+	}
+	return t.add(opDelete, refName, nil, oldHash, hasOld)
+}
+
+// Verify checks that the ref is at oldHash without changing it.
+// Zero or nil oldHash means the ref must not exist.
+func (t *RefTransaction) Verify(refName string, oldHash sha.Hash) error {
+	if oldHash == nil {
+		oldHash = t.format.ZeroHash()
+	}
+	return t.add(opVerify, refName, nil, oldHash, true)
+}
+
+// Commit locks every ref, verifies the old values and applies the updates.
+func (t *RefTransaction) Commit() ([]*RefUpdateResult, error) {
+	if err := t.prepare(); err != nil {
+		t.rollback()
+		return nil, err
+	}
+
+	results, err := t.apply()
+	if err != nil {
+		t.rollback()
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (t *RefTransaction) prepare() error {
+	// resolve symbolic refs and detect duplicates
+	seen := make(map[string]struct{})
+	for _, u := range t.updates {
+		refName, err := ResolveRefName(t.rootGoitPath, u.name)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[refName]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateRef, refName)
+		}
+		seen[refName] = struct{}{}
+		u.refName = refName
+	}
+
+	// check directory/file conflicts, such as refs/heads/a and refs/heads/a/b
+	refs, err := listRefs(t.rootGoitPath, "refs/")
+	if err != nil {
+		return err
+	}
+	for _, u := range t.updates {
+		if u.operation != opUpdate {
+			continue
+		}
+		for _, ref := range refs {
+			if !strings.HasPrefix(ref.name, u.refName+"/") && !strings.HasPrefix(u.refName, ref.name+"/") {
+				continue
+			}
+			if t.isDeleted(ref.name) {
+				continue
+			}
+			return fmt.Errorf("%w: '%s' exists; cannot create '%s'", ErrRefNameConflict, ref.name, u.refName)
+		}
+	}
+
+	// lock refs
+	for _, u := range t.updates {
+		lockPath := refPath(t.rootGoitPath, u.refName) + ".lock"
+		if err := os.MkdirAll(filepath.Dir(lockPath), os.ModePerm); err != nil {
+			return fmt.Errorf("%w '%s': %v", ErrRefLocked, u.refName, err)
+		}
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if err != nil {
+			return fmt.Errorf("%w '%s': %v", ErrRefLocked, u.refName, err)
+		}
+		f.Close()
+		u.lockPath = lockPath
+	}
+
+	// packed-refs is locked as well, since deleting a ref might rewrite it
+	if t.hasDelete() {
+		lockPath := filepath.Join(t.rootGoitPath, "packed-refs.lock")
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if err != nil {
+			return fmt.Errorf("%w 'packed-refs': %v", ErrRefLocked, err)
+		}
+		f.Close()
+		t.packedLockPath = lockPath
+	}
+
+	// remember the loose refs to restore them if applying fails halfway
+	for _, u := range t.updates {
+		path := refPath(t.rootGoitPath, u.refName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrIOHandling, path)
+			}
+			u.loose = content
+		}
+	}
+
+	// verify old values
+	for _, u := range t.updates {
+		curHash, err := readDirectRef(t.rootGoitPath, u.refName)
+		if err != nil && !errors.Is(err, ErrRefNotFound) {
+			return err
+		}
+		u.curHash = curHash
+		if u.operation == opDelete && curHash == nil {
+			return fmt.Errorf("%w '%s': reference does not exist", ErrRefLocked, u.refName)
+		}
+		if !u.hasOld {
+			continue
+		}
+		if u.oldHash.IsZero() {
+			if curHash != nil {
+				return fmt.Errorf("%w '%s': reference already exists", ErrRefOldValue, u.refName)
+			}
+			continue
+		}
+		if curHash == nil {
+			return fmt.Errorf("%w '%s': reference is missing but expected %s", ErrRefOldValue, u.refName, u.oldHash)
+		}
+		if !curHash.Compare(u.oldHash) {
+			return fmt.Errorf("%w '%s': is at %s but expected %s", ErrRefOldValue, u.refName, curHash, u.oldHash)
+		}
+	}
+
+	// verify new values
+	for _, u := range t.updates {
+		if u.operation != opUpdate {
+			continue
+		}
+		obj, err := object.GetObject(t.rootGoitPath, u.newHash)
+		if err != nil {
+			return fmt.Errorf("%w: '%s' %s", ErrNonexistentObject, u.refName, u.newHash)
+		}
+		if strings.HasPrefix(u.refName, "refs/heads/") && obj.Type != object.CommitObject {
+			return fmt.Errorf("trying to write non-commit object %s to branch '%s'", u.newHash, u.refName)
+		}
+	}
+
+	return nil
+}
+
+func (t *RefTransaction) isDeleted(refName string) bool {
+	for _, u := range t.updates {
+		if u.refName == refName && u.operation == opDelete {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *RefTransaction) hasDelete() bool {
+	for _, u := range t.updates {
+		if u.operation == opDelete {
+			return true
+		}
+	}
+	return false
+}
+
+// write the new values into the lock files, and then move them into place. Nothing is visible
+// until every lock file is written, and the refs already changed are restored if a later step fails.
+func (t *RefTransaction) apply() ([]*RefUpdateResult, error) {
+	for _, u := range t.updates {
+		if u.operation != opUpdate {
+			continue
+		}
+		if err := os.WriteFile(u.lockPath, []byte(u.newHash.String()), 0666); err != nil {
+			return nil, fmt.Errorf("fail to write %s: %w", u.lockPath, err)
+		}
+	}
+
+	// remove deleted refs from packed-refs, so that they never reappear
+	packedRefsPath := filepath.Join(t.rootGoitPath, "packed-refs")
+	var packed *packedRefs
+	var oldPacked []byte
+	if t.packedLockPath != "" {
+		var err error
+		packed, err = readPackedRefs(t.rootGoitPath)
+		if err != nil {
+			return nil, err
+		}
+		isPackedChanged := false
+		for _, u := range t.updates {
+			if u.operation == opDelete && packed.remove(u.refName) {
+				isPackedChanged = true
+			}
+		}
+		if !isPackedChanged {
+			packed = nil
+		} else {
+			if oldPacked, err = os.ReadFile(packedRefsPath); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrIOHandling, packedRefsPath)
+			}
+			if len(packed.refs) > 0 {
+				if err := os.WriteFile(t.packedLockPath, []byte(packed.String()), 0666); err != nil {
+					return nil, fmt.Errorf("fail to write %s: %w", t.packedLockPath, err)
+				}
+			}
+		}
+	}
+
+	// from here on, every change is undone in the reverse order if a later one fails
+	var undo []func()
+	fail := func(err error) ([]*RefUpdateResult, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return nil, err
+	}
+
+	if packed != nil {
+		if len(packed.refs) == 0 {
+			if err := os.Remove(packedRefsPath); err != nil {
+				return fail(fmt.Errorf("fail to remove %s: %w", packedRefsPath, err))
+			}
+		} else if err := os.Rename(t.packedLockPath, packedRefsPath); err != nil {
+			return fail(fmt.Errorf("fail to rename %s: %w", t.packedLockPath, err))
+		}
+		undo = append(undo, func() {
+			os.WriteFile(packedRefsPath, oldPacked, 0666)
+		})
+	}
+
+	results := make([]*RefUpdateResult, 0)
+	for _, u := range t.updates {
+		path := refPath(t.rootGoitPath, u.refName)
+		switch u.operation {
+		case opUpdate:
+			// a directory left by a deleted nested ref might be in the way
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				if err := os.Remove(path); err != nil {
+					return fail(fmt.Errorf("%w: %s", ErrRefNameConflict, u.refName))
+				}
+			}
+			if err := os.Rename(u.lockPath, path); err != nil {
+				return fail(fmt.Errorf("fail to rename %s: %w", u.lockPath, err))
+			}
+			undo = append(undo, t.restoreLoose(u))
+			results = append(results, &RefUpdateResult{
+				Name:    u.name,
+				RefName: u.refName,
+				OldHash: u.curHash,
+				NewHash: u.newHash,
+			})
+		case opDelete:
+			if u.loose != nil {
+				if err := os.Remove(path); err != nil {
+					return fail(fmt.Errorf("fail to delete %s: %w", path, err))
+				}
+				undo = append(undo, t.restoreLoose(u))
+			}
+			results = append(results, &RefUpdateResult{
+				Name:    u.name,
+				RefName: u.refName,
+				OldHash: u.curHash,
+				NewHash: nil,
+			})
+		}
+	}
+
+	// the lock files of the updates are gone by the rename, and the rest are released
+	for _, u := range t.updates {
+		if u.operation == opUpdate {
+			u.lockPath = ""
+		}
+	}
+	t.rollback()
+	for _, u := range t.updates {
+		if u.operation == opDelete {
+			pruneEmptyRefDirs(t.rootGoitPath, filepath.Dir(refPath(t.rootGoitPath, u.refName)))
+		}
+	}
+
+	return results, nil
+}
+
+// return the function which puts the loose ref back to the content found while locking.
+func (t *RefTransaction) restoreLoose(u *refUpdate) func() {
+	return func() {
+		path := refPath(t.rootGoitPath, u.refName)
+		if u.loose == nil {
+			os.Remove(path)
+			return
+		}
+		os.WriteFile(path, u.loose, 0666)
+	}
+}
+
+// remove the lock files which are still held.
+func (t *RefTransaction) rollback() {
+	for _, u := range t.updates {
+		if u.lockPath == "" {
+			continue
+		}
+		os.Remove(u.lockPath)
+		pruneEmptyRefDirs(t.rootGoitPath, filepath.Dir(u.lockPath))
+		u.lockPath = ""
+	}
+	if t.packedLockPath != "" {
+		os.Remove(t.packedLockPath)
+		t.packedLockPath = ""
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JunNishimura/Goit/internal/object"
+	"github.com/JunNishimura/Goit/internal/sha"
+)
+
+func TestRefTransactionCommit(t *testing.T) {
+	type test struct {
+		name       string
+		queue      func(tx *RefTransaction, oldHash, newHash sha.Hash) error
+		setup      func(goitDir string) error
+		isLocked   bool
+		wantErr    error
+		wantRefs   map[string]bool // ref name -> whether it points to the new hash
+		wantAbsent []string
+	}
+	tests := []*test{
+		{
+			name: "success: update with old value",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				return tx.Update("refs/heads/main", newHash, oldHash, true)
+			},
+			wantErr:  nil,
+			wantRefs: map[string]bool{"refs/heads/main": true},
+		},
+		{
+			name: "success: update through HEAD",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				return tx.Update("HEAD", newHash, nil, false)
+			},
+			wantErr:  nil,
+			wantRefs: map[string]bool{"refs/heads/main": true},
+		},
+		{
+			name: "success: create, delete and verify atomically",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				if err := tx.Create("refs/remotes/origin/main", newHash); err != nil {
+					return err
+				}
+				if err := tx.Delete("refs/tags/v1", oldHash, true); err != nil {
+					return err
+				}
+				return tx.Verify("refs/heads/main", oldHash)
+			},
+			wantErr:    nil,
+			wantRefs:   map[string]bool{"refs/remotes/origin/main": true, "refs/heads/main": false},
+			wantAbsent: []string{"refs/tags/v1"},
+		},
+		{
+			name: "fail: old value mismatch rolls back everything",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				if err := tx.Create("refs/remotes/origin/main", newHash); err != nil {
+					return err
+				}
+				return tx.Update("refs/heads/main", newHash, newHash, true)
+			},
+			wantErr:    ErrRefOldValue,
+			wantRefs:   map[string]bool{"refs/heads/main": false},
+			wantAbsent: []string{"refs/remotes/origin/main"},
+		},
+		{
+			name: "fail: create existing ref",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				return tx.Create("refs/heads/main", newHash)
+			},
+			wantErr:  ErrRefOldValue,
+			wantRefs: map[string]bool{"refs/heads/main": false},
+		},
+		{
+			name: "fail: locked ref",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				return tx.Update("refs/heads/main", newHash, nil, false)
+			},
+			isLocked: true,
+			wantErr:  ErrRefLocked,
+			wantRefs: map[string]bool{"refs/heads/main": false},
+		},
+		{
+			name: "fail: hash of the other format",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				return tx.Update("refs/heads/main", sha.Hash(strings.Repeat("a", sha.SHA256Format.Size)), oldHash, true)
+			},
+			wantErr:  sha.ErrInvalidHash,
+			wantRefs: map[string]bool{"refs/heads/main": false},
+		},
+		{
+			name: "fail: directory conflict",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				return tx.Create("refs/heads/main/sub", newHash)
+			},
+			wantErr:  ErrRefNameConflict,
+			wantRefs: map[string]bool{"refs/heads/main": false},
+		},
+		{
+			name: "fail: applying halfway rolls back everything",
+			queue: func(tx *RefTransaction, oldHash, newHash sha.Hash) error {
+				if err := tx.Delete("refs/tags/v1", oldHash, true); err != nil {
+					return err
+				}
+				if err := tx.Update("refs/heads/main", newHash, oldHash, true); err != nil {
+					return err
+				}
+				return tx.Create("refs/heads/blocked", newHash)
+			},
+			// the directory which is not a ref but cannot be removed is in the way of the last update
+			setup: func(goitDir string) error {
+				return os.MkdirAll(filepath.Join(goitDir, "refs", "heads", "blocked", "sub"), os.ModePerm)
+			},
+			wantErr:    ErrRefNameConflict,
+			wantRefs:   map[string]bool{"refs/heads/main": false, "refs/tags/v1": false},
+			wantAbsent: []string{"refs/heads/blocked"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goitDir := filepath.Join(t.TempDir(), ".goit")
+			if err := os.MkdirAll(filepath.Join(goitDir, "objects"), os.ModePerm); err != nil {
+				t.Log(err)
+			}
+			var hashes []sha.Hash
+			for _, message := range []string{"first", "second"} {
+				data := "tree 87f3c49bccf2597484ece08746d3ee5defaba335\nauthor Test Taro <test@example.com> 1700000000 +0900\ncommitter Test Taro <test@example.com> 1700000000 +0900\n\n" + message + "\n"
+				obj, _ := object.NewObject(sha.SHA1Format, object.CommitObject, []byte(data))
+				if err := obj.Write(goitDir); err != nil {
+					t.Log(err)
+				}
+				hashes = append(hashes, obj.Hash)
+			}
+			oldHash, newHash := hashes[0], hashes[1]
+			if err := writeLooseRef(goitDir, "refs/heads/main", oldHash); err != nil {
+				t.Log(err)
+			}
+			if err := WriteSymbolicRef(goitDir, "HEAD", "refs/heads/main"); err != nil {
+				t.Log(err)
+			}
+			packed := newPackedRefs()
+			packed.set(&packedRef{name: "refs/tags/v1", hash: oldHash})
+			if err := packed.write(goitDir); err != nil {
+				t.Log(err)
+			}
+			if tt.setup != nil {
+				if err := tt.setup(goitDir); err != nil {
+					t.Log(err)
+				}
+			}
+			if tt.isLocked {
+				if err := os.WriteFile(refPath(goitDir, "refs/heads/main")+".lock", nil, os.ModePerm); err != nil {
+					t.Log(err)
+				}
+			}
+
+			tx := NewRefTransaction(goitDir, sha.SHA1Format)
+			err := tt.queue(tx, oldHash, newHash)
+			if err == nil {
+				_, err = tx.Commit()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got = %v, want = %v", err, tt.wantErr)
+			}
+
+			for refName, isNew := range tt.wantRefs {
+				want := oldHash
+				if isNew {
+					want = newHash
+				}
+				got, err := ReadRef(goitDir, refName)
+				if err != nil {
+					t.Errorf("fail to read %s: %v", refName, err)
+				}
+				if !got.Compare(want) {
+					t.Errorf("%s: got = %s, want = %s", refName, got, want)
+				}
+			}
+			for _, refName := range tt.wantAbsent {
+				if _, err := ReadRef(goitDir, refName); !errors.Is(err, ErrRefNotFound) {
+					t.Errorf("%s: got = %v, want = %v", refName, err, ErrRefNotFound)
+				}
+			}
+			if !tt.isLocked {
+				filepath.Walk(goitDir, func(path string, info os.FileInfo, err error) error {
+					if err == nil && strings.HasSuffix(path, ".lock") {
+						t.Errorf("lock file is left: %s", path)
+					}
+					return nil
+				})
+			}
+		})
+	}
+}